@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -44,10 +45,59 @@ type Config struct {
 	LogLevel    string `json:"log_level"`   // debug, info, warn, error
 
 	// Business logic configuration
-	MinPrice           int     `json:"min_price"`
+	MinPrice           int     `json:"min_price"`       // если для города и машины нет тарифа
 	MaxDistance        float64 `json:"max_distance_km"` // for driver matching
 	DefaultMatchRadius float64 `json:"default_match_radius_km"`
 
+	// Order expiry: pending заявки без ответа переводятся в expired
+	OrderTTL            time.Duration            `json:"order_ttl"`
+	OrderTTLByCity      map[string]time.Duration `json:"order_ttl_by_city"`       // ключ — город, как его возвращает extractCityFromAddress
+	OrderTTLByTruckType map[string]time.Duration `json:"order_ttl_by_truck_type"` // small, medium, large, refrigerator, tow
+	OrderExpiryInterval time.Duration            `json:"order_expiry_interval"`
+	RepostRaisePercent  int                      `json:"repost_raise_percent"`
+
+	// Scheduled orders
+	TimeZone             string        `json:"time_zone"`
+	DispatchLeadTime     time.Duration `json:"dispatch_lead_time"` // за сколько до подачи рассылать заявку водителям
+	PickupReminderBefore time.Duration `json:"pickup_reminder_before"`
+	MaxScheduleAhead     time.Duration `json:"max_schedule_ahead"`
+
+	// Driver bids: сколько живёт предложение водителя без ответа клиента
+	BidTTL time.Duration `json:"bid_ttl"`
+
+	// Trip bookings: сколько водитель может думать над бронью места в рейсе
+	TripBookingTTL time.Duration `json:"trip_booking_ttl"`
+	// Driver trips: через сколько после времени выезда рейс считается завершённым
+	TripExpiryGrace time.Duration `json:"trip_expiry_grace"`
+
+	// Driver cancellations: Threshold отказов за Window → пауза в рассылке на Cooldown
+	DriverCancelWindow    time.Duration `json:"driver_cancel_window"`
+	DriverCancelThreshold int           `json:"driver_cancel_threshold"`
+	DriverCancelCooldown  time.Duration `json:"driver_cancel_cooldown"`
+
+	// Public tracking link: сколько ссылка /t/{token} работает после завершения заявки
+	TrackingLinkTTL time.Duration `json:"tracking_link_ttl"`
+
+	// Favourite driver: сколько заявка ждёт избранного водителя перед общей рассылкой
+	FavoriteDriverTimeout time.Duration `json:"favorite_driver_timeout"`
+
+	// Recurring orders: за сколько до подачи создавать заявку по расписанию
+	RecurringOrderAhead time.Duration `json:"recurring_order_ahead"`
+
+	// Surge: как часто пересчитывать спрос по зонам и потолок коэффициента
+	SurgeInterval      time.Duration `json:"surge_interval"`
+	SurgeMaxMultiplier float64       `json:"surge_max_multiplier"`
+
+	// Notifications: SMS-провайдер принимает POST {"to","text"}; пустой URL — SMS выключены.
+	// Email уходит через SMTP, пустой SMTPHost — email выключен.
+	SMSProviderURL string        `json:"sms_provider_url"`
+	SMSTimeout     time.Duration `json:"sms_timeout"`
+	SMTPHost       string        `json:"smtp_host"`
+	SMTPPort       int           `json:"smtp_port"`
+	SMTPUsername   string        `json:"smtp_username"`
+	SMTPPassword   string        `json:"-"`
+	SMTPFrom       string        `json:"smtp_from"`
+
 	// Rate limiting
 	RateLimitRequests int           `json:"rate_limit_requests"`
 	RateLimitWindow   time.Duration `json:"rate_limit_window"`
@@ -94,6 +144,48 @@ func NewConfig() (*Config, error) {
 		MaxDistance:        50.0, // 50km
 		DefaultMatchRadius: 10.0, // 10km
 
+		// Order expiry defaults
+		OrderTTL:            24 * time.Hour,
+		OrderTTLByCity:      map[string]time.Duration{},
+		OrderTTLByTruckType: map[string]time.Duration{},
+		OrderExpiryInterval: time.Minute,
+		RepostRaisePercent:  10,
+
+		// Scheduled orders defaults
+		TimeZone:             "Asia/Almaty",
+		DispatchLeadTime:     2 * time.Hour,
+		PickupReminderBefore: time.Hour,
+		MaxScheduleAhead:     30 * 24 * time.Hour,
+
+		// Driver bids defaults
+		BidTTL: 30 * time.Minute,
+
+		// Trip booking defaults
+		TripBookingTTL:  time.Hour,
+		TripExpiryGrace: 12 * time.Hour,
+
+		// Driver cancellation defaults
+		DriverCancelWindow:    7 * 24 * time.Hour,
+		DriverCancelThreshold: 3,
+		DriverCancelCooldown:  24 * time.Hour,
+
+		// Tracking link defaults
+		TrackingLinkTTL: 24 * time.Hour,
+
+		// Favourite driver defaults
+		FavoriteDriverTimeout: 10 * time.Minute,
+
+		// Recurring orders defaults
+		RecurringOrderAhead: 12 * time.Hour,
+
+		// Surge defaults
+		SurgeInterval:      time.Minute,
+		SurgeMaxMultiplier: 2.0,
+
+		// Notification defaults
+		SMSTimeout: 10 * time.Second,
+		SMTPPort:   587,
+
 		// Rate limiting defaults
 		RateLimitRequests: 100,
 		RateLimitWindow:   time.Hour,
@@ -140,6 +232,10 @@ func NewConfig() (*Config, error) {
 		cfg.UploadDir = uploadDir
 	}
 
+	if tz := os.Getenv("TIME_ZONE"); tz != "" {
+		cfg.TimeZone = tz
+	}
+
 	if env := os.Getenv("ENVIRONMENT"); env != "" {
 		cfg.Environment = env
 	}
@@ -173,6 +269,12 @@ func NewConfig() (*Config, error) {
 		}
 	}
 
+	if raise := os.Getenv("REPOST_RAISE_PERCENT"); raise != "" {
+		if percent, err := strconv.Atoi(raise); err == nil {
+			cfg.RepostRaisePercent = percent
+		}
+	}
+
 	if maxOpenConns := os.Getenv("DB_MAX_OPEN_CONNS"); maxOpenConns != "" {
 		if conns, err := strconv.Atoi(maxOpenConns); err == nil {
 			cfg.MaxOpenConns = conns
@@ -222,9 +324,186 @@ func NewConfig() (*Config, error) {
 		}
 	}
 
+	if orderTTL := os.Getenv("ORDER_TTL"); orderTTL != "" {
+		if ttl, err := time.ParseDuration(orderTTL); err == nil {
+			cfg.OrderTTL = ttl
+		}
+	}
+
+	if expiryInterval := os.Getenv("ORDER_EXPIRY_INTERVAL"); expiryInterval != "" {
+		if interval, err := time.ParseDuration(expiryInterval); err == nil {
+			cfg.OrderExpiryInterval = interval
+		}
+	}
+
+	if lead := os.Getenv("DISPATCH_LEAD_TIME"); lead != "" {
+		if d, err := time.ParseDuration(lead); err == nil {
+			cfg.DispatchLeadTime = d
+		}
+	}
+
+	if reminder := os.Getenv("PICKUP_REMINDER_BEFORE"); reminder != "" {
+		if d, err := time.ParseDuration(reminder); err == nil {
+			cfg.PickupReminderBefore = d
+		}
+	}
+
+	if ahead := os.Getenv("MAX_SCHEDULE_AHEAD"); ahead != "" {
+		if d, err := time.ParseDuration(ahead); err == nil {
+			cfg.MaxScheduleAhead = d
+		}
+	}
+
+	if bidTTL := os.Getenv("BID_TTL"); bidTTL != "" {
+		if d, err := time.ParseDuration(bidTTL); err == nil {
+			cfg.BidTTL = d
+		}
+	}
+
+	if bookingTTL := os.Getenv("TRIP_BOOKING_TTL"); bookingTTL != "" {
+		if d, err := time.ParseDuration(bookingTTL); err == nil {
+			cfg.TripBookingTTL = d
+		}
+	}
+
+	if grace := os.Getenv("TRIP_EXPIRY_GRACE"); grace != "" {
+		if d, err := time.ParseDuration(grace); err == nil {
+			cfg.TripExpiryGrace = d
+		}
+	}
+
+	if window := os.Getenv("DRIVER_CANCEL_WINDOW"); window != "" {
+		if d, err := time.ParseDuration(window); err == nil {
+			cfg.DriverCancelWindow = d
+		}
+	}
+
+	if threshold := os.Getenv("DRIVER_CANCEL_THRESHOLD"); threshold != "" {
+		if n, err := strconv.Atoi(threshold); err == nil {
+			cfg.DriverCancelThreshold = n
+		}
+	}
+
+	if cooldown := os.Getenv("DRIVER_CANCEL_COOLDOWN"); cooldown != "" {
+		if d, err := time.ParseDuration(cooldown); err == nil {
+			cfg.DriverCancelCooldown = d
+		}
+	}
+
+	if trackingTTL := os.Getenv("TRACKING_LINK_TTL"); trackingTTL != "" {
+		if d, err := time.ParseDuration(trackingTTL); err == nil {
+			cfg.TrackingLinkTTL = d
+		}
+	}
+
+	if favTimeout := os.Getenv("FAVORITE_DRIVER_TIMEOUT"); favTimeout != "" {
+		if d, err := time.ParseDuration(favTimeout); err == nil {
+			cfg.FavoriteDriverTimeout = d
+		}
+	}
+
+	if ahead := os.Getenv("RECURRING_ORDER_AHEAD"); ahead != "" {
+		if d, err := time.ParseDuration(ahead); err == nil {
+			cfg.RecurringOrderAhead = d
+		}
+	}
+
+	if surgeInterval := os.Getenv("SURGE_INTERVAL"); surgeInterval != "" {
+		if d, err := time.ParseDuration(surgeInterval); err == nil && d > 0 {
+			cfg.SurgeInterval = d
+		}
+	}
+
+	if surgeMax := os.Getenv("SURGE_MAX_MULTIPLIER"); surgeMax != "" {
+		if m, err := strconv.ParseFloat(surgeMax, 64); err == nil && m >= 1 {
+			cfg.SurgeMaxMultiplier = m
+		}
+	}
+
+	// Для разработки: SMS_PROVIDER_URL=http://localhost:8082/stub/sms
+	if smsURL := os.Getenv("SMS_PROVIDER_URL"); smsURL != "" {
+		cfg.SMSProviderURL = smsURL
+	}
+
+	if smsTimeout := os.Getenv("SMS_TIMEOUT"); smsTimeout != "" {
+		if d, err := time.ParseDuration(smsTimeout); err == nil && d > 0 {
+			cfg.SMSTimeout = d
+		}
+	}
+
+	if smtpHost := os.Getenv("SMTP_HOST"); smtpHost != "" {
+		cfg.SMTPHost = smtpHost
+	}
+
+	if smtpPort := os.Getenv("SMTP_PORT"); smtpPort != "" {
+		if p, err := strconv.Atoi(smtpPort); err == nil && p > 0 {
+			cfg.SMTPPort = p
+		}
+	}
+
+	cfg.SMTPUsername = os.Getenv("SMTP_USERNAME")
+	cfg.SMTPPassword = os.Getenv("SMTP_PASSWORD")
+	cfg.SMTPFrom = os.Getenv("SMTP_FROM")
+
+	// Формат: "Алматы=6h,Астана=12h"
+	if byCity := os.Getenv("ORDER_TTL_BY_CITY"); byCity != "" {
+		cfg.OrderTTLByCity = parseDurationMap(byCity)
+	}
+
+	// Формат: "tow=2h,large=48h"
+	if byTruck := os.Getenv("ORDER_TTL_BY_TRUCK_TYPE"); byTruck != "" {
+		cfg.OrderTTLByTruckType = parseDurationMap(byTruck)
+	}
+
 	return cfg, nil
 }
 
+// parseDurationMap parses "key=duration,key=duration" pairs, skipping malformed entries
+func parseDurationMap(s string) map[string]time.Duration {
+	out := make(map[string]time.Duration)
+	for _, pair := range strings.Split(s, ",") {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		d, err := time.ParseDuration(strings.TrimSpace(value))
+		if key == "" || err != nil || d <= 0 {
+			continue
+		}
+		out[key] = d
+	}
+	return out
+}
+
+// OrderTTLFor returns how long a pending order lives before it expires.
+// A city rule wins over a truck type rule; otherwise OrderTTL is used.
+func (c *Config) OrderTTLFor(city, truckType string) time.Duration {
+	if ttl, ok := c.OrderTTLByCity[city]; ok {
+		return ttl
+	}
+	if ttl, ok := c.OrderTTLByTruckType[truckType]; ok {
+		return ttl
+	}
+	return c.OrderTTL
+}
+
+// MinOrderTTL returns the shortest configured order TTL
+func (c *Config) MinOrderTTL() time.Duration {
+	min := c.OrderTTL
+	for _, ttl := range c.OrderTTLByCity {
+		if ttl < min {
+			min = ttl
+		}
+	}
+	for _, ttl := range c.OrderTTLByTruckType {
+		if ttl < min {
+			min = ttl
+		}
+	}
+	return min
+}
+
 // IsDevelopment returns true if the environment is development
 func (c *Config) IsDevelopment() bool {
 	return c.Environment == "development"
@@ -283,9 +562,70 @@ func (c *Config) ValidateConfig() error {
 		return fmt.Errorf("maximum file size must be positive")
 	}
 
+	if c.OrderTTL <= 0 {
+		return fmt.Errorf("order TTL must be positive")
+	}
+
+	if c.OrderExpiryInterval <= 0 {
+		return fmt.Errorf("order expiry interval must be positive")
+	}
+
+	if c.DispatchLeadTime < 0 {
+		return fmt.Errorf("dispatch lead time cannot be negative")
+	}
+
+	if c.MaxScheduleAhead <= 0 {
+		return fmt.Errorf("max schedule ahead must be positive")
+	}
+
+	if c.BidTTL <= 0 {
+		return fmt.Errorf("bid TTL must be positive")
+	}
+
+	if c.TripBookingTTL <= 0 {
+		return fmt.Errorf("trip booking TTL must be positive")
+	}
+
+	if c.TripExpiryGrace < 0 {
+		return fmt.Errorf("trip expiry grace cannot be negative")
+	}
+
+	if c.DriverCancelWindow <= 0 || c.DriverCancelThreshold <= 0 || c.DriverCancelCooldown <= 0 {
+		return fmt.Errorf("driver cancel window, threshold and cooldown must be positive")
+	}
+
+	if c.TrackingLinkTTL <= 0 {
+		return fmt.Errorf("tracking link TTL must be positive")
+	}
+
+	if c.FavoriteDriverTimeout <= 0 {
+		return fmt.Errorf("favorite driver timeout must be positive")
+	}
+
+	if c.RecurringOrderAhead <= 0 || c.RecurringOrderAhead > c.MaxScheduleAhead {
+		return fmt.Errorf("recurring order ahead must be positive and not exceed max schedule ahead")
+	}
+
+	if c.RepostRaisePercent <= 0 {
+		return fmt.Errorf("repost raise percent must be positive")
+	}
+
+	if c.SMTPHost != "" && c.SMTPFrom == "" {
+		return fmt.Errorf("SMTP from address is required when SMTP host is set")
+	}
+
 	return nil
 }
 
+// Location returns the business time zone. Если tzdata в системе нет,
+// используется фиксированный UTC+5 (Алматы с 2024 года).
+func (c *Config) Location() *time.Location {
+	if loc, err := time.LoadLocation(c.TimeZone); err == nil {
+		return loc
+	}
+	return time.FixedZone(c.TimeZone, 5*60*60)
+}
+
 // GetAllowedExtensions returns the list of allowed file extensions
 func (c *Config) GetAllowedExtensions() []string {
 	return c.AllowedExts
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOrderTTLFor(t *testing.T) {
+	cfg := &Config{
+		OrderTTL:            24 * time.Hour,
+		OrderTTLByCity:      map[string]time.Duration{"Алматы": 2 * time.Hour},
+		OrderTTLByTruckType: map[string]time.Duration{"tow": time.Hour, "large": 48 * time.Hour},
+	}
+
+	tests := []struct {
+		name      string
+		city      string
+		truckType string
+		want      time.Duration
+	}{
+		{"default", "Шымкент", "small", 24 * time.Hour},
+		{"by truck type", "Шымкент", "tow", time.Hour},
+		{"by city", "Алматы", "small", 2 * time.Hour},
+		{"city wins over truck type", "Алматы", "large", 2 * time.Hour},
+		{"unknown city and empty truck type", "", "", 24 * time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cfg.OrderTTLFor(tt.city, tt.truckType); got != tt.want {
+				t.Errorf("OrderTTLFor(%q, %q) = %v, want %v", tt.city, tt.truckType, got, tt.want)
+			}
+		})
+	}
+
+	if got := cfg.MinOrderTTL(); got != time.Hour {
+		t.Errorf("MinOrderTTL() = %v, want %v", got, time.Hour)
+	}
+}
+
+func TestParseDurationMap(t *testing.T) {
+	got := parseDurationMap("Алматы=2h, tow = 30m,bad=x,=1h,large=-1h")
+	want := map[string]time.Duration{"Алматы": 2 * time.Hour, "tow": 30 * time.Minute}
+	if len(got) != len(want) {
+		t.Fatalf("parseDurationMap() = %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("parseDurationMap()[%q] = %v, want %v", k, got[k], v)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package domain
+
+import (
+	"math"
+	"sort"
+)
+
+// Обратная загрузка: после межгородней доставки водителю предлагают грузы
+// от точки выгрузки в сторону его города (drivers.start_city)
+const (
+	BackhaulMinTripKm   = 100.0 // доставка короче — водитель и так недалеко от дома
+	BackhaulPickupKm    = 50.0  // груз забирают не дальше этого от точки выгрузки
+	BackhaulMinProgress = 0.5   // груз приближает к дому хотя бы на половину своего пути
+	MaxBackhaulOffers   = 5
+)
+
+// Откуда предложение в подборке
+const (
+	BackhaulKindOrder  = "order"  // открытая заявка
+	BackhaulKindSearch = "search" // сохранённый поиск клиента
+)
+
+// BackhaulOffer — груз по пути домой. Price есть только у заявок.
+type BackhaulOffer struct {
+	Kind        string    `json:"kind"`
+	ID          string    `json:"id"`
+	FromAddress string    `json:"from_address"`
+	From        GeoPoint  `json:"from"`
+	ToAddress   string    `json:"to_address"`
+	To          GeoPoint  `json:"to"`
+	Price       int       `json:"price"`
+	TruckType   string    `json:"truck_type"`
+	Cargo       CargoSpec `json:"cargo"`
+	PickupKm    float64   `json:"pickup_km"`   // порожний пробег до погрузки
+	ProgressKm  float64   `json:"progress_km"` // на сколько груз приближает к дому
+	Score       float64   `json:"score"`
+}
+
+// BackhaulRoute — где водитель выгрузился и где его дом
+type BackhaulRoute struct {
+	Dropoff GeoPoint
+	Home    GeoPoint
+}
+
+// IsIntercity — доставка из from в to межгородняя, по расстоянию по прямой
+func IsIntercity(from, to GeoPoint) bool {
+	return haversineKm(from, to) >= IntercityMinKm
+}
+
+// NeedsBackhaul — до дома ещё далеко, обратно поедет межгородом
+func (r BackhaulRoute) NeedsBackhaul() bool {
+	return haversineKm(r.Dropoff, r.Home) >= BackhaulMinTripKm
+}
+
+// Evaluate считает пробег и выгоду предложения; false — груз не по пути домой
+func (r BackhaulRoute) Evaluate(o *BackhaulOffer) bool {
+	pickup := haversineKm(r.Dropoff, o.From)
+	leg := haversineKm(o.From, o.To)
+	if pickup > BackhaulPickupKm || leg < 1 {
+		return false
+	}
+	progress := haversineKm(o.From, r.Home) - haversineKm(o.To, r.Home)
+	if progress < BackhaulMinProgress*leg {
+		return false
+	}
+	o.PickupKm = pickup
+	o.ProgressKm = progress
+	// километры к дому за вычетом порожнего пробега до погрузки
+	o.Score = progress - pickup
+	return true
+}
+
+// RankBackhaul sorts offers by score, then price, and keeps the best MaxBackhaulOffers
+func RankBackhaul(offers []BackhaulOffer) []BackhaulOffer {
+	sort.SliceStable(offers, func(i, j int) bool {
+		if offers[i].Score != offers[j].Score {
+			return offers[i].Score > offers[j].Score
+		}
+		return offers[i].Price > offers[j].Price
+	})
+	if len(offers) > MaxBackhaulOffers {
+		offers = offers[:MaxBackhaulOffers]
+	}
+	return offers
+}
+
+func haversineKm(a, b GeoPoint) float64 {
+	const earthRadiusKm = 6371.0
+	lat1, lat2 := a.Lat*math.Pi/180, b.Lat*math.Pi/180
+	dLat := lat2 - lat1
+	dLon := (b.Lon - a.Lon) * math.Pi / 180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Статусы брони места в рейсе водителя
+const (
+	BookingRequested = "requested"
+	BookingAccepted  = "accepted"
+	BookingDeclined  = "declined"
+	BookingCancelled = "cancelled"
+	BookingExpired   = "expired"
+)
+
+// MaxOpenBookings — сколько броней клиент может ждать одновременно
+const MaxOpenBookings = 5
+
+// TripBooking — запрос клиента на место в опубликованном рейсе (driver_trips).
+// Payload — тело заявки; сама заявка создаётся, когда водитель принимает бронь,
+// и привязывается к рейсу через trip_id.
+type TripBooking struct {
+	ID               int64           `json:"id" db:"id"`
+	TripID           string          `json:"trip_id" db:"trip_id"`
+	DriverID         string          `json:"driver_id" db:"driver_id"`
+	DriverTelegramID int64           `json:"-" db:"driver_telegram_id"`
+	TelegramID       int64           `json:"-" db:"telegram_id"`
+	FromAddress      string          `json:"from_address" db:"from_address"`
+	ToAddress        string          `json:"to_address" db:"to_address"`
+	Price            int             `json:"price" db:"price"`
+	WeightKg         float64         `json:"weight_kg" db:"weight_kg"`
+	VolumeM3         float64         `json:"volume_m3" db:"volume_m3"`
+	Status           string          `json:"status" db:"status"`
+	RequestID        string          `json:"request_id,omitempty" db:"request_id"` // заявка после принятия
+	ExpiresAt        time.Time       `json:"expires_at" db:"expires_at"`
+	DecidedAt        *time.Time      `json:"decided_at,omitempty" db:"decided_at"`
+	CreatedAt        time.Time       `json:"created_at" db:"created_at"`
+	Payload          json.RawMessage `json:"-" db:"payload"`
+}
+
+// IsOpen — водитель ещё может принять или отклонить бронь
+func (b *TripBooking) IsOpen(now time.Time) bool {
+	return b.Status == BookingRequested && now.Before(b.ExpiresAt)
+}
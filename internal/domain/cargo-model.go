@@ -0,0 +1,156 @@
+package domain
+
+import "sort"
+
+// CargoSpec — структурированное описание груза в заявке. Нулевые значения
+// означают "не указано" и не участвуют в подборе машины.
+type CargoSpec struct {
+	WeightKg     float64 `json:"weight_kg" db:"cargo_weight_kg"`
+	VolumeM3     float64 `json:"volume_m3" db:"cargo_volume_m3"`
+	LengthCm     int     `json:"length_cm" db:"cargo_length_cm"`
+	WidthCm      int     `json:"width_cm" db:"cargo_width_cm"`
+	HeightCm     int     `json:"height_cm" db:"cargo_height_cm"`
+	Items        int     `json:"items" db:"cargo_items"`
+	NeedsLoaders bool    `json:"needs_loaders" db:"needs_loaders"`
+	Fragile      bool    `json:"fragile" db:"fragile"`
+	Floor        int     `json:"floor" db:"floor"` // этаж погрузки/выгрузки, 0 — не указан
+	HasElevator  bool    `json:"has_elevator" db:"has_elevator"`
+}
+
+// VehicleCapacity is what a driver's vehicle (or a particular trip) can carry
+type VehicleCapacity struct {
+	TruckType    string  `json:"truck_type"`
+	MaxWeightKg  float64 `json:"max_weight_kg" db:"max_weight_kg"`
+	MaxVolumeM3  float64 `json:"max_volume_m3" db:"max_volume_m3"`
+	BodyLengthCm int     `json:"body_length_cm" db:"body_length_cm"`
+	BodyWidthCm  int     `json:"body_width_cm" db:"body_width_cm"`
+	BodyHeightCm int     `json:"body_height_cm" db:"body_height_cm"`
+}
+
+// Вместимость по умолчанию для водителей, которые не указали свою
+var defaultTruckCapacity = map[string]VehicleCapacity{
+	TruckTypeSmall:  {MaxWeightKg: 1500, MaxVolumeM3: 9},
+	TruckTypeMedium: {MaxWeightKg: 5000, MaxVolumeM3: 25},
+	TruckTypeLarge:  {MaxWeightKg: 20000, MaxVolumeM3: 82},
+}
+
+// Классы кузова по грузоподъёмности; спецтехника (рефрижератор, эвакуатор) вне шкалы
+var truckClassRank = map[string]int{
+	TruckTypeSmall:  1,
+	TruckTypeMedium: 2,
+	TruckTypeLarge:  3,
+}
+
+// IsEmpty reports whether the client gave no cargo details at all
+func (c CargoSpec) IsEmpty() bool {
+	return c == CargoSpec{}
+}
+
+// HasDimensions — указаны все три габарита
+func (c CargoSpec) HasDimensions() bool {
+	return c.LengthCm > 0 && c.WidthCm > 0 && c.HeightCm > 0
+}
+
+// Effective fills unknown limits from the truck type defaults
+func (v VehicleCapacity) Effective() VehicleCapacity {
+	def, ok := defaultTruckCapacity[v.TruckType]
+	if !ok {
+		return v
+	}
+	if v.MaxWeightKg == 0 {
+		v.MaxWeightKg = def.MaxWeightKg
+	}
+	if v.MaxVolumeM3 == 0 {
+		v.MaxVolumeM3 = def.MaxVolumeM3
+	}
+	return v
+}
+
+// class — класс машины: по типу кузова, а если тип не указан — по грузоподъёмности
+func (v VehicleCapacity) class() int {
+	if rank, ok := truckClassRank[v.TruckType]; ok {
+		return rank
+	}
+	switch {
+	case v.MaxWeightKg <= 0:
+		return 0
+	case v.MaxWeightKg <= defaultTruckCapacity[TruckTypeSmall].MaxWeightKg:
+		return 1
+	case v.MaxWeightKg <= defaultTruckCapacity[TruckTypeMedium].MaxWeightKg:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// CanCarry reports whether the vehicle fits an order of the given truck type and cargo.
+// Рефрижератор и эвакуатор нужны именно такие, остальные машины — не меньше запрошенного
+// класса. Указанные вес и объём груза сравниваем с вместимостью: если она неизвестна,
+// считаем, что груз не влезет. Габариты проверяем, только когда водитель указал кузов.
+func (v VehicleCapacity) CanCarry(orderTruckType string, cargo CargoSpec) bool {
+	if orderTruckType == TruckTypeRefrigerator || orderTruckType == TruckTypeTow {
+		if v.TruckType != orderTruckType {
+			return false
+		}
+	}
+
+	eff := v.Effective()
+	if want, ok := truckClassRank[orderTruckType]; ok && eff.class() < want {
+		return false
+	}
+	if cargo.WeightKg > 0 && (eff.MaxWeightKg <= 0 || cargo.WeightKg > eff.MaxWeightKg) {
+		return false
+	}
+	if cargo.VolumeM3 > 0 && (eff.MaxVolumeM3 <= 0 || cargo.VolumeM3 > eff.MaxVolumeM3) {
+		return false
+	}
+	if cargo.HasDimensions() && eff.BodyLengthCm > 0 && eff.BodyWidthCm > 0 && eff.BodyHeightCm > 0 {
+		if !fitsBox(
+			[]int{cargo.LengthCm, cargo.WidthCm, cargo.HeightCm},
+			[]int{eff.BodyLengthCm, eff.BodyWidthCm, eff.BodyHeightCm},
+		) {
+			return false
+		}
+	}
+	return true
+}
+
+// FitScore ранжирует подходящие машины: 1 — груз занимает машину почти полностью,
+// ближе к 0 — машина сильно больше, чем нужно.
+func (v VehicleCapacity) FitScore(orderTruckType string, cargo CargoSpec) float64 {
+	eff := v.Effective()
+	switch {
+	case cargo.WeightKg > 0 && eff.MaxWeightKg > 0:
+		return clamp01(cargo.WeightKg / eff.MaxWeightKg)
+	case cargo.VolumeM3 > 0 && eff.MaxVolumeM3 > 0:
+		return clamp01(cargo.VolumeM3 / eff.MaxVolumeM3)
+	}
+	want, ok := truckClassRank[orderTruckType]
+	have := eff.class()
+	if !ok || have == 0 {
+		return 0.5
+	}
+	return clamp01(1 - 0.25*float64(have-want))
+}
+
+// fitsBox: груз можно повернуть, поэтому сравниваем отсортированные габариты
+func fitsBox(item, body []int) bool {
+	sort.Sort(sort.Reverse(sort.IntSlice(item)))
+	sort.Sort(sort.Reverse(sort.IntSlice(body)))
+	for i := range item {
+		if item[i] > body[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func clamp01(x float64) float64 {
+	if x < 0 {
+		return 0
+	}
+	if x > 1 {
+		return 1
+	}
+	return x
+}
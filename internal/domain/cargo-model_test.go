@@ -0,0 +1,73 @@
+package domain
+
+import "testing"
+
+func TestVehicleCapacityCanCarry(t *testing.T) {
+	small := VehicleCapacity{TruckType: TruckTypeSmall}
+	large := VehicleCapacity{TruckType: TruckTypeLarge}
+	unknown := VehicleCapacity{}
+	heavyUntyped := VehicleCapacity{MaxWeightKg: 8000, MaxVolumeM3: 40}
+	box := VehicleCapacity{TruckType: TruckTypeMedium, BodyLengthCm: 420, BodyWidthCm: 200, BodyHeightCm: 200}
+
+	tests := []struct {
+		name      string
+		vehicle   VehicleCapacity
+		truckType string
+		cargo     CargoSpec
+		want      bool
+	}{
+		{"any order without cargo", unknown, "", CargoSpec{}, true},
+		{"same class", small, TruckTypeSmall, CargoSpec{}, true},
+		{"bigger class", large, TruckTypeMedium, CargoSpec{}, true},
+		{"smaller class", small, TruckTypeLarge, CargoSpec{}, false},
+		{"smaller class with light explicit cargo", small, TruckTypeLarge, CargoSpec{WeightKg: 300}, false},
+		{"class by weight when type is unknown", heavyUntyped, TruckTypeMedium, CargoSpec{}, true},
+		{"unknown vehicle for classed order", unknown, TruckTypeSmall, CargoSpec{}, false},
+		{"weight within defaults", small, TruckTypeSmall, CargoSpec{WeightKg: 1500}, true},
+		{"weight over defaults", small, "", CargoSpec{WeightKg: 1600}, false},
+		{"own limit overrides default", VehicleCapacity{TruckType: TruckTypeSmall, MaxWeightKg: 800}, "", CargoSpec{WeightKg: 900}, false},
+		{"volume over defaults", small, "", CargoSpec{VolumeM3: 10}, false},
+		{"zero weight capacity with explicit weight", unknown, "", CargoSpec{WeightKg: 100}, false},
+		{"zero volume capacity with explicit volume", VehicleCapacity{MaxWeightKg: 3000}, "", CargoSpec{VolumeM3: 5}, false},
+		{"zero capacity refrigerator with explicit weight", VehicleCapacity{TruckType: TruckTypeRefrigerator}, TruckTypeRefrigerator, CargoSpec{WeightKg: 500}, false},
+		{"refrigerator needs refrigerator", large, TruckTypeRefrigerator, CargoSpec{}, false},
+		{"refrigerator order", VehicleCapacity{TruckType: TruckTypeRefrigerator, MaxWeightKg: 3000}, TruckTypeRefrigerator, CargoSpec{WeightKg: 500}, true},
+		{"tow needs tow", VehicleCapacity{TruckType: TruckTypeTow}, TruckTypeTow, CargoSpec{}, true},
+		{"fits body rotated", box, "", CargoSpec{LengthCm: 150, WidthCm: 410, HeightCm: 100}, true},
+		{"too long for body", box, "", CargoSpec{LengthCm: 450, WidthCm: 100, HeightCm: 100}, false},
+		{"dimensions ignored without body", small, "", CargoSpec{LengthCm: 900, WidthCm: 100, HeightCm: 100}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.vehicle.CanCarry(tt.truckType, tt.cargo); got != tt.want {
+				t.Errorf("CanCarry(%q, %+v) = %v, want %v", tt.truckType, tt.cargo, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVehicleCapacityFitScore(t *testing.T) {
+	tests := []struct {
+		name      string
+		vehicle   VehicleCapacity
+		truckType string
+		cargo     CargoSpec
+		want      float64
+	}{
+		{"by weight", VehicleCapacity{TruckType: TruckTypeMedium}, "", CargoSpec{WeightKg: 2500}, 0.5},
+		{"by volume", VehicleCapacity{TruckType: TruckTypeSmall}, "", CargoSpec{VolumeM3: 9}, 1},
+		{"overload clamps to one", VehicleCapacity{MaxWeightKg: 1000}, "", CargoSpec{WeightKg: 2000}, 1},
+		{"same class", VehicleCapacity{TruckType: TruckTypeSmall}, TruckTypeSmall, CargoSpec{}, 1},
+		{"two classes bigger", VehicleCapacity{TruckType: TruckTypeLarge}, TruckTypeSmall, CargoSpec{}, 0.5},
+		{"zero capacity falls back to class", VehicleCapacity{TruckType: TruckTypeRefrigerator}, "", CargoSpec{WeightKg: 500}, 0.5},
+		{"unknown vehicle", VehicleCapacity{}, TruckTypeMedium, CargoSpec{}, 0.5},
+		{"any order", VehicleCapacity{TruckType: TruckTypeLarge}, "", CargoSpec{}, 0.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.vehicle.FitScore(tt.truckType, tt.cargo); got != tt.want {
+				t.Errorf("FitScore(%q, %+v) = %v, want %v", tt.truckType, tt.cargo, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package domain
+
+import "time"
+
+// Статусы жалобы
+const (
+	ComplaintStatusOpen          = "open"
+	ComplaintStatusInvestigating = "investigating"
+	ComplaintStatusResolved      = "resolved"
+	ComplaintStatusRejected      = "rejected"
+)
+
+// Кто подал жалобу
+const (
+	ComplainantClient = "client"
+	ComplainantDriver = "driver"
+)
+
+// Категории жалоб
+const (
+	ComplaintDamagedCargo   = "damaged_cargo"
+	ComplaintDriverNoShow   = "driver_no_show"
+	ComplaintClientNoShow   = "client_no_show"
+	ComplaintPaymentRefused = "payment_refused"
+	ComplaintLateDelivery   = "late_delivery"
+	ComplaintBehavior       = "behavior"
+	ComplaintOther          = "other"
+)
+
+// Итог разбора жалобы
+const (
+	ComplaintOutcomeNone            = "none"
+	ComplaintOutcomeWarning         = "warning"
+	ComplaintOutcomeDriverSuspended = "driver_suspended"
+)
+
+var complaintCategories = map[string]bool{
+	ComplaintDamagedCargo:   true,
+	ComplaintDriverNoShow:   true,
+	ComplaintClientNoShow:   true,
+	ComplaintPaymentRefused: true,
+	ComplaintLateDelivery:   true,
+	ComplaintBehavior:       true,
+	ComplaintOther:          true,
+}
+
+func IsValidComplaintCategory(c string) bool {
+	return complaintCategories[c]
+}
+
+func IsValidComplaintOutcome(o string) bool {
+	switch o {
+	case ComplaintOutcomeNone, ComplaintOutcomeWarning, ComplaintOutcomeDriverSuspended:
+		return true
+	}
+	return false
+}
+
+// Complaint — жалоба одной из сторон по конкретной заявке
+type Complaint struct {
+	ID                int64      `json:"id" db:"id"`
+	RequestID         string     `json:"request_id" db:"request_id"`
+	FiledBy           string     `json:"filed_by" db:"filed_by"` // client, driver
+	FiledByTelegramID int64      `json:"filed_by_telegram_id" db:"filed_by_telegram_id"`
+	DriverID          string     `json:"driver_id" db:"driver_id"`
+	ClientTelegramID  int64      `json:"client_telegram_id" db:"client_telegram_id"`
+	Category          string     `json:"category" db:"category"`
+	Description       string     `json:"description" db:"description"`
+	Status            string     `json:"status" db:"status"`
+	AssignedTo        int64      `json:"assigned_to,omitempty" db:"assigned_to"` // telegram_id администратора
+	Resolution        string     `json:"resolution,omitempty" db:"resolution"`
+	Outcome           string     `json:"outcome,omitempty" db:"outcome"`
+	CreatedAt         time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at" db:"updated_at"`
+	ResolvedAt        *time.Time `json:"resolved_at,omitempty" db:"resolved_at"`
+
+	Attachments []string           `json:"attachments,omitempty"`
+	Comments    []ComplaintComment `json:"comments,omitempty"`
+}
+
+// ComplaintComment — внутренняя заметка администратора по жалобе
+type ComplaintComment struct {
+	ID               int64     `json:"id" db:"id"`
+	ComplaintID      int64     `json:"complaint_id" db:"complaint_id"`
+	AuthorTelegramID int64     `json:"author_telegram_id" db:"author_telegram_id"`
+	Body             string    `json:"body" db:"body"`
+	CreatedAt        time.Time `json:"created_at" db:"created_at"`
+}
+
+// IsClosed — решённую или отклонённую жалобу больше не меняют
+func (c *Complaint) IsClosed() bool {
+	return c.Status == ComplaintStatusResolved || c.Status == ComplaintStatusRejected
+}
@@ -37,6 +37,12 @@ type Driver struct {
 	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt        time.Time  `json:"updated_at" db:"updated_at"`
 
+	// Грузоподъёмность и кузов, заявленные водителем
+	Capacity VehicleCapacity `json:"capacity"`
+
+	// Настройки рассылки; заполняются только при подборе водителей для заявки
+	Prefs DriverPreferences `json:"-"`
+
 	FromLat float64 `json:"from_lat" db:"from_lat"`
 	FromLon float64 `json:"from_lon" db:"from_lon"`
 	ToLat   float64 `json:"to_lat" db:"to_lat"`
@@ -85,6 +91,7 @@ type DriverMatch struct {
 	FinalPrice        *int       `json:"final_price" db:"final_price"`
 	PickupTime        *time.Time `json:"pickup_time" db:"pickup_time"`
 	DeliveryTime      *time.Time `json:"delivery_time" db:"delivery_time"`
+	EtaMin            int        `json:"eta_min" db:"eta_min"` // через сколько минут водитель подаст машину
 	DriverComment     string     `json:"driver_comment" db:"driver_comment"`
 	ClientComment     string     `json:"client_comment" db:"client_comment"`
 	DriverRating      *int       `json:"driver_rating" db:"driver_rating"` // 1-5
@@ -92,6 +99,18 @@ type DriverMatch struct {
 	CreatedAt         time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt         time.Time  `json:"updated_at" db:"updated_at"`
 	CompletedAt       *time.Time `json:"completed_at" db:"completed_at"`
+	ExpiresAt         *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+}
+
+// DriverBid — предложение водителя вместе с данными, которые видит клиент
+type DriverBid struct {
+	DriverMatch
+	DriverTelegramID int64  `json:"driver_telegram_id"`
+	DriverName       string `json:"driver_name"`
+	DriverPhone      string `json:"driver_phone"`
+	TruckType        string `json:"truck_type"`
+	ProfilePhoto     string `json:"profile_photo"`
+	IsVerified       bool   `json:"is_verified"`
 }
 
 // CreateDriverRequest represents a request to create a new driver
@@ -118,7 +137,7 @@ type CreateDriverRouteRequest struct {
 	ToAddress      string    `json:"to_address" validate:"required"`
 	ToLat          float64   `json:"to_lat" validate:"required"`
 	ToLon          float64   `json:"to_lon" validate:"required"`
-	Price          int       `json:"price" validate:"required,min=2000"`
+	Price          int       `json:"price" validate:"required,min=1"`
 	TruckType      string    `json:"truck_type" validate:"required"`
 	MaxWeight      int       `json:"max_weight"`
 	Comment        string    `json:"comment"`
@@ -209,6 +228,8 @@ const (
 	MatchStatusAccepted  = "accepted"
 	MatchStatusRejected  = "rejected"
 	MatchStatusCompleted = "completed"
+	MatchStatusExpired   = "expired"
+	MatchStatusCancelled = "cancelled" // водитель отказался после назначения
 )
 
 // Helper functions for UUID operations
@@ -237,7 +258,7 @@ func (d *Driver) IsValidAge() bool {
 
 func (dr *DriverRoute) IsValid() bool {
 	return IsValidDriverID(dr.DriverID) && dr.FromAddress != "" && dr.ToAddress != "" &&
-		dr.Price >= 2000 && !dr.DepartureTime.IsZero() && IsValidDriverID(dr.ID)
+		dr.Price > 0 && !dr.DepartureTime.IsZero() && IsValidDriverID(dr.ID)
 }
 
 func (dr *DriverRoute) IsValidCoordinates() bool {
@@ -0,0 +1,159 @@
+package domain
+
+import (
+	"sort"
+	"time"
+)
+
+// Карта спроса: окно и размер ячейки задаёт клиент в пределах этих границ
+const (
+	DefaultHeatmapWindow    = 24 * time.Hour
+	MaxHeatmapWindow        = 30 * 24 * time.Hour
+	DefaultHeatmapPrecision = 5 // ~4.9 км
+	MinHeatmapPrecision     = 4 // ~39 км
+	MaxHeatmapPrecision     = 6 // ~1.2 км
+)
+
+// MapFilter — фильтры слоёв карты. City ищется в адресе точки A.
+type MapFilter struct {
+	City      string
+	TruckType string
+	Since     time.Time
+}
+
+// OrderPoint — заявка на карте: точки A и B без данных клиента
+type OrderPoint struct {
+	ID        string
+	From      GeoPoint
+	To        GeoPoint
+	Price     int
+	TruckType string
+	Status    string
+	CreatedAt time.Time
+}
+
+// TripRoute — активная поездка водителя для слоя маршрутов
+type TripRoute struct {
+	ID        string
+	From      GeoPoint
+	To        GeoPoint
+	Price     int
+	TruckType string
+	StartTime string
+}
+
+// HeatCell — число заявок в ячейке geohash; Unmet — из них не взятые водителем
+type HeatCell struct {
+	Geohash string
+	Count   int
+	Unmet   int
+}
+
+// Unmet reports whether no driver took the order (yet)
+func (p OrderPoint) Unmet() bool {
+	return p.Status == "pending" || p.Status == "expired"
+}
+
+// AggregateHeatCells groups orders by the geohash of their origin or, with
+// byDestination, of their destination. Самые загруженные ячейки — первыми.
+func AggregateHeatCells(points []OrderPoint, byDestination bool, precision int) []HeatCell {
+	byHash := make(map[string]*HeatCell)
+	for _, p := range points {
+		at := p.From
+		if byDestination {
+			at = p.To
+		}
+		if at.Lat == 0 && at.Lon == 0 {
+			continue
+		}
+		hash := GeohashEncode(at.Lat, at.Lon, precision)
+		c, ok := byHash[hash]
+		if !ok {
+			c = &HeatCell{Geohash: hash}
+			byHash[hash] = c
+		}
+		c.Count++
+		if p.Unmet() {
+			c.Unmet++
+		}
+	}
+
+	cells := make([]HeatCell, 0, len(byHash))
+	for _, c := range byHash {
+		cells = append(cells, *c)
+	}
+	sort.Slice(cells, func(i, j int) bool {
+		if cells[i].Count != cells[j].Count {
+			return cells[i].Count > cells[j].Count
+		}
+		return cells[i].Geohash < cells[j].Geohash
+	})
+	return cells
+}
+
+// GeoJSON (RFC 7946): координаты идут в порядке [lon, lat]
+type GeoJSONGeometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+type GeoJSONFeature struct {
+	Type       string                 `json:"type"`
+	Geometry   GeoJSONGeometry        `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type GeoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []GeoJSONFeature `json:"features"`
+}
+
+func NewFeatureCollection() *GeoJSONFeatureCollection {
+	return &GeoJSONFeatureCollection{Type: "FeatureCollection", Features: []GeoJSONFeature{}}
+}
+
+func (p GeoPoint) position() []float64 {
+	return []float64{p.Lon, p.Lat}
+}
+
+// PointFeature builds a GeoJSON Point
+func PointFeature(p GeoPoint, props map[string]interface{}) GeoJSONFeature {
+	return GeoJSONFeature{
+		Type:       "Feature",
+		Geometry:   GeoJSONGeometry{Type: "Point", Coordinates: p.position()},
+		Properties: props,
+	}
+}
+
+// LineFeature builds a GeoJSON LineString through the given points
+func LineFeature(points []GeoPoint, props map[string]interface{}) GeoJSONFeature {
+	coords := make([][]float64, 0, len(points))
+	for _, p := range points {
+		coords = append(coords, p.position())
+	}
+	return GeoJSONFeature{
+		Type:       "Feature",
+		Geometry:   GeoJSONGeometry{Type: "LineString", Coordinates: coords},
+		Properties: props,
+	}
+}
+
+// CellFeature builds a GeoJSON Polygon covering a geohash cell; false for invalid hash
+func CellFeature(hash string, props map[string]interface{}) (GeoJSONFeature, bool) {
+	sw, ne, ok := GeohashBounds(hash)
+	if !ok {
+		return GeoJSONFeature{}, false
+	}
+	ring := [][]float64{
+		{sw.Lon, sw.Lat},
+		{ne.Lon, sw.Lat},
+		{ne.Lon, ne.Lat},
+		{sw.Lon, ne.Lat},
+		{sw.Lon, sw.Lat},
+	}
+	return GeoJSONFeature{
+		Type:       "Feature",
+		Geometry:   GeoJSONGeometry{Type: "Polygon", Coordinates: [][][]float64{ring}},
+		Properties: props,
+	}, true
+}
@@ -0,0 +1,65 @@
+package domain
+
+import "time"
+
+// Каналы доставки уведомлений
+const (
+	NotifyChannelTelegram = "telegram"
+	NotifyChannelSMS      = "sms"
+	NotifyChannelEmail    = "email"
+)
+
+var NotifyChannels = []string{NotifyChannelTelegram, NotifyChannelSMS, NotifyChannelEmail}
+
+// Статусы доставки: pending пишется до отправки, skipped — канал не настроен или нет адреса
+const (
+	NotificationPending = "pending"
+	NotificationSent    = "sent"
+	NotificationFailed  = "failed"
+	NotificationSkipped = "skipped"
+)
+
+// NotificationSettings — куда пользователь хочет получать уведомления.
+// Пустые Phone/Email — берём контакт из заявки или профиля.
+type NotificationSettings struct {
+	TelegramID int64    `json:"telegram_id"`
+	Channels   []string `json:"channels"`
+	Phone      string   `json:"phone"`
+	Email      string   `json:"email"`
+}
+
+func DefaultNotificationSettings(telegramID int64) NotificationSettings {
+	return NotificationSettings{TelegramID: telegramID, Channels: []string{NotifyChannelTelegram}}
+}
+
+func IsNotifyChannel(channel string) bool {
+	for _, c := range NotifyChannels {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// HasChannel reports whether the user chose the channel
+func (s NotificationSettings) HasChannel(channel string) bool {
+	for _, c := range s.Channels {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// Notification — одна попытка доставки шаблона по одному каналу
+type Notification struct {
+	ID         int64      `json:"id"`
+	TelegramID int64      `json:"telegram_id"`
+	Template   string     `json:"template"`
+	Channel    string     `json:"channel"`
+	Recipient  string     `json:"recipient"`
+	Status     string     `json:"status"`
+	Error      string     `json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	SentAt     *time.Time `json:"sent_at,omitempty"`
+}
@@ -0,0 +1,159 @@
+package domain
+
+import "time"
+
+// RevisionChange хранит старое и новое значение изменённого поля заявки
+type RevisionChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// DeliveryRequestRevision is one client edit of a delivery request
+type DeliveryRequestRevision struct {
+	ID         int64                     `json:"id" db:"id"`
+	RequestID  string                    `json:"request_id" db:"request_id"`
+	TelegramID int64                     `json:"telegram_id" db:"telegram_id"`
+	Changes    map[string]RevisionChange `json:"changes" db:"changes"`
+	CreatedAt  time.Time                 `json:"created_at" db:"created_at"`
+}
+
+// OrderBroadcast is a bot message with an order sent to a driver.
+// Нужен, чтобы при изменении заявки отредактировать уже разосланные сообщения.
+type OrderBroadcast struct {
+	RequestID        string    `json:"request_id" db:"request_id"`
+	DriverTelegramID int64     `json:"driver_telegram_id" db:"driver_telegram_id"`
+	MessageID        int       `json:"message_id" db:"message_id"`
+	HasPhoto         bool      `json:"has_photo" db:"has_photo"`
+	CreatedAt        time.Time `json:"created_at" db:"created_at"`
+}
+
+// PickupReminder — данные для напоминания клиенту и водителю перед подачей
+type PickupReminder struct {
+	RequestID        string    `json:"request_id"`
+	ClientTelegramID int64     `json:"client_telegram_id"`
+	DriverTelegramID int64     `json:"driver_telegram_id"`
+	FromAddress      string    `json:"from_address"`
+	ToAddress        string    `json:"to_address"`
+	Contact          string    `json:"contact"`
+	DriverName       string    `json:"driver_name"`
+	DriverPhone      string    `json:"driver_phone"`
+	PickupAt         time.Time `json:"pickup_at"`
+}
+
+// Статусы точки выгрузки
+const (
+	StopStatusPending = "pending"
+	StopStatusDone    = "done"
+)
+
+// OrderStop — точка выгрузки многоточечной заявки. Seq начинается с 1,
+// последняя точка дублируется в to_address/to_lat/to_lon заявки.
+type OrderStop struct {
+	ID        int64      `json:"id" db:"id"`
+	RequestID string     `json:"request_id" db:"request_id"`
+	Seq       int        `json:"seq" db:"seq"`
+	Address   string     `json:"address" db:"address"`
+	Lat       float64    `json:"lat" db:"lat"`
+	Lon       float64    `json:"lon" db:"lon"`
+	Contact   string     `json:"contact" db:"contact"`
+	Status    string     `json:"status" db:"status"` // pending, done
+	DoneAt    *time.Time `json:"done_at,omitempty" db:"done_at"`
+}
+
+// IsDone reports whether the driver marked the stop as delivered
+func (s OrderStop) IsDone() bool {
+	return s.Status == StopStatusDone
+}
+
+// Этапы подтверждения доставки
+const (
+	ProofKindPickup   = "pickup"
+	ProofKindDelivery = "delivery"
+)
+
+// OrderProof — фото груза, загруженное водителем при погрузке или выгрузке.
+// Хранится вместе с заявкой для разбора споров.
+type OrderProof struct {
+	ID        int64     `json:"id" db:"id"`
+	RequestID string    `json:"request_id" db:"request_id"`
+	Kind      string    `json:"kind" db:"kind"` // pickup, delivery
+	PhotoPath string    `json:"photo_path" db:"photo_path"`
+	DriverID  string    `json:"driver_id" db:"driver_id"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// ConfirmResult — исход проверки кода получателя
+type ConfirmResult int
+
+const (
+	ConfirmNotAllowed ConfirmResult = iota // заявка не в пути или назначена другому водителю
+	ConfirmWrongCode
+	ConfirmLocked // исчерпаны попытки ввода кода
+	ConfirmOK
+)
+
+// Причины отказа водителя от назначенной заявки (короткие — идут в callback_data)
+const (
+	CancelReasonBreakdown = "breakdown"  // поломка машины
+	CancelReasonNoContact = "no_contact" // клиент не выходит на связь
+	CancelReasonCargo     = "cargo"      // груз не соответствует описанию
+	CancelReasonEmergency = "emergency"  // личные обстоятельства
+	CancelReasonOther     = "other"
+)
+
+var driverCancelReasons = []string{
+	CancelReasonBreakdown, CancelReasonNoContact, CancelReasonCargo, CancelReasonEmergency, CancelReasonOther,
+}
+
+// DriverCancelReasons returns reasons in the order they are shown to the driver
+func DriverCancelReasons() []string {
+	return driverCancelReasons
+}
+
+func IsValidCancelReason(reason string) bool {
+	for _, r := range driverCancelReasons {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
+
+// DriverCancellation — отказ водителя от заявки после назначения
+type DriverCancellation struct {
+	ID        int64     `json:"id" db:"id"`
+	RequestID string    `json:"request_id" db:"request_id"`
+	DriverID  string    `json:"driver_id" db:"driver_id"`
+	Reason    string    `json:"reason" db:"reason"`
+	Comment   string    `json:"comment" db:"comment"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// CancelPolicy — сколько отказов за окно приводит к паузе в рассылке заявок
+type CancelPolicy struct {
+	Window    time.Duration
+	Threshold int
+	Cooldown  time.Duration
+}
+
+// PriceRaised reports whether the revision increased the order price
+func (r *DeliveryRequestRevision) PriceRaised() bool {
+	c, ok := r.Changes["price"]
+	if !ok {
+		return false
+	}
+	oldPrice, ok1 := revisionInt(c.Old)
+	newPrice, ok2 := revisionInt(c.New)
+	return ok1 && ok2 && newPrice > oldPrice
+}
+
+// revisionInt: после json.Unmarshal числа приходят как float64
+func revisionInt(v interface{}) (int, bool) {
+	switch n := v.(type) {
+	case int:
+		return n, true
+	case float64:
+		return int(n), true
+	}
+	return 0, false
+}
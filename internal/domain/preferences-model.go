@@ -0,0 +1,230 @@
+package domain
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"tezjet/internal/i18n"
+)
+
+// Настройки рассылки заявок водителю (/settings)
+const (
+	DefaultDriverRadiusKm = 30.0
+	MaxDriverRadiusKm     = 300.0
+	QuietTimeLayout       = "15:04"
+	MaxPreferenceCities   = 10
+	IntercityMinKm        = 50.0 // маршрут длиннее считаем межгородом
+	MaxVacationDays       = 90
+)
+
+// Варианты, которые бот перебирает по нажатию кнопки
+var (
+	DriverRadiusOptions   = []float64{10, 20, 30, 50, 100, 300}
+	DriverMinPriceOptions = []int{0, 3000, 5000, 10000, 20000, 50000}
+	DriverTruckTypes      = []string{TruckTypeSmall, TruckTypeMedium, TruckTypeLarge, TruckTypeRefrigerator, TruckTypeTow}
+)
+
+// DriverPreferences — какие заявки и когда присылать водителю.
+// Пустые Cities/TruckTypes означают "любые".
+type DriverPreferences struct {
+	TelegramID    int64      `json:"telegram_id"`
+	RadiusKm      float64    `json:"radius_km"`
+	Cities        []string   `json:"cities"`
+	MinPrice      int        `json:"min_price"`
+	TruckTypes    []string   `json:"truck_types"` // типы машин в заявке, которые водитель берёт
+	QuietFrom     string     `json:"quiet_from"`  // "22:00", пусто — без тихих часов
+	QuietTo       string     `json:"quiet_to"`
+	Paused        bool       `json:"paused"`
+	PausedUntil   *time.Time `json:"paused_until,omitempty"` // отпуск
+	IntercityOnly bool       `json:"intercity_only"`
+}
+
+// OrderDispatch — то, что нужно знать о заявке для проверки настроек водителя
+type OrderDispatch struct {
+	Price      int
+	TruckType  string
+	FromCity   string
+	DistanceKm float64
+	Now        time.Time // местное время
+}
+
+// Intercity — по длине маршрута: город из адреса определяется не всегда
+func (o OrderDispatch) Intercity() bool {
+	return o.DistanceKm >= IntercityMinKm
+}
+
+func DefaultDriverPreferences(telegramID int64) DriverPreferences {
+	return DriverPreferences{TelegramID: telegramID, RadiusKm: DefaultDriverRadiusKm}
+}
+
+// EffectiveRadius keeps the radius within sane bounds
+func (p *DriverPreferences) EffectiveRadius() float64 {
+	switch {
+	case p.RadiusKm <= 0:
+		return DefaultDriverRadiusKm
+	case p.RadiusKm > MaxDriverRadiusKm:
+		return MaxDriverRadiusKm
+	}
+	return p.RadiusKm
+}
+
+// IsPaused — пауза без срока или отпуск, который ещё не закончился
+func (p *DriverPreferences) IsPaused(now time.Time) bool {
+	return p.Paused || (p.PausedUntil != nil && now.Before(*p.PausedUntil))
+}
+
+// InQuietHours reports whether local time falls into quiet hours; the window may cross midnight
+func (p *DriverPreferences) InQuietHours(local time.Time) bool {
+	from, err1 := time.Parse(QuietTimeLayout, p.QuietFrom)
+	to, err2 := time.Parse(QuietTimeLayout, p.QuietTo)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	m := local.Hour()*60 + local.Minute()
+	f := from.Hour()*60 + from.Minute()
+	t := to.Hour()*60 + to.Minute()
+	if f == t {
+		return false
+	}
+	if f < t {
+		return m >= f && m < t
+	}
+	return m >= f || m < t
+}
+
+// Accepts checks everything except distance, which the repository filters by RadiusKm.
+// Тихие часы заявку не отсекают — она приходит без звука.
+func (p *DriverPreferences) Accepts(o OrderDispatch) bool {
+	if p.IsPaused(o.Now) {
+		return false
+	}
+	if p.MinPrice > 0 && o.Price < p.MinPrice {
+		return false
+	}
+	if p.IntercityOnly && !o.Intercity() {
+		return false
+	}
+	if len(p.TruckTypes) > 0 && o.TruckType != "" && !p.HasTruckType(o.TruckType) {
+		return false
+	}
+	if len(p.Cities) > 0 && !p.HasCity(o.FromCity) {
+		return false
+	}
+	return true
+}
+
+func (p *DriverPreferences) HasTruckType(t string) bool {
+	for _, tt := range p.TruckTypes {
+		if tt == t {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *DriverPreferences) HasCity(city string) bool {
+	for _, c := range p.Cities {
+		if strings.EqualFold(c, city) {
+			return true
+		}
+	}
+	return false
+}
+
+// ToggleTruckType включает или выключает тип машины
+func (p *DriverPreferences) ToggleTruckType(t string) {
+	for i, tt := range p.TruckTypes {
+		if tt == t {
+			p.TruckTypes = append(p.TruckTypes[:i], p.TruckTypes[i+1:]...)
+			return
+		}
+	}
+	p.TruckTypes = append(p.TruckTypes, t)
+}
+
+// ParseQuietHours parses "22:00-07:00"; "-" or empty turns quiet hours off
+func ParseQuietHours(s string) (from, to string, err error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "-" {
+		return "", "", nil
+	}
+	a, b, ok := strings.Cut(strings.ReplaceAll(s, " ", ""), "-")
+	if !ok {
+		return "", "", i18n.E("quiet_hours_format")
+	}
+	f, err := time.Parse(QuietTimeLayout, a)
+	if err != nil {
+		return "", "", i18n.E("quiet_hours_format")
+	}
+	t, err := time.Parse(QuietTimeLayout, b)
+	if err != nil {
+		return "", "", i18n.E("quiet_hours_format")
+	}
+	if f.Equal(t) {
+		return "", "", i18n.E("quiet_hours_equal")
+	}
+	return f.Format(QuietTimeLayout), t.Format(QuietTimeLayout), nil
+}
+
+// ParseCities parses a comma separated list; "-" clears it
+func ParseCities(s string) []string {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "-" {
+		return nil
+	}
+	var out []string
+	for _, c := range strings.Split(s, ",") {
+		c = strings.TrimSpace(c)
+		if c == "" {
+			continue
+		}
+		out = append(out, c)
+		if len(out) == MaxPreferenceCities {
+			break
+		}
+	}
+	return out
+}
+
+// ParseVacation parses a number of days or a last day "02.01.2006" (inclusive).
+// "-" or empty ends the vacation and returns nil.
+func ParseVacation(s string, now time.Time, loc *time.Location) (*time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "-" {
+		return nil, nil
+	}
+	local := now.In(loc)
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+
+	var until time.Time
+	if days, err := strconv.Atoi(s); err == nil {
+		if days < 1 || days > MaxVacationDays {
+			return nil, i18n.E("vacation_days_range", MaxVacationDays)
+		}
+		until = today.AddDate(0, 0, days)
+	} else {
+		last, err := time.ParseInLocation("02.01.2006", s, loc)
+		if err != nil {
+			return nil, i18n.E("vacation_input_invalid")
+		}
+		until = last.AddDate(0, 0, 1)
+		if !until.After(now) {
+			return nil, i18n.E("date_in_past")
+		}
+		if until.After(today.AddDate(0, 0, MaxVacationDays+1)) {
+			return nil, i18n.E("vacation_too_long", MaxVacationDays)
+		}
+	}
+	return &until, nil
+}
+
+// NextOption returns the option after current, wrapping around
+func NextOption[T comparable](options []T, current T) T {
+	for i, o := range options {
+		if o == current {
+			return options[(i+1)%len(options)]
+		}
+	}
+	return options[0]
+}
@@ -0,0 +1,141 @@
+package domain
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Подсказка цены по истории заявок
+const (
+	PriceSuggestTarget  = 0.7                  // "скорее всего примут" — вероятность принятия не ниже
+	MinPriceSamples     = 20                   // меньше заявок в корзине — подсказываем по тарифу
+	PriceHistoryWindow  = 180 * 24 * time.Hour // старше — цены уже неактуальны
+	priceCurveMaxPoints = 8
+)
+
+// Границы корзин по расстоянию, км. Последняя корзина открыта сверху.
+var distanceBuckets = []float64{0, 5, 10, 20, 50, 100, 300}
+
+// PriceSample — исход одной прошлой заявки
+type PriceSample struct {
+	Price    int
+	Accepted bool      // водитель взял заявку
+	PickupAt time.Time // время подачи, для старых заявок — создания
+}
+
+// CurvePoint — доля принятых заявок в ценовом диапазоне, который заканчивается на Price
+type CurvePoint struct {
+	Price       int     `json:"price"`
+	Probability float64 `json:"probability"`
+	Samples     int     `json:"samples"`
+}
+
+// PriceSuggestion — ответ подсказки. Source: "history" или "tariff".
+type PriceSuggestion struct {
+	Source         string       `json:"source"`
+	SuggestedPrice int          `json:"suggested_price"` // примут с вероятностью >= Target
+	Target         float64      `json:"target"`
+	Samples        int          `json:"samples"`
+	Curve          []CurvePoint `json:"curve,omitempty"`
+	Quote          PriceQuote   `json:"quote"`
+	Surge          *SurgeUplift `json:"surge,omitempty"` // точка подачи в зоне повышенного спроса
+}
+
+// DistanceBucket returns the [lo, hi) km band for a route; hi = 0 means unbounded
+func DistanceBucket(km float64) (float64, float64) {
+	for i := len(distanceBuckets) - 1; i >= 0; i-- {
+		if km >= distanceBuckets[i] {
+			if i == len(distanceBuckets)-1 {
+				return distanceBuckets[i], 0
+			}
+			return distanceBuckets[i], distanceBuckets[i+1]
+		}
+	}
+	return 0, distanceBuckets[1]
+}
+
+// TimeBucket — часть суток по местному времени: ночь, утро, день, вечер
+func TimeBucket(t time.Time) int {
+	switch h := t.Hour(); {
+	case h < 6:
+		return 0
+	case h < 11:
+		return 1
+	case h < 18:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// AcceptanceCurve groups samples into price bins and returns the share of
+// accepted orders per bin. The curve is made non-decreasing in price
+// (pool adjacent violators): дороже не может приниматься хуже.
+func AcceptanceCurve(samples []PriceSample) []CurvePoint {
+	if len(samples) == 0 {
+		return nil
+	}
+	sorted := append([]PriceSample(nil), samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Price < sorted[j].Price })
+
+	bins := priceCurveMaxPoints
+	if len(sorted) < bins*MinPriceSamples/4 {
+		bins = int(math.Max(1, float64(len(sorted)/(MinPriceSamples/4))))
+	}
+
+	type block struct {
+		price, accepted, total int
+	}
+	var blocks []block
+	for i := 0; i < bins; i++ {
+		lo, hi := i*len(sorted)/bins, (i+1)*len(sorted)/bins
+		if lo == hi {
+			continue
+		}
+		b := block{price: sorted[hi-1].Price}
+		for _, s := range sorted[lo:hi] {
+			b.total++
+			if s.Accepted {
+				b.accepted++
+			}
+		}
+		// одинаковые цены на границе бинов сливаем, чтобы цена точки была уникальной
+		if n := len(blocks); n > 0 && blocks[n-1].price == b.price {
+			blocks[n-1].accepted += b.accepted
+			blocks[n-1].total += b.total
+		} else {
+			blocks = append(blocks, b)
+		}
+
+		// pool adjacent violators
+		for n := len(blocks); n > 1; n = len(blocks) {
+			prev, last := blocks[n-2], blocks[n-1]
+			if prev.accepted*last.total <= last.accepted*prev.total {
+				break
+			}
+			blocks[n-2] = block{price: last.price, accepted: prev.accepted + last.accepted, total: prev.total + last.total}
+			blocks = blocks[:n-1]
+		}
+	}
+
+	curve := make([]CurvePoint, 0, len(blocks))
+	for _, b := range blocks {
+		curve = append(curve, CurvePoint{
+			Price:       b.price,
+			Probability: math.Round(float64(b.accepted)/float64(b.total)*100) / 100,
+			Samples:     b.total,
+		})
+	}
+	return curve
+}
+
+// PriceForProbability returns the lowest curve price reaching target, 0 if none does
+func PriceForProbability(curve []CurvePoint, target float64) int {
+	for _, p := range curve {
+		if p.Probability >= target {
+			return int(math.Ceil(float64(p.Price)/PriceRoundStep)) * PriceRoundStep
+		}
+	}
+	return 0
+}
@@ -0,0 +1,35 @@
+package domain
+
+import "time"
+
+// Ограничения профиля клиента
+const (
+	MaxSavedAddresses  = 20
+	MaxFavoriteDrivers = 20
+	MaxAddressNameLen  = 50
+	MaxSavedAddressLen = 300
+)
+
+// SavedAddress — именованный адрес клиента («Склад», «Дом») с координатами
+type SavedAddress struct {
+	ID        int64     `json:"id" db:"id"`
+	UserID    string    `json:"-" db:"user_id"`
+	Name      string    `json:"name" db:"name"`
+	Address   string    `json:"address" db:"address"`
+	Lat       float64   `json:"lat" db:"lat"`
+	Lon       float64   `json:"lon" db:"lon"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// FavoriteDriver — водитель из избранного клиента; заявку можно сначала отправить ему
+type FavoriteDriver struct {
+	DriverID     string    `json:"driver_id" db:"driver_id"`
+	FirstName    string    `json:"first_name" db:"first_name"`
+	LastName     string    `json:"last_name" db:"last_name"`
+	TruckType    string    `json:"truck_type" db:"truck_type"`
+	ProfilePhoto string    `json:"profile_photo" db:"profile_photo"`
+	Rating       float64   `json:"rating" db:"rating"`
+	RatingCount  int       `json:"rating_count" db:"rating_count"`
+	Status       string    `json:"status" db:"status"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,47 @@
+package domain
+
+import "time"
+
+// Кто оставил отзыв по заявке
+const (
+	ReviewerClient = "client" // клиент оценивает водителя
+	ReviewerDriver = "driver" // водитель оценивает клиента
+)
+
+// Байесовское сглаживание: новичок с одной пятёркой не обгоняет
+// водителя с сотней оценок 4.8. Априорно считаем RatingPriorWeight оценок по RatingPriorMean.
+const (
+	RatingPriorMean   = 4.5
+	RatingPriorWeight = 5
+)
+
+// OrderReview — оценка 1–5 и необязательный комментарий после завершения заявки
+type OrderReview struct {
+	ID               int64     `json:"id" db:"id"`
+	RequestID        string    `json:"request_id" db:"request_id"`
+	RaterRole        string    `json:"rater_role" db:"rater_role"` // client, driver
+	RaterTelegramID  int64     `json:"-" db:"rater_telegram_id"`
+	DriverID         string    `json:"driver_id" db:"driver_id"`
+	ClientTelegramID int64     `json:"-" db:"client_telegram_id"`
+	Rating           int       `json:"rating" db:"rating"`
+	Comment          string    `json:"comment" db:"comment"`
+	CreatedAt        time.Time `json:"created_at" db:"created_at"`
+}
+
+// IsValidRating — оценка в диапазоне 1–5
+func IsValidRating(r int) bool {
+	return r >= 1 && r <= 5
+}
+
+// SmoothedRating returns the Bayesian average for count ratings summing to sum
+func SmoothedRating(sum float64, count int) float64 {
+	return (RatingPriorMean*RatingPriorWeight + sum) / float64(RatingPriorWeight+count)
+}
+
+// EffectiveRating — рейтинг для ранжирования: без оценок считаем априорное среднее
+func EffectiveRating(rating float64, count int) float64 {
+	if count == 0 || rating <= 0 {
+		return RatingPriorMean
+	}
+	return rating
+}
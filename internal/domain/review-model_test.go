@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSmoothedRating(t *testing.T) {
+	tests := []struct {
+		name  string
+		sum   float64
+		count int
+		want  float64
+	}{
+		{"no ratings is the prior", 0, 0, RatingPriorMean},
+		{"single five barely moves", 5, 1, (RatingPriorMean*RatingPriorWeight + 5) / (RatingPriorWeight + 1)},
+		{"single one pulls down a little", 1, 1, (RatingPriorMean*RatingPriorWeight + 1) / (RatingPriorWeight + 1)},
+		{"many ratings approach the mean", 4.8 * 100, 100, (RatingPriorMean*RatingPriorWeight + 480) / (RatingPriorWeight + 100)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SmoothedRating(tt.sum, tt.count); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("SmoothedRating(%v, %d) = %v, want %v", tt.sum, tt.count, got, tt.want)
+			}
+		})
+	}
+
+	// один отзыв на 5 не должен обгонять сотню оценок 4.8
+	if one, many := SmoothedRating(5, 1), SmoothedRating(480, 100); one >= many {
+		t.Errorf("single 5 (%v) ranks above a hundred 4.8 ratings (%v)", one, many)
+	}
+}
+
+func TestEffectiveRating(t *testing.T) {
+	tests := []struct {
+		rating float64
+		count  int
+		want   float64
+	}{
+		{0, 0, RatingPriorMean},
+		{4.9, 0, RatingPriorMean},
+		{0, 3, RatingPriorMean},
+		{4.2, 3, 4.2},
+	}
+	for _, tt := range tests {
+		if got := EffectiveRating(tt.rating, tt.count); got != tt.want {
+			t.Errorf("EffectiveRating(%v, %d) = %v, want %v", tt.rating, tt.count, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package domain
+
+import (
+	"strings"
+	"time"
+
+	"tezjet/internal/i18n"
+)
+
+// Статусы сохранённого поиска водителя
+const (
+	SavedSearchActive  = "active"
+	SavedSearchExpired = "expired"
+	SavedSearchDeleted = "deleted"
+)
+
+// Ограничения сохранённых поисков
+const (
+	MaxSavedSearches      = 10
+	MaxSavedSearchDays    = 30
+	DefaultSearchRadiusKm = 50.0
+	MaxSearchRadiusKm     = 200.0
+	SearchDateLayout      = "2006-01-02"
+)
+
+// SavedSearch — поиск водителя по маршруту, который клиент сохранил, когда
+// /api/driver-request ничего не нашёл. Новые поездки водителей сверяются с ним
+// до конца окна дат. DateFrom/DateTo — границы окна в UTC, DateTo не включается.
+type SavedSearch struct {
+	ID          int64      `json:"id" db:"id"`
+	TelegramID  int64      `json:"-" db:"telegram_id"`
+	FromAddress string     `json:"from_address" db:"from_address"`
+	FromLat     float64    `json:"from_lat" db:"from_lat"`
+	FromLon     float64    `json:"from_lon" db:"from_lon"`
+	ToAddress   string     `json:"to_address" db:"to_address"`
+	ToLat       float64    `json:"to_lat" db:"to_lat"`
+	ToLon       float64    `json:"to_lon" db:"to_lon"`
+	TruckType   string     `json:"truck_type" db:"truck_type"` // "any" — любой
+	Contact     string     `json:"contact" db:"contact"`
+	RadiusKm    float64    `json:"radius_km" db:"radius_km"`
+	DateFrom    time.Time  `json:"date_from" db:"date_from"`
+	DateTo      time.Time  `json:"date_to" db:"date_to"`
+	Status      string     `json:"status" db:"status"`
+	NotifiedAt  *time.Time `json:"notified_at,omitempty" db:"notified_at"` // последнее уведомление о поездке
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+}
+
+// ParseSearchWindow parses local dates "2006-01-02" into a UTC window [from, to+1 day).
+// Пустая дата начала — сегодня, пустая дата конца — день начала.
+func ParseSearchWindow(from, to string, now time.Time, loc *time.Location) (time.Time, time.Time, error) {
+	local := now.In(loc)
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+
+	start := today
+	if s := strings.TrimSpace(from); s != "" {
+		t, err := time.ParseInLocation(SearchDateLayout, s, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, i18n.E("invalid_start_date", s)
+		}
+		start = t
+	}
+	end := start
+	if s := strings.TrimSpace(to); s != "" {
+		t, err := time.ParseInLocation(SearchDateLayout, s, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, i18n.E("invalid_end_date", s)
+		}
+		end = t
+	}
+
+	if start.Before(today) {
+		return time.Time{}, time.Time{}, i18n.E("start_date_in_past")
+	}
+	if end.Before(start) {
+		return time.Time{}, time.Time{}, i18n.E("end_before_start")
+	}
+	if end.After(today.AddDate(0, 0, MaxSavedSearchDays)) {
+		return time.Time{}, time.Time{}, i18n.E("search_window_too_long", MaxSavedSearchDays)
+	}
+	return start.UTC(), end.AddDate(0, 0, 1).UTC(), nil
+}
+
+// EffectiveRadius keeps the corridor radius within sane bounds
+func (s *SavedSearch) EffectiveRadius() float64 {
+	switch {
+	case s.RadiusKm <= 0:
+		return DefaultSearchRadiusKm
+	case s.RadiusKm > MaxSearchRadiusKm:
+		return MaxSearchRadiusKm
+	}
+	return s.RadiusKm
+}
+
+// InWindow reports whether a trip departing at t falls into the search dates
+func (s *SavedSearch) InWindow(t time.Time) bool {
+	return !t.Before(s.DateFrom) && t.Before(s.DateTo)
+}
+
+// AnyTruck — клиенту подходит любая машина
+func (s *SavedSearch) AnyTruck() bool {
+	return s.TruckType == "" || s.TruckType == "any"
+}
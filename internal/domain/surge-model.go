@@ -0,0 +1,182 @@
+package domain
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Повышенный спрос по зонам: ожидающие заявки против водителей онлайн в ячейке geohash
+const (
+	SurgeGeohashPrecision = 5   // ячейка ~4.9×4.9 км
+	SurgeMinPending       = 3   // меньше заявок в зоне — спрос не считаем повышенным
+	SurgeSensitivity      = 0.2 // +20% за каждую лишнюю заявку на одного водителя
+	SurgeStep             = 0.1
+	HighDemandMultiplier  = 1.3 // с этого коэффициента водителю показываем "высокий спрос"
+)
+
+const geohashBase32 = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// GeoPoint — точка на карте
+type GeoPoint struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// SurgeZone — спрос и предложение в одной ячейке geohash. Lat/Lon — центр ячейки.
+type SurgeZone struct {
+	Geohash    string    `json:"geohash"`
+	Lat        float64   `json:"lat"`
+	Lon        float64   `json:"lon"`
+	Pending    int       `json:"pending"`
+	Drivers    int       `json:"drivers"`
+	Multiplier float64   `json:"multiplier"`
+	HighDemand bool      `json:"high_demand"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// SurgeUplift — подсказка клиенту поднять цену в зоне повышенного спроса
+type SurgeUplift struct {
+	Geohash        string  `json:"geohash"`
+	Multiplier     float64 `json:"multiplier"`
+	SuggestedPrice int     `json:"suggested_price"`
+}
+
+// GeohashEncode returns the geohash of a point with the given number of characters
+func GeohashEncode(lat, lon float64, precision int) string {
+	latLo, latHi := -90.0, 90.0
+	lonLo, lonHi := -180.0, 180.0
+
+	var sb strings.Builder
+	bit, ch, even := 0, 0, true
+	for sb.Len() < precision {
+		if even {
+			mid := (lonLo + lonHi) / 2
+			if lon >= mid {
+				ch = ch<<1 | 1
+				lonLo = mid
+			} else {
+				ch <<= 1
+				lonHi = mid
+			}
+		} else {
+			mid := (latLo + latHi) / 2
+			if lat >= mid {
+				ch = ch<<1 | 1
+				latLo = mid
+			} else {
+				ch <<= 1
+				latHi = mid
+			}
+		}
+		even = !even
+		if bit++; bit == 5 {
+			sb.WriteByte(geohashBase32[ch])
+			bit, ch = 0, 0
+		}
+	}
+	return sb.String()
+}
+
+// GeohashBounds returns the south-west and north-east corners of a geohash cell;
+// ok is false for invalid input
+func GeohashBounds(hash string) (sw, ne GeoPoint, ok bool) {
+	latLo, latHi := -90.0, 90.0
+	lonLo, lonHi := -180.0, 180.0
+	even := true
+	for _, c := range hash {
+		idx := strings.IndexRune(geohashBase32, c)
+		if idx < 0 {
+			return GeoPoint{}, GeoPoint{}, false
+		}
+		for mask := 16; mask > 0; mask >>= 1 {
+			if even {
+				mid := (lonLo + lonHi) / 2
+				if idx&mask != 0 {
+					lonLo = mid
+				} else {
+					lonHi = mid
+				}
+			} else {
+				mid := (latLo + latHi) / 2
+				if idx&mask != 0 {
+					latLo = mid
+				} else {
+					latHi = mid
+				}
+			}
+			even = !even
+		}
+	}
+	return GeoPoint{Lat: latLo, Lon: lonLo}, GeoPoint{Lat: latHi, Lon: lonHi}, hash != ""
+}
+
+// GeohashCenter returns the center of a geohash cell; ok is false for invalid input
+func GeohashCenter(hash string) (GeoPoint, bool) {
+	sw, ne, ok := GeohashBounds(hash)
+	return GeoPoint{Lat: (sw.Lat + ne.Lat) / 2, Lon: (sw.Lon + ne.Lon) / 2}, ok
+}
+
+// SurgeMultiplier grows with pending orders per online driver and is capped at maxMultiplier.
+// Без водителей в зоне считаем, что водитель один — иначе коэффициент сразу упирается в потолок.
+func SurgeMultiplier(pending, drivers int, maxMultiplier float64) float64 {
+	if pending < SurgeMinPending {
+		return 1
+	}
+	ratio := float64(pending) / math.Max(1, float64(drivers))
+	if ratio <= 1 {
+		return 1
+	}
+	m := 1 + SurgeSensitivity*(ratio-1)
+	m = math.Floor(m/SurgeStep+1e-9) * SurgeStep
+	if m > maxMultiplier {
+		m = maxMultiplier
+	}
+	return math.Round(m*100) / 100
+}
+
+// ComputeSurgeZones counts orders and drivers per geohash cell. Зоны без заявок не
+// возвращаются; результат отсортирован по убыванию коэффициента.
+func ComputeSurgeZones(orders, drivers []GeoPoint, precision int, maxMultiplier float64, now time.Time) []SurgeZone {
+	byHash := make(map[string]*SurgeZone)
+	for _, o := range orders {
+		hash := GeohashEncode(o.Lat, o.Lon, precision)
+		z, ok := byHash[hash]
+		if !ok {
+			z = &SurgeZone{Geohash: hash}
+			byHash[hash] = z
+		}
+		z.Pending++
+	}
+	for _, d := range drivers {
+		if z, ok := byHash[GeohashEncode(d.Lat, d.Lon, precision)]; ok {
+			z.Drivers++
+		}
+	}
+
+	zones := make([]SurgeZone, 0, len(byHash))
+	for _, z := range byHash {
+		center, _ := GeohashCenter(z.Geohash)
+		z.Lat, z.Lon = center.Lat, center.Lon
+		z.Multiplier = SurgeMultiplier(z.Pending, z.Drivers, maxMultiplier)
+		z.HighDemand = z.Multiplier >= HighDemandMultiplier
+		z.UpdatedAt = now
+		zones = append(zones, *z)
+	}
+	sort.Slice(zones, func(i, j int) bool {
+		if zones[i].Multiplier != zones[j].Multiplier {
+			return zones[i].Multiplier > zones[j].Multiplier
+		}
+		return zones[i].Pending > zones[j].Pending
+	})
+	return zones
+}
+
+// ApplySurge multiplies a price and rounds it up to PriceRoundStep
+func ApplySurge(price int, multiplier float64) int {
+	if multiplier <= 1 {
+		return price
+	}
+	return int(math.Ceil(float64(price)*multiplier/PriceRoundStep)) * PriceRoundStep
+}
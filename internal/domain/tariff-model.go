@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+// PriceRoundStep — рекомендуемая цена округляется вверх до шага, ₸
+const PriceRoundStep = 100
+
+// Tariff — тариф по городу и типу машины. Пустой City или TruckType
+// означает "любой": так задаются тарифы по умолчанию.
+type Tariff struct {
+	ID        int64     `json:"id" db:"id"`
+	City      string    `json:"city" db:"city"`
+	TruckType string    `json:"truck_type" db:"truck_type"`
+	BaseFare  int       `json:"base_fare" db:"base_fare"`
+	PerKm     float64   `json:"per_km" db:"per_km"`
+	PerMin    float64   `json:"per_min" db:"per_min"`
+	MinPrice  int       `json:"min_price" db:"min_price"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// PriceQuote — минимальная и рекомендуемая цена для маршрута
+type PriceQuote struct {
+	TariffID    int64  `json:"tariff_id,omitempty"`
+	City        string `json:"city"`
+	TruckType   string `json:"truck_type"`
+	MinPrice    int    `json:"min_price"`
+	Recommended int    `json:"recommended_price"`
+}
+
+// Quote computes the recommended price for a route, never below MinPrice
+func (t *Tariff) Quote(distanceKm float64, etaMin int) int {
+	raw := float64(t.BaseFare) + t.PerKm*distanceKm + t.PerMin*float64(etaMin)
+	price := int(math.Ceil(raw/PriceRoundStep)) * PriceRoundStep
+	if price < t.MinPrice {
+		return t.MinPrice
+	}
+	return price
+}
+
+// specificity: точное совпадение города важнее типа машины
+func (t *Tariff) specificity() int {
+	s := 0
+	if t.City != "" {
+		s += 2
+	}
+	if t.TruckType != "" {
+		s++
+	}
+	return s
+}
+
+// MatchTariff picks the most specific tariff for city and truck type, nil if none applies
+func MatchTariff(tariffs []Tariff, city, truckType string) *Tariff {
+	var best *Tariff
+	for i := range tariffs {
+		t := &tariffs[i]
+		if t.City != "" && !strings.EqualFold(t.City, city) {
+			continue
+		}
+		if t.TruckType != "" && t.TruckType != truckType {
+			continue
+		}
+		if best == nil || t.specificity() > best.specificity() {
+			best = t
+		}
+	}
+	return best
+}
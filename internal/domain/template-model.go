@@ -0,0 +1,111 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+
+	"tezjet/internal/i18n"
+)
+
+// Статусы повторяющейся заявки
+const (
+	RecurringActive    = "active"
+	RecurringPaused    = "paused"
+	RecurringCancelled = "cancelled"
+)
+
+// Ограничения шаблонов
+const (
+	MaxOrderTemplates   = 30
+	MaxRecurringOrders  = 10
+	MaxTemplateNameLen  = 50
+	RecurringTimeLayout = "15:04"
+)
+
+// OrderTemplate — сохранённая заявка клиента. Payload — тело формы заявки без времени подачи.
+type OrderTemplate struct {
+	ID         int64           `json:"id" db:"id"`
+	TelegramID int64           `json:"-" db:"telegram_id"`
+	Name       string          `json:"name" db:"name"`
+	Payload    json.RawMessage `json:"payload" db:"payload"`
+	CreatedAt  time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at" db:"updated_at"`
+}
+
+// RecurringOrder — расписание, по которому из шаблона создаются заявки.
+// Weekdays — битовая маска: бит 0 — понедельник, бит 6 — воскресенье.
+type RecurringOrder struct {
+	ID            int64      `json:"id" db:"id"`
+	TelegramID    int64      `json:"-" db:"telegram_id"`
+	TemplateID    int64      `json:"template_id" db:"template_id"`
+	TemplateName  string     `json:"template_name"`
+	Weekdays      int        `json:"-" db:"weekdays"`
+	Days          []int      `json:"weekdays"`
+	TimeOfDay     string     `json:"time_of_day" db:"time_of_day"` // "09:00", местное время
+	Status        string     `json:"status" db:"status"`
+	NextPickupAt  *time.Time `json:"next_pickup_at,omitempty" db:"next_pickup_at"`
+	LastRequestID string     `json:"last_request_id,omitempty" db:"last_request_id"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+
+	Payload json.RawMessage `json:"-"` // шаблон, подгружается для запуска
+}
+
+// WeekdayMask собирает маску из ISO-номеров дней (1 — понедельник … 7 — воскресенье)
+func WeekdayMask(days []int) (int, error) {
+	mask := 0
+	for _, d := range days {
+		if d < 1 || d > 7 {
+			return 0, i18n.E("weekday_range")
+		}
+		mask |= 1 << (d - 1)
+	}
+	if mask == 0 {
+		return 0, i18n.E("weekdays_required")
+	}
+	return mask, nil
+}
+
+// WeekdayDays — обратное преобразование маски в ISO-номера дней по возрастанию
+func WeekdayDays(mask int) []int {
+	days := make([]int, 0, 7)
+	for d := 1; d <= 7; d++ {
+		if mask&(1<<(d-1)) != 0 {
+			days = append(days, d)
+		}
+	}
+	return days
+}
+
+func isoWeekday(t time.Time) int {
+	if t.Weekday() == time.Sunday {
+		return 7
+	}
+	return int(t.Weekday())
+}
+
+// NextOccurrence returns the first pickup strictly after `after` that falls on
+// a scheduled weekday at TimeOfDay in loc.
+func (ro *RecurringOrder) NextOccurrence(after time.Time, loc *time.Location) (time.Time, error) {
+	tod, err := time.ParseInLocation(RecurringTimeLayout, ro.TimeOfDay, loc)
+	if err != nil {
+		return time.Time{}, i18n.E("invalid_time_of_day", ro.TimeOfDay)
+	}
+	if ro.Weekdays&0x7f == 0 {
+		return time.Time{}, i18n.E("weekdays_required")
+	}
+
+	local := after.In(loc)
+	for i := 0; i <= 7; i++ {
+		day := local.AddDate(0, 0, i)
+		at := time.Date(day.Year(), day.Month(), day.Day(), tod.Hour(), tod.Minute(), 0, 0, loc)
+		if at.After(after) && ro.Weekdays&(1<<(isoWeekday(at)-1)) != 0 {
+			return at, nil
+		}
+	}
+	return time.Time{}, i18n.E("next_occurrence_failed")
+}
+
+// IsActive — расписание создаёт заявки
+func (ro *RecurringOrder) IsActive() bool {
+	return ro.Status == RecurringActive
+}
@@ -0,0 +1,78 @@
+package domain
+
+import "time"
+
+// Этапы публичной ленты отслеживания
+const (
+	TrackingCreated   = "created"
+	TrackingAccepted  = "accepted"
+	TrackingPickedUp  = "picked_up"
+	TrackingStopDone  = "stop_done"
+	TrackingCompleted = "completed"
+)
+
+// DriverPosition — последняя координата водителя (живая геолокация из бота или Mini App)
+type DriverPosition struct {
+	Lat       float64   `json:"lat"`
+	Lon       float64   `json:"lon"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TrackingEvent — шаг ленты статусов; Seq заполнен только для точек выгрузки
+type TrackingEvent struct {
+	Kind string    `json:"kind"`
+	At   time.Time `json:"at"`
+	Seq  int       `json:"seq,omitempty"`
+}
+
+// OrderTracking — то, что видит получатель по ссылке /t/{token}.
+// Контакты клиента, цена и код подтверждения сюда не попадают.
+type OrderTracking struct {
+	RequestID       string      `json:"request_id"`
+	Status          string      `json:"status"`
+	FromAddress     string      `json:"from_address"`
+	ToAddress       string      `json:"to_address"`
+	Stops           []OrderStop `json:"stops,omitempty"`
+	DriverID        string      `json:"-"`
+	DriverFirstName string      `json:"driver_first_name,omitempty"`
+	TruckType       string      `json:"truck_type,omitempty"`
+	TruckNumber     string      `json:"truck_number,omitempty"`
+	CreatedAt       time.Time   `json:"created_at"`
+	AcceptedAt      *time.Time  `json:"accepted_at,omitempty"`
+	PickedUpAt      *time.Time  `json:"picked_up_at,omitempty"`
+	CompletedAt     *time.Time  `json:"completed_at,omitempty"`
+
+	Timeline []TrackingEvent `json:"timeline"`
+	Position *DriverPosition `json:"position,omitempty"`
+}
+
+// BuildTimeline собирает ленту статусов по отметкам времени заявки и точек
+func (t *OrderTracking) BuildTimeline() []TrackingEvent {
+	events := []TrackingEvent{{Kind: TrackingCreated, At: t.CreatedAt}}
+	if t.AcceptedAt != nil {
+		events = append(events, TrackingEvent{Kind: TrackingAccepted, At: *t.AcceptedAt})
+	}
+	if t.PickedUpAt != nil {
+		events = append(events, TrackingEvent{Kind: TrackingPickedUp, At: *t.PickedUpAt})
+	}
+	for _, s := range t.Stops {
+		if s.DoneAt != nil {
+			events = append(events, TrackingEvent{Kind: TrackingStopDone, At: *s.DoneAt, Seq: s.Seq})
+		}
+	}
+	if t.CompletedAt != nil {
+		events = append(events, TrackingEvent{Kind: TrackingCompleted, At: *t.CompletedAt})
+	}
+	return events
+}
+
+// TrackingExpired — ссылка не действует для отменённых заявок и после grace с момента завершения
+func (t *OrderTracking) TrackingExpired(now time.Time, grace time.Duration) bool {
+	switch t.Status {
+	case DeliveryStatusCancelled, DeliveryStatusExpired:
+		return true
+	case DeliveryStatusCompleted:
+		return t.CompletedAt == nil || now.After(t.CompletedAt.Add(grace))
+	}
+	return false
+}
@@ -0,0 +1,168 @@
+package domain
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Статусы рейса водителя (driver_trips)
+const (
+	TripStatusActive    = "active"
+	TripStatusFull      = "full" // места не осталось, новые брони не принимаются
+	TripStatusCompleted = "completed"
+	TripStatusCancelled = "cancelled"
+)
+
+// TripIsOpen — рейс ещё можно править и бронировать
+func TripIsOpen(status string) bool {
+	return status == TripStatusActive || status == TripStatusFull
+}
+
+// Остаток меньше этого считается заполненным рейсом: такой груз никто не повезёт попутно
+const (
+	MinTripFreeKg = 20.0
+	MinTripFreeM3 = 0.2
+)
+
+// Виды остановок в плане рейса
+const (
+	TripStopPickup  = "pickup"
+	TripStopDropoff = "dropoff"
+)
+
+// TripLoad — сколько уже занято в рейсе заявками
+type TripLoad struct {
+	WeightKg float64 `json:"weight_kg" db:"booked_weight"`
+	VolumeM3 float64 `json:"volume_m3" db:"booked_volume"`
+}
+
+// TripSpace — свободное место в рейсе. Отрицательное значение — не ограничено
+// (вместимость машины неизвестна).
+type TripSpace struct {
+	WeightKg float64 `json:"weight_kg"`
+	VolumeM3 float64 `json:"volume_m3"`
+}
+
+// FreeSpace returns what is left of the vehicle after load is on board
+func (v VehicleCapacity) FreeSpace(load TripLoad) TripSpace {
+	c := v.Effective()
+	free := TripSpace{WeightKg: -1, VolumeM3: -1}
+	if c.MaxWeightKg > 0 {
+		free.WeightKg = math.Max(0, c.MaxWeightKg-load.WeightKg)
+	}
+	if c.MaxVolumeM3 > 0 {
+		free.VolumeM3 = math.Max(0, c.MaxVolumeM3-load.VolumeM3)
+	}
+	return free
+}
+
+// Fits reports whether cargo fits into the free space; неуказанный вес или объём не ограничивает
+func (s TripSpace) Fits(c CargoSpec) bool {
+	if s.WeightKg >= 0 && c.WeightKg > s.WeightKg {
+		return false
+	}
+	if s.VolumeM3 >= 0 && c.VolumeM3 > s.VolumeM3 {
+		return false
+	}
+	return true
+}
+
+// IsFull — попутный груз больше не поместится
+func (s TripSpace) IsFull() bool {
+	return (s.WeightKg >= 0 && s.WeightKg < MinTripFreeKg) ||
+		(s.VolumeM3 >= 0 && s.VolumeM3 < MinTripFreeM3)
+}
+
+// TripOrder — заявка, прикреплённая к рейсу
+type TripOrder struct {
+	ID          string     `json:"id"`
+	TelegramID  int64      `json:"-"` // клиент, для уведомлений
+	FromAddress string     `json:"from_address"`
+	From        GeoPoint   `json:"from"`
+	ToAddress   string     `json:"to_address"`
+	To          GeoPoint   `json:"to"`
+	Price       int        `json:"price"`
+	Contact     string     `json:"contact"`
+	Status      string     `json:"status"`
+	Cargo       CargoSpec  `json:"cargo"`
+	PickedUpAt  *time.Time `json:"picked_up_at,omitempty"`
+}
+
+// IsOnTrip — заявка ещё едет этим рейсом
+func (o *TripOrder) IsOnTrip() bool {
+	return o.Status == DeliveryStatusMatched || o.Status == DeliveryStatusInProgress
+}
+
+// TripStop — остановка в плане рейса. LoadKg — вес в кузове после остановки.
+type TripStop struct {
+	Seq      int      `json:"seq"`
+	OrderID  string   `json:"order_id"`
+	Kind     string   `json:"kind"`
+	Address  string   `json:"address"`
+	Point    GeoPoint `json:"point"`
+	Position float64  `json:"position"` // доля пути A→B по прямой, может выходить за 0..1
+	LoadKg   float64  `json:"load_kg"`
+}
+
+// SequenceTripStops orders pickups and drop-offs of the trip's orders along the A→B line.
+// Выгрузка заявки никогда не идёт раньше её погрузки; на одной точке сначала выгружаем.
+// Уже забранные заявки дают только выгрузку и с самого начала лежат в кузове.
+func SequenceTripStops(from, to GeoPoint, orders []TripOrder) []TripStop {
+	var stops []TripStop
+	var onBoard float64
+	for i := range orders {
+		o := &orders[i]
+		if !o.IsOnTrip() {
+			continue
+		}
+		pickup := projectOnRoute(from, to, o.From)
+		if o.PickedUpAt != nil {
+			onBoard += o.Cargo.WeightKg
+			pickup = math.Inf(-1)
+		} else {
+			stops = append(stops, TripStop{OrderID: o.ID, Kind: TripStopPickup, Address: o.FromAddress, Point: o.From, Position: pickup})
+		}
+		dropoff := math.Max(projectOnRoute(from, to, o.To), pickup)
+		stops = append(stops, TripStop{OrderID: o.ID, Kind: TripStopDropoff, Address: o.ToAddress, Point: o.To, Position: dropoff})
+	}
+
+	sort.SliceStable(stops, func(i, j int) bool {
+		a, b := stops[i], stops[j]
+		if a.Position != b.Position {
+			return a.Position < b.Position
+		}
+		if a.OrderID == b.OrderID {
+			return a.Kind == TripStopPickup && b.Kind == TripStopDropoff
+		}
+		return a.Kind == TripStopDropoff && b.Kind == TripStopPickup
+	})
+
+	weights := make(map[string]float64, len(orders))
+	for i := range orders {
+		weights[orders[i].ID] = orders[i].Cargo.WeightKg
+	}
+	load := onBoard
+	for i := range stops {
+		stops[i].Seq = i + 1
+		if stops[i].Kind == TripStopPickup {
+			load += weights[stops[i].OrderID]
+		} else {
+			load -= weights[stops[i].OrderID]
+		}
+		stops[i].LoadKg = math.Max(0, load)
+	}
+	return stops
+}
+
+// projectOnRoute — доля пути от from до to, на которую проецируется p (плоская аппроксимация)
+func projectOnRoute(from, to, p GeoPoint) float64 {
+	k := math.Cos((from.Lat + to.Lat) / 2 * math.Pi / 180)
+	dx, dy := (to.Lon-from.Lon)*k, to.Lat-from.Lat
+	length := dx*dx + dy*dy
+	if length == 0 {
+		return 0
+	}
+	px, py := (p.Lon-from.Lon)*k, p.Lat-from.Lat
+	return (px*dx + py*dy) / length
+}
@@ -24,29 +24,41 @@ type User struct {
 
 // DeliveryRequest represents a delivery request from a client
 type DeliveryRequest struct {
-	ID              string     `json:"id" db:"id"`                   // Changed from int64 to string (UUID)
-	UserID          string     `json:"user_id" db:"user_id"`         // Changed from int64 to string (UUID)
-	TelegramID      int64      `json:"telegram_id" db:"telegram_id"` // Kept as int64 for Telegram API
-	FromAddress     string     `json:"from_address" db:"from_address"`
-	FromLat         float64    `json:"from_lat" db:"from_lat"`
-	FromLon         float64    `json:"from_lon" db:"from_lon"`
-	ToAddress       string     `json:"to_address" db:"to_address"`
-	ToLat           float64    `json:"to_lat" db:"to_lat"`
-	ToLon           float64    `json:"to_lon" db:"to_lon"`
-	Price           int        `json:"price" db:"price"`
-	Contact         string     `json:"contact" db:"contact"`
-	Comment         string     `json:"comment" db:"comment"`
-	EtaMin          int        `json:"eta_min"`
-	CargoPhoto      string     `json:"cargo_photo"`
-	TruckType       string     `json:"truck_type" db:"truck_type"`
-	DistanceKm      float64    `json:"distance_km" db:"distance_km"`
-	Status          string     `json:"status" db:"status"` // pending, matched, completed, cancelled
-	ItemPhotoPath   string     `json:"item_photo_path" db:"item_photo_path"`
-	MatchedDriverID *string    `json:"matched_driver_id" db:"matched_driver_id"` // Changed from *int64 to *string (UUID)
-	CompletedAt     *time.Time `json:"completed_at" db:"completed_at"`
-	TimeStart       string     `json:"time_start"`
-	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
-	UpdatedAt       time.Time  `json:"updated_at" db:"updated_at"`
+	ID              string      `json:"id" db:"id"`                   // Changed from int64 to string (UUID)
+	UserID          string      `json:"user_id" db:"user_id"`         // Changed from int64 to string (UUID)
+	TelegramID      int64       `json:"telegram_id" db:"telegram_id"` // Kept as int64 for Telegram API
+	FromAddress     string      `json:"from_address" db:"from_address"`
+	FromLat         float64     `json:"from_lat" db:"from_lat"`
+	FromLon         float64     `json:"from_lon" db:"from_lon"`
+	ToAddress       string      `json:"to_address" db:"to_address"`
+	ToLat           float64     `json:"to_lat" db:"to_lat"`
+	ToLon           float64     `json:"to_lon" db:"to_lon"`
+	Price           int         `json:"price" db:"price"`
+	Contact         string      `json:"contact" db:"contact"`
+	Comment         string      `json:"comment" db:"comment"`
+	EtaMin          int         `json:"eta_min"`
+	CargoPhoto      string      `json:"cargo_photo"`
+	TruckType       string      `json:"truck_type" db:"truck_type"`
+	DistanceKm      float64     `json:"distance_km" db:"distance_km"`
+	Status          string      `json:"status" db:"status"` // pending, matched, completed, cancelled, expired
+	ItemPhotoPath   string      `json:"item_photo_path" db:"item_photo_path"`
+	MatchedDriverID *string     `json:"matched_driver_id" db:"matched_driver_id"` // Changed from *int64 to *string (UUID)
+	CompletedAt     *time.Time  `json:"completed_at" db:"completed_at"`
+	TimeStart       string      `json:"time_start"`
+	PickupAt        *time.Time  `json:"pickup_at,omitempty" db:"pickup_at"`         // время подачи, UTC
+	DispatchedAt    *time.Time  `json:"dispatched_at,omitempty" db:"dispatched_at"` // nil — заявка отложена до lead time
+	PickedUpAt      *time.Time  `json:"picked_up_at,omitempty" db:"picked_up_at"`   // водитель забрал груз и загрузил фото
+	Stops           []OrderStop `json:"stops,omitempty"`                            // точки выгрузки, пусто для обычной A→B заявки
+	Cargo           CargoSpec   `json:"cargo"`
+	CreatedAt       time.Time   `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time   `json:"updated_at" db:"updated_at"`
+
+	// Избранный водитель: до PreferredUntil заявку видит только он, потом — общая рассылка
+	PreferredDriverID string     `json:"preferred_driver_id,omitempty" db:"preferred_driver_id"`
+	PreferredUntil    *time.Time `json:"preferred_until,omitempty" db:"preferred_until"`
+
+	RecurringID int64  `json:"recurring_id,omitempty" db:"recurring_id"` // создана по расписанию, 0 — вручную
+	TripID      string `json:"trip_id,omitempty" db:"trip_id"`           // бронь места в рейсе водителя
 }
 
 // CreateUserRequest represents a request to create a new user
@@ -68,7 +80,7 @@ type CreateDeliveryRequestReq struct {
 	ToAddress     string  `json:"to_address" validate:"required"`
 	ToLat         float64 `json:"to_lat" validate:"required"`
 	ToLon         float64 `json:"to_lon" validate:"required"`
-	Price         int     `json:"price" validate:"required,min=2000"`
+	Price         int     `json:"price" validate:"required,min=1"`
 	Contact       string  `json:"contact" validate:"required"`
 	Comment       string  `json:"comment"`
 	TruckType     string  `json:"truck_type" validate:"required"`
@@ -107,6 +119,7 @@ const (
 	DeliveryStatusInProgress = "in_progress"
 	DeliveryStatusCompleted  = "completed"
 	DeliveryStatusCancelled  = "cancelled"
+	DeliveryStatusExpired    = "expired"
 )
 
 // Helper functions for UUID operations
@@ -135,7 +148,7 @@ func (u *User) IsValid() bool {
 
 func (dr *DeliveryRequest) IsValid() bool {
 	return dr.TelegramID > 0 && dr.FromAddress != "" && dr.ToAddress != "" &&
-		dr.Price >= 2000 && dr.Contact != "" && dr.TruckType != "" &&
+		dr.Price > 0 && dr.Contact != "" && dr.TruckType != "" &&
 		IsValidDeliveryRequestID(dr.ID) && IsValidUserID(dr.UserID)
 }
 
@@ -156,6 +169,54 @@ func (dr *DeliveryRequest) CanBeCancelled() bool {
 	return dr.Status == DeliveryStatusPending || dr.Status == DeliveryStatusMatched
 }
 
+// CanBeReposted - истёкшую заявку клиент может опубликовать заново или отменить
+func (dr *DeliveryRequest) CanBeReposted() bool {
+	return dr.Status == DeliveryStatusExpired
+}
+
+// IsHeld reports whether a scheduled order is still waiting for its dispatch time
+func (dr *DeliveryRequest) IsHeld(now time.Time, lead time.Duration) bool {
+	return dr.PickupAt != nil && dr.PickupAt.After(now.Add(lead))
+}
+
+// PickupWithin reports whether pickup falls into [from, to]; nil bounds are open
+func (dr *DeliveryRequest) PickupWithin(from, to *time.Time) bool {
+	if from == nil && to == nil {
+		return true
+	}
+	if dr.PickupAt == nil {
+		return false
+	}
+	if from != nil && dr.PickupAt.Before(*from) {
+		return false
+	}
+	if to != nil && dr.PickupAt.After(*to) {
+		return false
+	}
+	return true
+}
+
+// IsMultiStop — заявка с несколькими точками выгрузки
+func (dr *DeliveryRequest) IsMultiStop() bool {
+	return len(dr.Stops) > 1
+}
+
+// PendingStops returns drop-offs the driver has not marked done yet
+func (dr *DeliveryRequest) PendingStops() []OrderStop {
+	var out []OrderStop
+	for _, s := range dr.Stops {
+		if !s.IsDone() {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// InPreferredWindow — заявка ещё закреплена за избранным водителем
+func (dr *DeliveryRequest) InPreferredWindow(now time.Time) bool {
+	return dr.PreferredDriverID != "" && (dr.PreferredUntil == nil || now.Before(*dr.PreferredUntil))
+}
+
 func (dr *DeliveryRequest) HasMatchedDriver() bool {
 	return dr.MatchedDriverID != nil && *dr.MatchedDriverID != ""
 }
@@ -205,4 +266,5 @@ type JustEntry struct {
 	UserId         int64  `json:"userID" db:"id_user"`
 	UserName       string `json:"userName" db:"userName"`
 	DateRegistered string `json:"dateRegistered" db:"dataRegistred"`
+	LanguageCode   string `json:"languageCode" db:"language_code"` // из Telegram
 }
@@ -4,13 +4,16 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
-	"fmt"
 	"html"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
+	"tezjet/internal/domain"
+	"tezjet/internal/i18n"
+	"tezjet/internal/notifier"
+
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
 	"github.com/gorilla/mux"
@@ -53,25 +56,29 @@ type AdminDriver struct {
 
 // AdminOrder represents full order details for admin panel
 type AdminOrder struct {
-	ID          string  `json:"id"`
-	TelegramID  int64   `json:"telegram_id"`
-	FromAddress string  `json:"from_address"`
-	FromLat     float64 `json:"from_lat"`
-	FromLon     float64 `json:"from_lon"`
-	ToAddress   string  `json:"to_address"`
-	ToLat       float64 `json:"to_lat"`
-	ToLon       float64 `json:"to_lon"`
-	DistanceKm  float64 `json:"distance_km"`
-	EtaMin      int     `json:"eta_min"`
-	Price       int     `json:"price"`
-	TruckType   string  `json:"truck_type"`
-	Contact     string  `json:"contact"`
-	TimeStart   string  `json:"time_start"`
-	Comment     string  `json:"comment"`
-	ItemPhoto   string  `json:"item_photo_path"`
-	Status      string  `json:"status"`
-	CreatedAt   string  `json:"created_at"`
-	UpdatedAt   string  `json:"updated_at"`
+	ID          string             `json:"id"`
+	TelegramID  int64              `json:"telegram_id"`
+	FromAddress string             `json:"from_address"`
+	FromLat     float64            `json:"from_lat"`
+	FromLon     float64            `json:"from_lon"`
+	ToAddress   string             `json:"to_address"`
+	ToLat       float64            `json:"to_lat"`
+	ToLon       float64            `json:"to_lon"`
+	DistanceKm  float64            `json:"distance_km"`
+	EtaMin      int                `json:"eta_min"`
+	Price       int                `json:"price"`
+	TruckType   string             `json:"truck_type"`
+	Contact     string             `json:"contact"`
+	TimeStart   string             `json:"time_start"`
+	Comment     string             `json:"comment"`
+	ItemPhoto   string             `json:"item_photo_path"`
+	Status      string             `json:"status"`
+	Stops       []domain.OrderStop `json:"stops,omitempty"`
+	CreatedAt   string             `json:"created_at"`
+	UpdatedAt   string             `json:"updated_at"`
+
+	// Фото погрузки/выгрузки для разбора споров
+	Proofs []domain.OrderProof `json:"proofs,omitempty"`
 }
 
 // DayStat represents statistics for a single day
@@ -92,28 +99,19 @@ func (h *Handler) SendDriverMessage(w http.ResponseWriter, r *http.Request) {
 	// Get admin telegram ID from query
 	adminTidStr := r.URL.Query().Get("telegram_id")
 	if adminTidStr == "" {
-		h.writeJSON(w, http.StatusBadRequest, Response{
-			Success: false,
-			Message: "telegram_id қажет",
-		})
+		h.sendErrorResponse(w, r, "telegram_id_required", http.StatusBadRequest)
 		return
 	}
 
 	adminTid, err := strconv.ParseInt(adminTidStr, 10, 64)
 	if err != nil {
-		h.writeJSON(w, http.StatusBadRequest, Response{
-			Success: false,
-			Message: "telegram_id дұрыс емес",
-		})
+		h.sendErrorResponse(w, r, "invalid_telegram_id", http.StatusBadRequest)
 		return
 	}
 
 	// Verify admin permissions
 	if !h.isAdmin(adminTid) {
-		h.writeJSON(w, http.StatusForbidden, Response{
-			Success: false,
-			Message: "Тек әкімшіге рұқсат",
-		})
+		h.sendErrorResponse(w, r, "forbidden", http.StatusForbidden)
 		return
 	}
 
@@ -122,36 +120,24 @@ func (h *Handler) SendDriverMessage(w http.ResponseWriter, r *http.Request) {
 		Message string `json:"message"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.writeJSON(w, http.StatusBadRequest, Response{
-			Success: false,
-			Message: "JSON қате",
-		})
+		h.sendErrorResponse(w, r, "invalid_json", http.StatusBadRequest)
 		return
 	}
 
 	// Validate message content
 	message := strings.TrimSpace(req.Message)
 	if message == "" {
-		h.writeJSON(w, http.StatusBadRequest, Response{
-			Success: false,
-			Message: "Хабарлама бос болмауы керек",
-		})
+		h.sendErrorResponse(w, r, "message_empty", http.StatusBadRequest)
 		return
 	}
 
 	if len(message) < 10 {
-		h.writeJSON(w, http.StatusBadRequest, Response{
-			Success: false,
-			Message: "Хабарлама тым қысқа (кемінде 10 таңба)",
-		})
+		h.sendErrorResponse(w, r, "message_too_short", http.StatusBadRequest, 10)
 		return
 	}
 
 	if len(message) > 4000 {
-		h.writeJSON(w, http.StatusBadRequest, Response{
-			Success: false,
-			Message: "Хабарлама тым ұзын (максимум 4000 таңба)",
-		})
+		h.sendErrorResponse(w, r, "message_too_long", http.StatusBadRequest, 4000)
 		return
 	}
 
@@ -166,18 +152,12 @@ func (h *Handler) SendDriverMessage(w http.ResponseWriter, r *http.Request) {
 		&driver.Status, &driver.IsVerified, &driver.Contact)
 
 	if err == sql.ErrNoRows {
-		h.writeJSON(w, http.StatusNotFound, Response{
-			Success: false,
-			Message: "Жүргізуші табылмады",
-		})
+		h.sendErrorResponse(w, r, "driver_not_found", http.StatusNotFound)
 		return
 	}
 	if err != nil {
 		h.logErr("select driver for message", err)
-		h.writeJSON(w, http.StatusInternalServerError, Response{
-			Success: false,
-			Message: "Деректер базасының қатесі",
-		})
+		h.sendErrorResponse(w, r, "db_error", http.StatusInternalServerError)
 		return
 	}
 
@@ -185,17 +165,14 @@ func (h *Handler) SendDriverMessage(w http.ResponseWriter, r *http.Request) {
 	err = h.sendAdminMessageSync(ctx, driver, message)
 	if err != nil {
 		h.logErr("send admin message", err)
-		h.writeJSON(w, http.StatusInternalServerError, Response{
-			Success: false,
-			Message: "Хабарлама жіберу сәтсіз: " + err.Error(),
-		})
+		h.sendError(w, r, err, http.StatusInternalServerError)
 		return
 	}
 
 	// Success response
 	h.writeJSON(w, http.StatusOK, Response{
 		Success: true,
-		Message: "Хабарлама сәтті жіберілді",
+		Message: successText(r, "admin_message_sent"),
 		Data: map[string]interface{}{
 			"driver_id":   driverID,
 			"driver_name": driver.FirstName + " " + driver.LastName,
@@ -207,11 +184,11 @@ func (h *Handler) SendDriverMessage(w http.ResponseWriter, r *http.Request) {
 // sendAdminMessageSync sends admin message synchronously with proper HTML escaping
 func (h *Handler) sendAdminMessageSync(ctx context.Context, d DriverShort, message string) error {
 	if d.TelegramID == 0 {
-		return fmt.Errorf("жүргізушінің telegram ID жоқ")
+		return i18n.E("driver_no_telegram")
 	}
 
 	if h.bot == nil {
-		return fmt.Errorf("telegram bot инициализацияланбаған")
+		return i18n.E("message_send_failed")
 	}
 
 	// CRITICAL: Escape ALL user input for HTML
@@ -219,30 +196,11 @@ func (h *Handler) sendAdminMessageSync(ctx context.Context, d DriverShort, messa
 	escapedMessage := html.EscapeString(message)
 
 	// Format message with HTML tags
-	text := fmt.Sprintf(
-		"📢 <b>ALASH-GO ӘКІМШІ ХАБАРЛАМАСЫ</b>\n"+
-			"━━━━━━━━━━━━━━━━━━━━━━\n\n"+
-			"Құрметті <b>%s</b>!\n\n"+
-			"%s\n\n"+
-			"━━━━━━━━━━━━━━━━━━━━━━\n\n"+
-			"Сұрақтарыңыз болса, бізге хабарласыңыз:\n"+
-			"WhatsApp: +7 (776) 900-10-29\n\n"+
-			"<i>Рахмет, Alash-Go командасы</i>",
-		escapedFirstName,
-		escapedMessage,
-	)
+	lang := h.userLocale(ctx, d.TelegramID)
+	text := i18n.T(lang, "bot.admin.message", escapedFirstName, escapedMessage)
 
 	// WhatsApp contact button
-	keyboard := &models.InlineKeyboardMarkup{
-		InlineKeyboard: [][]models.InlineKeyboardButton{
-			{
-				{
-					Text: "💬 WhatsApp хабарласу",
-					URL:  "https://wa.me/87769001029",
-				},
-			},
-		},
-	}
+	keyboard := supportKeyboard(lang)
 
 	// Send message with HTML parse mode
 	_, err := h.bot.SendMessage(ctx, &bot.SendMessageParams{
@@ -253,7 +211,7 @@ func (h *Handler) sendAdminMessageSync(ctx context.Context, d DriverShort, messa
 	})
 
 	if err != nil {
-		return fmt.Errorf("Telegram API қатесі: %v", err)
+		return i18n.Wrap(err, "message_send_failed")
 	}
 
 	// Log successful send
@@ -277,28 +235,19 @@ func (h *Handler) RejectDriver(w http.ResponseWriter, r *http.Request) {
 	// Get admin telegram ID
 	adminTidStr := r.URL.Query().Get("telegram_id")
 	if adminTidStr == "" {
-		h.writeJSON(w, http.StatusBadRequest, Response{
-			Success: false,
-			Message: "telegram_id қажет",
-		})
+		h.sendErrorResponse(w, r, "telegram_id_required", http.StatusBadRequest)
 		return
 	}
 
 	adminTid, err := strconv.ParseInt(adminTidStr, 10, 64)
 	if err != nil {
-		h.writeJSON(w, http.StatusBadRequest, Response{
-			Success: false,
-			Message: "telegram_id дұрыс емес",
-		})
+		h.sendErrorResponse(w, r, "invalid_telegram_id", http.StatusBadRequest)
 		return
 	}
 
 	// Verify admin permissions
 	if !h.isAdmin(adminTid) {
-		h.writeJSON(w, http.StatusForbidden, Response{
-			Success: false,
-			Message: "Тек әкімшіге рұқсат",
-		})
+		h.sendErrorResponse(w, r, "forbidden", http.StatusForbidden)
 		return
 	}
 
@@ -308,19 +257,13 @@ func (h *Handler) RejectDriver(w http.ResponseWriter, r *http.Request) {
 		CustomReason string `json:"custom_reason"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.writeJSON(w, http.StatusBadRequest, Response{
-			Success: false,
-			Message: "JSON қате",
-		})
+		h.sendErrorResponse(w, r, "invalid_json", http.StatusBadRequest)
 		return
 	}
 
 	// Validate reason type
 	if req.ReasonType == "" {
-		h.writeJSON(w, http.StatusBadRequest, Response{
-			Success: false,
-			Message: "Блоктау себебі міндетті",
-		})
+		h.sendErrorResponse(w, r, "block_reason_required", http.StatusBadRequest)
 		return
 	}
 
@@ -328,10 +271,7 @@ func (h *Handler) RejectDriver(w http.ResponseWriter, r *http.Request) {
 	if req.ReasonType == "custom" {
 		customReason := strings.TrimSpace(req.CustomReason)
 		if customReason == "" {
-			h.writeJSON(w, http.StatusBadRequest, Response{
-				Success: false,
-				Message: "Өз себебіңізді жазыңыз",
-			})
+			h.sendErrorResponse(w, r, "block_custom_reason_required", http.StatusBadRequest)
 			return
 		}
 		req.CustomReason = customReason
@@ -341,10 +281,7 @@ func (h *Handler) RejectDriver(w http.ResponseWriter, r *http.Request) {
 	tx, err := h.db.BeginTx(ctx, nil)
 	if err != nil {
 		h.logErr("begin transaction", err)
-		h.writeJSON(w, http.StatusInternalServerError, Response{
-			Success: false,
-			Message: "Транзакция қатесі",
-		})
+		h.sendErrorResponse(w, r, "db_error", http.StatusInternalServerError)
 		return
 	}
 	defer tx.Rollback()
@@ -360,18 +297,12 @@ func (h *Handler) RejectDriver(w http.ResponseWriter, r *http.Request) {
 		&driver.Status, &driver.IsVerified, &driver.Contact)
 
 	if err == sql.ErrNoRows {
-		h.writeJSON(w, http.StatusNotFound, Response{
-			Success: false,
-			Message: "Жүргізуші табылмады",
-		})
+		h.sendErrorResponse(w, r, "driver_not_found", http.StatusNotFound)
 		return
 	}
 	if err != nil {
 		h.logErr("select driver for rejection", err)
-		h.writeJSON(w, http.StatusInternalServerError, Response{
-			Success: false,
-			Message: "Деректер базасының қатесі",
-		})
+		h.sendErrorResponse(w, r, "db_error", http.StatusInternalServerError)
 		return
 	}
 
@@ -387,20 +318,14 @@ func (h *Handler) RejectDriver(w http.ResponseWriter, r *http.Request) {
 	)
 	if err != nil {
 		h.logErr("update driver status to rejected", err)
-		h.writeJSON(w, http.StatusInternalServerError, Response{
-			Success: false,
-			Message: "Статусты жаңарту қатесі",
-		})
+		h.sendErrorResponse(w, r, "update_failed", http.StatusInternalServerError)
 		return
 	}
 
 	// Commit transaction
 	if err := tx.Commit(); err != nil {
 		h.logErr("commit rejection transaction", err)
-		h.writeJSON(w, http.StatusInternalServerError, Response{
-			Success: false,
-			Message: "Транзакцияны сақтау қатесі",
-		})
+		h.sendErrorResponse(w, r, "db_error", http.StatusInternalServerError)
 		return
 	}
 
@@ -410,7 +335,7 @@ func (h *Handler) RejectDriver(w http.ResponseWriter, r *http.Request) {
 	// Success response
 	h.writeJSON(w, http.StatusOK, Response{
 		Success: true,
-		Message: "Жүргізуші сәтті блокталды",
+		Message: successText(r, "driver_blocked"),
 		Data: map[string]interface{}{
 			"driver_id":   driverID,
 			"driver_name": driver.FirstName + " " + driver.LastName,
@@ -423,91 +348,25 @@ func (h *Handler) RejectDriver(w http.ResponseWriter, r *http.Request) {
 
 // notifyDriverBlocked sends block notification to driver
 func (h *Handler) notifyDriverBlocked(ctx context.Context, d DriverShort, reasonType, customReason string) {
-	if d.TelegramID == 0 || h.bot == nil {
+	if d.TelegramID == 0 {
 		return
 	}
 
-	// Build reason message based on type (bilingual - Kazakh/Russian)
-	var reasonMessage string
-	switch reasonType {
-	case "incorrect_documents":
-		reasonMessage = "📄 <b>Себебі / Причина:</b> Құжаттарыңыз платформа талаптарына сәйкес келмейді / " +
-			"Ваши документы не соответствуют требованиям платформы.\n\n" +
-			"Құжаттарды қайта тексеріп, дұрыс және анық фотосуреттерді жүктеңіз / " +
-			"Проверьте документы и загрузите четкие фотографии."
-
-	case "name_mismatch":
-		reasonMessage = "👤 <b>Себебі / Причина:</b> Профильдегі аты-жөніңіз құжаттармен сәйкес келмейді / " +
-			"Ваше имя в профиле не совпадает с документами.\n\n" +
-			"Профильде және құжаттарда бірдей аты-жөні болуы керек / " +
-			"Имя в профиле и документах должно совпадать."
-
-	case "photo_mismatch":
-		reasonMessage = "📸 <b>Себебі / Причина:</b> Профиль фотосуретіңіз құжаттардағы суретпен сәйкес келмейді / " +
-			"Ваше фото профиля не совпадает с фото в документах.\n\n" +
-			"Құжаттардағы және профильдегі фото бір адам болуы керек / " +
-			"Фото в документах и профиле должны быть одного человека."
-
-	case "payment_required":
-		reasonMessage = "💳 <b>Себебі / Причина:</b> Платформаға қол жеткізу үшін төлем төленбеген / " +
-			"Оплата за доступ к платформе не произведена.\n\n" +
-			"Қызметті жалғастыру үшін төлемді орындаңыз / " +
-			"Произведите оплату для продолжения работы."
-
-	case "custom":
-		if customReason != "" {
-			// CRITICAL: Escape custom reason for HTML
-			escapedReason := html.EscapeString(strings.TrimSpace(customReason))
-			reasonMessage = fmt.Sprintf("✍️ <b>Себебі / Причина:</b>\n%s", escapedReason)
-		} else {
-			reasonMessage = "✍️ <b>Себебі / Причина:</b> Әкімші көрсеткен себептер бойынша / " +
-				"По причинам, указанным администратором."
-		}
-
-	default:
-		reasonMessage = "Блоктау себебі көрсетілмеген / Причина блокировки не указана."
-	}
-
-	// CRITICAL: Escape driver name for HTML
-	escapedFirstName := html.EscapeString(d.FirstName)
-
-	// Format notification message
-	text := fmt.Sprintf(
-		"🚫 <b>Alash-Go Әкімші Хабарламасы / Сообщение Администрации</b>\n\n"+
-			"Сәлеметсіз бе / Здравствуйте, <b>%s</b>!\n\n"+
-			"Өкінішке орай, сіздің Alash-Go жүргізуші аккаунтыңыз уақытша блокталды / "+
-			"К сожалению, ваш аккаунт водителя Alash-Go временно заблокирован.\n\n"+
-			"%s\n\n"+
-			"━━━━━━━━━━━━━━━━━━━━━━\n\n"+
-			"📞 <b>Аккаунтты қалпына келтіру үшін / Для восстановления аккаунта:</b>\n\n"+
-			"WhatsApp арқылы бізге хабарласыңыз / Свяжитесь с нами через WhatsApp:\n"+
-			"+7 (776) 900-10-29\n\n"+
-			"Біз сізге көмектесуге әрқашан дайынбыз! / Мы всегда готовы помочь! 🤝\n\n"+
-			"<i>Рахмет / Спасибо, Alash-Go командасы 🚀</i>",
-		escapedFirstName,
-		reasonMessage,
-	)
-
 	// WhatsApp contact button
-	keyboard := &models.InlineKeyboardMarkup{
-		InlineKeyboard: [][]models.InlineKeyboardButton{
-			{
-				{
-					Text: "💬 WhatsApp хабарласу / Связаться",
-					URL:  "https://wa.me/87769001029",
-				},
-			},
+	lang := h.userLocale(ctx, d.TelegramID)
+	keyboard := supportKeyboard(lang)
+
+	err := h.notifier.Send(ctx, notifier.Message{
+		Template: "driver_blocked",
+		Data: driverBlockedNotice{
+			FirstName:    d.FirstName,
+			Reason:       reasonType,
+			CustomReason: strings.TrimSpace(customReason),
 		},
-	}
-
-	// Send notification
-	_, err := h.bot.SendMessage(ctx, &bot.SendMessageParams{
-		ChatID:      d.TelegramID,
-		Text:        text,
-		ParseMode:   models.ParseModeHTML,
-		ReplyMarkup: keyboard,
+		To:     notifier.Recipient{TelegramID: d.TelegramID, Phone: d.Contact},
+		Locale: lang,
+		Markup: keyboard,
 	})
-
 	if err != nil {
 		h.logErr("send block notification", err)
 	} else {
@@ -530,28 +389,19 @@ func (h *Handler) UnblockDriver(w http.ResponseWriter, r *http.Request) {
 	// Get admin telegram ID
 	adminTidStr := r.URL.Query().Get("telegram_id")
 	if adminTidStr == "" {
-		h.writeJSON(w, http.StatusBadRequest, Response{
-			Success: false,
-			Message: "telegram_id қажет",
-		})
+		h.sendErrorResponse(w, r, "telegram_id_required", http.StatusBadRequest)
 		return
 	}
 
 	adminTid, err := strconv.ParseInt(adminTidStr, 10, 64)
 	if err != nil {
-		h.writeJSON(w, http.StatusBadRequest, Response{
-			Success: false,
-			Message: "telegram_id дұрыс емес",
-		})
+		h.sendErrorResponse(w, r, "invalid_telegram_id", http.StatusBadRequest)
 		return
 	}
 
 	// Verify admin permissions
 	if !h.isAdmin(adminTid) {
-		h.writeJSON(w, http.StatusForbidden, Response{
-			Success: false,
-			Message: "Тек әкімшіге рұқсат",
-		})
+		h.sendErrorResponse(w, r, "forbidden", http.StatusForbidden)
 		return
 	}
 
@@ -559,10 +409,7 @@ func (h *Handler) UnblockDriver(w http.ResponseWriter, r *http.Request) {
 	tx, err := h.db.BeginTx(ctx, nil)
 	if err != nil {
 		h.logErr("begin transaction", err)
-		h.writeJSON(w, http.StatusInternalServerError, Response{
-			Success: false,
-			Message: "Транзакция қатесі",
-		})
+		h.sendErrorResponse(w, r, "db_error", http.StatusInternalServerError)
 		return
 	}
 	defer tx.Rollback()
@@ -578,27 +425,18 @@ func (h *Handler) UnblockDriver(w http.ResponseWriter, r *http.Request) {
 		&driver.Status, &driver.IsVerified, &driver.Contact)
 
 	if err == sql.ErrNoRows {
-		h.writeJSON(w, http.StatusNotFound, Response{
-			Success: false,
-			Message: "Жүргізуші табылмады",
-		})
+		h.sendErrorResponse(w, r, "driver_not_found", http.StatusNotFound)
 		return
 	}
 	if err != nil {
 		h.logErr("select driver for unblocking", err)
-		h.writeJSON(w, http.StatusInternalServerError, Response{
-			Success: false,
-			Message: "Деректер базасының қатесі",
-		})
+		h.sendErrorResponse(w, r, "db_error", http.StatusInternalServerError)
 		return
 	}
 
 	// Check if driver is actually blocked
 	if driver.Status != "rejected" && driver.Status != "suspended" {
-		h.writeJSON(w, http.StatusBadRequest, Response{
-			Success: false,
-			Message: "Жүргізуші блокталмаған",
-		})
+		h.sendErrorResponse(w, r, "driver_not_blocked", http.StatusBadRequest)
 		return
 	}
 
@@ -615,20 +453,14 @@ func (h *Handler) UnblockDriver(w http.ResponseWriter, r *http.Request) {
 	)
 	if err != nil {
 		h.logErr("update driver status to pending", err)
-		h.writeJSON(w, http.StatusInternalServerError, Response{
-			Success: false,
-			Message: "Статусты жаңарту қатесі",
-		})
+		h.sendErrorResponse(w, r, "update_failed", http.StatusInternalServerError)
 		return
 	}
 
 	// Commit transaction
 	if err := tx.Commit(); err != nil {
 		h.logErr("commit unblock transaction", err)
-		h.writeJSON(w, http.StatusInternalServerError, Response{
-			Success: false,
-			Message: "Транзакцияны сақтау қатесі",
-		})
+		h.sendErrorResponse(w, r, "db_error", http.StatusInternalServerError)
 		return
 	}
 
@@ -638,7 +470,7 @@ func (h *Handler) UnblockDriver(w http.ResponseWriter, r *http.Request) {
 	// Success response
 	h.writeJSON(w, http.StatusOK, Response{
 		Success: true,
-		Message: "Жүргізуші блоктан шығарылды",
+		Message: successText(r, "driver_unblocked"),
 		Data: map[string]interface{}{
 			"driver_id":    driverID,
 			"driver_name":  driver.FirstName + " " + driver.LastName,
@@ -657,39 +489,12 @@ func (h *Handler) notifyDriverUnblocked(ctx context.Context, d DriverShort) {
 	// CRITICAL: Escape driver name for HTML
 	escapedFirstName := html.EscapeString(d.FirstName)
 
-	// Format notification message (bilingual)
-	text := fmt.Sprintf(
-		"✅ <b>Alash-Go Хабарламасы / Сообщение</b>\n\n"+
-			"Құрметті / Уважаемый <b>%s</b>!\n\n"+
-			"🎉 <b>Сіздің аккаунтыңыз қалпына келтірілді! / Ваш аккаунт восстановлен!</b>\n\n"+
-			"Сіз қазір Alash-Go платформасында жүргізуші ретінде жұмыс жасай аласыз / "+
-			"Теперь вы можете работать водителем на платформе Alash-Go.\n\n"+
-			"━━━━━━━━━━━━━━━━━━━━━━\n\n"+
-			"📋 <b>Келесі қадам / Следующий шаг:</b>\n\n"+
-			"1️⃣ Платформада тіркелуіңізді аяқтаңыз / Завершите регистрацию\n"+
-			"2️⃣ Жүк тасымалдау өтінімдерін алуды бастаңыз / Начните получать заказы\n"+
-			"3️⃣ Табыс табыңыз! 💰 / Зарабатывайте! 💰\n\n"+
-			"━━━━━━━━━━━━━━━━━━━━━━\n\n"+
-			"📞 <b>Көмек керек пе? / Нужна помощь?</b>\n\n"+
-			"Кез келген сұрақтар бойынша бізге хабарласыңыз / "+
-			"По любым вопросам свяжитесь с нами:\n\n"+
-			"💬 WhatsApp: +7 (776) 900-10-29\n\n"+
-			"Сәттілік тілейміз! / Желаем успехов! 🚀\n"+
-			"<i>Alash-Go командасы / команда</i>",
-		escapedFirstName,
-	)
+	// Format notification message
+	lang := h.userLocale(ctx, d.TelegramID)
+	text := i18n.T(lang, "bot.admin.unblocked", escapedFirstName)
 
 	// WhatsApp contact button
-	keyboard := &models.InlineKeyboardMarkup{
-		InlineKeyboard: [][]models.InlineKeyboardButton{
-			{
-				{
-					Text: "💬 WhatsApp хабарласу / Связаться",
-					URL:  "https://wa.me/87769001029",
-				},
-			},
-		},
-	}
+	keyboard := supportKeyboard(lang)
 
 	// Send notification
 	_, err := h.bot.SendMessage(ctx, &bot.SendMessageParams{
@@ -726,7 +531,7 @@ func (h *Handler) handleAdminSummary(w http.ResponseWriter, r *http.Request) {
 
 	// Verify admin permissions
 	if !h.isAdmin(telegramID) {
-		h.sendErrorResponse(w, "Рұқсат жоқ / Нет доступа", http.StatusForbidden)
+		h.sendErrorResponse(w, r, "forbidden", http.StatusForbidden)
 		return
 	}
 
@@ -738,13 +543,14 @@ func (h *Handler) handleAdminSummary(w http.ResponseWriter, r *http.Request) {
 	_ = h.db.QueryRow(`SELECT COUNT(*) FROM drivers WHERE status='rejected'`).Scan(&rejectedDrivers)
 
 	// Get order statistics
-	var totalOrders, pendingOrders, matchedOrders, inProgressOrders, completedOrders, cancelledOrders int
+	var totalOrders, pendingOrders, matchedOrders, inProgressOrders, completedOrders, cancelledOrders, expiredOrders int
 	_ = h.db.QueryRow(`SELECT COUNT(*) FROM delivery_requests`).Scan(&totalOrders)
 	_ = h.db.QueryRow(`SELECT COUNT(*) FROM delivery_requests WHERE status='pending'`).Scan(&pendingOrders)
 	_ = h.db.QueryRow(`SELECT COUNT(*) FROM delivery_requests WHERE status='matched'`).Scan(&matchedOrders)
 	_ = h.db.QueryRow(`SELECT COUNT(*) FROM delivery_requests WHERE status='in_progress'`).Scan(&inProgressOrders)
 	_ = h.db.QueryRow(`SELECT COUNT(*) FROM delivery_requests WHERE status='completed'`).Scan(&completedOrders)
 	_ = h.db.QueryRow(`SELECT COUNT(*) FROM delivery_requests WHERE status='cancelled'`).Scan(&cancelledOrders)
+	_ = h.db.QueryRow(`SELECT COUNT(*) FROM delivery_requests WHERE status='expired'`).Scan(&expiredOrders)
 
 	// Get orders by day (last 7 days)
 	rows, err := h.db.Query(`
@@ -803,6 +609,7 @@ func (h *Handler) handleAdminSummary(w http.ResponseWriter, r *http.Request) {
 			"in_progress": inProgressOrders,
 			"completed":   completedOrders,
 			"cancelled":   cancelledOrders,
+			"expired":     expiredOrders,
 		},
 		"charts": map[string]interface{}{
 			"orders_by_day":  ordersByDay,
@@ -810,7 +617,7 @@ func (h *Handler) handleAdminSummary(w http.ResponseWriter, r *http.Request) {
 		},
 	}
 
-	h.sendSuccessResponse(w, "Admin summary", resp)
+	h.sendSuccessResponse(w, r, "admin_summary", resp)
 }
 
 // handleAdminDrivers returns list of all drivers for admin panel
@@ -829,7 +636,7 @@ func (h *Handler) handleAdminDrivers(w http.ResponseWriter, r *http.Request) {
 
 	// Verify admin permissions
 	if !h.isAdmin(telegramID) {
-		h.sendErrorResponse(w, "Рұқсат жоқ / Нет доступа", http.StatusForbidden)
+		h.sendErrorResponse(w, r, "forbidden", http.StatusForbidden)
 		return
 	}
 
@@ -846,7 +653,7 @@ func (h *Handler) handleAdminDrivers(w http.ResponseWriter, r *http.Request) {
 
 	if err != nil {
 		h.logger.Error("Failed to query drivers", zap.Error(err))
-		h.sendErrorResponse(w, "Қате / Ошибка", http.StatusInternalServerError)
+		h.sendErrorResponse(w, r, "internal", http.StatusInternalServerError)
 		return
 	}
 	defer rows.Close()
@@ -870,7 +677,7 @@ func (h *Handler) handleAdminDrivers(w http.ResponseWriter, r *http.Request) {
 		drivers = append(drivers, d)
 	}
 
-	h.sendSuccessResponse(w, "Admin drivers", map[string]interface{}{
+	h.sendSuccessResponse(w, r, "admin_drivers", map[string]interface{}{
 		"count":   len(drivers),
 		"drivers": drivers,
 	})
@@ -892,7 +699,7 @@ func (h *Handler) handleAdminDriverDetail(w http.ResponseWriter, r *http.Request
 
 	// Verify admin permissions
 	if !h.isAdmin(telegramID) {
-		h.sendErrorResponse(w, "Рұқсат жоқ / Нет доступа", http.StatusForbidden)
+		h.sendErrorResponse(w, r, "forbidden", http.StatusForbidden)
 		return
 	}
 
@@ -900,7 +707,7 @@ func (h *Handler) handleAdminDriverDetail(w http.ResponseWriter, r *http.Request
 	vars := mux.Vars(r)
 	driverID := vars["id"]
 	if driverID == "" {
-		h.sendErrorResponse(w, "Driver ID қажет / нужен", http.StatusBadRequest)
+		h.sendErrorResponse(w, r, "driver_id_required", http.StatusBadRequest)
 		return
 	}
 
@@ -927,12 +734,12 @@ func (h *Handler) handleAdminDriverDetail(w http.ResponseWriter, r *http.Request
 	)
 
 	if err == sql.ErrNoRows {
-		h.sendErrorResponse(w, "Driver табылмады / не найден", http.StatusNotFound)
+		h.sendErrorResponse(w, r, "driver_not_found", http.StatusNotFound)
 		return
 	}
 	if err != nil {
 		h.logger.Error("Failed to query driver detail", zap.Error(err))
-		h.sendErrorResponse(w, "Қате / Ошибка", http.StatusInternalServerError)
+		h.sendErrorResponse(w, r, "internal", http.StatusInternalServerError)
 		return
 	}
 
@@ -976,7 +783,26 @@ func (h *Handler) handleAdminDriverDetail(w http.ResponseWriter, r *http.Request
 		resp["approved_by"] = *d.ApprovedBy
 	}
 
-	h.sendSuccessResponse(w, "Driver detail", resp)
+	// Отказы от назначенных заявок и пауза в рассылке
+	var cancelCount int
+	if err := h.db.QueryRow(`SELECT COALESCE(cancel_count, 0) FROM drivers WHERE id = ?`, driverID).Scan(&cancelCount); err != nil {
+		h.logger.Warn("Failed to query driver cancel count", zap.Error(err))
+	}
+	resp["cancel_count"] = cancelCount
+
+	if until, err := h.driverRepo.GetDispatchCooldown(r.Context(), driverID); err != nil {
+		h.logger.Warn("Failed to query dispatch cooldown", zap.Error(err))
+	} else if until != nil {
+		resp["dispatch_cooldown_until"] = until.Format(time.RFC3339)
+	}
+
+	cancellations, err := h.driverRepo.GetCancellations(r.Context(), driverID, adminCancellationsLimit)
+	if err != nil {
+		h.logger.Warn("Failed to query driver cancellations", zap.Error(err))
+	}
+	resp["cancellations"] = cancellations
+
+	h.sendSuccessResponse(w, r, "driver_detail", resp)
 }
 
 // handleAdminOrders returns list of all orders for admin panel
@@ -995,7 +821,7 @@ func (h *Handler) handleAdminOrders(w http.ResponseWriter, r *http.Request) {
 
 	// Verify admin permissions
 	if !h.isAdmin(telegramID) {
-		h.sendErrorResponse(w, "Рұқсат жоқ / Нет доступа", http.StatusForbidden)
+		h.sendErrorResponse(w, r, "forbidden", http.StatusForbidden)
 		return
 	}
 
@@ -1012,7 +838,7 @@ func (h *Handler) handleAdminOrders(w http.ResponseWriter, r *http.Request) {
 
 	if err != nil {
 		h.logger.Error("Failed to query orders", zap.Error(err))
-		h.sendErrorResponse(w, "Қате / Ошибка", http.StatusInternalServerError)
+		h.sendErrorResponse(w, r, "internal", http.StatusInternalServerError)
 		return
 	}
 	defer rows.Close()
@@ -1036,7 +862,26 @@ func (h *Handler) handleAdminOrders(w http.ResponseWriter, r *http.Request) {
 		orders = append(orders, o)
 	}
 
-	h.sendSuccessResponse(w, "Admin orders", map[string]interface{}{
+	ids := make([]string, len(orders))
+	for i := range orders {
+		ids[i] = orders[i].ID
+	}
+	if stops, err := h.orderRepo.GetStopsByRequestIDs(r.Context(), ids); err != nil {
+		h.logger.Warn("Failed to load order stops", zap.Error(err))
+	} else {
+		for i := range orders {
+			orders[i].Stops = stops[orders[i].ID]
+		}
+	}
+	if proofs, err := h.orderRepo.GetProofsByRequestIDs(r.Context(), ids); err != nil {
+		h.logger.Warn("Failed to load order proofs", zap.Error(err))
+	} else {
+		for i := range orders {
+			orders[i].Proofs = publicProofs(proofs[orders[i].ID])
+		}
+	}
+
+	h.sendSuccessResponse(w, r, "admin_orders", map[string]interface{}{
 		"count":  len(orders),
 		"orders": orders,
 	})
@@ -1078,3 +923,17 @@ func min(a, b int) int {
 	}
 	return b
 }
+
+// supportKeyboard — кнопка связи с поддержкой в WhatsApp
+func supportKeyboard(lang i18n.Locale) *models.InlineKeyboardMarkup {
+	return &models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{
+			{
+				{
+					Text: i18n.T(lang, "bot.btn.whatsapp"),
+					URL:  "https://wa.me/87769001029",
+				},
+			},
+		},
+	}
+}
@@ -2,9 +2,15 @@ package handler
 
 import (
 	"context"
+	"html"
 	"time"
 
+	"tezjet/internal/domain"
+	"tezjet/internal/i18n"
+	"tezjet/internal/repository"
+
 	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
 	"go.uber.org/zap"
 )
 
@@ -28,7 +34,7 @@ func (h *Handler) ChangeDriverStatus(ctx context.Context, b *bot.Bot) {
 				id := ids[i]
 				_, err := b.SendMessage(ctx, &bot.SendMessageParams{
 					ChatID: id,
-					Text:   "✅ Сіздің жүргізуші мәртебеңіз мақұлданды! 🚗 Енді сіз жолсапарды бастай аласыз! 🎉🛣️",
+					Text:   i18n.T(h.userLocale(ctx, id), "bot.driver.approved"),
 				})
 				if err != nil {
 					h.logger.Error("error send message to driver", zap.Error(err))
@@ -37,3 +43,96 @@ func (h *Handler) ChangeDriverStatus(ctx context.Context, b *bot.Bot) {
 		}
 	}
 }
+
+// ExpireStaleOrders переводит pending заявки без ответа в expired и предлагает клиенту
+// переопубликовать заявку с более высокой ценой или отменить её.
+func (h *Handler) ExpireStaleOrders(ctx context.Context, b *bot.Bot) {
+	h.logger.Info("started order expiry service", zap.Duration("interval", h.cfg.OrderExpiryInterval))
+	ticker := time.NewTicker(h.cfg.OrderExpiryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			h.logger.Info("order expiry service stopped")
+			return
+		case <-ticker.C:
+			h.expireStaleOrders(ctx, b)
+		}
+	}
+}
+
+// expireStaleOrders проходит все страницы кандидатов: заявки с длинным TTL
+// не должны заслонять уже просроченные заявки с коротким
+func (h *Handler) expireStaleOrders(ctx context.Context, b *bot.Bot) {
+	now := time.Now().UTC()
+	var after *domain.DeliveryRequest
+	for {
+		orders, err := h.userRepo.GetStalePendingDeliveryRequests(ctx, h.cfg.MinOrderTTL(), after)
+		if err != nil {
+			h.logger.Error("load stale orders error", zap.Error(err))
+			return
+		}
+		for _, order := range orders {
+			ttl := h.cfg.OrderTTLFor(h.extractCityFromAddress(order.FromAddress), order.TruckType)
+			if now.Sub(*order.DispatchedAt) < ttl {
+				continue
+			}
+			expired, err := h.userRepo.ExpireDeliveryRequest(ctx, order.ID)
+			if err != nil {
+				h.logger.Error("expire order error", zap.String("order_id", order.ID), zap.Error(err))
+				continue
+			}
+			if !expired {
+				continue
+			}
+			h.logger.Info("order expired", zap.String("order_id", order.ID), zap.Duration("ttl", ttl))
+			h.sendOrderExpiredMessage(ctx, b, order)
+		}
+		if len(orders) < repository.StaleOrdersPageSize {
+			return
+		}
+		after = orders[len(orders)-1]
+	}
+}
+
+func (h *Handler) sendOrderExpiredMessage(ctx context.Context, b *bot.Bot, order *domain.DeliveryRequest) {
+	if order.TelegramID == 0 {
+		return
+	}
+
+	lang := h.userLocale(ctx, order.TelegramID)
+	newPrice := raisedPrice(order.Price, h.cfg.RepostRaisePercent)
+	text := i18n.T(lang, "bot.order.expired",
+		order.ID, html.EscapeString(order.FromAddress), html.EscapeString(order.ToAddress), order.Price, newPrice)
+
+	keyboard := &models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{
+			{
+				{Text: i18n.T(lang, "bot.order.btn_repost", newPrice), CallbackData: "repost:" + order.ID},
+			},
+			{
+				{Text: i18n.T(lang, "bot.order.btn_cancel"), CallbackData: "cancel_order:" + order.ID},
+			},
+		},
+	}
+
+	_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      order.TelegramID,
+		Text:        text,
+		ParseMode:   models.ParseModeHTML,
+		ReplyMarkup: keyboard,
+	})
+	if err != nil {
+		h.logger.Error("error send order expired message", zap.Int64("telegram_id", order.TelegramID), zap.Error(err))
+	}
+}
+
+// raisedPrice поднимает цену на percent процентов с округлением вверх до 100 ₸
+func raisedPrice(price, percent int) int {
+	step := (price*percent + 99) / 100
+	step = (step + 99) / 100 * 100
+	if step < 100 {
+		step = 100
+	}
+	return price + step
+}
@@ -0,0 +1,25 @@
+package handler
+
+import "testing"
+
+func TestRaisedPrice(t *testing.T) {
+	tests := []struct {
+		name    string
+		price   int
+		percent int
+		want    int
+	}{
+		{"round step", 10000, 10, 11000},
+		{"step rounded up to hundreds", 1050, 10, 1250},
+		{"odd price", 12345, 7, 13245},
+		{"minimum step", 500, 5, 600},
+		{"zero percent still raises", 3000, 0, 3100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := raisedPrice(tt.price, tt.percent); got != tt.want {
+				t.Errorf("raisedPrice(%d, %d) = %d, want %d", tt.price, tt.percent, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+// backhaul-handler.go
+package handler
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"math"
+	"strings"
+	"time"
+
+	"tezjet/internal/domain"
+	"tezjet/internal/i18n"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"go.uber.org/zap"
+)
+
+// sendBackhaulDigest — после межгородней доставки присылает водителю подборку грузов
+// от точки выгрузки в сторону дома: открытые заявки и сохранённые поиски клиентов.
+// Водителю, у которого в рейсе ещё есть заявки, подборка не нужна.
+func (h *Handler) sendBackhaulDigest(ctx context.Context, b *bot.Bot, driver *DriverRegistration, orderID string) {
+	order, err := h.getDeliveryOrderById(orderID)
+	if err != nil || order == nil {
+		return
+	}
+	intercity := domain.IsIntercity(
+		domain.GeoPoint{Lat: order.FromLat, Lon: order.FromLon},
+		domain.GeoPoint{Lat: order.ToLat, Lon: order.ToLon})
+	if !h.isValidCoordinates(driver.Latitude, driver.Longitude) || !intercity {
+		return
+	}
+	route := domain.BackhaulRoute{
+		Dropoff: domain.GeoPoint{Lat: order.ToLat, Lon: order.ToLon},
+		Home:    domain.GeoPoint{Lat: driver.Latitude, Lon: driver.Longitude},
+	}
+	if !route.NeedsBackhaul() {
+		return
+	}
+
+	var busy int
+	if err := h.db.QueryRowContext(ctx, `
+		SELECT COUNT(1) FROM delivery_requests
+		WHERE driver_id = ? AND status IN ('matched', 'in_progress')`, driver.ID).Scan(&busy); err != nil || busy > 0 {
+		return
+	}
+	prefs, err := h.prefsRepo.GetPreferences(ctx, driver.TelegramID)
+	if err != nil {
+		return
+	}
+	now := time.Now()
+	if prefs.IsPaused(now) {
+		return
+	}
+
+	capacity := tripCapacity(tripVehicleType("", driver.TruckType), 0, driver.Capacity)
+	offers, err := h.backhaulOrders(ctx, route, capacity, &prefs)
+	if err != nil {
+		h.logger.Error("Failed to load backhaul orders", zap.String("driver_id", driver.ID), zap.Error(err))
+	}
+	offers = append(offers, h.backhaulSearches(ctx, route, capacity, driver.TelegramID, now)...)
+	if len(offers) == 0 {
+		return
+	}
+	offers = domain.RankBackhaul(offers)
+
+	lang := h.userLocale(ctx, driver.TelegramID)
+	if _, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:              driver.TelegramID,
+		Text:                h.formatBackhaulDigest(lang, order.ToAddress, driver.StartCity, offers),
+		ParseMode:           models.ParseModeHTML,
+		ReplyMarkup:         h.backhaulKeyboard(lang, offers),
+		DisableNotification: prefs.InQuietHours(now.In(h.cfg.Location())),
+	}); err != nil {
+		h.logger.Warn("send backhaul digest", zap.Int64("tg_id", driver.TelegramID), zap.Error(err))
+		return
+	}
+	h.logger.Info("Backhaul digest sent",
+		zap.String("driver_id", driver.ID),
+		zap.String("order_id", orderID),
+		zap.Int("offers", len(offers)))
+}
+
+// backhaulOrders — открытые заявки, которые водитель видит, с погрузкой у точки выгрузки
+func (h *Handler) backhaulOrders(ctx context.Context, route domain.BackhaulRoute, capacity domain.VehicleCapacity, prefs *domain.DriverPreferences) ([]domain.BackhaulOffer, error) {
+	dLat := domain.BackhaulPickupKm / 111.32
+	dLon := domain.BackhaulPickupKm / (111.32 * math.Cos(route.Dropoff.Lat*math.Pi/180))
+	rows, err := h.db.QueryContext(ctx, `
+		SELECT id, from_address, from_lat, from_lon, to_address, to_lat, to_lon,
+			   price, COALESCE(truck_type, ''), `+cargoColumns+`
+		FROM delivery_requests
+		WHERE status = 'pending'
+		  AND dispatched_at IS NOT NULL
+		  AND (preferred_driver_id IS NULL OR preferred_until <= datetime('now'))
+		  AND from_lat BETWEEN ? AND ?
+		  AND from_lon BETWEEN ? AND ?
+		LIMIT 200`,
+		route.Dropoff.Lat-dLat, route.Dropoff.Lat+dLat, route.Dropoff.Lon-dLon, route.Dropoff.Lon+dLon)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query backhaul orders: %w", err)
+	}
+	defer rows.Close()
+
+	var offers []domain.BackhaulOffer
+	for rows.Next() {
+		o := domain.BackhaulOffer{Kind: domain.BackhaulKindOrder}
+		dest := []interface{}{
+			&o.ID, &o.FromAddress, &o.From.Lat, &o.From.Lon, &o.ToAddress, &o.To.Lat, &o.To.Lon,
+			&o.Price, &o.TruckType,
+		}
+		if err := rows.Scan(append(dest, cargoScanDest(&o.Cargo)...)...); err != nil {
+			h.logger.Error("Failed to scan backhaul order", zap.Error(err))
+			continue
+		}
+		o.TruckType = strings.ToLower(strings.TrimSpace(o.TruckType))
+		if prefs.MinPrice > 0 && o.Price < prefs.MinPrice {
+			continue
+		}
+		if len(prefs.TruckTypes) > 0 && o.TruckType != "" && !prefs.HasTruckType(o.TruckType) {
+			continue
+		}
+		if !capacity.CanCarry(o.TruckType, o.Cargo) || !route.Evaluate(&o) {
+			continue
+		}
+		offers = append(offers, o)
+	}
+	return offers, rows.Err()
+}
+
+// backhaulSearches — клиенты, которые сейчас ищут машину от точки выгрузки в сторону дома
+func (h *Handler) backhaulSearches(ctx context.Context, route domain.BackhaulRoute, capacity domain.VehicleCapacity, driverTelegramID int64, now time.Time) []domain.BackhaulOffer {
+	searches, err := h.searchRepo.GetSearchesAt(ctx, now, driverTelegramID)
+	if err != nil {
+		return nil
+	}
+	var offers []domain.BackhaulOffer
+	for i := range searches {
+		s := &searches[i]
+		if !s.AnyTruck() && !capacity.CanCarry(s.TruckType, domain.CargoSpec{}) {
+			continue
+		}
+		o := domain.BackhaulOffer{
+			Kind:        domain.BackhaulKindSearch,
+			ID:          fmt.Sprint(s.ID),
+			FromAddress: s.FromAddress,
+			From:        domain.GeoPoint{Lat: s.FromLat, Lon: s.FromLon},
+			ToAddress:   s.ToAddress,
+			To:          domain.GeoPoint{Lat: s.ToLat, Lon: s.ToLon},
+			TruckType:   s.TruckType,
+		}
+		if route.Evaluate(&o) {
+			offers = append(offers, o)
+		}
+	}
+	return offers
+}
+
+func (h *Handler) formatBackhaulDigest(lang i18n.Locale, dropoff, homeCity string, offers []domain.BackhaulOffer) string {
+	var sb strings.Builder
+	sb.WriteString(i18n.T(lang, "bot.backhaul.title") + "\n")
+	fmt.Fprintf(&sb, "📍 %s → 🏠 %s\n", html.EscapeString(h.extractCityFromAddress(dropoff)), html.EscapeString(homeCity))
+
+	hasSearches := false
+	for i, o := range offers {
+		fmt.Fprintf(&sb, "\n<b>%d.</b> %s → %s\n", i+1, html.EscapeString(o.FromAddress), html.EscapeString(o.ToAddress))
+		if o.Kind == domain.BackhaulKindSearch {
+			hasSearches = true
+			sb.WriteString("   " + i18n.T(lang, "bot.backhaul.search") + "\n")
+		} else {
+			fmt.Fprintf(&sb, "   💰 %d ₸%s\n", o.Price, strings.ReplaceAll(formatCargoText(lang, o.Cargo), "\n", " "))
+		}
+		sb.WriteString("   " + i18n.T(lang, "bot.backhaul.distance", o.PickupKm, o.ProgressKm) + "\n")
+	}
+	if hasSearches {
+		sb.WriteString("\n" + i18n.T(lang, "bot.backhaul.post_hint"))
+	}
+	return sb.String()
+}
+
+// backhaulKeyboard — быстрое предложение цены по заявкам и публикация обратного рейса
+func (h *Handler) backhaulKeyboard(lang i18n.Locale, offers []domain.BackhaulOffer) *models.InlineKeyboardMarkup {
+	var rows [][]models.InlineKeyboardButton
+	var bidRow []models.InlineKeyboardButton
+	for i, o := range offers {
+		if o.Kind != domain.BackhaulKindOrder {
+			continue
+		}
+		bidRow = append(bidRow, models.InlineKeyboardButton{
+			Text:         fmt.Sprintf("💰 %d. +%d%%", i+1, quickBidPercents[0]),
+			CallbackData: fmt.Sprintf("bid:%s:%d", o.ID, quickBidPercents[0]),
+		})
+		if len(bidRow) == 3 {
+			rows = append(rows, bidRow)
+			bidRow = nil
+		}
+	}
+	if len(bidRow) > 0 {
+		rows = append(rows, bidRow)
+	}
+	rows = append(rows, []models.InlineKeyboardButton{{
+		Text:   i18n.T(lang, "bot.backhaul.btn_post"),
+		WebApp: &models.WebAppInfo{URL: h.cfg.BaseURL + "/driver"},
+	}})
+	return &models.InlineKeyboardMarkup{InlineKeyboard: rows}
+}
@@ -0,0 +1,468 @@
+// bid-handler.go
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"tezjet/internal/domain"
+	"tezjet/internal/i18n"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"go.uber.org/zap"
+)
+
+const (
+	maxBidEtaMin     = 7 * 24 * 60 // ETA больше недели — скорее опечатка
+	maxBidCommentLen = 500
+	maxBidsInMessage = 5 // сколько лучших предложений показывать в боте
+)
+
+// Быстрые ставки из сообщения с заявкой: +N% к цене клиента
+var quickBidPercents = []int{10, 20}
+
+var (
+	errBidOrderClosed  = i18n.E("bid_order_closed")
+	errBidDriverDenied = i18n.E("driver_not_approved")
+	errBidNotFound     = i18n.E("bid_not_found")
+)
+
+// bidRequest — предложение водителя из Mini App
+type bidRequest struct {
+	TelegramID int64  `json:"telegram_id"`
+	OrderID    string `json:"order_id"`
+	TripID     string `json:"trip_id"`
+	Price      int    `json:"price"`
+	EtaMin     int    `json:"eta_min"`
+	Comment    string `json:"comment"`
+}
+
+func (h *Handler) validateBid(req *bidRequest) error {
+	req.Comment = strings.TrimSpace(req.Comment)
+	if req.Price < h.cfg.MinPrice {
+		return i18n.E("bid_price_too_low", h.cfg.MinPrice)
+	}
+	if req.EtaMin < 0 || req.EtaMin > maxBidEtaMin {
+		return i18n.E("bid_eta_range", maxBidEtaMin)
+	}
+	if utf8.RuneCountInString(req.Comment) > maxBidCommentLen {
+		return i18n.E("comment_too_long", maxBidCommentLen)
+	}
+	return nil
+}
+
+// submitBid создаёт предложение водителя или обновляет его открытое предложение по заявке
+func (h *Handler) submitBid(ctx context.Context, b *bot.Bot, driver *DriverRegistration, req bidRequest) (*domain.DriverMatch, *domain.DeliveryRequest, error) {
+	if driver.Status != "approved" {
+		return nil, nil, errBidDriverDenied
+	}
+	if err := h.checkDispatchCooldown(ctx, driver.ID); err != nil {
+		return nil, nil, err
+	}
+
+	order, err := h.getDeliveryOrderById(req.OrderID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load order: %w", err)
+	}
+	if order == nil || order.Status != domain.DeliveryStatusPending || order.DispatchedAt == nil {
+		return nil, nil, errBidOrderClosed
+	}
+	if order.InPreferredWindow(time.Now()) && order.PreferredDriverID != driver.ID {
+		return nil, nil, errBidOrderClosed
+	}
+	if assigned, err := h.orderHasDriver(ctx, order.ID); err != nil {
+		return nil, nil, err
+	} else if assigned {
+		return nil, nil, errBidOrderClosed
+	}
+
+	expiresAt := time.Now().Add(h.cfg.BidTTL)
+	match, err := h.driverRepo.GetPendingBid(ctx, order.ID, driver.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if match != nil {
+		match.ProposedPrice, match.EtaMin, match.DriverComment, match.ExpiresAt = req.Price, req.EtaMin, req.Comment, &expiresAt
+		ok, err := h.driverRepo.UpdateBidOffer(ctx, match)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !ok {
+			return nil, nil, errBidOrderClosed
+		}
+	} else {
+		match, err = h.driverRepo.CreateDriverMatch(&domain.DriverMatch{
+			DriverID:          driver.ID,
+			DriverRouteID:     req.TripID,
+			DeliveryRequestID: order.ID,
+			ClientTelegramID:  order.TelegramID,
+			ProposedPrice:     req.Price,
+			EtaMin:            req.EtaMin,
+			DriverComment:     req.Comment,
+			ExpiresAt:         &expiresAt,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	h.logger.Info("Driver bid submitted",
+		zap.String("order_id", order.ID),
+		zap.String("driver_id", driver.ID),
+		zap.Int("price", req.Price),
+		zap.Int("eta_min", req.EtaMin))
+
+	h.notifyClientNewBid(ctx, b, order, match, driver)
+	return match, order, nil
+}
+
+// orderHasDriver — принятая через "Қабылдау" заявка остаётся pending, но с driver_id
+func (h *Handler) orderHasDriver(ctx context.Context, orderID string) (bool, error) {
+	var driverID string
+	err := h.db.QueryRowContext(ctx, `SELECT COALESCE(driver_id, '') FROM delivery_requests WHERE id = ?`, orderID).Scan(&driverID)
+	if err != nil {
+		return false, fmt.Errorf("failed to check order driver: %w", err)
+	}
+	return driverID != "", nil
+}
+
+// formatBidLine — одна строка предложения для сообщений бота
+func (h *Handler) formatBidLine(lang i18n.Locale, rank int, bid domain.DriverBid) string {
+	line := fmt.Sprintf("%d. <b>%d ₸</b> — %s", rank, bid.ProposedPrice, html.EscapeString(bid.DriverName))
+	if bid.IsVerified {
+		line += " ✔️"
+	}
+	if bid.TruckType != "" {
+		line += fmt.Sprintf(" (%s)", h.formatTruckType(lang, bid.TruckType))
+	}
+	if bid.EtaMin > 0 {
+		line += "\n   " + i18n.T(lang, "bot.bid.eta", bid.EtaMin)
+	}
+	if bid.DriverComment != "" {
+		line += fmt.Sprintf("\n   💬 %s", html.EscapeString(bid.DriverComment))
+	}
+	return line
+}
+
+// bidsKeyboard — кнопки выбора для лучших предложений
+func bidsKeyboard(lang i18n.Locale, bids []domain.DriverBid) *models.InlineKeyboardMarkup {
+	var rows [][]models.InlineKeyboardButton
+	for i, bid := range bids {
+		if i >= maxBidsInMessage {
+			break
+		}
+		rows = append(rows, []models.InlineKeyboardButton{{
+			Text:         i18n.T(lang, "bot.bid.btn_choose", i+1, bid.ProposedPrice),
+			CallbackData: "bid_accept:" + bid.ID,
+		}})
+	}
+	return &models.InlineKeyboardMarkup{InlineKeyboard: rows}
+}
+
+// bidsMessage — ранжированный список предложений по заявке
+func (h *Handler) bidsMessage(lang i18n.Locale, orderID string, bids []domain.DriverBid) string {
+	var sb strings.Builder
+	sb.WriteString(i18n.T(lang, "bot.bid.title", orderID, len(bids)) + "\n")
+	for i, bid := range bids {
+		if i >= maxBidsInMessage {
+			sb.WriteString("\n" + i18n.T(lang, "bot.bid.more", len(bids)-i))
+			break
+		}
+		sb.WriteString("\n" + h.formatBidLine(lang, i+1, bid))
+	}
+	return sb.String()
+}
+
+// notifyClientNewBid присылает клиенту актуальный рейтинг предложений с кнопками выбора
+func (h *Handler) notifyClientNewBid(ctx context.Context, b *bot.Bot, order *domain.DeliveryRequest, match *domain.DriverMatch, driver *DriverRegistration) {
+	if order.TelegramID == 0 {
+		return
+	}
+	bids, err := h.driverRepo.GetBidsForRequest(ctx, order.ID)
+	if err != nil || len(bids) == 0 {
+		h.logger.Warn("load bids for client", zap.String("order_id", order.ID), zap.Error(err))
+		return
+	}
+
+	lang := h.userLocale(ctx, order.TelegramID)
+	text := fmt.Sprintf("🆕 <b>%s %s</b>: %d ₸\n\n%s", html.EscapeString(driver.FirstName), html.EscapeString(driver.LastName), match.ProposedPrice, h.bidsMessage(lang, order.ID, bids))
+	if _, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      order.TelegramID,
+		Text:        text,
+		ParseMode:   models.ParseModeHTML,
+		ReplyMarkup: bidsKeyboard(lang, bids),
+	}); err != nil {
+		h.logger.Warn("notify client new bid", zap.Int64("tg_id", order.TelegramID), zap.Error(err))
+	}
+}
+
+// acceptBid — клиент выбрал предложение: заявка назначается водителю, остальные отклоняются
+func (h *Handler) acceptBid(ctx context.Context, b *bot.Bot, clientTelegramID int64, matchID string) (*domain.DriverBid, error) {
+	bid, rejected, err := h.driverRepo.AcceptBid(ctx, matchID, clientTelegramID)
+	if err != nil {
+		return nil, err
+	}
+	if bid == nil {
+		return nil, errBidNotFound
+	}
+
+	h.logger.Info("Bid accepted",
+		zap.String("order_id", bid.DeliveryRequestID),
+		zap.String("match_id", bid.ID),
+		zap.String("driver_id", bid.DriverID),
+		zap.Int("price", bid.ProposedPrice),
+		zap.Int("rejected", len(rejected)))
+
+	order, err := h.getDeliveryOrderById(bid.DeliveryRequestID)
+	if err != nil || order == nil {
+		h.logger.Error("load order after bid accept", zap.String("order_id", bid.DeliveryRequestID), zap.Error(err))
+		return bid, nil
+	}
+	if driver, err := h.CheckDriverExist(bid.DriverTelegramID); err == nil && driver != nil {
+		go h.sendOrderAcceptedNotifications(b, order, driver)
+	}
+
+	for _, tgID := range rejected {
+		if _, err := b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:    tgID,
+			Text:      i18n.T(h.userLocale(ctx, tgID), "bot.bid.rejected", order.ID),
+			ParseMode: models.ParseModeHTML,
+		}); err != nil {
+			h.logger.Debug("notify rejected bidder", zap.Int64("tg_id", tgID), zap.Error(err))
+		}
+	}
+	return bid, nil
+}
+
+// handleDriverBid — POST /api/driver/bid
+func (h *Handler) handleDriverBid(b *bot.Bot) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		var req bidRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.logger.Error("Failed to parse request body", zap.Error(err))
+			h.sendErrorResponse(w, r, "invalid_request", http.StatusBadRequest)
+			return
+		}
+		if req.TelegramID == 0 || req.OrderID == "" {
+			h.sendErrorResponse(w, r, "telegram_id_and_order_id_required", http.StatusBadRequest)
+			return
+		}
+		if err := h.validateBid(&req); err != nil {
+			h.sendError(w, r, err, http.StatusBadRequest)
+			return
+		}
+
+		driver, err := h.CheckDriverExist(req.TelegramID)
+		if err != nil {
+			h.logger.Error("Failed to check driver existence", zap.Error(err))
+			h.sendErrorResponse(w, r, "driver_check_failed", http.StatusInternalServerError)
+			return
+		}
+		if driver == nil {
+			h.sendErrorResponse(w, r, "driver_not_found", http.StatusNotFound)
+			return
+		}
+
+		match, _, err := h.submitBid(r.Context(), b, driver, req)
+		switch {
+		case errors.Is(err, errBidDriverDenied):
+			h.sendErrorResponse(w, r, "driver_not_approved", http.StatusForbidden)
+			return
+		case errors.Is(err, errDriverCooldown):
+			h.sendErrorResponse(w, r, "driver_cooldown", http.StatusForbidden)
+			return
+		case errors.Is(err, errBidOrderClosed):
+			h.sendErrorResponse(w, r, "bid_order_closed", http.StatusConflict)
+			return
+		case err != nil:
+			h.logger.Error("Failed to submit bid", zap.String("order_id", req.OrderID), zap.Error(err))
+			h.sendErrorResponse(w, r, "bid_save_failed", http.StatusInternalServerError)
+			return
+		}
+
+		h.sendSuccessResponse(w, r, "bid_sent", match)
+	}
+}
+
+// handleOrderBids — GET /api/user/bids: предложения по заявке клиента, лучшие первыми
+func (h *Handler) handleOrderBids(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	orderID := strings.TrimSpace(r.URL.Query().Get("order_id"))
+	telegramID, _ := strconv.ParseInt(r.URL.Query().Get("telegram_id"), 10, 64)
+	if orderID == "" || telegramID == 0 {
+		h.sendErrorResponse(w, r, "telegram_id_and_order_id_required", http.StatusBadRequest)
+		return
+	}
+
+	order, err := h.getDeliveryOrderById(orderID)
+	if err != nil {
+		h.logger.Error("Failed to get order", zap.Error(err))
+		h.sendErrorResponse(w, r, "order_load_failed", http.StatusInternalServerError)
+		return
+	}
+	if order == nil || order.TelegramID != telegramID {
+		h.sendErrorResponse(w, r, "order_not_found", http.StatusNotFound)
+		return
+	}
+
+	bids, err := h.driverRepo.GetBidsForRequest(r.Context(), orderID)
+	if err != nil {
+		h.logger.Error("Failed to load bids", zap.String("order_id", orderID), zap.Error(err))
+		h.sendErrorResponse(w, r, "bids_load_failed", http.StatusInternalServerError)
+		return
+	}
+	if bids == nil {
+		bids = []domain.DriverBid{}
+	}
+
+	h.sendSuccessResponse(w, r, "order_bids", map[string]interface{}{
+		"order_id": orderID,
+		"price":    order.Price,
+		"count":    len(bids),
+		"bids":     bids,
+	})
+}
+
+// handleAcceptBid — POST /api/user/accept-bid
+func (h *Handler) handleAcceptBid(b *bot.Bot) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		var reqData struct {
+			TelegramID int64  `json:"telegram_id"`
+			MatchID    string `json:"match_id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+			h.logger.Error("Failed to parse request body", zap.Error(err))
+			h.sendErrorResponse(w, r, "invalid_request", http.StatusBadRequest)
+			return
+		}
+		if reqData.TelegramID == 0 || reqData.MatchID == "" {
+			h.sendErrorResponse(w, r, "telegram_id_and_match_id_required", http.StatusBadRequest)
+			return
+		}
+
+		bid, err := h.acceptBid(r.Context(), b, reqData.TelegramID, reqData.MatchID)
+		switch {
+		case errors.Is(err, errBidNotFound):
+			h.sendErrorResponse(w, r, "bid_not_found", http.StatusConflict)
+			return
+		case err != nil:
+			h.logger.Error("Failed to accept bid", zap.String("match_id", reqData.MatchID), zap.Error(err))
+			h.sendErrorResponse(w, r, "bid_choose_failed", http.StatusInternalServerError)
+			return
+		}
+
+		h.sendSuccessResponse(w, r, "driver_assigned", map[string]interface{}{
+			"order_id":  bid.DeliveryRequestID,
+			"driver_id": bid.DriverID,
+			"price":     bid.ProposedPrice,
+			"status":    domain.DeliveryStatusMatched,
+		})
+	}
+}
+
+// quickBidByCallback — кнопка "+N%" в сообщении с заявкой. Аргумент: "<order_id>:<percent>"
+func (h *Handler) quickBidByCallback(ctx context.Context, b *bot.Bot, cq *models.CallbackQuery, arg string) string {
+	i := strings.LastIndex(arg, ":")
+	if i < 0 {
+		return tr(ctx, "bot.error")
+	}
+	orderID := arg[:i]
+	percent, err := strconv.Atoi(arg[i+1:])
+	if err != nil || percent <= 0 {
+		return tr(ctx, "bot.error")
+	}
+
+	driver, err := h.CheckDriverExist(cq.From.ID)
+	if err != nil || driver == nil {
+		return tr(ctx, "bot.driver_not_found")
+	}
+	order, err := h.getDeliveryOrderById(orderID)
+	if err != nil || order == nil {
+		return tr(ctx, "bot.order_not_found")
+	}
+
+	req := bidRequest{TelegramID: cq.From.ID, OrderID: orderID, Price: raisedPrice(order.Price, percent)}
+	if err := h.validateBid(&req); err != nil {
+		return h.errText(i18n.FromContext(ctx), err)
+	}
+	_, _, err = h.submitBid(ctx, b, driver, req)
+	switch {
+	case errors.Is(err, errBidDriverDenied):
+		return tr(ctx, "bot.bid.driver_denied")
+	case errors.Is(err, errDriverCooldown):
+		return tr(ctx, "bot.bid.cooldown")
+	case errors.Is(err, errBidOrderClosed):
+		return tr(ctx, "bot.bid.order_closed")
+	case err != nil:
+		h.logger.Error("Failed to submit quick bid", zap.String("order_id", orderID), zap.Error(err))
+		return tr(ctx, "bot.error")
+	}
+	return tr(ctx, "bot.bid.sent", req.Price)
+}
+
+// acceptBidByCallback — кнопка "таңдау" у клиента
+func (h *Handler) acceptBidByCallback(ctx context.Context, b *bot.Bot, cq *models.CallbackQuery, matchID string) string {
+	bid, err := h.acceptBid(ctx, b, cq.From.ID, matchID)
+	if errors.Is(err, errBidNotFound) {
+		h.clearCallbackKeyboard(ctx, b, cq)
+		return tr(ctx, "bot.bid.stale")
+	}
+	if err != nil {
+		h.logger.Error("Failed to accept bid", zap.String("match_id", matchID), zap.Error(err))
+		return tr(ctx, "bot.error")
+	}
+	h.clearCallbackKeyboard(ctx, b, cq)
+	return tr(ctx, "bot.bid.chosen", bid.DriverName, bid.ProposedPrice)
+}
+
+// ExpireBids закрывает просроченные предложения водителей
+func (h *Handler) ExpireBids(ctx context.Context) {
+	h.logger.Info("started bid expiry service", zap.Duration("ttl", h.cfg.BidTTL))
+	ticker := time.NewTicker(h.cfg.OrderExpiryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			h.logger.Info("bid expiry service stopped")
+			return
+		case <-ticker.C:
+			n, err := h.driverRepo.ExpireBids(ctx)
+			if err != nil {
+				continue
+			}
+			if n > 0 {
+				h.logger.Info("bids expired", zap.Int64("count", n))
+			}
+		}
+	}
+}
@@ -0,0 +1,471 @@
+// booking-handler.go
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"tezjet/internal/domain"
+	"tezjet/internal/i18n"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+var (
+	errBookingClosed = i18n.E("booking_closed")
+	errTripFull      = i18n.E("trip_full")
+)
+
+// bookTripRequest — бронь места в рейсе из списка водителей. Точки по умолчанию берутся из рейса.
+type bookTripRequest struct {
+	deliveryRequestJSON
+	TripID string `json:"trip_id"`
+}
+
+// handleBookTrip — POST /api/trip/book: клиент просит место в опубликованном рейсе водителя
+func (h *Handler) handleBookTrip(b *bot.Bot) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		var in bookTripRequest
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			h.logger.Error("Failed to parse request body", zap.Error(err))
+			h.sendErrorResponse(w, r, "invalid_request", http.StatusBadRequest)
+			return
+		}
+		in.TripID = strings.TrimSpace(in.TripID)
+		if in.TelegramID == 0 || in.TripID == "" {
+			h.sendErrorResponse(w, r, "telegram_id_and_trip_id_required", http.StatusBadRequest)
+			return
+		}
+
+		ctx := r.Context()
+		trip, capacity, err := h.getActiveDriverTrip(ctx, in.TripID)
+		if err != nil {
+			h.logger.Error("Failed to load trip for booking", zap.String("trip_id", in.TripID), zap.Error(err))
+			h.sendErrorResponse(w, r, "trip_load_failed", http.StatusInternalServerError)
+			return
+		}
+		if trip == nil {
+			h.sendErrorResponse(w, r, "trip_unavailable", http.StatusNotFound)
+			return
+		}
+		if trip.TelegramID == in.TelegramID {
+			h.sendErrorResponse(w, r, "booking_own_trip", http.StatusBadRequest)
+			return
+		}
+
+		// Точки и цена по умолчанию — как в рейсе
+		if strings.TrimSpace(in.FromAddress) == "" {
+			in.FromAddress, in.FromLat, in.FromLon = trip.FromAddress, trip.FromLat, trip.FromLon
+		}
+		if strings.TrimSpace(in.ToAddress) == "" {
+			in.ToAddress, in.ToLat, in.ToLon = trip.ToAddress, trip.ToLat, trip.ToLon
+		}
+		if in.Price == 0 {
+			in.Price = trip.Price
+		}
+		in.TruckType = capacity.TruckType
+		in.Stops = nil
+		in.FavoriteDriverID = ""
+		in.Date, in.Time, in.TimeStart = "", "", ""
+		departure := h.tripDeparture(trip.StartTime)
+		if departure.After(time.Now()) {
+			in.TimeStart = departure.In(h.cfg.Location()).Format("2006-01-02T15:04")
+		}
+
+		req, err := h.deliveryRequestFromJSON(&in.deliveryRequestJSON)
+		if err != nil {
+			h.sendError(w, r, err, http.StatusBadRequest)
+			return
+		}
+		if !h.isValidCoordinates(req.FromLat, req.FromLon) || !h.isValidCoordinates(req.ToLat, req.ToLon) {
+			h.sendErrorResponse(w, r, "invalid_coordinates", http.StatusBadRequest)
+			return
+		}
+		if !capacity.CanCarry("", req.Cargo) {
+			h.sendErrorResponse(w, r, "cargo_exceeds_truck", http.StatusBadRequest)
+			return
+		}
+		if !capacity.FreeSpace(trip.load()).Fits(req.Cargo) {
+			h.sendErrorResponse(w, r, "trip_full", http.StatusConflict)
+			return
+		}
+
+		if req.DistanceKm == 0 || req.EtaMin == 0 {
+			req.DistanceKm, req.EtaMin = h.calculateRoute(req.FromLat, req.FromLon, req.ToLat, req.ToLon)
+			in.DistanceKm, in.ETAMin = req.DistanceKm, req.EtaMin
+		}
+		if _, err := h.checkTariffPrice(ctx, req.FromAddress, req.TruckType, req.DistanceKm, req.EtaMin, req.Price); err != nil {
+			var te *tariffError
+			if errors.As(err, &te) {
+				h.sendError(w, r, te, http.StatusBadRequest)
+				return
+			}
+			h.sendErrorResponse(w, r, "price_check_failed", http.StatusInternalServerError)
+			return
+		}
+
+		payload, err := json.Marshal(in.deliveryRequestJSON)
+		if err != nil {
+			h.sendErrorResponse(w, r, "booking_save_failed", http.StatusInternalServerError)
+			return
+		}
+		// Водитель отвечает до TripBookingTTL, но не позже выезда
+		expiresAt := time.Now().Add(h.cfg.TripBookingTTL)
+		if departure.After(time.Now()) && departure.Before(expiresAt) {
+			expiresAt = departure
+		}
+		booking := &domain.TripBooking{
+			TripID:           trip.ID,
+			DriverID:         trip.DriverID,
+			DriverTelegramID: trip.TelegramID,
+			TelegramID:       in.TelegramID,
+			FromAddress:      req.FromAddress,
+			ToAddress:        req.ToAddress,
+			Price:            req.Price,
+			WeightKg:         req.Cargo.WeightKg,
+			VolumeM3:         req.Cargo.VolumeM3,
+			Status:           domain.BookingRequested,
+			ExpiresAt:        expiresAt.UTC(),
+			Payload:          payload,
+		}
+		booking.ID, err = h.bookingRepo.CreateBooking(ctx, booking)
+		if err != nil {
+			h.sendErrorResponse(w, r, "booking_save_failed", http.StatusInternalServerError)
+			return
+		}
+		if booking.ID == 0 {
+			h.sendErrorResponse(w, r, "booking_limit", http.StatusConflict, domain.MaxOpenBookings)
+			return
+		}
+
+		h.logger.Info("Trip booking requested",
+			zap.Int64("booking_id", booking.ID),
+			zap.String("trip_id", trip.ID),
+			zap.Int64("telegram_id", in.TelegramID),
+			zap.Float64("weight_kg", booking.WeightKg))
+		go h.notifyDriverBooking(b, booking, req)
+
+		booking.CreatedAt = time.Now()
+		h.sendSuccessResponse(w, r, "booking_sent", map[string]interface{}{
+			"booking": booking,
+		})
+	}
+}
+
+// handleTripBookings — GET /api/trip/bookings?telegram_id= : брони клиента
+func (h *Handler) handleTripBookings(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	telegramID, _ := strconv.ParseInt(r.URL.Query().Get("telegram_id"), 10, 64)
+	if telegramID == 0 {
+		h.sendErrorResponse(w, r, "telegram_id_required", http.StatusBadRequest)
+		return
+	}
+	bookings, err := h.bookingRepo.ListClientBookings(r.Context(), telegramID)
+	if err != nil {
+		h.sendErrorResponse(w, r, "bookings_load_failed", http.StatusInternalServerError)
+		return
+	}
+	h.sendSuccessResponse(w, r, "bookings_loaded", map[string]interface{}{
+		"bookings": bookings,
+	})
+}
+
+// handleCancelTripBooking — POST /api/trip/bookings/cancel {telegram_id, id}
+func (h *Handler) handleCancelTripBooking(b *bot.Bot) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		var reqData struct {
+			TelegramID int64 `json:"telegram_id"`
+			ID         int64 `json:"id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+			h.logger.Error("Failed to parse request body", zap.Error(err))
+			h.sendErrorResponse(w, r, "invalid_request", http.StatusBadRequest)
+			return
+		}
+		if reqData.TelegramID == 0 || reqData.ID == 0 {
+			h.sendErrorResponse(w, r, "telegram_id_and_booking_id_required", http.StatusBadRequest)
+			return
+		}
+
+		ctx := r.Context()
+		ok, err := h.bookingRepo.CancelBooking(ctx, reqData.ID, reqData.TelegramID)
+		if err != nil {
+			h.sendErrorResponse(w, r, "booking_cancel_failed", http.StatusInternalServerError)
+			return
+		}
+		if !ok {
+			h.sendErrorResponse(w, r, "booking_closed", http.StatusNotFound)
+			return
+		}
+
+		if booking, err := h.bookingRepo.GetBooking(ctx, reqData.ID); err == nil && booking != nil {
+			h.notifyBookingUser(ctx, b, booking.DriverTelegramID, "bot.booking.client_cancelled",
+				booking.ID, html.EscapeString(booking.FromAddress), html.EscapeString(booking.ToAddress))
+		}
+		h.sendSuccessResponse(w, r, "booking_cancelled", map[string]interface{}{
+			"id": reqData.ID,
+		})
+	}
+}
+
+// notifyDriverBooking — карточка брони водителю с кнопками принять/отклонить
+func (h *Handler) notifyDriverBooking(b *bot.Bot, booking *domain.TripBooking, req *domain.DeliveryRequest) {
+	ctx := context.Background()
+	lang := h.userLocale(ctx, booking.DriverTelegramID)
+	text := i18n.T(lang, "bot.booking.request",
+		booking.ID,
+		html.EscapeString(booking.FromAddress),
+		html.EscapeString(booking.ToAddress),
+		booking.Price,
+	)
+	text += formatCargoText(lang, req.Cargo)
+	if req.Comment != "" {
+		text += "\n" + i18n.T(lang, "bot.booking.comment", html.EscapeString(req.Comment))
+	}
+	text += "\n\n" + i18n.T(lang, "bot.booking.reply_by",
+		booking.ExpiresAt.In(h.cfg.Location()).Format("02.01 15:04"))
+
+	id := strconv.FormatInt(booking.ID, 10)
+	if _, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    booking.DriverTelegramID,
+		Text:      text,
+		ParseMode: models.ParseModeHTML,
+		ReplyMarkup: &models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{{
+			{Text: i18n.T(lang, "bot.booking.btn_accept"), CallbackData: "tb:" + id + ":accept"},
+			{Text: i18n.T(lang, "bot.booking.btn_decline"), CallbackData: "tb:" + id + ":decline"},
+		}}},
+	}); err != nil {
+		h.logger.Warn("notify driver booking", zap.Int64("booking_id", booking.ID), zap.Error(err))
+	}
+}
+
+// notifyBookingUser — сообщение по брони на языке получателя
+func (h *Handler) notifyBookingUser(ctx context.Context, b *bot.Bot, chatID int64, key string, args ...interface{}) {
+	if _, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    chatID,
+		Text:      i18n.T(h.userLocale(ctx, chatID), key, args...),
+		ParseMode: models.ParseModeHTML,
+	}); err != nil {
+		h.logger.Debug("notify trip booking", zap.Int64("tg_id", chatID), zap.Error(err))
+	}
+}
+
+// bookingByCallback — кнопки водителя "tb:<id>:accept" и "tb:<id>:decline"
+func (h *Handler) bookingByCallback(ctx context.Context, b *bot.Bot, cq *models.CallbackQuery, arg string) string {
+	idStr, action, _ := strings.Cut(arg, ":")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		return tr(ctx, "bot.error")
+	}
+	booking, err := h.bookingRepo.GetBooking(ctx, id)
+	if err != nil {
+		return tr(ctx, "bot.error")
+	}
+	if booking == nil || booking.DriverTelegramID != cq.From.ID {
+		return tr(ctx, "bot.booking.not_found")
+	}
+
+	switch action {
+	case "decline":
+		ok, err := h.bookingRepo.DeclineBooking(ctx, id, cq.From.ID)
+		if err != nil {
+			return tr(ctx, "bot.error")
+		}
+		h.clearCallbackKeyboard(ctx, b, cq)
+		if !ok {
+			return tr(ctx, "bot.booking.stale")
+		}
+		h.notifyBookingUser(ctx, b, booking.TelegramID, "bot.booking.driver_declined",
+			booking.ID, html.EscapeString(booking.FromAddress), html.EscapeString(booking.ToAddress))
+		return tr(ctx, "bot.booking.declined")
+	case "accept":
+		order, err := h.acceptTripBooking(ctx, b, booking, cq.From.ID)
+		switch {
+		case errors.Is(err, errBookingClosed):
+			h.clearCallbackKeyboard(ctx, b, cq)
+			return tr(ctx, "bot.booking.stale")
+		case errors.Is(err, errTripFull):
+			return tr(ctx, "bot.booking.trip_full")
+		case errors.Is(err, errBidDriverDenied):
+			return tr(ctx, "bot.bid.driver_denied")
+		case err != nil:
+			h.logger.Error("Failed to accept trip booking", zap.Int64("booking_id", id), zap.Error(err))
+			return tr(ctx, "bot.error")
+		}
+		h.clearCallbackKeyboard(ctx, b, cq)
+		return tr(ctx, "bot.booking.accepted", order.ID)
+	}
+	return tr(ctx, "bot.error")
+}
+
+// acceptTripBooking создаёт заявку из брони, назначает её водителю и занимает место в рейсе — всё в одной транзакции
+func (h *Handler) acceptTripBooking(ctx context.Context, b *bot.Bot, booking *domain.TripBooking, driverTelegramID int64) (*domain.DeliveryRequest, error) {
+	if !booking.IsOpen(time.Now()) {
+		return nil, errBookingClosed
+	}
+	driver, err := h.CheckDriverExist(driverTelegramID)
+	if err != nil {
+		return nil, err
+	}
+	if driver == nil || driver.ID != booking.DriverID || driver.Status != "approved" {
+		return nil, errBidDriverDenied
+	}
+
+	in, err := decodeTemplatePayload(booking.Payload)
+	if err != nil {
+		return nil, err
+	}
+	// Рейс мог уже выехать — тогда забираем "сейчас"
+	if t, err := h.parsePickupTime(in.TimeStart); err != nil || !t.After(time.Now()) {
+		in.TimeStart = ""
+	}
+	req, err := h.deliveryRequestFromJSON(in)
+	if err != nil {
+		return nil, err
+	}
+	h.prepareClientOrder(ctx, req, "", "", "")
+	req.ID = uuid.New().String()
+	req.TripID = booking.TripID
+
+	trip, capacity, err := h.getActiveDriverTrip(ctx, booking.TripID)
+	if err != nil {
+		return nil, err
+	}
+	if trip == nil {
+		return nil, errBookingClosed
+	}
+
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	now := time.Now()
+	result, err := tx.ExecContext(ctx, `
+		UPDATE trip_bookings SET status = 'accepted', request_id = ?, decided_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND status = 'requested' AND expires_at > ?`, req.ID, booking.ID, sqliteTime(now))
+	if err != nil {
+		return nil, fmt.Errorf("failed to accept booking: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return nil, errBookingClosed
+	}
+
+	full, err := occupyTripSpace(ctx, tx, booking.TripID, capacity, req.Cargo)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.insertDeliveryRequest(tx, req); err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE delivery_requests
+		SET status = 'matched', driver_id = ?, matched_driver_id = ?, accepted_at = CURRENT_TIMESTAMP
+		WHERE id = ?`, driver.ID, driver.ID, req.ID); err != nil {
+		return nil, fmt.Errorf("failed to match order: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit booking: %w", err)
+	}
+
+	for i := range req.Stops {
+		req.Stops[i].RequestID = req.ID
+	}
+	req.Status = domain.DeliveryStatusMatched
+	req.MatchedDriverID = &driver.ID
+	req.CreatedAt = now
+
+	h.logger.Info("Trip booking accepted",
+		zap.Int64("booking_id", booking.ID),
+		zap.String("trip_id", booking.TripID),
+		zap.String("order_id", req.ID),
+		zap.String("driver_id", driver.ID),
+		zap.Bool("trip_full", full))
+	go h.sendOrderAcceptedNotifications(b, req, driver)
+	go func() {
+		bg := context.Background()
+		if full {
+			h.closeTripBookings(bg, b, booking.TripID, domain.BookingDeclined, "bot.booking.reason_full")
+		}
+		h.sendTripPlan(bg, b, booking.TripID)
+	}()
+	return req, nil
+}
+
+// closeTripBookings закрывает брони, которые ждали рейс, и сообщает клиентам причину.
+// reasonKey — ключ каталога bot.booking.reason_*
+func (h *Handler) closeTripBookings(ctx context.Context, b *bot.Bot, tripID, status, reasonKey string) {
+	closed, err := h.bookingRepo.CloseTripBookings(ctx, tripID, status)
+	if err != nil {
+		return
+	}
+	for i := range closed {
+		booking := &closed[i]
+		h.notifyBookingUser(ctx, b, booking.TelegramID, "bot.booking.closed",
+			i18n.T(h.userLocale(ctx, booking.TelegramID), reasonKey),
+			booking.ID, html.EscapeString(booking.FromAddress), html.EscapeString(booking.ToAddress))
+	}
+}
+
+// ExpireTripBookings закрывает брони, на которые водитель не ответил, и сообщает клиенту
+func (h *Handler) ExpireTripBookings(ctx context.Context, b *bot.Bot) {
+	h.logger.Info("started trip booking expiry service", zap.Duration("ttl", h.cfg.TripBookingTTL))
+	ticker := time.NewTicker(h.cfg.OrderExpiryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			h.logger.Info("trip booking expiry service stopped")
+			return
+		case <-ticker.C:
+			bookings, err := h.bookingRepo.GetExpiredBookings(ctx)
+			if err != nil {
+				continue
+			}
+			for i := range bookings {
+				booking := &bookings[i]
+				ok, err := h.bookingRepo.ExpireBooking(ctx, booking.ID)
+				if err != nil || !ok {
+					continue
+				}
+				h.notifyBookingUser(ctx, b, booking.TelegramID, "bot.booking.expired",
+					booking.ID, html.EscapeString(booking.FromAddress), html.EscapeString(booking.ToAddress))
+			}
+			if len(bookings) > 0 {
+				h.logger.Info("trip bookings expired", zap.Int("count", len(bookings)))
+			}
+		}
+	}
+}
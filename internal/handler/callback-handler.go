@@ -0,0 +1,129 @@
+// callback-handler.go
+package handler
+
+import (
+	"context"
+	"strings"
+
+	"tezjet/internal/i18n"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"go.uber.org/zap"
+)
+
+// handleCallbackQuery разбирает inline-кнопки бота. CallbackData имеет вид "action:arg".
+func (h *Handler) handleCallbackQuery(ctx context.Context, b *bot.Bot, cq *models.CallbackQuery) {
+	action, arg, _ := strings.Cut(cq.Data, ":")
+	// ответы на кнопки — на языке нажавшего; сообщения другим людям берут их язык сами
+	ctx = i18n.WithLocale(ctx, h.senderLocale(ctx, &cq.From))
+
+	h.logger.Info("Callback query received",
+		zap.Int64("user_id", cq.From.ID),
+		zap.String("action", action))
+
+	var answer string
+	switch action {
+	case "repost":
+		answer = h.repostExpiredOrder(ctx, b, cq, arg)
+	case "cancel_order":
+		answer = h.cancelExpiredOrder(ctx, b, cq, arg)
+	case "stop_done":
+		answer = h.markStopDoneByCallback(ctx, b, cq, arg)
+	case "bid":
+		answer = h.quickBidByCallback(ctx, b, cq, arg)
+	case "bid_accept":
+		answer = h.acceptBidByCallback(ctx, b, cq, arg)
+	case "rate":
+		answer = h.rateByCallback(ctx, b, cq, arg)
+	case "dcancel":
+		answer = h.driverCancelByCallback(ctx, b, cq, arg)
+	case "rec":
+		answer = h.recurringByCallback(ctx, b, cq, arg)
+	case "dset":
+		answer = h.driverSettingsByCallback(ctx, b, cq, arg)
+	case "ss":
+		answer = h.savedSearchByCallback(ctx, b, cq, arg)
+	case "tb":
+		answer = h.bookingByCallback(ctx, b, cq, arg)
+	case "trip":
+		answer = h.tripByCallback(ctx, b, cq, arg)
+	case "my_trips":
+		h.sendDriverOpenTrips(ctx, b, cq.From.ID)
+	}
+
+	if _, err := b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: cq.ID,
+		Text:            answer,
+	}); err != nil {
+		h.logger.Warn("Failed to answer callback query", zap.Error(err))
+	}
+}
+
+// repostExpiredOrder публикует истёкшую заявку заново с повышенной ценой
+func (h *Handler) repostExpiredOrder(ctx context.Context, b *bot.Bot, cq *models.CallbackQuery, orderID string) string {
+	order, err := h.getDeliveryOrderById(orderID)
+	if err != nil {
+		h.logger.Error("Failed to get order for repost", zap.String("order_id", orderID), zap.Error(err))
+		return tr(ctx, "bot.error")
+	}
+	if order == nil || order.TelegramID != cq.From.ID {
+		return tr(ctx, "bot.order_not_found")
+	}
+	if !order.CanBeReposted() {
+		h.clearCallbackKeyboard(ctx, b, cq)
+		return tr(ctx, "bot.repost.not_allowed")
+	}
+
+	newPrice := raisedPrice(order.Price, h.cfg.RepostRaisePercent)
+	ok, err := h.userRepo.RepostDeliveryRequest(ctx, order.ID, cq.From.ID, newPrice)
+	if err != nil {
+		return tr(ctx, "bot.error")
+	}
+	if !ok {
+		h.clearCallbackKeyboard(ctx, b, cq)
+		return tr(ctx, "bot.repost.not_allowed")
+	}
+
+	h.clearCallbackKeyboard(ctx, b, cq)
+	h.logger.Info("Expired order reposted",
+		zap.String("order_id", order.ID),
+		zap.Int("old_price", order.Price),
+		zap.Int("new_price", newPrice))
+
+	order.Price = newPrice
+	order.Status = "pending"
+	go h.SendToDriver(ctx, b, order)
+
+	return tr(ctx, "bot.repost.done", newPrice)
+}
+
+// cancelExpiredOrder отменяет истёкшую заявку по кнопке из уведомления
+func (h *Handler) cancelExpiredOrder(ctx context.Context, b *bot.Bot, cq *models.CallbackQuery, orderID string) string {
+	ok, err := h.userRepo.CancelExpiredDeliveryRequest(ctx, orderID, cq.From.ID)
+	if err != nil {
+		return tr(ctx, "bot.error")
+	}
+	h.clearCallbackKeyboard(ctx, b, cq)
+	if !ok {
+		return tr(ctx, "bot.order_not_found")
+	}
+
+	h.logger.Info("Expired order cancelled by client", zap.String("order_id", orderID))
+	return tr(ctx, "bot.repost.cancelled")
+}
+
+// clearCallbackKeyboard убирает кнопки у сообщения, чтобы действие не повторяли
+func (h *Handler) clearCallbackKeyboard(ctx context.Context, b *bot.Bot, cq *models.CallbackQuery) {
+	msg := cq.Message.Message
+	if msg == nil {
+		return
+	}
+	if _, err := b.EditMessageReplyMarkup(ctx, &bot.EditMessageReplyMarkupParams{
+		ChatID:      msg.Chat.ID,
+		MessageID:   msg.ID,
+		ReplyMarkup: &models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{}},
+	}); err != nil {
+		h.logger.Debug("Failed to clear callback keyboard", zap.Error(err))
+	}
+}
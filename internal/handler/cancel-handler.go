@@ -0,0 +1,312 @@
+// cancel-handler.go
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"tezjet/internal/domain"
+	"tezjet/internal/i18n"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"go.uber.org/zap"
+)
+
+const (
+	maxCancelCommentLen     = 500
+	adminCancellationsLimit = 20 // последние отказы в карточке водителя
+	cancelPenaltyKm         = 2  // каждый отказ ≈ +2 км к расстоянию в ранжировании
+	maxCancelPenalty        = 10 // учитываем не больше 10 отказов
+)
+
+// Ключ подсказки с ForceReply для комментария к отказу "Басқа": по нему DefaultHandler узнаёт ответ
+const cancelPromptComment = "bot.cancel.prompt_comment"
+
+var (
+	errCancelNotAllowed = i18n.E("cancel_not_allowed")
+	errDriverCooldown   = i18n.E("driver_cooldown")
+)
+
+// cancelReasonTitle — подпись причины отказа для кнопок и уведомлений
+func cancelReasonTitle(lang i18n.Locale, reason string) string {
+	return i18n.T(lang, "bot.cancel.reason_"+reason)
+}
+
+func (h *Handler) cancelPolicy() domain.CancelPolicy {
+	return domain.CancelPolicy{
+		Window:    h.cfg.DriverCancelWindow,
+		Threshold: h.cfg.DriverCancelThreshold,
+		Cooldown:  h.cfg.DriverCancelCooldown,
+	}
+}
+
+// cancelPenalty — надбавка к "расстоянию" водителя за отказы
+func cancelPenalty(cancelCount int) float64 {
+	if cancelCount > maxCancelPenalty {
+		cancelCount = maxCancelPenalty
+	}
+	return float64(cancelCount * cancelPenaltyKm)
+}
+
+// checkDispatchCooldown возвращает errDriverCooldown, если водитель на паузе после отказов
+func (h *Handler) checkDispatchCooldown(ctx context.Context, driverID string) error {
+	until, err := h.driverRepo.GetDispatchCooldown(ctx, driverID)
+	if err != nil {
+		return err
+	}
+	if until != nil {
+		return errDriverCooldown
+	}
+	return nil
+}
+
+// driverCancelOrder возвращает назначенную заявку в поиск, уведомляет клиента и рассылает её заново
+func (h *Handler) driverCancelOrder(ctx context.Context, b *bot.Bot, driver *DriverRegistration, orderID, reason, comment string) (*time.Time, error) {
+	ok, cooldownUntil, err := h.orderRepo.CancelByDriver(ctx, orderID, driver.ID, reason, comment, h.cancelPolicy())
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errCancelNotAllowed
+	}
+
+	h.logger.Info("Order cancelled by driver",
+		zap.String("order_id", orderID),
+		zap.String("driver_id", driver.ID),
+		zap.String("reason", reason),
+		zap.Bool("cooldown", cooldownUntil != nil))
+
+	// Заявка возвращается в общий поиск и больше не занимает место в рейсе водителя
+	if err := h.releaseTripSpace(ctx, orderID); err != nil {
+		h.logger.Error("Failed to release trip space", zap.String("order_id", orderID), zap.Error(err))
+	}
+
+	order, err := h.getDeliveryOrderById(orderID)
+	if err != nil || order == nil {
+		h.logger.Error("Failed to reload cancelled order", zap.String("order_id", orderID), zap.Error(err))
+		return cooldownUntil, nil
+	}
+
+	go func() {
+		bg := context.Background()
+		h.notifyClientDriverCancelled(bg, b, order, reason)
+		h.redispatchOrder(bg, b, order, driver.TelegramID)
+	}()
+
+	return cooldownUntil, nil
+}
+
+// redispatchOrder рассылает вернувшуюся в поиск заявку всем, кроме отказавшегося водителя
+func (h *Handler) redispatchOrder(ctx context.Context, b *bot.Bot, order *domain.DeliveryRequest, excludeTelegramID int64) {
+	nearDrivers, err := h.findNearDrivers(ctx, order)
+	if err != nil {
+		h.logger.Error("NO DRIVERS", zap.Error(err))
+		return
+	}
+	filtered := nearDrivers[:0]
+	for _, d := range nearDrivers {
+		if d.TelegramID != excludeTelegramID {
+			filtered = append(filtered, d)
+		}
+	}
+	h.broadcastOrder(ctx, b, order, filtered)
+}
+
+func (h *Handler) notifyClientDriverCancelled(ctx context.Context, b *bot.Bot, order *domain.DeliveryRequest, reason string) {
+	if order.TelegramID == 0 {
+		return
+	}
+	lang := h.userLocale(ctx, order.TelegramID)
+	text := i18n.T(lang, "bot.cancel.client_notice", order.ID, cancelReasonTitle(lang, reason))
+
+	if _, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    order.TelegramID,
+		Text:      text,
+		ParseMode: models.ParseModeHTML,
+	}); err != nil {
+		h.logger.Warn("notify client driver cancelled", zap.Int64("tg_id", order.TelegramID), zap.Error(err))
+	}
+}
+
+func cooldownMessage(lang i18n.Locale, until *time.Time) string {
+	return i18n.T(lang, "bot.cancel.cooldown", until.Format("02.01 15:04"))
+}
+
+// handleDriverCancel — отказ водителя от назначенной заявки из Mini App
+func (h *Handler) handleDriverCancel(b *bot.Bot) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		var reqData struct {
+			TelegramID int64  `json:"telegram_id"`
+			OrderID    string `json:"order_id"`
+			Reason     string `json:"reason"`
+			Comment    string `json:"comment"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+			h.logger.Error("Failed to parse request body", zap.Error(err))
+			h.sendErrorResponse(w, r, "invalid_request", http.StatusBadRequest)
+			return
+		}
+		if reqData.TelegramID == 0 || reqData.OrderID == "" {
+			h.sendErrorResponse(w, r, "telegram_id_and_order_id_required", http.StatusBadRequest)
+			return
+		}
+		if !domain.IsValidCancelReason(reqData.Reason) {
+			h.sendErrorResponse(w, r, "cancel_reason_required", http.StatusBadRequest)
+			return
+		}
+		reqData.Comment = strings.TrimSpace(reqData.Comment)
+		if reqData.Reason == domain.CancelReasonOther && reqData.Comment == "" {
+			h.sendErrorResponse(w, r, "cancel_comment_required", http.StatusBadRequest)
+			return
+		}
+		if utf8.RuneCountInString(reqData.Comment) > maxCancelCommentLen {
+			h.sendErrorResponse(w, r, "comment_too_long", http.StatusBadRequest, maxCancelCommentLen)
+			return
+		}
+
+		driver, err := h.CheckDriverExist(reqData.TelegramID)
+		if err != nil {
+			h.logger.Error("Failed to check driver existence", zap.Error(err))
+			h.sendErrorResponse(w, r, "driver_check_failed", http.StatusInternalServerError)
+			return
+		}
+		if driver == nil {
+			h.sendErrorResponse(w, r, "driver_not_found", http.StatusNotFound)
+			return
+		}
+
+		cooldownUntil, err := h.driverCancelOrder(r.Context(), b, driver, reqData.OrderID, reqData.Reason, reqData.Comment)
+		switch {
+		case errors.Is(err, errCancelNotAllowed):
+			h.sendErrorResponse(w, r, "cancel_not_allowed", http.StatusConflict)
+			return
+		case err != nil:
+			h.logger.Error("Failed to cancel order by driver", zap.String("order_id", reqData.OrderID), zap.Error(err))
+			h.sendErrorResponse(w, r, "order_decline_failed", http.StatusInternalServerError)
+			return
+		}
+
+		resp := map[string]interface{}{
+			"order_id": reqData.OrderID,
+			"status":   domain.DeliveryStatusPending,
+		}
+		if cooldownUntil != nil {
+			resp["cooldown_until"] = cooldownUntil.Format(time.RFC3339)
+		}
+		h.sendSuccessResponse(w, r, "order_declined", resp)
+	}
+}
+
+func cancelReasonsKeyboard(lang i18n.Locale, orderID string) *models.InlineKeyboardMarkup {
+	var rows [][]models.InlineKeyboardButton
+	for _, reason := range domain.DriverCancelReasons() {
+		rows = append(rows, []models.InlineKeyboardButton{{
+			Text:         cancelReasonTitle(lang, reason),
+			CallbackData: fmt.Sprintf("dcancel:%s:%s", orderID, reason),
+		}})
+	}
+	return &models.InlineKeyboardMarkup{InlineKeyboard: rows}
+}
+
+// driverCancelByCallback — кнопка "Бас тарту" у водителя. Аргумент: "<order_id>" —
+// показать причины, "<order_id>:<reason>" — отказаться. Для "other" сначала просим
+// комментарий, как и API.
+func (h *Handler) driverCancelByCallback(ctx context.Context, b *bot.Bot, cq *models.CallbackQuery, arg string) string {
+	orderID, reason, withReason := strings.Cut(arg, ":")
+	if !withReason {
+		if _, err := b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:      cq.From.ID,
+			Text:        tr(ctx, "bot.cancel.choose_reason", orderID),
+			ReplyMarkup: cancelReasonsKeyboard(i18n.FromContext(ctx), orderID),
+		}); err != nil {
+			h.logger.Warn("Failed to send cancel reasons", zap.Int64("tg_id", cq.From.ID), zap.Error(err))
+			return tr(ctx, "bot.error")
+		}
+		return ""
+	}
+	if !domain.IsValidCancelReason(reason) {
+		return tr(ctx, "bot.error")
+	}
+
+	driver, err := h.CheckDriverExist(cq.From.ID)
+	if err != nil || driver == nil {
+		return tr(ctx, "bot.driver_not_found")
+	}
+
+	h.clearCallbackKeyboard(ctx, b, cq)
+	if reason == domain.CancelReasonOther {
+		return h.sendSettingsPrompt(ctx, b, cq.From.ID,
+			tr(ctx, cancelPromptComment)+" #"+orderID+"\n"+tr(ctx, "bot.cancel.hint_comment"), "")
+	}
+	return h.driverCancelFromBot(ctx, b, cq.From.ID, driver, orderID, reason, "")
+}
+
+// saveCancelCommentReply принимает комментарий к отказу "Басқа"; false — это не такой ответ
+func (h *Handler) saveCancelCommentReply(ctx context.Context, b *bot.Bot, msg *models.Message) bool {
+	reply := msg.ReplyToMessage
+	if msg.From == nil || reply == nil || reply.From == nil || !reply.From.IsBot {
+		return false
+	}
+	_, rest, ok := promptKey(reply.Text, cancelPromptComment)
+	if !ok || !strings.HasPrefix(rest, " #") {
+		return false
+	}
+	firstLine, _, _ := strings.Cut(strings.TrimPrefix(rest, " #"), "\n")
+	orderID := strings.TrimSpace(firstLine)
+
+	lang := i18n.FromContext(ctx)
+	comment := strings.TrimSpace(msg.Text)
+	switch {
+	case comment == "":
+		h.replySettingsError(ctx, b, msg.Chat.ID, "❌ "+i18n.E("cancel_comment_required").Localize(lang))
+		return true
+	case utf8.RuneCountInString(comment) > maxCancelCommentLen:
+		h.replySettingsError(ctx, b, msg.Chat.ID, "❌ "+i18n.E("comment_too_long", maxCancelCommentLen).Localize(lang))
+		return true
+	}
+
+	driver, err := h.CheckDriverExist(msg.From.ID)
+	if err != nil || driver == nil {
+		h.replySettingsError(ctx, b, msg.Chat.ID, i18n.T(lang, "bot.driver_not_found"))
+		return true
+	}
+	h.replySettingsError(ctx, b, msg.Chat.ID,
+		h.driverCancelFromBot(ctx, b, msg.Chat.ID, driver, orderID, domain.CancelReasonOther, comment))
+	return true
+}
+
+// driverCancelFromBot отменяет заявку по действию в боте и возвращает текст ответа водителю
+func (h *Handler) driverCancelFromBot(ctx context.Context, b *bot.Bot, chatID int64, driver *DriverRegistration, orderID, reason, comment string) string {
+	cooldownUntil, err := h.driverCancelOrder(ctx, b, driver, orderID, reason, comment)
+	switch {
+	case errors.Is(err, errCancelNotAllowed):
+		return tr(ctx, "bot.cancel.not_allowed")
+	case err != nil:
+		h.logger.Error("Failed to cancel order by driver", zap.String("order_id", orderID), zap.Error(err))
+		return tr(ctx, "bot.error")
+	}
+
+	if cooldownUntil != nil {
+		if _, err := b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "⏸ " + cooldownMessage(i18n.FromContext(ctx), cooldownUntil),
+		}); err != nil {
+			h.logger.Warn("Failed to send cooldown notice", zap.Int64("tg_id", chatID), zap.Error(err))
+		}
+	}
+	return tr(ctx, "bot.cancel.done")
+}
@@ -0,0 +1,193 @@
+// cargo-handler.go
+package handler
+
+import (
+	"strconv"
+	"strings"
+
+	"tezjet/internal/domain"
+	"tezjet/internal/i18n"
+)
+
+// Колонки груза в delivery_requests, в порядке cargoScanDest/cargoArgs
+const cargoColumns = `cargo_weight_kg, cargo_volume_m3, cargo_length_cm, cargo_width_cm, cargo_height_cm,
+	cargo_items, needs_loaders, fragile, floor, has_elevator`
+
+// Колонки вместимости машины в drivers, в порядке capacityScanDest
+const capacityColumns = `max_weight_kg, max_volume_m3, body_length_cm, body_width_cm, body_height_cm`
+
+// Разумные пределы, чтобы отсечь опечатки вроде 15000 м³
+const (
+	maxCargoWeightKg = 40000
+	maxCargoVolumeM3 = 120
+	maxCargoSideCm   = 2000
+	maxCargoFloor    = 50
+)
+
+func cargoScanDest(c *domain.CargoSpec) []interface{} {
+	return []interface{}{
+		&c.WeightKg, &c.VolumeM3, &c.LengthCm, &c.WidthCm, &c.HeightCm,
+		&c.Items, &c.NeedsLoaders, &c.Fragile, &c.Floor, &c.HasElevator,
+	}
+}
+
+func cargoArgs(c domain.CargoSpec) []interface{} {
+	return []interface{}{
+		c.WeightKg, c.VolumeM3, c.LengthCm, c.WidthCm, c.HeightCm,
+		c.Items, c.NeedsLoaders, c.Fragile, c.Floor, c.HasElevator,
+	}
+}
+
+func capacityScanDest(v *domain.VehicleCapacity) []interface{} {
+	return []interface{}{&v.MaxWeightKg, &v.MaxVolumeM3, &v.BodyLengthCm, &v.BodyWidthCm, &v.BodyHeightCm}
+}
+
+// validateCargo checks that cargo numbers are non-negative and realistic
+func validateCargo(c domain.CargoSpec) error {
+	if c.WeightKg < 0 || c.WeightKg > maxCargoWeightKg {
+		return i18n.E("cargo_weight_range", maxCargoWeightKg)
+	}
+	if c.VolumeM3 < 0 || c.VolumeM3 > maxCargoVolumeM3 {
+		return i18n.E("cargo_volume_range", maxCargoVolumeM3)
+	}
+	for _, side := range []int{c.LengthCm, c.WidthCm, c.HeightCm} {
+		if side < 0 || side > maxCargoSideCm {
+			return i18n.E("cargo_size_range", maxCargoSideCm)
+		}
+	}
+	if c.Items < 0 {
+		return i18n.E("cargo_items_negative")
+	}
+	if c.Floor < 0 || c.Floor > maxCargoFloor {
+		return i18n.E("cargo_floor_range", maxCargoFloor)
+	}
+	return nil
+}
+
+// validateCapacity — то же для машины водителя
+func validateCapacity(v domain.VehicleCapacity) error {
+	if v.MaxWeightKg < 0 || v.MaxWeightKg > maxCargoWeightKg {
+		return i18n.E("truck_capacity_range", maxCargoWeightKg)
+	}
+	if v.MaxVolumeM3 < 0 || v.MaxVolumeM3 > maxCargoVolumeM3 {
+		return i18n.E("truck_volume_range", maxCargoVolumeM3)
+	}
+	for _, side := range []int{v.BodyLengthCm, v.BodyWidthCm, v.BodyHeightCm} {
+		if side < 0 || side > maxCargoSideCm {
+			return i18n.E("truck_size_range", maxCargoSideCm)
+		}
+	}
+	return nil
+}
+
+// parseCargoForm читает груз из form/multipart полей cargo_*
+func parseCargoForm(getValue func(string) string) (domain.CargoSpec, error) {
+	var c domain.CargoSpec
+	var err error
+	float := func(key string, dst *float64) {
+		if v := strings.ReplaceAll(getValue(key), ",", "."); v != "" && err == nil {
+			if *dst, err = strconv.ParseFloat(v, 64); err != nil {
+				err = i18n.Wrap(err, "invalid_value", key)
+			}
+		}
+	}
+	integer := func(key string, dst *int) {
+		if v := getValue(key); v != "" && err == nil {
+			if *dst, err = strconv.Atoi(v); err != nil {
+				err = i18n.Wrap(err, "invalid_value", key)
+			}
+		}
+	}
+	boolean := func(key string) bool {
+		v := strings.ToLower(getValue(key))
+		return v == "1" || v == "true" || v == "on" || v == "yes"
+	}
+
+	float("cargo_weight_kg", &c.WeightKg)
+	float("cargo_volume_m3", &c.VolumeM3)
+	integer("cargo_length_cm", &c.LengthCm)
+	integer("cargo_width_cm", &c.WidthCm)
+	integer("cargo_height_cm", &c.HeightCm)
+	integer("cargo_items", &c.Items)
+	integer("floor", &c.Floor)
+	c.NeedsLoaders = boolean("needs_loaders")
+	c.Fragile = boolean("fragile")
+	c.HasElevator = boolean("has_elevator")
+	if err != nil {
+		return domain.CargoSpec{}, err
+	}
+	return c, validateCargo(c)
+}
+
+// parseCapacityForm — поля вместимости из формы регистрации/обновления водителя
+func parseCapacityForm(getValue func(string) string, fallback domain.VehicleCapacity) (domain.VehicleCapacity, error) {
+	v := fallback
+	var err error
+	float := func(key string, dst *float64) {
+		if raw := strings.ReplaceAll(getValue(key), ",", "."); raw != "" && err == nil {
+			if *dst, err = strconv.ParseFloat(raw, 64); err != nil {
+				err = i18n.Wrap(err, "invalid_value", key)
+			}
+		}
+	}
+	integer := func(key string, dst *int) {
+		if raw := getValue(key); raw != "" && err == nil {
+			if *dst, err = strconv.Atoi(raw); err != nil {
+				err = i18n.Wrap(err, "invalid_value", key)
+			}
+		}
+	}
+
+	float("maxWeightKg", &v.MaxWeightKg)
+	float("maxVolumeM3", &v.MaxVolumeM3)
+	integer("bodyLengthCm", &v.BodyLengthCm)
+	integer("bodyWidthCm", &v.BodyWidthCm)
+	integer("bodyHeightCm", &v.BodyHeightCm)
+	if err != nil {
+		return fallback, err
+	}
+	return v, validateCapacity(v)
+}
+
+// formatCargoText — строка про груз для сообщений бота, пусто если ничего не указано
+func formatCargoText(lang i18n.Locale, c domain.CargoSpec) string {
+	if c.IsEmpty() {
+		return ""
+	}
+	var parts []string
+	if c.WeightKg > 0 {
+		parts = append(parts, i18n.T(lang, "bot.cargo.weight", c.WeightKg))
+	}
+	if c.VolumeM3 > 0 {
+		parts = append(parts, i18n.T(lang, "bot.cargo.volume", c.VolumeM3))
+	}
+	if c.HasDimensions() {
+		parts = append(parts, i18n.T(lang, "bot.cargo.dimensions", c.LengthCm, c.WidthCm, c.HeightCm))
+	}
+	if c.Items > 0 {
+		parts = append(parts, i18n.N(lang, "bot.cargo.items", c.Items))
+	}
+	if c.Fragile {
+		parts = append(parts, i18n.T(lang, "bot.cargo.fragile"))
+	}
+	if c.NeedsLoaders {
+		parts = append(parts, i18n.T(lang, "bot.cargo.loaders"))
+	}
+	if c.Floor > 0 {
+		lift := i18n.T(lang, "bot.cargo.no_lift")
+		if c.HasElevator {
+			lift = i18n.T(lang, "bot.cargo.lift")
+		}
+		parts = append(parts, i18n.T(lang, "bot.cargo.floor", c.Floor, lift))
+	}
+	return "\n" + i18n.T(lang, "bot.cargo.title", strings.Join(parts, ", "))
+}
+
+// tripCapacity — вместимость для конкретного рейса: max_weight рейса перекрывает машину
+func tripCapacity(truckType string, tripMaxWeight int, vehicle domain.VehicleCapacity) domain.VehicleCapacity {
+	vehicle.TruckType = truckType
+	if tripMaxWeight > 0 {
+		vehicle.MaxWeightKg = float64(tripMaxWeight)
+	}
+	return vehicle
+}
@@ -0,0 +1,550 @@
+// complaint-handler.go
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"html"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"tezjet/internal/domain"
+	"tezjet/internal/i18n"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+const (
+	maxComplaintPhotos      = 5
+	maxComplaintDescription = 2000
+	maxComplaintComment     = 2000
+	complaintPhotoSubdir    = "complaint"
+	adminComplaintsLimit    = 200
+)
+
+// errComplaintNoDriver — отстранить некого: жалоба подана без назначенного водителя
+var errComplaintNoDriver = i18n.E("complaint_no_driver_to_suspend")
+
+// complaintCategoryTitle — подпись категории для уведомлений в боте
+func complaintCategoryTitle(lang i18n.Locale, category string) string {
+	return i18n.T(lang, "bot.complaint.category_"+category)
+}
+
+func (h *Handler) complaintPhotoDir() string {
+	return filepath.Join(h.cfg.CargoPhoto, complaintPhotoSubdir)
+}
+
+// publicComplaint оставляет только имена файлов вложений
+func publicComplaint(c *domain.Complaint) *domain.Complaint {
+	for i := range c.Attachments {
+		c.Attachments[i] = filepath.Base(c.Attachments[i])
+	}
+	return c
+}
+
+// handleFileComplaint — жалоба клиента или водителя по заявке (multipart: telegram_id, order_id, category, description, photos)
+func (h *Handler) handleFileComplaint(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		h.sendErrorResponse(w, r, "invalid_form", http.StatusBadRequest)
+		return
+	}
+	telegramID, _ := strconv.ParseInt(r.FormValue("telegram_id"), 10, 64)
+	orderID := strings.TrimSpace(r.FormValue("order_id"))
+	category := strings.TrimSpace(r.FormValue("category"))
+	description := strings.TrimSpace(r.FormValue("description"))
+
+	if telegramID == 0 || orderID == "" {
+		h.sendErrorResponse(w, r, "telegram_id_and_order_id_required", http.StatusBadRequest)
+		return
+	}
+	// order_id попадает в имя файла
+	if _, err := uuid.Parse(orderID); err != nil {
+		h.sendErrorResponse(w, r, "invalid_order_id", http.StatusBadRequest)
+		return
+	}
+	if !domain.IsValidComplaintCategory(category) {
+		h.sendErrorResponse(w, r, "invalid_complaint_category", http.StatusBadRequest)
+		return
+	}
+	if description == "" {
+		h.sendErrorResponse(w, r, "complaint_description_required", http.StatusBadRequest)
+		return
+	}
+	if utf8.RuneCountInString(description) > maxComplaintDescription {
+		h.sendErrorResponse(w, r, "description_too_long", http.StatusBadRequest, maxComplaintDescription)
+		return
+	}
+
+	parties, err := h.getOrderParties(r.Context(), orderID)
+	if err != nil {
+		h.logger.Error("Failed to load order parties", zap.String("order_id", orderID), zap.Error(err))
+		h.sendErrorResponse(w, r, "order_load_failed", http.StatusInternalServerError)
+		return
+	}
+	if parties == nil {
+		h.sendErrorResponse(w, r, "order_not_found", http.StatusNotFound)
+		return
+	}
+	var filedBy string
+	switch parties.roleOf(telegramID) {
+	case domain.ReviewerClient:
+		filedBy = domain.ComplainantClient
+	case domain.ReviewerDriver:
+		filedBy = domain.ComplainantDriver
+	default:
+		h.sendErrorResponse(w, r, "order_access_denied", http.StatusForbidden)
+		return
+	}
+	if parties.DriverID == "" {
+		h.sendErrorResponse(w, r, "complaint_no_driver", http.StatusConflict)
+		return
+	}
+
+	open, err := h.complaintRepo.HasOpenComplaint(r.Context(), orderID, telegramID)
+	if err != nil {
+		h.logger.Error("Failed to check open complaints", zap.String("order_id", orderID), zap.Error(err))
+		h.sendErrorResponse(w, r, "complaint_save_failed", http.StatusInternalServerError)
+		return
+	}
+	if open {
+		h.sendErrorResponse(w, r, "complaint_already_open", http.StatusConflict)
+		return
+	}
+
+	paths, err := h.saveFormPhotos(r, h.complaintPhotoDir(), orderID+"_"+filedBy, maxComplaintPhotos)
+	if err != nil {
+		h.sendError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	c := &domain.Complaint{
+		RequestID:         orderID,
+		FiledBy:           filedBy,
+		FiledByTelegramID: telegramID,
+		DriverID:          parties.DriverID,
+		ClientTelegramID:  parties.ClientTelegramID,
+		Category:          category,
+		Description:       description,
+		Attachments:       paths,
+	}
+	if err := h.complaintRepo.CreateComplaint(r.Context(), c); err != nil {
+		removeFiles(paths)
+		h.logger.Error("Failed to create complaint", zap.String("order_id", orderID), zap.Error(err))
+		h.sendErrorResponse(w, r, "complaint_save_failed", http.StatusInternalServerError)
+		return
+	}
+
+	h.logger.Info("Complaint filed",
+		zap.Int64("complaint_id", c.ID),
+		zap.String("order_id", orderID),
+		zap.String("filed_by", filedBy),
+		zap.String("category", category))
+
+	go h.notifyAdminNewComplaint(context.Background(), c)
+
+	h.sendSuccessResponse(w, r, "complaint_filed", map[string]interface{}{
+		"complaint_id": c.ID,
+		"status":       c.Status,
+	})
+}
+
+// handleUserComplaints — жалобы, поданные пользователем, со статусом разбора
+func (h *Handler) handleUserComplaints(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	telegramID, _ := strconv.ParseInt(r.URL.Query().Get("telegram_id"), 10, 64)
+	if telegramID == 0 {
+		h.sendErrorResponse(w, r, "telegram_id_required", http.StatusBadRequest)
+		return
+	}
+
+	complaints, err := h.complaintRepo.ListComplaintsByTelegramID(r.Context(), telegramID)
+	if err != nil {
+		h.logger.Error("Failed to list complaints", zap.Int64("telegram_id", telegramID), zap.Error(err))
+		h.sendErrorResponse(w, r, "complaints_load_failed", http.StatusInternalServerError)
+		return
+	}
+	for i := range complaints {
+		publicComplaint(&complaints[i])
+	}
+
+	h.sendSuccessResponse(w, r, "complaints_loaded", map[string]interface{}{
+		"count":      len(complaints),
+		"complaints": complaints,
+	})
+}
+
+// ==================== ADMIN: COMPLAINTS ====================
+
+// adminFromRequest проверяет telegram_id администратора из query
+func (h *Handler) adminFromRequest(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	telegramID, _ := strconv.ParseInt(r.URL.Query().Get("telegram_id"), 10, 64)
+	if !h.isAdmin(telegramID) {
+		h.sendErrorResponse(w, r, "forbidden", http.StatusForbidden)
+		return 0, false
+	}
+	return telegramID, true
+}
+
+// complaintIDFromPath — id жалобы из /api/admin/complaints/{id}
+func (h *Handler) complaintIDFromPath(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil || id <= 0 {
+		h.sendErrorResponse(w, r, "invalid_complaint_id", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
+
+// handleAdminComplaints — список жалоб
+// GET /api/admin/complaints?telegram_id=...&status=open
+func (h *Handler) handleAdminComplaints(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if _, ok := h.adminFromRequest(w, r); !ok {
+		return
+	}
+
+	status := strings.TrimSpace(r.URL.Query().Get("status"))
+	switch status {
+	case "", domain.ComplaintStatusOpen, domain.ComplaintStatusInvestigating,
+		domain.ComplaintStatusResolved, domain.ComplaintStatusRejected:
+	default:
+		h.sendErrorResponse(w, r, "invalid_status", http.StatusBadRequest)
+		return
+	}
+
+	complaints, err := h.complaintRepo.ListComplaints(r.Context(), status, adminComplaintsLimit)
+	if err != nil {
+		h.logger.Error("Failed to list complaints", zap.Error(err))
+		h.sendErrorResponse(w, r, "internal", http.StatusInternalServerError)
+		return
+	}
+	for i := range complaints {
+		publicComplaint(&complaints[i])
+	}
+
+	h.sendSuccessResponse(w, r, "admin_complaints", map[string]interface{}{
+		"count":      len(complaints),
+		"complaints": complaints,
+	})
+}
+
+// handleAdminComplaintDetail — жалоба с вложениями и заметками
+// GET /api/admin/complaints/{id}?telegram_id=...
+func (h *Handler) handleAdminComplaintDetail(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if _, ok := h.adminFromRequest(w, r); !ok {
+		return
+	}
+	id, ok := h.complaintIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	c, err := h.complaintRepo.GetComplaint(r.Context(), id)
+	if err != nil {
+		h.logger.Error("Failed to get complaint", zap.Int64("complaint_id", id), zap.Error(err))
+		h.sendErrorResponse(w, r, "internal", http.StatusInternalServerError)
+		return
+	}
+	if c == nil {
+		h.sendErrorResponse(w, r, "complaint_not_found", http.StatusNotFound)
+		return
+	}
+
+	h.sendSuccessResponse(w, r, "complaint_detail", publicComplaint(c))
+}
+
+// handleAdminAssignComplaint — взять жалобу в работу (статус investigating)
+// POST /api/admin/complaints/{id}/assign?telegram_id=...  {"assignee_telegram_id": 0}
+func (h *Handler) handleAdminAssignComplaint(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	adminID, ok := h.adminFromRequest(w, r)
+	if !ok {
+		return
+	}
+	id, ok := h.complaintIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		AssigneeTelegramID int64 `json:"assignee_telegram_id"`
+	}
+	// тело необязательно: по умолчанию назначаем на себя
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.sendErrorResponse(w, r, "invalid_request", http.StatusBadRequest)
+			return
+		}
+	}
+	assignee := req.AssigneeTelegramID
+	if assignee == 0 {
+		assignee = adminID
+	}
+
+	updated, err := h.complaintRepo.AssignComplaint(r.Context(), id, assignee)
+	if err != nil {
+		h.logger.Error("Failed to assign complaint", zap.Int64("complaint_id", id), zap.Error(err))
+		h.sendErrorResponse(w, r, "internal", http.StatusInternalServerError)
+		return
+	}
+	if !updated {
+		h.sendErrorResponse(w, r, "complaint_closed", http.StatusConflict)
+		return
+	}
+
+	h.logger.Info("Complaint assigned", zap.Int64("complaint_id", id), zap.Int64("assignee", assignee))
+	h.sendSuccessResponse(w, r, "complaint_investigating", map[string]interface{}{
+		"complaint_id": id,
+		"assigned_to":  assignee,
+		"status":       domain.ComplaintStatusInvestigating,
+	})
+}
+
+// handleAdminCommentComplaint — заметка администратора к жалобе
+// POST /api/admin/complaints/{id}/comment?telegram_id=...  {"body": "..."}
+func (h *Handler) handleAdminCommentComplaint(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	adminID, ok := h.adminFromRequest(w, r)
+	if !ok {
+		return
+	}
+	id, ok := h.complaintIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Body string `json:"body"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendErrorResponse(w, r, "invalid_request", http.StatusBadRequest)
+		return
+	}
+	req.Body = strings.TrimSpace(req.Body)
+	if req.Body == "" {
+		h.sendErrorResponse(w, r, "comment_empty", http.StatusBadRequest)
+		return
+	}
+	if utf8.RuneCountInString(req.Body) > maxComplaintComment {
+		h.sendErrorResponse(w, r, "comment_too_long", http.StatusBadRequest, maxComplaintComment)
+		return
+	}
+
+	c, err := h.complaintRepo.GetComplaint(r.Context(), id)
+	if err != nil {
+		h.logger.Error("Failed to get complaint", zap.Int64("complaint_id", id), zap.Error(err))
+		h.sendErrorResponse(w, r, "internal", http.StatusInternalServerError)
+		return
+	}
+	if c == nil {
+		h.sendErrorResponse(w, r, "complaint_not_found", http.StatusNotFound)
+		return
+	}
+
+	cm, err := h.complaintRepo.AddComment(r.Context(), id, adminID, req.Body)
+	if err != nil {
+		h.logger.Error("Failed to comment complaint", zap.Int64("complaint_id", id), zap.Error(err))
+		h.sendErrorResponse(w, r, "internal", http.StatusInternalServerError)
+		return
+	}
+
+	h.sendSuccessResponse(w, r, "complaint_comment_added", cm)
+}
+
+// handleAdminResolveComplaint — закрыть жалобу: resolved или rejected.
+// outcome=driver_suspended переводит водителя в статус suspended.
+// POST /api/admin/complaints/{id}/resolve?telegram_id=...  {"status": "resolved", "resolution": "...", "outcome": "warning"}
+func (h *Handler) handleAdminResolveComplaint(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	adminID, ok := h.adminFromRequest(w, r)
+	if !ok {
+		return
+	}
+	id, ok := h.complaintIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Status     string `json:"status"`
+		Resolution string `json:"resolution"`
+		Outcome    string `json:"outcome"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.sendErrorResponse(w, r, "invalid_request", http.StatusBadRequest)
+		return
+	}
+	req.Resolution = strings.TrimSpace(req.Resolution)
+	if req.Status != domain.ComplaintStatusResolved && req.Status != domain.ComplaintStatusRejected {
+		h.sendErrorResponse(w, r, "complaint_status_invalid", http.StatusBadRequest)
+		return
+	}
+	if req.Outcome == "" {
+		req.Outcome = domain.ComplaintOutcomeNone
+	}
+	if !domain.IsValidComplaintOutcome(req.Outcome) {
+		h.sendErrorResponse(w, r, "invalid_complaint_outcome", http.StatusBadRequest)
+		return
+	}
+	if req.Status == domain.ComplaintStatusRejected && req.Outcome != domain.ComplaintOutcomeNone {
+		h.sendErrorResponse(w, r, "complaint_rejected_with_action", http.StatusBadRequest)
+		return
+	}
+	if req.Resolution == "" {
+		h.sendErrorResponse(w, r, "resolution_required", http.StatusBadRequest)
+		return
+	}
+	if utf8.RuneCountInString(req.Resolution) > maxComplaintComment {
+		h.sendErrorResponse(w, r, "resolution_too_long", http.StatusBadRequest, maxComplaintComment)
+		return
+	}
+
+	if req.Outcome == domain.ComplaintOutcomeDriverSuspended {
+		current, err := h.complaintRepo.GetComplaint(r.Context(), id)
+		if err != nil {
+			h.logger.Error("Failed to load complaint", zap.Int64("complaint_id", id), zap.Error(err))
+			h.sendErrorResponse(w, r, "internal", http.StatusInternalServerError)
+			return
+		}
+		if current == nil {
+			h.sendErrorResponse(w, r, "complaint_not_found", http.StatusNotFound)
+			return
+		}
+		if current.DriverID == "" {
+			h.sendError(w, r, errComplaintNoDriver, http.StatusBadRequest)
+			return
+		}
+	}
+
+	closed, err := h.complaintRepo.CloseComplaint(r.Context(), id, req.Status, req.Resolution, req.Outcome, adminID)
+	if err != nil {
+		h.logger.Error("Failed to close complaint", zap.Int64("complaint_id", id), zap.Error(err))
+		h.sendErrorResponse(w, r, "internal", http.StatusInternalServerError)
+		return
+	}
+	if !closed {
+		h.sendErrorResponse(w, r, "complaint_closed", http.StatusConflict)
+		return
+	}
+
+	c, err := h.complaintRepo.GetComplaint(r.Context(), id)
+	if err != nil || c == nil {
+		h.logger.Error("Failed to reload complaint", zap.Int64("complaint_id", id), zap.Error(err))
+		h.sendSuccessResponse(w, r, "complaint_closed", map[string]interface{}{"complaint_id": id, "status": req.Status})
+		return
+	}
+
+	h.logger.Info("Complaint closed",
+		zap.Int64("complaint_id", id),
+		zap.String("status", c.Status),
+		zap.String("outcome", c.Outcome))
+
+	go h.notifyComplaintClosed(context.Background(), c)
+
+	h.sendSuccessResponse(w, r, "complaint_closed", publicComplaint(c))
+}
+
+// ==================== NOTIFICATIONS ====================
+
+func (h *Handler) notifyAdminNewComplaint(ctx context.Context, c *domain.Complaint) {
+	if h.cfg.AdminTelegramID == 0 || h.bot == nil {
+		return
+	}
+
+	lang := h.userLocale(ctx, h.cfg.AdminTelegramID)
+	text := i18n.T(lang, "bot.complaint.admin_new",
+		c.ID, c.RequestID,
+		i18n.T(lang, "bot.complaint.from_"+c.FiledBy),
+		complaintCategoryTitle(lang, c.Category),
+		len(c.Attachments),
+		html.EscapeString(truncateString(c.Description, 500)))
+
+	if _, err := h.bot.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    h.cfg.AdminTelegramID,
+		Text:      text,
+		ParseMode: models.ParseModeHTML,
+	}); err != nil {
+		h.logErr("send complaint notification", err)
+	}
+}
+
+// notifyComplaintClosed сообщает заявителю решение; при блокировке — уведомляет водителя
+func (h *Handler) notifyComplaintClosed(ctx context.Context, c *domain.Complaint) {
+	if h.bot == nil {
+		return
+	}
+
+	key := "bot.complaint.resolved"
+	if c.Status == domain.ComplaintStatusRejected {
+		key = "bot.complaint.rejected"
+	}
+	text := i18n.T(h.userLocale(ctx, c.FiledByTelegramID), key,
+		c.ID, c.RequestID, html.EscapeString(c.Resolution))
+
+	if _, err := h.bot.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    c.FiledByTelegramID,
+		Text:      text,
+		ParseMode: models.ParseModeHTML,
+	}); err != nil {
+		h.logErr("send complaint resolution", err)
+	}
+
+	if c.Outcome != domain.ComplaintOutcomeDriverSuspended || c.DriverID == "" {
+		return
+	}
+
+	var d DriverShort
+	err := h.db.QueryRowContext(ctx, `
+		SELECT id, telegram_id, first_name, last_name, status, is_verified, contact_number
+		FROM drivers WHERE id = ?`, c.DriverID).
+		Scan(&d.ID, &d.TelegramID, &d.FirstName, &d.LastName, &d.Status, &d.IsVerified, &d.Contact)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			h.logErr("select suspended driver", err)
+		}
+		return
+	}
+	h.notifyDriverBlocked(ctx, d, "custom", c.Resolution)
+}
@@ -5,7 +5,9 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"html"
 	"io"
 	"log"
 	"math"
@@ -26,14 +28,18 @@ import (
 
 	"tezjet/config"
 	"tezjet/internal/domain"
+	"tezjet/internal/i18n"
+	"tezjet/internal/notifier"
 	"tezjet/internal/repository"
 )
 
 // Response represents the API response
 type Response struct {
-	Success bool        `json:"success"`
-	Message string      `json:"message,omitempty"`
-	Data    interface{} `json:"data,omitempty"`
+	Success bool          `json:"success"`
+	Code    string        `json:"code,omitempty"` // стабильный код ошибки, см. internal/i18n
+	Params  []interface{} `json:"params,omitempty"`
+	Message string        `json:"message,omitempty"`
+	Data    interface{}   `json:"data,omitempty"`
 }
 
 type MatchedDriver struct {
@@ -66,6 +72,13 @@ type MatchedDriver struct {
 	HasWhatsApp         bool    `json:"has_whatsapp"`
 	HasTelegram         bool    `json:"has_telegram"`
 	ResponseTimeMin     int     `json:"response_time_min"`
+
+	Capacity    domain.VehicleCapacity `json:"capacity"`
+	CapacityFit float64                `json:"capacity_fit"`
+
+	Rating      float64 `json:"rating"`
+	RatingCount int     `json:"rating_count"`
+	CancelCount int     `json:"cancel_count"`
 }
 
 type Handler struct {
@@ -76,8 +89,19 @@ type Handler struct {
 	userRepo   *repository.UserRepository
 	driverRepo *repository.DriverRepository
 	redisRepo  *repository.RedisRepository
+	orderRepo  *repository.OrderRepository
+	reviewRepo *repository.ReviewRepository
+
+	complaintRepo *repository.ComplaintRepository
+	templateRepo  *repository.TemplateRepository
+	tariffRepo    *repository.TariffRepository
+	prefsRepo     *repository.PreferencesRepository
+	searchRepo    *repository.SearchRepository
+	bookingRepo   *repository.BookingRepository
+	notifyRepo    *repository.NotificationRepository
 
-	chatHub *Hub
+	notifier *notifier.Notifier
+	chatHub  *Hub
 }
 
 func NewHandler(cfg *config.Config, logger *zap.Logger, db *sql.DB, userRepo *repository.UserRepository, driverRepo *repository.DriverRepository, redisClient *redis.Client) *Handler {
@@ -86,15 +110,27 @@ func NewHandler(cfg *config.Config, logger *zap.Logger, db *sql.DB, userRepo *re
 	os.MkdirAll("./documents", 0755)
 	os.MkdirAll("./delivery-photo", 0755)
 
-	return &Handler{
+	h := &Handler{
 		cfg:        cfg,
 		logger:     logger,
 		db:         db,
 		userRepo:   userRepo,
 		driverRepo: driverRepo,
 		redisRepo:  repository.NewRedisRepository(redisClient),
+		orderRepo:  repository.NewOrderRepository(db, logger),
+		reviewRepo: repository.NewReviewRepository(db, logger),
 		chatHub:    NewHub(),
+
+		complaintRepo: repository.NewComplaintRepository(db, logger),
+		templateRepo:  repository.NewTemplateRepository(db, logger),
+		tariffRepo:    repository.NewTariffRepository(db, logger),
+		prefsRepo:     repository.NewPreferencesRepository(db, logger),
+		searchRepo:    repository.NewSearchRepository(db, logger),
+		bookingRepo:   repository.NewBookingRepository(db, logger),
+		notifyRepo:    repository.NewNotificationRepository(db, logger),
 	}
+	h.notifier = h.newNotifier()
+	return h
 }
 
 // NEW: handleDriverRegister - Full implementation for driver registration
@@ -119,9 +155,9 @@ func (h *Handler) handleDriverRegister(b *bot.Bot) http.HandlerFunc {
 				zap.String("content_length", r.Header.Get("Content-Length")))
 
 			if ctx.Err() == context.DeadlineExceeded {
-				h.sendErrorResponse(w, "Время загрузки файлов истекло. Попробуйте уменьшить размер файлов.", http.StatusRequestTimeout)
+				h.sendErrorResponse(w, r, "upload_timeout", http.StatusRequestTimeout)
 			} else {
-				h.sendErrorResponse(w, "Ошибка обработки файлов. Проверьте размер и формат файлов.", http.StatusBadRequest)
+				h.sendErrorResponse(w, r, "files_invalid", http.StatusBadRequest)
 			}
 			return
 		}
@@ -129,30 +165,30 @@ func (h *Handler) handleDriverRegister(b *bot.Bot) http.HandlerFunc {
 		driver, err := h.parseDriverRegistration(r)
 		if err != nil {
 			h.logger.Error("Failed to parse driver registration", zap.Error(err))
-			h.sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+			h.sendError(w, r, err, http.StatusBadRequest)
 			return
 		}
 		taken, err := h.isTruckNumberTaken(driver.TruckNumber)
 		if err != nil {
 			h.logger.Error("Failed to check truck number uniqueness", zap.Error(err))
-			h.sendErrorResponse(w, "Ошибка проверки номера машины", http.StatusInternalServerError)
+			h.sendErrorResponse(w, r, "truck_number_check_failed", http.StatusInternalServerError)
 			return
 		}
 		if taken {
-			h.sendErrorResponse(w, "Этот номер машины уже зарегистрирован", http.StatusBadRequest)
+			h.sendErrorResponse(w, r, "truck_number_taken", http.StatusBadRequest)
 			return
 		}
 		profilePhotoPath, err := h.saveFile(r, "profilePhoto", "./ava", driver.TelegramID, "profile")
 		if err != nil {
 			h.logger.Error("Failed to save profile photo", zap.Error(err))
-			h.sendErrorResponse(w, "Ошибка сохранения фото профиля", http.StatusInternalServerError)
+			h.sendErrorResponse(w, r, "profile_photo_save_failed", http.StatusInternalServerError)
 			return
 		}
 		driver.ProfilePhoto = profilePhotoPath
 		licenseFrontPath, err := h.saveFile(r, "licenseFront", "./documents", driver.TelegramID, "license_front")
 		if err != nil {
 			h.logger.Error("Failed to save license front", zap.Error(err))
-			h.sendErrorResponse(w, "Ошибка сохранения водительского удостоверения", http.StatusInternalServerError)
+			h.sendErrorResponse(w, r, "license_save_failed", http.StatusInternalServerError)
 			return
 		}
 		driver.LicenseFront = licenseFrontPath
@@ -160,7 +196,7 @@ func (h *Handler) handleDriverRegister(b *bot.Bot) http.HandlerFunc {
 		licenseBackPath, err := h.saveFile(r, "licenseBack", "./documents", driver.TelegramID, "license_back")
 		if err != nil {
 			h.logger.Error("Failed to save license back", zap.Error(err))
-			h.sendErrorResponse(w, "Ошибка сохранения водительского удостоверения", http.StatusInternalServerError)
+			h.sendErrorResponse(w, r, "license_save_failed", http.StatusInternalServerError)
 			return
 		}
 		driver.LicenseBack = licenseBackPath
@@ -178,7 +214,7 @@ func (h *Handler) handleDriverRegister(b *bot.Bot) http.HandlerFunc {
 		driverID, err := h.saveDriverRegistration(driver)
 		if err != nil {
 			h.logger.Error("Failed to save driver registration", zap.Error(err))
-			h.sendErrorResponse(w, "Ошибка сохранения регистрации", http.StatusInternalServerError)
+			h.sendErrorResponse(w, r, "registration_save_failed", http.StatusInternalServerError)
 			return
 		}
 
@@ -191,7 +227,7 @@ func (h *Handler) handleDriverRegister(b *bot.Bot) http.HandlerFunc {
 		go h.sendDriverConfirmationMessage(b, driver, driverID)
 
 		// Send success response
-		h.sendSuccessResponse(w, "Регистрация успешно отправлена", map[string]interface{}{
+		h.sendSuccessResponse(w, r, "registration_sent", map[string]interface{}{
 			"driver_id": driverID,
 			"status":    "pending",
 		})
@@ -212,15 +248,13 @@ func (h *Handler) handleCheckTruckNumber() http.HandlerFunc {
 		w.Header().Set("Content-Type", "application/json")
 
 		if r.Method != http.MethodPatch {
-			w.WriteHeader(http.StatusMethodNotAllowed)
-			_ = json.NewEncoder(w).Encode(resp{Success: false, Message: "Method not allowed"})
+			h.sendErrorResponse(w, r, "method_not_allowed", http.StatusMethodNotAllowed)
 			return
 		}
 
 		var body req
 		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			_ = json.NewEncoder(w).Encode(resp{Success: false, Message: "Invalid JSON"})
+			h.sendErrorResponse(w, r, "invalid_json", http.StatusBadRequest)
 			return
 		}
 
@@ -229,15 +263,14 @@ func (h *Handler) handleCheckTruckNumber() http.HandlerFunc {
 
 		if len(tn) < 5 || len(tn) > 15 {
 			w.WriteHeader(http.StatusOK)
-			_ = json.NewEncoder(w).Encode(resp{Success: true, Available: false, Message: "Неверный формат номера"})
+			_ = json.NewEncoder(w).Encode(resp{Success: true, Available: false, Message: i18n.T(i18n.FromContext(r.Context()), "error.invalid_truck_number")})
 			return
 		}
 
 		taken, err := h.isTruckNumberTaken(tn)
 		if err != nil {
 			h.logger.Error("truck number check failed", zap.Error(err))
-			w.WriteHeader(http.StatusInternalServerError)
-			_ = json.NewEncoder(w).Encode(resp{Success: false, Message: "Server error"})
+			h.sendErrorResponse(w, r, "truck_number_check_failed", http.StatusInternalServerError)
 			return
 		}
 
@@ -276,7 +309,7 @@ func (h *Handler) handleDriverStart(b *bot.Bot) http.HandlerFunc {
 		}
 		if err != nil {
 			h.logger.Error("Failed to parse form", zap.Error(err))
-			h.sendErrorResponse(w, "Ошибка обработки данных", http.StatusBadRequest)
+			h.sendErrorResponse(w, r, "invalid_data", http.StatusBadRequest)
 			return
 		}
 
@@ -290,7 +323,7 @@ func (h *Handler) handleDriverStart(b *bot.Bot) http.HandlerFunc {
 		trip, err := h.parseDriverTripRequest(r)
 		if err != nil {
 			h.logger.Error("Failed to parse driver trip request", zap.Error(err))
-			h.sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+			h.sendError(w, r, err, http.StatusBadRequest)
 			return
 		}
 
@@ -298,17 +331,17 @@ func (h *Handler) handleDriverStart(b *bot.Bot) http.HandlerFunc {
 		driver, err := h.CheckDriverExist(trip.TelegramID)
 		if err != nil {
 			h.logger.Error("Failed to check driver existence", zap.Error(err))
-			h.sendErrorResponse(w, "Ошибка проверки водителя", http.StatusInternalServerError)
+			h.sendErrorResponse(w, r, "driver_check_failed", http.StatusInternalServerError)
 			return
 		}
 
 		if driver == nil {
-			h.sendErrorResponse(w, "Водитель не найден", http.StatusNotFound)
+			h.sendErrorResponse(w, r, "driver_not_found", http.StatusNotFound)
 			return
 		}
 
 		if driver.Status != "approved" {
-			h.sendErrorResponse(w, "Водитель не одобрен", http.StatusForbidden)
+			h.sendErrorResponse(w, r, "driver_not_approved", http.StatusForbidden)
 			return
 		}
 
@@ -325,6 +358,12 @@ func (h *Handler) handleDriverStart(b *bot.Bot) http.HandlerFunc {
 			}
 		}
 
+		quote, err := h.checkTariffPrice(r.Context(), trip.FromAddress, trip.TruckType, trip.DistanceKm, trip.EtaMin, trip.Price)
+		if err != nil {
+			h.sendError(w, r, err, http.StatusBadRequest)
+			return
+		}
+
 		h.logger.Info("Parsed driver trip request",
 			zap.String("from", trip.FromAddress),
 			zap.String("to", trip.ToAddress),
@@ -339,7 +378,7 @@ func (h *Handler) handleDriverStart(b *bot.Bot) http.HandlerFunc {
 		tripID, err := h.saveDriverTrip(trip)
 		if err != nil {
 			h.logger.Error("Failed to save driver trip", zap.Error(err))
-			h.sendErrorResponse(w, "Ошибка сохранения поездки", http.StatusInternalServerError)
+			h.sendErrorResponse(w, r, "trip_save_failed", http.StatusInternalServerError)
 			return
 		}
 
@@ -351,13 +390,16 @@ func (h *Handler) handleDriverStart(b *bot.Bot) http.HandlerFunc {
 
 		// Send confirmation message to driver
 		go h.sendDriverTripConfirmation(b, trip, driver)
+		go h.notifySavedSearches(b, trip, driver)
 
 		// Send success response
-		h.sendSuccessResponse(w, "Поездка успешно создана", map[string]interface{}{
-			"trip_id":  tripID,
-			"status":   "active",
-			"distance": trip.DistanceKm,
-			"eta":      trip.EtaMin,
+		h.sendSuccessResponse(w, r, "trip_created", map[string]interface{}{
+			"trip_id":           tripID,
+			"status":            "active",
+			"distance":          trip.DistanceKm,
+			"eta":               trip.EtaMin,
+			"min_price":         quote.MinPrice,
+			"recommended_price": quote.Recommended,
 		})
 	}
 }
@@ -377,13 +419,13 @@ func (h *Handler) parseDriverTripRequest(r *http.Request) (*DriverTrip, error) {
 	trip.StartTime = getValue("start_time")
 
 	if trip.FromAddress == "" {
-		return nil, fmt.Errorf("адрес отправления обязателен")
+		return nil, i18n.E("from_address_required")
 	}
 	if trip.ToAddress == "" {
-		return nil, fmt.Errorf("адрес назначения обязателен")
+		return nil, i18n.E("to_address_required")
 	}
 	if trip.StartTime == "" {
-		return nil, fmt.Errorf("время выезда обязательно")
+		return nil, i18n.E("departure_required")
 	}
 
 	// Parse coordinates
@@ -391,39 +433,39 @@ func (h *Handler) parseDriverTripRequest(r *http.Request) (*DriverTrip, error) {
 	if latStr := getValue("from_lat"); latStr != "" {
 		trip.FromLat, err = strconv.ParseFloat(latStr, 64)
 		if err != nil {
-			return nil, fmt.Errorf("неверная широта отправления: %v", err)
+			return nil, i18n.Wrap(err, "invalid_from_coordinates")
 		}
 	}
 
 	if lonStr := getValue("from_lon"); lonStr != "" {
 		trip.FromLon, err = strconv.ParseFloat(lonStr, 64)
 		if err != nil {
-			return nil, fmt.Errorf("неверная долгота отправления: %v", err)
+			return nil, i18n.Wrap(err, "invalid_from_coordinates")
 		}
 	}
 
 	if latStr := getValue("to_lat"); latStr != "" {
 		trip.ToLat, err = strconv.ParseFloat(latStr, 64)
 		if err != nil {
-			return nil, fmt.Errorf("неверная широта назначения: %v", err)
+			return nil, i18n.Wrap(err, "invalid_to_coordinates")
 		}
 	}
 
 	if lonStr := getValue("to_lon"); lonStr != "" {
 		trip.ToLon, err = strconv.ParseFloat(lonStr, 64)
 		if err != nil {
-			return nil, fmt.Errorf("неверная долгота назначения: %v", err)
+			return nil, i18n.Wrap(err, "invalid_to_coordinates")
 		}
 	}
 
 	// Parse price
 	if priceStr := getValue("price"); priceStr != "" {
 		trip.Price, err = strconv.Atoi(priceStr)
-		if err != nil || trip.Price < 2000 {
-			return nil, fmt.Errorf("цена должна быть не менее 2000 тенге")
+		if err != nil || trip.Price <= 0 {
+			return nil, i18n.E("invalid_price")
 		}
 	} else {
-		return nil, fmt.Errorf("цена обязательна")
+		return nil, i18n.E("price_required")
 	}
 
 	// Parse Telegram ID (should be passed from frontend)
@@ -432,11 +474,18 @@ func (h *Handler) parseDriverTripRequest(r *http.Request) (*DriverTrip, error) {
 	}
 
 	if trip.TelegramID == 0 {
-		return nil, fmt.Errorf("telegram ID обязателен")
+		return nil, i18n.E("telegram_id_required")
 	}
 
 	// Optional fields
 	trip.Comment = getValue("comment")
+	trip.TruckType = getValue("truck_type")
+	if mw := getValue("max_weight"); mw != "" {
+		trip.MaxWeight, err = strconv.Atoi(mw)
+		if err != nil || trip.MaxWeight < 0 || trip.MaxWeight > maxCargoWeightKg {
+			return nil, i18n.E("trip_capacity_range", maxCargoWeightKg)
+		}
+	}
 
 	// Parse distance and ETA from frontend if provided
 	if distStr := getValue("distance"); distStr != "" {
@@ -488,14 +537,14 @@ func (h *Handler) saveDriverTrip(trip *DriverTrip) (string, error) {
 			to_address, to_lat, to_lon, 
 			distance_km, eta_min, price, 
 			truck_type, start_time, comment, 
-			departure_time, status, created_at
+			max_weight, departure_time, status, created_at
 		) VALUES (
 			?, ?, ?, 
 			?, ?, ?, 
 			?, ?, ?, 
 			?, ?, ?, 
 			?, ?, ?, 
-			CURRENT_TIMESTAMP, 'active', CURRENT_TIMESTAMP
+			?, ?, 'active', CURRENT_TIMESTAMP
 		)`
 
 	_, err := h.db.Exec(
@@ -505,6 +554,7 @@ func (h *Handler) saveDriverTrip(trip *DriverTrip) (string, error) {
 		trip.ToAddress, trip.ToLat, trip.ToLon,
 		trip.DistanceKm, trip.EtaMin, trip.Price,
 		truckType, startTime, comment,
+		trip.MaxWeight, sqliteTime(h.tripDeparture(startTime)),
 	)
 
 	if err != nil {
@@ -537,62 +587,26 @@ func (h *Handler) sendDriverTripConfirmation(b *bot.Bot, trip *DriverTrip, drive
 		return
 	}
 
-	// Format start time
-	startTimeText := h.formatTripStartTime(trip.StartTime)
-
-	// FIXED: Using %s for string UUID
-	message := fmt.Sprintf(`🚚 <b>Жаңа сапар басталды!</b>
-
-📋 <b>Сапар нөмірі:</b> #%s
-
-👤 <b>Жүргізуші:</b> %s %s
-📱 <b>Байланыс:</b> %s
-
-📍 <b>Қайдан:</b> %s
-🎯 <b>Қайда:</b> %s
-
-💰 <b>Бағасы:</b> %d ₸
-🛣️ <b>Қашықтық:</b> %.1f км
-⏱️ <b>Болжамды уақыт:</b> %d мин
-%s`,
-		trip.ID, // FIXED: Using %s for string UUID
-		driver.FirstName,
-		driver.LastName,
-		driver.ContactNumber,
-		trip.FromAddress,
-		trip.ToAddress,
-		trip.Price,
-		trip.DistanceKm,
-		trip.EtaMin,
-		startTimeText,
-	)
-
-	// Add comment if provided
-	if trip.Comment != "" {
-		message += fmt.Sprintf("\n💬 <b>Түсініктеме:</b> %s", trip.Comment)
-	}
-
-	message += "\n\n✅ Сіздің сапарыңыз белсенді режимде!"
-
-	// Send message with trip management keyboard
-	keyboard := &models.InlineKeyboardMarkup{
-		InlineKeyboard: [][]models.InlineKeyboardButton{
-			{
-				{Text: "🚚 Менің сапарларым", CallbackData: "my_trips"},
-				{Text: "✅ Аяқтау", CallbackData: fmt.Sprintf("complete_trip_%s", trip.ID)}, // FIXED: %s for string
-			},
-		},
-	}
-
 	ctx := context.Background()
-
-	_, err := b.SendMessage(ctx, &bot.SendMessageParams{
-		ChatID:      trip.TelegramID,
-		Text:        message,
-		ParseMode:   models.ParseModeHTML,
-		ReplyMarkup: keyboard,
+	lang := h.userLocale(ctx, trip.TelegramID)
+	err := h.notifier.Send(ctx, notifier.Message{
+		Template: "trip_created",
+		Locale:   lang,
+		Data: tripNotice{
+			ID:         trip.ID,
+			DriverName: strings.TrimSpace(driver.FirstName + " " + driver.LastName),
+			Contact:    driver.ContactNumber,
+			From:       trip.FromAddress,
+			To:         trip.ToAddress,
+			Price:      trip.Price,
+			DistanceKm: trip.DistanceKm,
+			EtaMin:     trip.EtaMin,
+			StartTime:  h.tripStartLabel(lang, trip.StartTime),
+			Comment:    trip.Comment,
+		},
+		To:     notifier.Recipient{TelegramID: trip.TelegramID, Phone: driver.ContactNumber},
+		Markup: tripManageKeyboard(lang, trip.ID),
 	})
-
 	if err != nil {
 		h.logger.Error("Failed to send driver trip confirmation message",
 			zap.Error(err),
@@ -606,9 +620,14 @@ func (h *Handler) sendDriverTripConfirmation(b *bot.Bot, trip *DriverTrip, drive
 }
 
 // formatTripStartTime formats the trip start time
-func (h *Handler) formatTripStartTime(startTime string) string {
+func (h *Handler) formatTripStartTime(lang i18n.Locale, startTime string) string {
+	return i18n.T(lang, "bot.trip.start_time", html.EscapeString(h.tripStartLabel(lang, startTime)))
+}
+
+// tripStartLabel — время выезда без разметки: "Сегодня 18:00"
+func (h *Handler) tripStartLabel(lang i18n.Locale, startTime string) string {
 	if startTime == "" {
-		return "🕐 <b>Кету уақыты:</b> Қазір | Сейчас"
+		return i18n.T(lang, "bot.time.now")
 	}
 
 	// Parse ISO format time (2006-01-02T15:04)
@@ -618,24 +637,21 @@ func (h *Handler) formatTripStartTime(startTime string) string {
 		if t2, err2 := time.Parse("2006-01-02 15:04", startTime); err2 == nil {
 			t = t2
 		} else {
-			return fmt.Sprintf("🕐 <b>Кету уақыты:</b> %s", startTime)
+			return startTime
 		}
 	}
 
 	// Format in local time
 	now := time.Now()
 	if t.Format("2006-01-02") == now.Format("2006-01-02") {
-		return fmt.Sprintf("🕐 <b>Кету уақыты:</b> Бүгін %s | Сегодня %s",
-			t.Format("15:04"), t.Format("15:04"))
+		return i18n.T(lang, "bot.time.today", t.Format("15:04"))
 	} else if t.Before(now.AddDate(0, 0, 1)) && t.After(now.AddDate(0, 0, -1)) {
 		if t.After(now) {
-			return fmt.Sprintf("🕐 <b>Кету уақыты:</b> Ертең %s | Завтра %s",
-				t.Format("15:04"), t.Format("15:04"))
+			return i18n.T(lang, "bot.time.tomorrow", t.Format("15:04"))
 		}
 	}
 
-	return fmt.Sprintf("🕐 <b>Кету уақыты:</b> %s %s",
-		t.Format("02.01.2006"), t.Format("15:04"))
+	return fmt.Sprintf("%s %s", t.Format("02.01.2006"), t.Format("15:04"))
 }
 
 // handleDriverTrips handles getting driver's trips
@@ -665,7 +681,7 @@ func (h *Handler) handleDriverTrips(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if telegramID == 0 {
-		h.sendErrorResponse(w, "Telegram ID обязателен", http.StatusBadRequest)
+		h.sendErrorResponse(w, r, "telegram_id_required", http.StatusBadRequest)
 		return
 	}
 
@@ -673,11 +689,11 @@ func (h *Handler) handleDriverTrips(w http.ResponseWriter, r *http.Request) {
 	trips, err := h.getDriverTrips(telegramID)
 	if err != nil {
 		h.logger.Error("Failed to get driver trips", zap.Error(err))
-		h.sendErrorResponse(w, "Ошибка получения поездок", http.StatusInternalServerError)
+		h.sendErrorResponse(w, r, "trips_load_failed", http.StatusInternalServerError)
 		return
 	}
 
-	h.sendSuccessResponse(w, "Поездки получены", map[string]interface{}{
+	h.sendSuccessResponse(w, r, "trips_loaded", map[string]interface{}{
 		"trips": trips,
 		"count": len(trips),
 	})
@@ -688,6 +704,7 @@ func (h *Handler) getDriverTrips(telegramID int64) ([]DriverTrip, error) {
 	query := `
 		SELECT id, driver_id, telegram_id, from_address, from_lat, from_lon,
 			   to_address, to_lat, to_lon, distance_km, eta_min, price,
+			   COALESCE(booked_weight, 0), COALESCE(booked_volume, 0),
 			   start_time, comment, status, created_at
 		FROM driver_trips 
 		WHERE telegram_id = ?
@@ -708,6 +725,7 @@ func (h *Handler) getDriverTrips(telegramID int64) ([]DriverTrip, error) {
 			&trip.FromAddress, &trip.FromLat, &trip.FromLon,
 			&trip.ToAddress, &trip.ToLat, &trip.ToLon,
 			&trip.DistanceKm, &trip.EtaMin, &trip.Price,
+			&trip.BookedWeight, &trip.BookedVolume,
 			&trip.StartTime, &trip.Comment, &trip.Status, &trip.CreatedAt,
 		)
 		if err != nil {
@@ -753,24 +771,24 @@ func (h *Handler) parseDriverRegistration(r *http.Request) (*DriverRegistration,
 	driver.TruckNumber = tn
 
 	if len(driver.TruckNumber) < 5 || len(driver.TruckNumber) > 15 {
-		return nil, fmt.Errorf("Неверный номер машины")
+		return nil, i18n.E("invalid_truck_number")
 	}
 
 	// Validate required fields
 	if driver.FirstName == "" {
-		return nil, fmt.Errorf("имя обязательно")
+		return nil, i18n.E("first_name_required")
 	}
 	if driver.LastName == "" {
-		return nil, fmt.Errorf("фамилия обязательна")
+		return nil, i18n.E("last_name_required")
 	}
 	if driver.Birthday == "" {
-		return nil, fmt.Errorf("дата рождения обязательна")
+		return nil, i18n.E("birthday_required")
 	}
 	if driver.ContactNumber == "" {
-		return nil, fmt.Errorf("контактный номер обязателен")
+		return nil, i18n.E("contact_required")
 	}
 	if driver.StartCity == "" {
-		return nil, fmt.Errorf("город работы обязателен")
+		return nil, i18n.E("city_required")
 	}
 
 	// Parse coordinates
@@ -778,14 +796,14 @@ func (h *Handler) parseDriverRegistration(r *http.Request) (*DriverRegistration,
 	if latStr := getValue("latitude"); latStr != "" {
 		driver.Latitude, err = strconv.ParseFloat(latStr, 64)
 		if err != nil {
-			return nil, fmt.Errorf("неверная широта: %v", err)
+			return nil, i18n.Wrap(err, "invalid_coordinates")
 		}
 	}
 
 	if lonStr := getValue("longitude"); lonStr != "" {
 		driver.Longitude, err = strconv.ParseFloat(lonStr, 64)
 		if err != nil {
-			return nil, fmt.Errorf("неверная долгота: %v", err)
+			return nil, i18n.Wrap(err, "invalid_coordinates")
 		}
 	}
 
@@ -795,50 +813,59 @@ func (h *Handler) parseDriverRegistration(r *http.Request) (*DriverRegistration,
 	}
 
 	if driver.TelegramID == 0 {
-		return nil, fmt.Errorf("telegram ID обязателен")
+		return nil, i18n.E("telegram_id_required")
 	}
 
 	// UPDATED: Parse truck type
 	driver.TruckType = getValue("truckType")
 	if driver.TruckType == "" {
-		return nil, fmt.Errorf("көлік түрі міндетті / тип транспорта обязателен")
+		return nil, i18n.E("truck_type_required")
 	}
 
 	// Validate truck type
 	validTruckTypes := map[string]bool{
-		"intercity": true,
-		"small":     true,
-		"medium":    true,
-		"large":     true,
-		"tow":       true,
-		"any":       true,
+		"intercity":    true,
+		"small":        true,
+		"medium":       true,
+		"large":        true,
+		"refrigerator": true,
+		"tow":          true,
+		"any":          true,
 	}
 	if !validTruckTypes[driver.TruckType] {
-		return nil, fmt.Errorf("неверный тип транспорта")
+		return nil, i18n.E("invalid_truck_type")
+	}
+
+	// Грузоподъёмность и кузов необязательны: без них берутся значения по типу машины
+	capacity, err := parseCapacityForm(getValue, domain.VehicleCapacity{})
+	if err != nil {
+		return nil, err
 	}
+	driver.Capacity = capacity
+	driver.Capacity.TruckType = driver.TruckType
 
 	// Validate age
 	if driver.Birthday != "" {
 		birthday, err := time.Parse("2006-01-02", driver.Birthday)
 		if err != nil {
-			return nil, fmt.Errorf("неверный формат даты рождения")
+			return nil, i18n.E("invalid_birthday")
 		}
 
 		age := time.Now().Year() - birthday.Year()
 		if age < 18 || age > 80 {
-			return nil, fmt.Errorf("возраст должен быть от 18 до 80 лет")
+			return nil, i18n.E("driver_age_range")
 		}
 	}
 
 	// Validate files
 	if _, _, err := r.FormFile("profilePhoto"); err != nil {
-		return nil, fmt.Errorf("фото профиля обязательно")
+		return nil, i18n.E("profile_photo_required")
 	}
 	if _, _, err := r.FormFile("licenseFront"); err != nil {
-		return nil, fmt.Errorf("лицевая сторона водительского удостоверения обязательна")
+		return nil, i18n.E("license_front_required")
 	}
 	if _, _, err := r.FormFile("licenseBack"); err != nil {
-		return nil, fmt.Errorf("обратная сторона водительского удостоверения обязательна")
+		return nil, i18n.E("license_back_required")
 	}
 
 	return driver, nil
@@ -851,7 +878,7 @@ func (h *Handler) saveFile(r *http.Request, fieldName, dir string, telegramID in
 		h.logger.Error("Failed to get form file",
 			zap.String("field", fieldName),
 			zap.Error(err))
-		return "", fmt.Errorf("файл %s не найден: %v", fieldName, err)
+		return "", i18n.Wrap(err, "file_missing", fieldName)
 	}
 	defer file.Close()
 
@@ -864,19 +891,18 @@ func (h *Handler) saveFile(r *http.Request, fieldName, dir string, telegramID in
 	// Validate file size (5MB max to prevent timeouts)
 	maxSize := int64(5 * 1024 * 1024) // 5MB
 	if header.Size > maxSize {
-		return "", fmt.Errorf("файл %s слишком большой (максимум 5МБ, получен %dMB)",
-			fieldName, header.Size/(1024*1024))
+		return "", i18n.E("file_too_large", maxSize>>20)
 	}
 
 	// Validate file type
 	contentType := header.Header.Get("Content-Type")
 	if fieldName == "profilePhoto" {
 		if !strings.HasPrefix(contentType, "image/") {
-			return "", fmt.Errorf("профиль фото должно быть изображением (получен %s)", contentType)
+			return "", i18n.E("photo_must_be_image", contentType)
 		}
 	} else {
 		if !strings.HasPrefix(contentType, "image/") && contentType != "application/pdf" {
-			return "", fmt.Errorf("документ должен быть изображением или PDF (получен %s)", contentType)
+			return "", i18n.E("document_must_be_image_or_pdf", contentType)
 		}
 	}
 
@@ -885,7 +911,7 @@ func (h *Handler) saveFile(r *http.Request, fieldName, dir string, telegramID in
 		h.logger.Error("Failed to create directory",
 			zap.String("dir", dir),
 			zap.Error(err))
-		return "", fmt.Errorf("ошибка создания директории: %v", err)
+		return "", i18n.Wrap(err, "file_save_failed")
 	}
 
 	// Get file extension from content type or filename
@@ -921,7 +947,7 @@ func (h *Handler) saveFile(r *http.Request, fieldName, dir string, telegramID in
 		h.logger.Error("Failed to create destination file",
 			zap.String("path", filePath),
 			zap.Error(err))
-		return "", fmt.Errorf("ошибка создания файла: %v", err)
+		return "", i18n.Wrap(err, "file_save_failed")
 	}
 	defer dst.Close()
 
@@ -932,7 +958,7 @@ func (h *Handler) saveFile(r *http.Request, fieldName, dir string, telegramID in
 			zap.String("path", filePath),
 			zap.Error(err))
 		os.Remove(filePath)
-		return "", fmt.Errorf("ошибка записи файла: %v", err)
+		return "", i18n.Wrap(err, "file_save_failed")
 	}
 
 	h.logger.Info("File saved successfully",
@@ -962,17 +988,21 @@ func (h *Handler) saveDriverRegistration(driver *DriverRegistration) (string, er
 	INSERT INTO drivers (
 		id, telegram_id, first_name, last_name, birthday, contact_number,
 		start_city, latitude, longitude, profile_photo, license_front,
-		license_back, truck_number, truck_type, status, created_at
+		license_back, truck_number, truck_type, status, created_at,
+		` + capacityColumns + `
 	) VALUES (
-		?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 'pending', CURRENT_TIMESTAMP
+		?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 'pending', CURRENT_TIMESTAMP,
+		?, ?, ?, ?, ?
 	)`
 
+	c := driver.Capacity
 	_, err := h.db.Exec(
 		query,
 		driverID, driver.TelegramID, driver.FirstName, driver.LastName, driver.Birthday,
 		driver.ContactNumber, driver.StartCity, driver.Latitude, driver.Longitude,
 		driver.ProfilePhoto, driver.LicenseFront, driver.LicenseBack,
 		driver.TruckNumber, driver.TruckType,
+		c.MaxWeightKg, c.MaxVolumeM3, c.BodyLengthCm, c.BodyWidthCm, c.BodyHeightCm,
 	)
 
 	if err != nil {
@@ -989,46 +1019,18 @@ func (h *Handler) sendDriverConfirmationMessage(b *bot.Bot, driver *DriverRegist
 		return
 	}
 
-	// Truck type names in Kazakh and Russian
-	truckTypeNames := map[string]string{
-		"intercity": "Қала аралық / Межгород",
-		"small":     "Кіші (1.5т дейін) / Малый (до 1.5т)",
-		"medium":    "Орташа (5т дейін) / Средний (до 5т)",
-		"large":     "Үлкен (20т дейін) / Большой (до 20т)",
-		"tow":       "Эвакуатор / Эвакуатор",
-		"any":       "Кез келген / Любой",
-	}
-
-	truckTypeName := truckTypeNames[driver.TruckType]
-	if truckTypeName == "" {
-		truckTypeName = driver.TruckType
-	}
-
-	message := fmt.Sprintf(`🚗 <b>Жүргізуші ретінде тіркелу!</b>
-
-📋 <b>Өтінім нөмірі:</b> #%s
-
-👤 <b>Аты-жөні:</b> %s %s
-📱 <b>Байланыс:</b> %s
-🏙️ <b>Жұмыс қаласы:</b> %s
-🎂 <b>Туған күні:</b> %s
-🚚 <b>Көлік түрі:</b> %s
-
-✅ Сіздің өтініміңіз қабылданды!
-⏳ Құжаттарды тексеру 24 сағат ішінде аяқталады.
-
-📞 Сұрақтар болса хабарласыңыз: @support`,
+	ctx := context.Background()
+	lang := h.userLocale(ctx, driver.TelegramID)
+	message := i18n.T(lang, "bot.driver.registered",
 		driverID,
-		driver.FirstName,
-		driver.LastName,
-		driver.ContactNumber,
-		driver.StartCity,
-		driver.Birthday,
-		truckTypeName,
+		html.EscapeString(driver.FirstName),
+		html.EscapeString(driver.LastName),
+		html.EscapeString(driver.ContactNumber),
+		html.EscapeString(driver.StartCity),
+		html.EscapeString(driver.Birthday),
+		h.formatTruckType(lang, driver.TruckType),
 	)
 
-	ctx := context.Background()
-
 	_, err := b.SendMessage(ctx, &bot.SendMessageParams{
 		ChatID:    driver.TelegramID,
 		Text:      message,
@@ -1066,12 +1068,13 @@ func (h *Handler) CheckDriverExist(telegramID int64) (*DriverRegistration, error
 			truck_number,
 			is_verified,
 			status,
-			created_at
+			created_at,
+			` + capacityColumns + `
         FROM drivers
         WHERE telegram_id = ?`
 
 	var d DriverRegistration
-	err := h.db.QueryRow(query, telegramID).Scan(
+	dest := []interface{}{
 		&d.ID,
 		&d.TelegramID,
 		&d.FirstName,
@@ -1089,7 +1092,8 @@ func (h *Handler) CheckDriverExist(telegramID int64) (*DriverRegistration, error
 		&d.IsVerified,
 		&d.Status,
 		&d.CreatedAt,
-	)
+	}
+	err := h.db.QueryRow(query, telegramID).Scan(append(dest, capacityScanDest(&d.Capacity)...)...)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -1097,6 +1101,7 @@ func (h *Handler) CheckDriverExist(telegramID int64) (*DriverRegistration, error
 		}
 		return nil, err
 	}
+	d.Capacity.TruckType = d.TruckType
 	return &d, nil
 }
 
@@ -1116,7 +1121,7 @@ func (h *Handler) handleCheckWho(w http.ResponseWriter, r *http.Request) {
 	if r.Method == "POST" {
 		if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
 			h.logger.Error("Failed to parse request body", zap.Error(err))
-			h.sendErrorResponse(w, "Неверные данные запроса", http.StatusBadRequest)
+			h.sendErrorResponse(w, r, "invalid_request", http.StatusBadRequest)
 			return
 		}
 	} else {
@@ -1130,7 +1135,7 @@ func (h *Handler) handleCheckWho(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if reqData.TelegramID == 0 {
-		h.sendErrorResponse(w, "Telegram ID обязателен", http.StatusBadRequest)
+		h.sendErrorResponse(w, r, "telegram_id_required", http.StatusBadRequest)
 		return
 	}
 
@@ -1140,7 +1145,7 @@ func (h *Handler) handleCheckWho(w http.ResponseWriter, r *http.Request) {
 	driver, err := h.CheckDriverExist(reqData.TelegramID)
 	if err != nil {
 		h.logger.Error("Failed to check driver existence", zap.Error(err))
-		h.sendErrorResponse(w, "Ошибка проверки водителя", http.StatusInternalServerError)
+		h.sendErrorResponse(w, r, "driver_check_failed", http.StatusInternalServerError)
 		return
 	}
 
@@ -1178,7 +1183,7 @@ func (h *Handler) handleCheckWho(w http.ResponseWriter, r *http.Request) {
 		h.logger.Info("Driver not found, treating as client", zap.Int64("telegram_id", reqData.TelegramID))
 	}
 
-	h.sendSuccessResponse(w, "Проверка выполнена", response)
+	h.sendSuccessResponse(w, r, "check_done", response)
 }
 
 // handleDriverUpdate handles driver profile updates
@@ -1198,7 +1203,7 @@ func (h *Handler) handleDriverUpdate(b *bot.Bot) http.HandlerFunc {
 		err := r.ParseMultipartForm(maxMemory)
 		if err != nil {
 			h.logger.Error("Failed to parse multipart form", zap.Error(err))
-			h.sendErrorResponse(w, "Ошибка обработки файлов", http.StatusBadRequest)
+			h.sendErrorResponse(w, r, "files_invalid", http.StatusBadRequest)
 			return
 		}
 
@@ -1206,7 +1211,7 @@ func (h *Handler) handleDriverUpdate(b *bot.Bot) http.HandlerFunc {
 		telegramIDStr := strings.TrimSpace(r.FormValue("telegramId"))
 		telegramID, err := strconv.ParseInt(telegramIDStr, 10, 64)
 		if err != nil || telegramID == 0 {
-			h.sendErrorResponse(w, "Неверный Telegram ID", http.StatusBadRequest)
+			h.sendErrorResponse(w, r, "invalid_telegram_id", http.StatusBadRequest)
 			return
 		}
 
@@ -1214,12 +1219,12 @@ func (h *Handler) handleDriverUpdate(b *bot.Bot) http.HandlerFunc {
 		existingDriver, err := h.CheckDriverExist(telegramID)
 		if err != nil {
 			h.logger.Error("Failed to check driver existence", zap.Error(err))
-			h.sendErrorResponse(w, "Ошибка проверки водителя", http.StatusInternalServerError)
+			h.sendErrorResponse(w, r, "driver_check_failed", http.StatusInternalServerError)
 			return
 		}
 
 		if existingDriver == nil {
-			h.sendErrorResponse(w, "Водитель не найден", http.StatusNotFound)
+			h.sendErrorResponse(w, r, "driver_not_found", http.StatusNotFound)
 			return
 		}
 
@@ -1229,13 +1234,21 @@ func (h *Handler) handleDriverUpdate(b *bot.Bot) http.HandlerFunc {
 
 		// Parse update data
 		updateData := h.parseDriverUpdateData(r, existingDriver)
+		updateData.Capacity, err = parseCapacityForm(func(key string) string {
+			return strings.TrimSpace(r.FormValue(key))
+		}, existingDriver.Capacity)
+		if err != nil {
+			h.sendError(w, r, err, http.StatusBadRequest)
+			return
+		}
+		updateData.Capacity.TruckType = updateData.TruckType
 
 		// Handle file uploads (only if new files are provided)
 		if _, _, err := r.FormFile("profilePhoto"); err == nil {
 			photoPath, err := h.saveFile(r, "profilePhoto", "./ava", telegramID, "profile")
 			if err != nil {
 				h.logger.Error("Failed to save profile photo", zap.Error(err))
-				h.sendErrorResponse(w, "Ошибка сохранения фото профиля", http.StatusInternalServerError)
+				h.sendErrorResponse(w, r, "profile_photo_save_failed", http.StatusInternalServerError)
 				return
 			}
 			updateData.ProfilePhoto = photoPath
@@ -1245,7 +1258,7 @@ func (h *Handler) handleDriverUpdate(b *bot.Bot) http.HandlerFunc {
 			frontPath, err := h.saveFile(r, "licenseFront", "./documents", telegramID, "license_front")
 			if err != nil {
 				h.logger.Error("Failed to save license front", zap.Error(err))
-				h.sendErrorResponse(w, "Ошибка сохранения водительского удостоверения", http.StatusInternalServerError)
+				h.sendErrorResponse(w, r, "license_save_failed", http.StatusInternalServerError)
 				return
 			}
 			updateData.LicenseFront = frontPath
@@ -1255,7 +1268,7 @@ func (h *Handler) handleDriverUpdate(b *bot.Bot) http.HandlerFunc {
 			backPath, err := h.saveFile(r, "licenseBack", "./documents", telegramID, "license_back")
 			if err != nil {
 				h.logger.Error("Failed to save license back", zap.Error(err))
-				h.sendErrorResponse(w, "Ошибка сохранения водительского удостоверения", http.StatusInternalServerError)
+				h.sendErrorResponse(w, r, "license_save_failed", http.StatusInternalServerError)
 				return
 			}
 			updateData.LicenseBack = backPath
@@ -1265,7 +1278,7 @@ func (h *Handler) handleDriverUpdate(b *bot.Bot) http.HandlerFunc {
 		err = h.updateDriverInDatabase(updateData)
 		if err != nil {
 			h.logger.Error("Failed to update driver", zap.Error(err))
-			h.sendErrorResponse(w, "Ошибка обновления данных", http.StatusInternalServerError)
+			h.sendErrorResponse(w, r, "update_failed", http.StatusInternalServerError)
 			return
 		}
 
@@ -1274,7 +1287,7 @@ func (h *Handler) handleDriverUpdate(b *bot.Bot) http.HandlerFunc {
 		// Send notification
 		go h.sendDriverUpdateNotification(b, updateData)
 
-		h.sendSuccessResponse(w, "Данные успешно обновлены", map[string]interface{}{
+		h.sendSuccessResponse(w, r, "driver_updated", map[string]interface{}{
 			"driver_id": updateData.ID,
 			"status":    "updated",
 		})
@@ -1363,15 +1376,20 @@ func (h *Handler) updateDriverInDatabase(driver *DriverRegistration) error {
 			first_name = ?, last_name = ?, contact_number = ?,
 			start_city = ?, latitude = ?, longitude = ?,
 			truck_type = ?, truck_number = ?,
-			profile_photo = ?, license_front = ?, license_back = ?
+			profile_photo = ?, license_front = ?, license_back = ?,
+			max_weight_kg = ?, max_volume_m3 = ?,
+			body_length_cm = ?, body_width_cm = ?, body_height_cm = ?
 		WHERE id = ?`
 
+	c := driver.Capacity
 	_, err := h.db.Exec(
 		query,
 		driver.FirstName, driver.LastName, driver.ContactNumber,
 		driver.StartCity, driver.Latitude, driver.Longitude,
 		driver.TruckType, driver.TruckNumber,
 		driver.ProfilePhoto, driver.LicenseFront, driver.LicenseBack,
+		c.MaxWeightKg, c.MaxVolumeM3,
+		c.BodyLengthCm, c.BodyWidthCm, c.BodyHeightCm,
 		driver.ID,
 	)
 	return err
@@ -1383,24 +1401,15 @@ func (h *Handler) sendDriverUpdateNotification(b *bot.Bot, driver *DriverRegistr
 		return
 	}
 
-	message := fmt.Sprintf(`🔄 <b>Профиль жаңартылды!</b>
-
-👤 <b>Аты-жөні:</b> %s %s
-📱 <b>Байланыс:</b> %s
-🏙️ <b>Жұмыс қаласы:</b> %s
-
-✅ Сіздің деректеріңіз сәтті жаңартылды!
-📋 Статус: %s
-
-🚗 Тапсырыстар алуды жалғастыра аласыз.`,
-		driver.FirstName,
-		driver.LastName,
-		driver.ContactNumber,
-		driver.StartCity,
+	ctx := context.Background()
+	message := i18n.T(h.userLocale(ctx, driver.TelegramID), "bot.driver.updated",
+		html.EscapeString(driver.FirstName),
+		html.EscapeString(driver.LastName),
+		html.EscapeString(driver.ContactNumber),
+		html.EscapeString(driver.StartCity),
 		driver.Status,
 	)
 
-	ctx := context.Background()
 	_, err := b.SendMessage(ctx, &bot.SendMessageParams{
 		ChatID:    driver.TelegramID,
 		Text:      message,
@@ -1575,16 +1584,25 @@ func (h *Handler) serveOffertaView(w http.ResponseWriter, r *http.Request) {
 
 func (h *Handler) SetBot(b *bot.Bot) {
 	h.bot = b
+	h.notifier.Register(notifier.NewTelegram(b))
 }
 
 // Updated StartWebServer function with welcome page as default
 func (h *Handler) StartWebServer(ctx context.Context, b *bot.Bot) {
 	go h.ChangeDriverStatus(ctx, b)
+	go h.ExpireStaleOrders(ctx, b)
+	go h.DispatchScheduledOrders(ctx, b)
+	go h.ExpireBids(ctx)
+	go h.RecomputeSurge(ctx)
+	go h.ExpireSavedSearches(ctx)
+	go h.ExpireTripBookings(ctx, b)
+	go h.ExpireDriverTrips(ctx, b)
 
 	r := mux.NewRouter()
 	h.SetBot(b)
 
 	r.Use(h.corsMiddleware)
+	r.Use(h.localeMiddleware)
 
 	// Serve static files
 	r.PathPrefix("/static/").Handler(http.StripPrefix("/static/", http.FileServer(http.Dir("./static/"))))
@@ -1618,6 +1636,7 @@ func (h *Handler) StartWebServer(ctx context.Context, b *bot.Bot) {
 	r.HandleFunc("/delivery-list", h.deliveryListHandler).Methods("GET")
 	r.HandleFunc("/main-client", h.mainClientHandler).Methods("GET")
 	r.HandleFunc("/user-history", h.userHistoryHandler).Methods("GET")
+	r.HandleFunc("/t/{token}", h.trackingPageHandler).Methods("GET")
 
 	r.HandleFunc("/live", h.liveHandler).Methods("GET")
 	r.HandleFunc("/ws/live-chat", h.LiveChatWS)
@@ -1638,13 +1657,40 @@ func (h *Handler) StartWebServer(ctx context.Context, b *bot.Bot) {
 	r.HandleFunc("/api/admin/drivers", h.handleAdminDrivers).Methods("GET", "OPTIONS")
 	r.HandleFunc("/api/admin/drivers/{id}", h.handleAdminDriverDetail).Methods("GET", "OPTIONS")
 	r.HandleFunc("/api/admin/orders", h.handleAdminOrders).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/admin/orders/{id}/reset-code", h.handleAdminResetConfirmCode(b)).Methods("POST", "OPTIONS")
+	r.HandleFunc("/api/admin/orders/{id}/complete", h.handleAdminCompleteDelivery(b)).Methods("POST", "OPTIONS")
 	r.HandleFunc("/api/admin/drivers/{id}/message", h.SendDriverMessage).Methods("POST", "OPTIONS") // ⬅️ ADD THIS
 	r.HandleFunc("/api/admin/drivers/{id}/reject", h.RejectDriver).Methods("POST", "OPTIONS")
 	r.HandleFunc("/api/admin/drivers/{id}/unblock", h.UnblockDriver).Methods("POST", "OPTIONS")
+	r.HandleFunc("/api/admin/complaints", h.handleAdminComplaints).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/admin/complaints/{id}", h.handleAdminComplaintDetail).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/admin/complaints/{id}/assign", h.handleAdminAssignComplaint).Methods("POST", "OPTIONS")
+	r.HandleFunc("/api/admin/complaints/{id}/comment", h.handleAdminCommentComplaint).Methods("POST", "OPTIONS")
+	r.HandleFunc("/api/admin/complaints/{id}/resolve", h.handleAdminResolveComplaint).Methods("POST", "OPTIONS")
+	r.HandleFunc("/api/tariff/quote", h.handleTariffQuote).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/price/suggest", h.handleSuggestPrice).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/surge/zones", h.handleSurgeZones).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/map/heatmap", h.handleHeatmap).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/map/live", h.handleLiveMap).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/admin/tariffs", h.handleAdminTariffs).Methods("GET", "POST", "OPTIONS")
+	r.HandleFunc("/api/admin/tariffs/{id}/delete", h.handleAdminDeleteTariff).Methods("POST", "OPTIONS")
 
 	// Delivery list routes
 	r.HandleFunc("/api/delivery-list", h.handleDeliveryList).Methods("POST", "OPTIONS")
 	r.HandleFunc("/api/driver/accept-order", h.handleDriverAcceptOrder(b)).Methods("POST", "OPTIONS")
+	r.HandleFunc("/api/driver/stop-done", h.handleDriverStopDone(b)).Methods("POST", "OPTIONS")
+	r.HandleFunc("/api/driver/pickup", h.handleDriverPickup(b)).Methods("POST", "OPTIONS")
+	r.HandleFunc("/api/driver/deliver", h.handleDriverDeliver(b)).Methods("POST", "OPTIONS")
+	r.HandleFunc("/api/order/proofs", h.handleOrderProofs).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/driver/bid", h.handleDriverBid(b)).Methods("POST", "OPTIONS")
+	r.HandleFunc("/api/driver/cancel", h.handleDriverCancel(b)).Methods("POST", "OPTIONS")
+	r.HandleFunc("/api/driver/location", h.handleDriverLocation).Methods("POST", "OPTIONS")
+	r.HandleFunc("/api/user/bids", h.handleOrderBids).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/user/accept-bid", h.handleAcceptBid(b)).Methods("POST", "OPTIONS")
+	r.HandleFunc("/api/review", h.handleSubmitReview).Methods("POST", "OPTIONS")
+	r.HandleFunc("/api/driver/reviews", h.handleDriverReviews).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/complaint", h.handleFileComplaint).Methods("POST", "OPTIONS")
+	r.HandleFunc("/api/complaints", h.handleUserComplaints).Methods("GET", "OPTIONS")
 
 	// Driver matching routes
 	r.HandleFunc("/driver-list", h.handleDriverList).Methods("GET")
@@ -1653,6 +1699,39 @@ func (h *Handler) StartWebServer(ctx context.Context, b *bot.Bot) {
 	r.HandleFunc("/api/driver-request", h.handleDriverRequest).Methods("POST", "OPTIONS")
 	// Add this line after the user history route
 	r.HandleFunc("/api/user/cancel-order", h.handleUserCancelOrder).Methods("POST", "OPTIONS")
+	r.HandleFunc("/api/user/update-order", h.handleUserUpdateOrder(ctx, b)).Methods("POST", "OPTIONS")
+	r.HandleFunc("/api/user/order-revisions", h.handleOrderRevisions).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/user/tracking-link", h.handleTrackingLink).Methods("POST", "OPTIONS")
+	r.HandleFunc("/api/user/tracking-link/revoke", h.handleRevokeTrackingLink).Methods("POST", "OPTIONS")
+	r.HandleFunc("/api/track/{token}", h.handleTrackingAPI).Methods("GET")
+	r.HandleFunc("/api/user/profile", h.handleUserProfile).Methods("GET", "POST", "OPTIONS")
+	r.HandleFunc("/api/user/addresses", h.handleSaveAddress).Methods("POST", "OPTIONS")
+	r.HandleFunc("/api/user/addresses/delete", h.handleDeleteAddress).Methods("POST", "OPTIONS")
+	r.HandleFunc("/api/user/favorites", h.handleAddFavoriteDriver).Methods("POST", "OPTIONS")
+	r.HandleFunc("/api/user/favorites/remove", h.handleRemoveFavoriteDriver).Methods("POST", "OPTIONS")
+
+	r.HandleFunc("/api/user/repeat-order", h.handleRepeatOrder(ctx, b)).Methods("POST", "OPTIONS")
+	r.HandleFunc("/api/user/templates", h.handleTemplates).Methods("GET", "POST", "OPTIONS")
+	r.HandleFunc("/api/user/templates/delete", h.handleDeleteTemplate).Methods("POST", "OPTIONS")
+	r.HandleFunc("/api/user/templates/order", h.handleOrderFromTemplate(ctx, b)).Methods("POST", "OPTIONS")
+	r.HandleFunc("/api/user/recurring", h.handleRecurring).Methods("GET", "POST", "OPTIONS")
+	r.HandleFunc("/api/user/recurring/status", h.handleRecurringStatus).Methods("POST", "OPTIONS")
+	r.HandleFunc("/api/user/saved-searches", h.handleSavedSearches).Methods("GET", "POST", "OPTIONS")
+	r.HandleFunc("/api/user/saved-searches/delete", h.handleDeleteSavedSearch).Methods("POST", "OPTIONS")
+	r.HandleFunc("/api/trip/book", h.handleBookTrip(b)).Methods("POST", "OPTIONS")
+	r.HandleFunc("/api/trip/bookings", h.handleTripBookings).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/trip/bookings/cancel", h.handleCancelTripBooking(b)).Methods("POST", "OPTIONS")
+	r.HandleFunc("/api/driver/trip-orders", h.handleDriverTripOrders).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/driver/trip/update", h.handleDriverTripUpdate(b)).Methods("POST", "OPTIONS")
+	r.HandleFunc("/api/driver/trip/cancel", h.handleDriverTripClose(b, domain.TripStatusCancelled)).Methods("POST", "OPTIONS")
+	r.HandleFunc("/api/driver/trip/complete", h.handleDriverTripClose(b, domain.TripStatusCompleted)).Methods("POST", "OPTIONS")
+	r.HandleFunc("/api/driver/trip/repost", h.handleDriverTripRepost(b)).Methods("POST", "OPTIONS")
+	r.HandleFunc("/api/user/notifications", h.handleUserNotifications).Methods("GET", "OPTIONS")
+	r.HandleFunc("/api/user/notifications/settings", h.handleNotificationSettings).Methods("GET", "POST", "OPTIONS")
+	r.HandleFunc("/api/admin/notifications", h.handleAdminNotifications).Methods("GET", "OPTIONS")
+	if !h.cfg.IsProduction() {
+		r.HandleFunc("/stub/sms", h.handleSMSStub).Methods("POST")
+	}
 
 	// Health check
 	r.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
@@ -1799,18 +1878,18 @@ func (h *Handler) handleUserHistory(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if telegramID == 0 {
-		h.sendErrorResponse(w, "Telegram ID обязателен", http.StatusBadRequest)
+		h.sendErrorResponse(w, r, "telegram_id_required", http.StatusBadRequest)
 		return
 	}
 
 	orders, err := h.getUserOrders(telegramID)
 	if err != nil {
 		h.logger.Error("Failed to get user orders", zap.Error(err))
-		h.sendErrorResponse(w, "Ошибка получения истории заказов", http.StatusInternalServerError)
+		h.sendErrorResponse(w, r, "order_history_load_failed", http.StatusInternalServerError)
 		return
 	}
 
-	h.sendSuccessResponse(w, "История заказов получена", map[string]interface{}{
+	h.sendSuccessResponse(w, r, "order_history_loaded", map[string]interface{}{
 		"orders": orders,
 		"count":  len(orders),
 	})
@@ -1828,7 +1907,7 @@ func (h *Handler) handleDeliveryList(w http.ResponseWriter, r *http.Request) {
 	var reqData DeliveryListRequest
 	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
 		h.logger.Error("Failed to parse request body", zap.Error(err))
-		h.sendErrorResponse(w, "Неверные данные запроса", http.StatusBadRequest)
+		h.sendErrorResponse(w, r, "invalid_request", http.StatusBadRequest)
 		return
 	}
 
@@ -1868,13 +1947,20 @@ func (h *Handler) handleDeliveryList(w http.ResponseWriter, r *http.Request) {
 		reqData.Radius = 50 // Increased default radius
 	}
 
+	pickupFrom, pickupTo, err := h.parsePickupWindow(reqData.PickupFrom, reqData.PickupTo)
+	if err != nil {
+		h.sendError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
 	// Get delivery orders within radius
 	orders, err := h.getDeliveryOrdersInRadius(r.Context(), reqData.DriverLat, reqData.DriverLon, reqData.Radius)
 	if err != nil {
 		h.logger.Error("Failed to get delivery orders", zap.Error(err))
-		h.sendErrorResponse(w, "Ошибка получения заказов", http.StatusInternalServerError)
+		h.sendErrorResponse(w, r, "orders_load_failed", http.StatusInternalServerError)
 		return
 	}
+	orders = filterByPickupWindow(orders, pickupFrom, pickupTo)
 
 	// Calculate statistics with more detailed logging
 	nearbyCount := 0
@@ -1908,6 +1994,7 @@ func (h *Handler) handleDeliveryList(w http.ResponseWriter, r *http.Request) {
 		TotalCount:  len(orders),
 		NearbyCount: nearbyCount,
 		AvgPrice:    avgPrice,
+		HighDemand:  h.highDemandNear(r.Context(), reqData.DriverLat, reqData.DriverLon, reqData.Radius),
 	}
 
 	h.logger.Info("Delivery orders response prepared",
@@ -1915,7 +2002,7 @@ func (h *Handler) handleDeliveryList(w http.ResponseWriter, r *http.Request) {
 		zap.Int("nearby_orders", nearbyCount),
 		zap.Float64("avg_price", avgPrice))
 
-	h.sendSuccessResponse(w, "Заказы получены", response)
+	h.sendSuccessResponse(w, r, "orders_loaded", response)
 }
 
 // helper: привести путь фото к публичному URL
@@ -1970,10 +2057,14 @@ SELECT
   COALESCE(comment, ''),      -- может быть NULL
   COALESCE(item_photo_path, ''), -- <-- ключевая правка
   status,
-  created_at
+  pickup_at,
+  created_at,
+  ` + cargoColumns + `
 FROM delivery_requests
 WHERE status = 'pending'
-  AND created_at >= datetime('now', '-72 hours')
+  AND dispatched_at IS NOT NULL -- отложенные заявки ещё не видны водителям
+  AND dispatched_at >= datetime('now', '-72 hours')
+  AND (preferred_driver_id IS NULL OR preferred_until <= datetime('now')) -- пока ждём избранного водителя
 ORDER BY created_at DESC
 LIMIT 200;
 `
@@ -1990,16 +2081,21 @@ LIMIT 200;
 
 	for rows.Next() {
 		var order domain.DeliveryRequest
-		err := rows.Scan(
+		var pickupAt sql.NullTime
+		dest := []interface{}{
 			&order.ID, &order.TelegramID, &order.FromAddress, &order.FromLat, &order.FromLon,
 			&order.ToAddress, &order.ToLat, &order.ToLon, &order.DistanceKm, &order.EtaMin,
 			&order.Price, &order.TruckType, &order.Contact, &order.TimeStart, &order.Comment,
-			&order.CargoPhoto, &order.Status, &order.CreatedAt,
-		)
+			&order.CargoPhoto, &order.Status, &pickupAt, &order.CreatedAt,
+		}
+		err := rows.Scan(append(dest, cargoScanDest(&order.Cargo)...)...)
 		if err != nil {
 			h.logger.Error("Error scanning delivery order", zap.Error(err))
 			continue
 		}
+		if pickupAt.Valid {
+			order.PickupAt = &pickupAt.Time
+		}
 
 		ordersProcessed++
 
@@ -2085,12 +2181,12 @@ func (h *Handler) handleDriverAcceptOrder(b *bot.Bot) http.HandlerFunc {
 
 		if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
 			h.logger.Error("Failed to parse request body", zap.Error(err))
-			h.sendErrorResponse(w, "Неверные данные запроса", http.StatusBadRequest)
+			h.sendErrorResponse(w, r, "invalid_request", http.StatusBadRequest)
 			return
 		}
 
 		if reqData.TelegramID == 0 || reqData.OrderID == "" { // FIXED: Check for empty string
-			h.sendErrorResponse(w, "Telegram ID и Order ID обязательны", http.StatusBadRequest)
+			h.sendErrorResponse(w, r, "telegram_id_and_order_id_required", http.StatusBadRequest)
 			return
 		}
 
@@ -2098,17 +2194,26 @@ func (h *Handler) handleDriverAcceptOrder(b *bot.Bot) http.HandlerFunc {
 		driver, err := h.CheckDriverExist(reqData.TelegramID)
 		if err != nil {
 			h.logger.Error("Failed to check driver existence", zap.Error(err))
-			h.sendErrorResponse(w, "Ошибка проверки водителя", http.StatusInternalServerError)
+			h.sendErrorResponse(w, r, "driver_check_failed", http.StatusInternalServerError)
 			return
 		}
 
 		if driver == nil {
-			h.sendErrorResponse(w, "Водитель не найден", http.StatusNotFound)
+			h.sendErrorResponse(w, r, "driver_not_found", http.StatusNotFound)
 			return
 		}
 
 		if driver.Status != "approved" {
-			h.sendErrorResponse(w, "Водитель не одобрен", http.StatusForbidden)
+			h.sendErrorResponse(w, r, "driver_not_approved", http.StatusForbidden)
+			return
+		}
+
+		if err := h.checkDispatchCooldown(r.Context(), driver.ID); errors.Is(err, errDriverCooldown) {
+			h.sendErrorResponse(w, r, "driver_cooldown", http.StatusForbidden)
+			return
+		} else if err != nil {
+			h.logger.Error("Failed to check dispatch cooldown", zap.Error(err))
+			h.sendErrorResponse(w, r, "driver_check_failed", http.StatusInternalServerError)
 			return
 		}
 
@@ -2116,17 +2221,21 @@ func (h *Handler) handleDriverAcceptOrder(b *bot.Bot) http.HandlerFunc {
 		order, err := h.getDeliveryOrderById(reqData.OrderID)
 		if err != nil {
 			h.logger.Error("Failed to get order", zap.Error(err))
-			h.sendErrorResponse(w, "Ошибка получения заказа", http.StatusInternalServerError)
+			h.sendErrorResponse(w, r, "order_load_failed", http.StatusInternalServerError)
 			return
 		}
 
 		if order == nil {
-			h.sendErrorResponse(w, "Заказ не найден", http.StatusNotFound)
+			h.sendErrorResponse(w, r, "order_not_found", http.StatusNotFound)
 			return
 		}
 
 		if order.Status != "pending" {
-			h.sendErrorResponse(w, "Заказ уже принят другим водителем", http.StatusConflict)
+			h.sendErrorResponse(w, r, "order_taken", http.StatusConflict)
+			return
+		}
+		if order.InPreferredWindow(time.Now()) && order.PreferredDriverID != driver.ID {
+			h.sendErrorResponse(w, r, "order_reserved_for_favorite", http.StatusConflict)
 			return
 		}
 
@@ -2134,7 +2243,7 @@ func (h *Handler) handleDriverAcceptOrder(b *bot.Bot) http.HandlerFunc {
 		err = h.updateOrderStatus(reqData.OrderID, "pending", driver.ID)
 		if err != nil {
 			h.logger.Error("Failed to update order status", zap.Error(err))
-			h.sendErrorResponse(w, "Ошибка принятия заказа", http.StatusInternalServerError)
+			h.sendErrorResponse(w, r, "order_accept_failed", http.StatusInternalServerError)
 			return
 		}
 
@@ -2146,7 +2255,7 @@ func (h *Handler) handleDriverAcceptOrder(b *bot.Bot) http.HandlerFunc {
 		// Send notifications
 		go h.sendOrderAcceptedNotifications(b, order, driver)
 
-		h.sendSuccessResponse(w, "Заказ успешно принят", map[string]interface{}{
+		h.sendSuccessResponse(w, r, "order_accepted", map[string]interface{}{
 			"order_id":  reqData.OrderID,
 			"driver_id": driver.ID,
 			"status":    "accepted",
@@ -2161,17 +2270,22 @@ func (h *Handler) getDeliveryOrderById(orderID string) (*domain.DeliveryRequest,
 			id, telegram_id, from_address, from_lat, from_lon, 
 			to_address, to_lat, to_lon, distance_km, eta_min,
 			price, truck_type, contact, time_start, comment, 
-			status, created_at
+			COALESCE(item_photo_path, ''), status, pickup_at, dispatched_at, picked_up_at, created_at,
+			COALESCE(preferred_driver_id, ''), preferred_until,
+			` + cargoColumns + `
 		FROM delivery_requests 
 		WHERE id = ?`
 
 	var order domain.DeliveryRequest
-	err := h.db.QueryRow(query, orderID).Scan(
+	var pickupAt, dispatchedAt, pickedUpAt, preferredUntil sql.NullTime
+	dest := []interface{}{
 		&order.ID, &order.TelegramID, &order.FromAddress, &order.FromLat, &order.FromLon,
 		&order.ToAddress, &order.ToLat, &order.ToLon, &order.DistanceKm, &order.EtaMin,
 		&order.Price, &order.TruckType, &order.Contact, &order.TimeStart, &order.Comment,
-		&order.Status, &order.CreatedAt,
-	)
+		&order.CargoPhoto, &order.Status, &pickupAt, &dispatchedAt, &pickedUpAt, &order.CreatedAt,
+		&order.PreferredDriverID, &preferredUntil,
+	}
+	err := h.db.QueryRow(query, orderID).Scan(append(dest, cargoScanDest(&order.Cargo)...)...)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -2179,6 +2293,19 @@ func (h *Handler) getDeliveryOrderById(orderID string) (*domain.DeliveryRequest,
 		}
 		return nil, err
 	}
+	if pickupAt.Valid {
+		order.PickupAt = &pickupAt.Time
+	}
+	if dispatchedAt.Valid {
+		order.DispatchedAt = &dispatchedAt.Time
+	}
+	if pickedUpAt.Valid {
+		order.PickedUpAt = &pickedUpAt.Time
+	}
+	if preferredUntil.Valid {
+		order.PreferredUntil = &preferredUntil.Time
+	}
+	h.loadStops(context.Background(), &order)
 
 	return &order, nil
 }
@@ -2192,10 +2319,11 @@ func (h *Handler) updateOrderStatus(orderID string, status string, driverID stri
 		"in_progress": true,
 		"completed":   true,
 		"cancelled":   true,
+		"expired":     true,
 	}
 
 	if !allowedStatuses[status] {
-		return fmt.Errorf("invalid status '%s'. Allowed values: pending, matched, in_progress, completed, cancelled", status)
+		return fmt.Errorf("invalid status '%s'. Allowed values: pending, matched, in_progress, completed, cancelled, expired", status)
 	}
 
 	var query string
@@ -2204,7 +2332,7 @@ func (h *Handler) updateOrderStatus(orderID string, status string, driverID stri
 	if driverID != "" && driverID != "0" { // FIXED: Proper string comparison
 		query = `
 			UPDATE delivery_requests 
-			SET status = ?, driver_id = ?, updated_at = CURRENT_TIMESTAMP
+			SET status = ?, driver_id = ?, accepted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
 			WHERE id = ? AND status = 'pending'`
 		args = []interface{}{status, driverID, orderID}
 
@@ -2243,30 +2371,19 @@ func (h *Handler) updateOrderStatus(orderID string, status string, driverID stri
 func (h *Handler) sendOrderAcceptedNotifications(b *bot.Bot, order *domain.DeliveryRequest, driver *DriverRegistration) {
 	// Send notification to client
 	if order.TelegramID != 0 {
-		// FIXED: Using %s for string UUID
-		clientMessage := fmt.Sprintf(`🚚 Сіздің тапсырысыңыз қабылданды! 🎉
-
-📋 Тапсырыс: #%s
-
-👤 Жүргізуші: %s %s
-📱 Байланыс: %s
-
-📍 Қайдан: %s
-🎯 Қайда: %s
-
-💰 Бағасы: %d ₸
-
-✅ Жүргізуші сізбен жақын арада байланысады! 😊`,
-			order.ID, // FIXED: Using %s for string UUID
+		ctx := context.Background()
+		lang := h.userLocale(ctx, order.TelegramID)
+		clientMessage := i18n.T(lang, "bot.order.accepted_client",
+			order.ID,
 			driver.FirstName,
 			driver.LastName,
 			driver.ContactNumber,
 			order.FromAddress,
 			order.ToAddress,
+			formatStopsText(lang, order, false),
 			order.Price,
 		)
 
-		ctx := context.Background()
 		_, err := b.SendMessage(ctx, &bot.SendMessageParams{
 			ChatID: order.TelegramID,
 			Text:   clientMessage,
@@ -2285,48 +2402,20 @@ func (h *Handler) sendOrderAcceptedNotifications(b *bot.Bot, order *domain.Deliv
 
 	// Send notification to driver
 	if driver.TelegramID != 0 {
-		// FIXED: Using %s for string UUID
-		driverMessage := fmt.Sprintf(`✅ Тапсырыс қабылданды! 🎊
-
-📋 Тапсырыс: #%s
-
-📍 Қайдан: %s
-🎯 Қайда: %s
-
-💰 Бағасы: %d ₸
-📱 Клиент: %s
-
-🚚 Толық мәліметтер үшін клиентпен байланысыңыз! 💪`,
-			order.ID, // FIXED: Using %s for string UUID
+		ctx := context.Background()
+		lang := h.userLocale(ctx, driver.TelegramID)
+		driverMessage := i18n.T(lang, "bot.order.accepted_driver",
+			order.ID,
 			order.FromAddress,
 			order.ToAddress,
+			formatStopsText(lang, order, true),
 			order.Price,
 			order.Contact,
 		)
 
-		var onlyDigits func(s string) string
-		onlyDigits = func(s string) string {
-			var b strings.Builder
-			for i := 0; i < len(s); i++ {
-				r := rune(s[i])
-				if r >= '0' && r <= '9' {
-					b.WriteRune(r)
-				}
-			}
-			return b.String()
-		}
+		// кнопки связи с клиентом; для многоточечной заявки — кнопки отметки точек
+		keyboard := acceptedOrderKeyboard(lang, order)
 
-		// Add contact buttons in Kazakh
-		keyboard := &models.InlineKeyboardMarkup{
-			InlineKeyboard: [][]models.InlineKeyboardButton{
-				{
-					{Text: "📞 Қоңырау шалу", URL: "tel:" + order.Contact},
-					{Text: "💬 WhatsApp", URL: "https://wa.me/" + onlyDigits(order.Contact)},
-				},
-			},
-		}
-
-		ctx := context.Background()
 		_, err := b.SendMessage(ctx, &bot.SendMessageParams{
 			ChatID:      driver.TelegramID,
 			Text:        driverMessage,
@@ -2355,6 +2444,9 @@ func (h *Handler) findDriversByPointA(params DriverRequestParams) ([]MatchedDriv
 			dr.id as route_id, dr.from_address, dr.to_address, 
 			dr.from_lat, dr.from_lon, dr.to_lat, dr.to_lon,
 			dr.price, dr.departure_time, dr.comment, dr.truck_photo,
+			COALESCE(dr.max_weight, 0),
+			d.max_weight_kg, d.max_volume_m3, d.body_length_cm, d.body_width_cm, d.body_height_cm,
+			COALESCE(d.rating, 0), COALESCE(d.rating_count, 0), COALESCE(d.cancel_count, 0),
 			(6371 * acos(
 				cos(radians(?)) * cos(radians(dr.from_lat)) * 
 				cos(radians(dr.from_lon) - radians(?)) + 
@@ -2386,12 +2478,17 @@ func (h *Handler) findDriversByPointA(params DriverRequestParams) ([]MatchedDriv
 	var drivers []MatchedDriver
 	for rows.Next() {
 		var driver MatchedDriver
+		var tripMaxWeight int
+		var vehicle domain.VehicleCapacity
 		err := rows.Scan(
 			&driver.ID, &driver.TelegramID, &driver.FirstName, &driver.LastName,
 			&driver.ContactNumber, &driver.TruckType, &driver.ProfilePhoto, &driver.IsVerified,
 			&driver.RouteID, &driver.FromAddress, &driver.ToAddress,
 			&driver.FromLat, &driver.FromLon, &driver.ToLat, &driver.ToLon,
 			&driver.Price, &driver.DepartureTime, &driver.Comment, &driver.TruckPhoto,
+			&tripMaxWeight,
+			&vehicle.MaxWeightKg, &vehicle.MaxVolumeM3, &vehicle.BodyLengthCm, &vehicle.BodyWidthCm, &vehicle.BodyHeightCm,
+			&driver.Rating, &driver.RatingCount, &driver.CancelCount,
 			&driver.DistanceToPickupKm)
 
 		if err != nil {
@@ -2399,6 +2496,7 @@ func (h *Handler) findDriversByPointA(params DriverRequestParams) ([]MatchedDriv
 			continue
 		}
 
+		driver.Capacity = tripCapacity(strings.ToLower(strings.TrimSpace(driver.TruckType)), tripMaxWeight, vehicle)
 		drivers = append(drivers, driver)
 	}
 
@@ -2409,17 +2507,17 @@ func (h *Handler) findDriversByPointA(params DriverRequestParams) ([]MatchedDriv
 	return drivers, nil
 }
 
-// Filter drivers by truck type and price compatibility
+// Filter drivers by vehicle capacity and price compatibility
 func (h *Handler) filterDriversByCompatibility(drivers []MatchedDriver, params DriverRequestParams) []MatchedDriver {
 	var compatible []MatchedDriver
+	orderType := strings.ToLower(strings.TrimSpace(params.TruckType))
 
 	for _, driver := range drivers {
-		// Truck type compatibility
-		if params.TruckType != "" && params.TruckType != "any" {
-			if driver.TruckType != params.TruckType {
-				continue
-			}
+		// Capacity compatibility: вместо точного совпадения truck_type
+		if !driver.Capacity.CanCarry(orderType, params.Cargo) {
+			continue
 		}
+		driver.CapacityFit = driver.Capacity.FitScore(orderType, params.Cargo)
 
 		// Price compatibility (driver price should be reasonable)
 		if params.PriceRange > 0 {
@@ -2456,8 +2554,12 @@ func (h *Handler) rankDriversByRouteMatch(drivers []MatchedDriver, params Driver
 		pickupScore := h.calculateProximityScore(driver.DistanceToPickupKm)
 		dropoffScore := h.calculateProximityScore(driver.DistanceToDropoffKm)
 
-		// Point A (pickup) is weighted more heavily (70% vs 30%)
-		driver.RouteMatchScore = int(pickupScore*0.7 + dropoffScore*0.3)
+		ratingScore := domain.EffectiveRating(driver.Rating, driver.RatingCount) / 5 * 100
+
+		// Point A (pickup) is weighted most, then drop-off, then rating and how well the cargo fits the truck;
+		// cancelled orders cost points
+		driver.RouteMatchScore = int(pickupScore*0.55 + dropoffScore*0.2 + ratingScore*0.15 + driver.CapacityFit*100*0.1 -
+			cancelPenalty(driver.CancelCount))
 
 		// Determine match quality
 		if driver.DistanceToPickupKm <= 2.0 && driver.DistanceToDropoffKm <= 5.0 {
@@ -2501,12 +2603,12 @@ func (h *Handler) handleUserCancelOrder(w http.ResponseWriter, r *http.Request)
 
 	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
 		h.logger.Error("Failed to parse request body", zap.Error(err))
-		h.sendErrorResponse(w, "Неверные данные запроса", http.StatusBadRequest)
+		h.sendErrorResponse(w, r, "invalid_request", http.StatusBadRequest)
 		return
 	}
 
 	if reqData.TelegramID == 0 || reqData.OrderID == "" {
-		h.sendErrorResponse(w, "Telegram ID и Order ID обязательны", http.StatusBadRequest)
+		h.sendErrorResponse(w, r, "telegram_id_and_order_id_required", http.StatusBadRequest)
 		return
 	}
 
@@ -2518,24 +2620,24 @@ func (h *Handler) handleUserCancelOrder(w http.ResponseWriter, r *http.Request)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			h.sendErrorResponse(w, "Заказ не найден", http.StatusNotFound)
+			h.sendErrorResponse(w, r, "order_not_found", http.StatusNotFound)
 			return
 		}
 		h.logger.Error("Failed to query order", zap.Error(err))
-		h.sendErrorResponse(w, "Ошибка проверки заказа", http.StatusInternalServerError)
+		h.sendErrorResponse(w, r, "order_check_failed", http.StatusInternalServerError)
 		return
 	}
 
 	// Verify ownership
 	if orderTelegramID != reqData.TelegramID {
-		h.sendErrorResponse(w, "Нет доступа к этому заказу", http.StatusForbidden)
+		h.sendErrorResponse(w, r, "order_access_denied", http.StatusForbidden)
 		return
 	}
 
 	// Check if order can be cancelled
 	currentStatus = strings.ToLower(currentStatus)
 	if currentStatus == "completed" || currentStatus == "cancelled" {
-		h.sendErrorResponse(w, "Этот заказ нельзя отменить", http.StatusConflict)
+		h.sendErrorResponse(w, r, "order_not_cancellable", http.StatusConflict)
 		return
 	}
 
@@ -2548,21 +2650,26 @@ func (h *Handler) handleUserCancelOrder(w http.ResponseWriter, r *http.Request)
 	result, err := h.db.Exec(updateQuery, reqData.OrderID, reqData.TelegramID)
 	if err != nil {
 		h.logger.Error("Failed to cancel order", zap.Error(err))
-		h.sendErrorResponse(w, "Ошибка отмены заказа", http.StatusInternalServerError)
+		h.sendErrorResponse(w, r, "order_cancel_failed", http.StatusInternalServerError)
 		return
 	}
 
 	rowsAffected, _ := result.RowsAffected()
 	if rowsAffected == 0 {
-		h.sendErrorResponse(w, "Заказ не найден или уже отменён", http.StatusNotFound)
+		h.sendErrorResponse(w, r, "order_not_found_or_cancelled", http.StatusNotFound)
 		return
 	}
 
+	// Заявка из рейса освобождает место под другие брони
+	if err := h.releaseTripSpace(r.Context(), reqData.OrderID); err != nil {
+		h.logger.Error("Failed to release trip space", zap.String("order_id", reqData.OrderID), zap.Error(err))
+	}
+
 	h.logger.Info("Order cancelled successfully",
 		zap.String("order_id", reqData.OrderID),
 		zap.Int64("telegram_id", reqData.TelegramID))
 
-	h.sendSuccessResponse(w, "Заказ успешно отменён", map[string]interface{}{
+	h.sendSuccessResponse(w, r, "order_cancelled", map[string]interface{}{
 		"order_id": reqData.OrderID,
 		"status":   "cancelled",
 	})
@@ -2638,12 +2745,12 @@ func (h *Handler) findMatchingDrivers(params DriverRequestParams) ([]DriverWithT
 		INNER JOIN driver_trips dt ON d.telegram_id = dt.telegram_id
 		WHERE d.status = 'approved' 
 		AND dt.status = 'active'
-		AND dt.created_at >= datetime('now', '-24 hours')
+		AND dt.departure_time > ?
 		AND dt.from_lat != 0 AND dt.from_lon != 0
 		ORDER BY dt.created_at DESC
 		LIMIT 100`
 
-	rows, err := h.db.Query(query)
+	rows, err := h.db.Query(query, h.tripExpiryCutoff())
 	if err != nil {
 		h.logger.Error("Database query failed", zap.Error(err))
 		return nil, err
@@ -2756,27 +2863,6 @@ func (h *Handler) handleDriverList(w http.ResponseWriter, r *http.Request) {
 	http.ServeFile(w, r, path)
 }
 
-// updateExpiredTrips marks old trips as completed
-func (h *Handler) updateExpiredTrips() {
-	// Mark trips older than 12 hours as completed
-	query := `
-		UPDATE driver_trips 
-		SET status = 'completed' 
-		WHERE status = 'active' 
-		AND created_at < datetime('now', '-12 hours')`
-
-	result, err := h.db.Exec(query)
-	if err != nil {
-		h.logger.Error("Failed to update expired trips", zap.Error(err))
-		return
-	}
-
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected > 0 {
-		h.logger.Info("Updated expired trips", zap.Int64("count", rowsAffected))
-	}
-}
-
 // haversineDistance calculates the distance between two points on Earth
 func (h *Handler) haversineDistance(lat1, lon1, lat2, lon2 float64) float64 {
 	const earthRadius = 6371 // Earth's radius in kilometers
@@ -2815,9 +2901,47 @@ func (h *Handler) calculateProximityScore(distanceKm float64) float64 {
 
 // DefaultHandler for Telegram bot to use welcome page
 func (h *Handler) DefaultHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if update.CallbackQuery != nil {
+		h.handleCallbackQuery(ctx, b, update.CallbackQuery)
+		return
+	}
+	if update.EditedMessage != nil && update.EditedMessage.Location != nil {
+		h.saveDriverLocationMessage(ctx, b, update.EditedMessage, true)
+		return
+	}
 	if update.Message == nil {
 		return
 	}
+	// язык ответов: профиль клиента, иначе language_code из Telegram
+	ctx = i18n.WithLocale(ctx, h.senderLocale(ctx, update.Message.From))
+
+	if update.Message.Location != nil && h.saveDriverLocationMessage(ctx, b, update.Message, false) {
+		return
+	}
+	if update.Message.ReplyToMessage != nil && h.saveDriverSettingsReply(ctx, b, update.Message) {
+		return
+	}
+	if update.Message.ReplyToMessage != nil && h.saveTripEditReply(ctx, b, update.Message) {
+		return
+	}
+	if update.Message.ReplyToMessage != nil && h.saveCancelCommentReply(ctx, b, update.Message) {
+		return
+	}
+	if update.Message.ReplyToMessage != nil && h.saveReviewComment(ctx, b, update.Message) {
+		return
+	}
+	if strings.HasPrefix(update.Message.Text, "/recurring") {
+		h.sendRecurringList(ctx, b, update.Message.Chat.ID)
+		return
+	}
+	if strings.HasPrefix(update.Message.Text, "/settings") {
+		h.sendDriverSettings(ctx, b, update.Message.Chat.ID)
+		return
+	}
+	if strings.HasPrefix(update.Message.Text, "/trips") {
+		h.sendDriverOpenTrips(ctx, b, update.Message.Chat.ID)
+		return
+	}
 
 	var userID int64
 	if update.Message != nil {
@@ -2837,9 +2961,15 @@ func (h *Handler) DefaultHandler(ctx context.Context, b *bot.Bot, update *models
 			UserId:         userID,
 			UserName:       update.Message.From.FirstName,
 			DateRegistered: timeNow,
+			LanguageCode:   update.Message.From.LanguageCode,
 		}); errIn != nil {
 			h.logger.Error("Failed to insert user", zap.Error(err))
 		}
+	} else if code := update.Message.From.LanguageCode; code != "" {
+		// язык в Telegram могли сменить — уведомления пойдут на новом
+		if err := h.userRepo.SetJustLanguage(ctx, userID, code); err != nil {
+			h.logger.Warn("Failed to update user language", zap.Int64("user_id", userID), zap.Error(err))
+		}
 	}
 
 	var keyboard interface{}
@@ -2848,7 +2978,7 @@ func (h *Handler) DefaultHandler(ctx context.Context, b *bot.Bot, update *models
 			InlineKeyboard: [][]models.InlineKeyboardButton{
 				{
 					{
-						Text:   "🚀 Ашу | Открыть QazLine",
+						Text:   tr(ctx, "bot.welcome.open", "QazLine"),
 						WebApp: &models.WebAppInfo{URL: h.cfg.BaseURL + "/welcome"},
 					},
 					{
@@ -2864,7 +2994,7 @@ func (h *Handler) DefaultHandler(ctx context.Context, b *bot.Bot, update *models
 			InlineKeyboard: [][]models.InlineKeyboardButton{
 				{
 					{
-						Text:   "🚀 Ашу | Открыть Alash-Go",
+						Text:   tr(ctx, "bot.welcome.open", "Alash-Go"),
 						WebApp: &models.WebAppInfo{URL: h.cfg.BaseURL + "/welcome"},
 					},
 				},
@@ -2872,16 +3002,9 @@ func (h *Handler) DefaultHandler(ctx context.Context, b *bot.Bot, update *models
 		}
 	}
 
-	// Bilingual welcome message
-	message := `AlashGo — Қазақстанға арналған заманауи цифрлық платформа 🚀
-Қызмет табу, тапсырыс беру, жүргізушілермен не клиенттермен байланысу — барлығы бір жерде.
-
-Біздің мақсат — сервисті жеңіл, жылдам жəне қолжетімді ету.
-AlashGo — ұлттық стильдегі жаңа буын сервисі 🇰🇿✨`
-
 	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
 		ChatID:      update.Message.From.ID,
-		Text:        message,
+		Text:        tr(ctx, "bot.welcome.text"),
 		ReplyMarkup: keyboard,
 	})
 
@@ -0,0 +1,94 @@
+// locale-handler.go
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"tezjet/internal/i18n"
+
+	"github.com/go-telegram/bot/models"
+	"go.uber.org/zap"
+)
+
+// localeMiddleware выбирает язык ответа: ?lang=, язык пользователя по ?telegram_id=,
+// затем Accept-Language. Тело запроса не читаем — для POST фронтенд передаёт lang.
+func (h *Handler) localeMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		loc, ok := i18n.Match(q.Get("lang"))
+		if !ok {
+			if tgID, _ := strconv.ParseInt(q.Get("telegram_id"), 10, 64); tgID != 0 {
+				loc, ok = h.storedLocale(r.Context(), tgID)
+			}
+		}
+		if !ok {
+			loc = i18n.Resolve(i18n.ParseAcceptLanguage(r.Header.Get("Accept-Language"))...)
+		}
+		next.ServeHTTP(w, r.WithContext(i18n.WithLocale(r.Context(), loc)))
+	})
+}
+
+// storedLocale — язык из профиля клиента, иначе тот, что прислал Telegram
+func (h *Handler) storedLocale(ctx context.Context, telegramID int64) (i18n.Locale, bool) {
+	profile, telegram, err := h.userRepo.GetLanguage(ctx, telegramID)
+	if err != nil {
+		h.logger.Warn("Failed to load user language", zap.Int64("telegram_id", telegramID), zap.Error(err))
+		return "", false
+	}
+	if loc, ok := i18n.Match(profile); ok {
+		return loc, true
+	}
+	return i18n.Match(telegram)
+}
+
+// userLocale — язык сообщений бота для пользователя, i18n.Default если неизвестен
+func (h *Handler) userLocale(ctx context.Context, telegramID int64) i18n.Locale {
+	if loc, ok := h.storedLocale(ctx, telegramID); ok {
+		return loc
+	}
+	return i18n.Default
+}
+
+// senderLocale — язык того, кто нажал кнопку или написал боту. Telegram присылает
+// language_code в каждом апдейте, но выбор в профиле важнее.
+func (h *Handler) senderLocale(ctx context.Context, from *models.User) i18n.Locale {
+	if from == nil {
+		return i18n.Default
+	}
+	profile, telegram, err := h.userRepo.GetLanguage(ctx, from.ID)
+	if err != nil {
+		h.logger.Warn("Failed to load user language", zap.Int64("telegram_id", from.ID), zap.Error(err))
+	}
+	return i18n.Resolve(profile, from.LanguageCode, telegram)
+}
+
+// errText — текст ошибки для пользователя бота. Ошибки без кода не показываем.
+func (h *Handler) errText(loc i18n.Locale, err error) string {
+	var coded i18n.Coded
+	if errors.As(err, &coded) {
+		return coded.Localize(loc)
+	}
+	return i18n.T(loc, "error.internal")
+}
+
+// promptKey узнаёт подсказку с ForceReply по началу текста. Язык мог смениться,
+// пока подсказка висела в чате, поэтому проверяем все языки.
+func promptKey(text string, keys ...string) (key, rest string, ok bool) {
+	for _, key := range keys {
+		for _, loc := range i18n.Locales {
+			if prefix := i18n.T(loc, key); strings.HasPrefix(text, prefix) {
+				return key, strings.TrimPrefix(text, prefix), true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// tr — текст бота на языке из ctx (см. handleCallbackQuery, DefaultHandler)
+func tr(ctx context.Context, key string, args ...interface{}) string {
+	return i18n.T(i18n.FromContext(ctx), key, args...)
+}
@@ -0,0 +1,150 @@
+// map-handler.go
+package handler
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"tezjet/internal/domain"
+
+	"go.uber.org/zap"
+)
+
+// mapFilterFromQuery reads city and truck_type; Since is set by the caller
+func mapFilterFromQuery(q url.Values) domain.MapFilter {
+	return domain.MapFilter{
+		City:      strings.TrimSpace(q.Get("city")),
+		TruckType: strings.TrimSpace(q.Get("truck_type")),
+	}
+}
+
+// handleHeatmap — заявки по ячейкам geohash за окно времени.
+// GET /api/map/heatmap?kind=origins|destinations&hours=24&precision=5&city=&truck_type=
+// Ячейки — GeoJSON Polygon со свойствами count и unmet (не взятые водителем).
+func (h *Handler) handleHeatmap(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	q := r.URL.Query()
+	kind := q.Get("kind")
+	if kind == "" {
+		kind = "origins"
+	}
+	if kind != "origins" && kind != "destinations" {
+		h.sendErrorResponse(w, r, "invalid_kind", http.StatusBadRequest)
+		return
+	}
+
+	window := domain.DefaultHeatmapWindow
+	if s := q.Get("hours"); s != "" {
+		hours, err := strconv.Atoi(s)
+		if err != nil || hours <= 0 || time.Duration(hours)*time.Hour > domain.MaxHeatmapWindow {
+			h.sendErrorResponse(w, r, "invalid_hours", http.StatusBadRequest)
+			return
+		}
+		window = time.Duration(hours) * time.Hour
+	}
+
+	precision := domain.DefaultHeatmapPrecision
+	if s := q.Get("precision"); s != "" {
+		p, err := strconv.Atoi(s)
+		if err != nil || p < domain.MinHeatmapPrecision || p > domain.MaxHeatmapPrecision {
+			h.sendErrorResponse(w, r, "invalid_precision", http.StatusBadRequest)
+			return
+		}
+		precision = p
+	}
+
+	f := mapFilterFromQuery(q)
+	f.Since = time.Now().Add(-window)
+	points, err := h.orderRepo.GetOrderPoints(r.Context(), f)
+	if err != nil {
+		h.logger.Error("load order points for heatmap", zap.Error(err))
+		h.sendErrorResponse(w, r, "orders_load_failed", http.StatusInternalServerError)
+		return
+	}
+
+	fc := domain.NewFeatureCollection()
+	maxCount := 0
+	for _, c := range domain.AggregateHeatCells(points, kind == "destinations", precision) {
+		feature, ok := domain.CellFeature(c.Geohash, map[string]interface{}{
+			"geohash": c.Geohash,
+			"count":   c.Count,
+			"unmet":   c.Unmet,
+		})
+		if !ok {
+			continue
+		}
+		if c.Count > maxCount {
+			maxCount = c.Count
+		}
+		fc.Features = append(fc.Features, feature)
+	}
+
+	h.sendSuccessResponse(w, r, "demand_map", map[string]interface{}{
+		"geojson":   fc,
+		"kind":      kind,
+		"hours":     int(window.Hours()),
+		"precision": precision,
+		"orders":    len(points),
+		"max_count": maxCount,
+	})
+}
+
+// handleLiveMap — ожидающие заявки (Point) и активные поездки водителей (LineString).
+// GET /api/map/live?city=&truck_type=
+func (h *Handler) handleLiveMap(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	f := mapFilterFromQuery(r.URL.Query())
+	orders, err := h.orderRepo.GetPendingOrderPoints(r.Context(), f)
+	if err != nil {
+		h.logger.Error("load pending orders for map", zap.Error(err))
+		h.sendErrorResponse(w, r, "orders_load_failed", http.StatusInternalServerError)
+		return
+	}
+	trips, err := h.driverRepo.GetActiveTripRoutes(r.Context(), f)
+	if err != nil {
+		h.sendErrorResponse(w, r, "trips_load_failed", http.StatusInternalServerError)
+		return
+	}
+
+	fc := domain.NewFeatureCollection()
+	for _, o := range orders {
+		fc.Features = append(fc.Features, domain.PointFeature(o.From, map[string]interface{}{
+			"kind":       "order",
+			"id":         o.ID,
+			"price":      o.Price,
+			"truck_type": o.TruckType,
+			"to":         []float64{o.To.Lon, o.To.Lat},
+			"created_at": o.CreatedAt,
+		}))
+	}
+	for _, t := range trips {
+		fc.Features = append(fc.Features, domain.LineFeature([]domain.GeoPoint{t.From, t.To}, map[string]interface{}{
+			"kind":       "trip",
+			"id":         t.ID,
+			"price":      t.Price,
+			"truck_type": t.TruckType,
+			"start_time": t.StartTime,
+		}))
+	}
+
+	h.sendSuccessResponse(w, r, "map_live", map[string]interface{}{
+		"geojson": fc,
+		"orders":  len(orders),
+		"trips":   len(trips),
+	})
+}
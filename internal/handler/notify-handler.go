@@ -0,0 +1,267 @@
+// notify-handler.go
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"tezjet/internal/domain"
+	"tezjet/internal/i18n"
+	"tezjet/internal/notifier"
+
+	"go.uber.org/zap"
+)
+
+const (
+	notificationsLimit      = 50
+	adminNotificationsLimit = 200
+)
+
+// newNotifier — SMS и email включаются настройками, Telegram добавляется в SetBot
+func (h *Handler) newNotifier() *notifier.Notifier {
+	templates, err := notifier.LoadTemplates()
+	if err != nil {
+		h.logger.Fatal("Failed to load notification templates", zap.Error(err))
+	}
+	n := notifier.New(h.logger, h.notifyRepo, templates)
+	if h.cfg.SMSProviderURL != "" {
+		n.Register(notifier.NewSMS(h.cfg.SMSProviderURL, h.cfg.SMSTimeout))
+	}
+	if h.cfg.SMTPHost != "" {
+		n.Register(notifier.NewEmail(notifier.SMTPConfig{
+			Host:     h.cfg.SMTPHost,
+			Port:     h.cfg.SMTPPort,
+			Username: h.cfg.SMTPUsername,
+			Password: h.cfg.SMTPPassword,
+			From:     h.cfg.SMTPFrom,
+		}))
+	}
+	return n
+}
+
+// orderNotice — данные заявки для шаблонов order_created и order_broadcast
+type orderNotice struct {
+	ID            string
+	From          string
+	To            string
+	Stops         string
+	Price         int
+	TruckType     string
+	TruckTypeText string
+	Contact       string
+	DistanceKm    float64
+	EtaMin        int
+	StartTime     string
+	Cargo         string
+	Comment       string
+	DispatchAt    string // заявка ещё не разослана — когда уйдёт водителям
+}
+
+func (h *Handler) newOrderNotice(lang i18n.Locale, req *domain.DeliveryRequest) orderNotice {
+	n := orderNotice{
+		ID:            req.ID,
+		From:          req.FromAddress,
+		To:            req.ToAddress,
+		Stops:         formatStopsText(lang, req, false),
+		Price:         req.Price,
+		TruckType:     req.TruckType,
+		TruckTypeText: h.formatTruckType(lang, req.TruckType),
+		Contact:       req.Contact,
+		DistanceKm:    req.DistanceKm,
+		EtaMin:        req.EtaMin,
+		StartTime:     h.timeStartLabel(lang, req.TimeStart),
+		Cargo:         formatCargoText(lang, req.Cargo),
+		Comment:       req.Comment,
+	}
+	if req.DispatchedAt == nil && req.PickupAt != nil {
+		n.DispatchAt = h.formatPickupLocal(req.PickupAt.Add(-h.cfg.DispatchLeadTime))
+	}
+	return n
+}
+
+// tripNotice — данные рейса для шаблона trip_created
+type tripNotice struct {
+	ID         string
+	DriverName string
+	Contact    string
+	From       string
+	To         string
+	Price      int
+	DistanceKm float64
+	EtaMin     int
+	StartTime  string
+	Comment    string
+}
+
+// driverBlockedNotice — данные для шаблона driver_blocked; Reason — тип причины из админки
+type driverBlockedNotice struct {
+	FirstName    string
+	Reason       string
+	CustomReason string
+}
+
+// handleNotificationSettings — каналы уведомлений пользователя
+// GET/POST /api/user/notifications/settings
+func (h *Handler) handleNotificationSettings(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		telegramID, _ := strconv.ParseInt(r.URL.Query().Get("telegram_id"), 10, 64)
+		if telegramID == 0 {
+			h.sendErrorResponse(w, r, "telegram_id_required", http.StatusBadRequest)
+			return
+		}
+		settings, err := h.notifyRepo.GetNotificationSettings(r.Context(), telegramID)
+		if err != nil {
+			h.sendErrorResponse(w, r, "settings_load_failed", http.StatusInternalServerError)
+			return
+		}
+		h.sendSuccessResponse(w, r, "notify_settings", map[string]interface{}{
+			"settings":  settings,
+			"available": h.notifier.Available(),
+		})
+		return
+	}
+
+	var reqData domain.NotificationSettings
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+		h.logger.Error("Failed to parse request body", zap.Error(err))
+		h.sendErrorResponse(w, r, "invalid_request", http.StatusBadRequest)
+		return
+	}
+	if reqData.TelegramID == 0 {
+		h.sendErrorResponse(w, r, "telegram_id_required", http.StatusBadRequest)
+		return
+	}
+
+	settings := domain.NotificationSettings{
+		TelegramID: reqData.TelegramID,
+		Phone:      strings.TrimSpace(reqData.Phone),
+		Email:      strings.TrimSpace(reqData.Email),
+	}
+	for _, c := range reqData.Channels {
+		c = strings.ToLower(strings.TrimSpace(c))
+		if !domain.IsNotifyChannel(c) {
+			h.sendErrorResponse(w, r, "notify_channel_unknown", http.StatusBadRequest, c)
+			return
+		}
+		if !settings.HasChannel(c) {
+			settings.Channels = append(settings.Channels, c)
+		}
+	}
+	if len(settings.Channels) == 0 {
+		h.sendErrorResponse(w, r, "notify_channels_required", http.StatusBadRequest)
+		return
+	}
+	if settings.Phone != "" {
+		if settings.Phone = notifier.NormalizePhone(settings.Phone); settings.Phone == "" {
+			h.sendErrorResponse(w, r, "invalid_phone", http.StatusBadRequest)
+			return
+		}
+	}
+	if settings.Email != "" {
+		addr, err := mail.ParseAddress(settings.Email)
+		if err != nil {
+			h.sendErrorResponse(w, r, "invalid_email", http.StatusBadRequest)
+			return
+		}
+		settings.Email = addr.Address
+	}
+	if settings.HasChannel(domain.NotifyChannelEmail) && settings.Email == "" {
+		h.sendErrorResponse(w, r, "email_required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.notifyRepo.SaveNotificationSettings(r.Context(), &settings); err != nil {
+		h.sendErrorResponse(w, r, "settings_save_failed", http.StatusInternalServerError)
+		return
+	}
+	h.sendSuccessResponse(w, r, "notify_settings_saved", map[string]interface{}{
+		"settings":  settings,
+		"available": h.notifier.Available(),
+	})
+}
+
+// handleUserNotifications — последние уведомления пользователя со статусом доставки
+// GET /api/user/notifications?telegram_id=...
+func (h *Handler) handleUserNotifications(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	telegramID, _ := strconv.ParseInt(r.URL.Query().Get("telegram_id"), 10, 64)
+	if telegramID == 0 {
+		h.sendErrorResponse(w, r, "telegram_id_required", http.StatusBadRequest)
+		return
+	}
+	list, err := h.notifyRepo.ListNotifications(r.Context(), telegramID, "", notificationsLimit)
+	if err != nil {
+		h.sendErrorResponse(w, r, "notifications_load_failed", http.StatusInternalServerError)
+		return
+	}
+	h.sendSuccessResponse(w, r, "notifications_loaded", map[string]interface{}{
+		"notifications": list,
+	})
+}
+
+// handleAdminNotifications — журнал доставки, например ?status=failed
+// GET /api/admin/notifications?telegram_id=...&status=...&user=...
+func (h *Handler) handleAdminNotifications(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if _, ok := h.adminFromRequest(w, r); !ok {
+		return
+	}
+
+	status := strings.TrimSpace(r.URL.Query().Get("status"))
+	switch status {
+	case "", domain.NotificationPending, domain.NotificationSent, domain.NotificationFailed, domain.NotificationSkipped:
+	default:
+		h.sendErrorResponse(w, r, "invalid_status", http.StatusBadRequest)
+		return
+	}
+	userID, _ := strconv.ParseInt(r.URL.Query().Get("user"), 10, 64)
+
+	list, err := h.notifyRepo.ListNotifications(r.Context(), userID, status, adminNotificationsLimit)
+	if err != nil {
+		h.sendErrorResponse(w, r, "internal", http.StatusInternalServerError)
+		return
+	}
+	h.sendSuccessResponse(w, r, "admin_notifications", map[string]interface{}{
+		"count":         len(list),
+		"notifications": list,
+	})
+}
+
+var smsStubSeq atomic.Int64
+
+// handleSMSStub — локальный SMS-провайдер для разработки: пишет сообщение в лог
+// POST /stub/sms {"to": "+77...", "text": "..."}
+func (h *Handler) handleSMSStub(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var reqData struct {
+		To   string `json:"to"`
+		Text string `json:"text"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil || reqData.To == "" || reqData.Text == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"status": "rejected"})
+		return
+	}
+	id := smsStubSeq.Add(1)
+	h.logger.Info("SMS stub", zap.Int64("id", id), zap.String("to", reqData.To), zap.String("text", reqData.Text))
+	json.NewEncoder(w).Encode(map[string]interface{}{"status": "queued", "id": id})
+}
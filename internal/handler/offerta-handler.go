@@ -9,6 +9,8 @@ import (
 	"strings"
 	"time"
 
+	"tezjet/internal/i18n"
+
 	"go.uber.org/zap"
 )
 
@@ -56,19 +58,13 @@ func (h *Handler) handleOffertaStatus(w http.ResponseWriter, r *http.Request) {
 	role := normalizeRole(r.URL.Query().Get("role"))
 
 	if !isAllowedRole(role) {
-		writeOffertaJSON(w, http.StatusBadRequest, offertaStatusResp{
-			Success: false,
-			Message: "Invalid role",
-		})
+		writeOffertaJSON(w, http.StatusBadRequest, errorResponse(r, i18n.E("invalid_role")))
 		return
 	}
 
 	tgID, err := strconv.ParseInt(tgStr, 10, 64)
 	if err != nil || tgID == 0 {
-		writeOffertaJSON(w, http.StatusBadRequest, offertaStatusResp{
-			Success: false,
-			Message: "Telegram ID обязателен",
-		})
+		writeOffertaJSON(w, http.StatusBadRequest, errorResponse(r, i18n.E("telegram_id_required")))
 		return
 	}
 
@@ -90,10 +86,7 @@ func (h *Handler) handleOffertaStatus(w http.ResponseWriter, r *http.Request) {
 
 	if err != nil {
 		h.logger.Error("Database error checking offerta", zap.Error(err))
-		writeOffertaJSON(w, http.StatusInternalServerError, offertaStatusResp{
-			Success: false,
-			Message: "Server error",
-		})
+		writeOffertaJSON(w, http.StatusInternalServerError, errorResponse(r, i18n.E("internal")))
 		return
 	}
 
@@ -121,14 +114,14 @@ func (h *Handler) handleOffertaApprove(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if r.Method != http.MethodPost {
-		writeOffertaJSON(w, http.StatusMethodNotAllowed, Response{Success: false, Message: "Method not allowed"})
+		writeOffertaJSON(w, http.StatusMethodNotAllowed, errorResponse(r, i18n.E("method_not_allowed")))
 		return
 	}
 
 	var req offertaApproveReq
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		h.logger.Error("Invalid JSON", zap.Error(err))
-		writeOffertaJSON(w, http.StatusBadRequest, Response{Success: false, Message: "Invalid JSON"})
+		writeOffertaJSON(w, http.StatusBadRequest, errorResponse(r, i18n.E("invalid_json")))
 		return
 	}
 
@@ -136,12 +129,12 @@ func (h *Handler) handleOffertaApprove(w http.ResponseWriter, r *http.Request) {
 
 	if req.TelegramID == 0 {
 		h.logger.Warn("Missing telegram_id in approval request")
-		writeOffertaJSON(w, http.StatusBadRequest, Response{Success: false, Message: "telegram_id обязателен"})
+		writeOffertaJSON(w, http.StatusBadRequest, errorResponse(r, i18n.E("telegram_id_required")))
 		return
 	}
 
 	if !isAllowedRole(req.Role) {
-		writeOffertaJSON(w, http.StatusBadRequest, Response{Success: false, Message: "Invalid role"})
+		writeOffertaJSON(w, http.StatusBadRequest, errorResponse(r, i18n.E("invalid_role")))
 		return
 	}
 
@@ -151,7 +144,7 @@ func (h *Handler) handleOffertaApprove(w http.ResponseWriter, r *http.Request) {
 	tx, err := h.db.Begin()
 	if err != nil {
 		h.logger.Error("Failed to begin transaction", zap.Error(err))
-		writeOffertaJSON(w, http.StatusInternalServerError, Response{Success: false, Message: "Server error"})
+		writeOffertaJSON(w, http.StatusInternalServerError, errorResponse(r, i18n.E("internal")))
 		return
 	}
 	defer tx.Rollback()
@@ -168,17 +161,14 @@ func (h *Handler) handleOffertaApprove(w http.ResponseWriter, r *http.Request) {
 			zap.Error(err),
 			zap.Int64("telegram_id", req.TelegramID),
 			zap.String("role", req.Role))
-		writeOffertaJSON(w, http.StatusInternalServerError, Response{
-			Success: false,
-			Message: "Database error: " + err.Error(),
-		})
+		writeOffertaJSON(w, http.StatusInternalServerError, errorResponse(r, i18n.E("db_error")))
 		return
 	}
 
 	// ✅ CRITICAL: Commit transaction before responding
 	if err := tx.Commit(); err != nil {
 		h.logger.Error("Failed to commit transaction", zap.Error(err))
-		writeOffertaJSON(w, http.StatusInternalServerError, Response{Success: false, Message: "Commit error"})
+		writeOffertaJSON(w, http.StatusInternalServerError, errorResponse(r, i18n.E("db_error")))
 		return
 	}
 
@@ -186,5 +176,5 @@ func (h *Handler) handleOffertaApprove(w http.ResponseWriter, r *http.Request) {
 		zap.Int64("telegram_id", req.TelegramID),
 		zap.String("role", req.Role))
 
-	writeOffertaJSON(w, http.StatusOK, Response{Success: true, Message: "Offerta approved"})
+	writeOffertaJSON(w, http.StatusOK, Response{Success: true, Message: successText(r, "offerta_approved")})
 }
@@ -0,0 +1,400 @@
+// order-edit-handler.go
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"tezjet/internal/domain"
+	"tezjet/internal/i18n"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"go.uber.org/zap"
+)
+
+// orderUpdateJSON — все поля кроме telegram_id/order_id необязательные:
+// nil значит "не менять".
+type orderUpdateJSON struct {
+	TelegramID  int64    `json:"telegram_id"`
+	OrderID     string   `json:"order_id"`
+	FromAddress *string  `json:"from_address"`
+	FromLat     *float64 `json:"from_lat"`
+	FromLon     *float64 `json:"from_lon"`
+	ToAddress   *string  `json:"to_address"`
+	ToLat       *float64 `json:"to_lat"`
+	ToLon       *float64 `json:"to_lon"`
+	Price       *int     `json:"price"`
+	TruckType   *string  `json:"truck_type"`
+	Contact     *string  `json:"contact"`
+	Comment     *string  `json:"comment"`
+	TimeStart   *string  `json:"time_start"`
+	Date        *string  `json:"date"`
+	Time        *string  `json:"time"`
+
+	Cargo *domain.CargoSpec `json:"cargo"` // заменяет описание груза целиком
+}
+
+// handleUserUpdateOrder lets the client edit an order while it CanBeUpdated()
+func (h *Handler) handleUserUpdateOrder(ctx context.Context, b *bot.Bot) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		var in orderUpdateJSON
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			h.logger.Error("Failed to parse request body", zap.Error(err))
+			h.sendErrorResponse(w, r, "invalid_request", http.StatusBadRequest)
+			return
+		}
+
+		if in.TelegramID == 0 || in.OrderID == "" {
+			h.sendErrorResponse(w, r, "telegram_id_and_order_id_required", http.StatusBadRequest)
+			return
+		}
+
+		order, err := h.getDeliveryOrderById(in.OrderID)
+		if err != nil {
+			h.logger.Error("Failed to get order", zap.Error(err))
+			h.sendErrorResponse(w, r, "order_load_failed", http.StatusInternalServerError)
+			return
+		}
+		if order == nil {
+			h.sendErrorResponse(w, r, "order_not_found", http.StatusNotFound)
+			return
+		}
+		if order.TelegramID != in.TelegramID {
+			h.sendErrorResponse(w, r, "order_access_denied", http.StatusForbidden)
+			return
+		}
+		if !order.CanBeUpdated() {
+			h.sendErrorResponse(w, r, "order_not_editable", http.StatusConflict)
+			return
+		}
+
+		rev, err := h.applyOrderUpdate(r.Context(), order, &in)
+		if err != nil {
+			h.sendError(w, r, err, http.StatusBadRequest)
+			return
+		}
+		if len(rev.Changes) == 0 {
+			h.sendErrorResponse(w, r, "no_changes", http.StatusBadRequest)
+			return
+		}
+
+		ok, err := h.orderRepo.UpdateDeliveryRequest(r.Context(), order, rev)
+		if err != nil {
+			h.sendErrorResponse(w, r, "order_save_failed", http.StatusInternalServerError)
+			return
+		}
+		if !ok {
+			h.sendErrorResponse(w, r, "order_not_editable", http.StatusConflict)
+			return
+		}
+
+		h.logger.Info("Order updated by client",
+			zap.String("order_id", order.ID),
+			zap.Int64("revision_id", rev.ID),
+			zap.Int("changed_fields", len(rev.Changes)))
+
+		switch {
+		case order.Status == domain.DeliveryStatusPending && order.DispatchedAt != nil:
+			go h.refreshOrderBroadcasts(ctx, b, order, rev)
+		case order.Status == domain.DeliveryStatusPending:
+			// отложенная заявка разошлётся по новому времени в DispatchScheduledOrders
+		case order.Status == domain.DeliveryStatusMatched:
+			go h.notifyDriverOrderChanged(ctx, b, order, rev)
+		}
+
+		h.sendSuccessResponse(w, r, "order_updated", map[string]interface{}{
+			"order":    order,
+			"revision": rev,
+		})
+	}
+}
+
+// applyOrderUpdate переносит изменения в order, валидирует их и собирает ревизию
+func (h *Handler) applyOrderUpdate(ctx context.Context, order *domain.DeliveryRequest, in *orderUpdateJSON) (*domain.DeliveryRequestRevision, error) {
+	rev := &domain.DeliveryRequestRevision{
+		RequestID:  order.ID,
+		TelegramID: order.TelegramID,
+		Changes:    map[string]domain.RevisionChange{},
+		CreatedAt:  time.Now(),
+	}
+
+	setString := func(field string, v *string, dst *string) {
+		if v == nil {
+			return
+		}
+		nv := strings.TrimSpace(*v)
+		if nv != *dst {
+			rev.Changes[field] = domain.RevisionChange{Old: *dst, New: nv}
+			*dst = nv
+		}
+	}
+	setFloat := func(field string, v *float64, dst *float64) {
+		if v != nil && *v != *dst {
+			rev.Changes[field] = domain.RevisionChange{Old: *dst, New: *v}
+			*dst = *v
+		}
+	}
+
+	// У многоточечной заявки to_* — это последняя точка, менять её отдельно нельзя
+	if order.IsMultiStop() && (in.ToAddress != nil || in.ToLat != nil || in.ToLon != nil) {
+		return nil, i18n.E("order_stops_fixed_destination")
+	}
+
+	setString("from_address", in.FromAddress, &order.FromAddress)
+	setString("to_address", in.ToAddress, &order.ToAddress)
+	setString("truck_type", in.TruckType, &order.TruckType)
+	if _, ok := rev.Changes["truck_type"]; ok && !validSearchTruckType(order.TruckType) {
+		return nil, i18n.E("invalid_truck_type")
+	}
+
+	// Новый адрес без координат оставил бы старые расстояние, время в пути и цену по тарифу
+	_, fromAddrChanged := rev.Changes["from_address"]
+	_, toAddrChanged := rev.Changes["to_address"]
+	if (fromAddrChanged && (in.FromLat == nil || in.FromLon == nil)) ||
+		(toAddrChanged && (in.ToLat == nil || in.ToLon == nil)) {
+		return nil, i18n.E("address_coordinates_required")
+	}
+	setString("contact", in.Contact, &order.Contact)
+	setString("comment", in.Comment, &order.Comment)
+	setFloat("from_lat", in.FromLat, &order.FromLat)
+	setFloat("from_lon", in.FromLon, &order.FromLon)
+	setFloat("to_lat", in.ToLat, &order.ToLat)
+	setFloat("to_lon", in.ToLon, &order.ToLon)
+
+	if in.TimeStart == nil && in.Date != nil && in.Time != nil {
+		ts := strings.TrimSpace(*in.Date) + "T" + strings.TrimSpace(*in.Time)
+		in.TimeStart = &ts
+	}
+	setString("time_start", in.TimeStart, &order.TimeStart)
+	if _, ok := rev.Changes["time_start"]; ok {
+		oldPickup := order.PickupAt
+		if err := h.resolvePickupTime(order); err != nil {
+			return nil, err
+		}
+		rev.Changes["pickup_at"] = domain.RevisionChange{Old: oldPickup, New: order.PickupAt}
+	}
+
+	if in.Cargo != nil && *in.Cargo != order.Cargo {
+		if err := validateCargo(*in.Cargo); err != nil {
+			return nil, err
+		}
+		rev.Changes["cargo"] = domain.RevisionChange{Old: order.Cargo, New: *in.Cargo}
+		order.Cargo = *in.Cargo
+	}
+
+	if in.Price != nil && *in.Price != order.Price {
+		if *in.Price <= 0 {
+			return nil, i18n.E("invalid_price")
+		}
+		rev.Changes["price"] = domain.RevisionChange{Old: order.Price, New: *in.Price}
+		order.Price = *in.Price
+	}
+
+	if order.FromAddress == "" {
+		return nil, i18n.E("from_address_required")
+	}
+	if order.ToAddress == "" {
+		return nil, i18n.E("to_address_required")
+	}
+	if order.Contact == "" {
+		return nil, i18n.E("contact_required")
+	}
+
+	_, fromLatChanged := rev.Changes["from_lat"]
+	_, fromLonChanged := rev.Changes["from_lon"]
+	_, toLatChanged := rev.Changes["to_lat"]
+	_, toLonChanged := rev.Changes["to_lon"]
+	if fromAddrChanged || toAddrChanged || fromLatChanged || fromLonChanged || toLatChanged || toLonChanged {
+		if !h.isValidCoordinates(order.FromLat, order.FromLon) || !h.isValidCoordinates(order.ToLat, order.ToLon) {
+			return nil, i18n.E("invalid_coordinates")
+		}
+		distance, duration := h.calculateRouteVia(routePoints(order))
+		if distance != order.DistanceKm {
+			rev.Changes["distance_km"] = domain.RevisionChange{Old: order.DistanceKm, New: distance}
+			order.DistanceKm = distance
+		}
+		if duration != order.EtaMin {
+			rev.Changes["eta_min"] = domain.RevisionChange{Old: order.EtaMin, New: duration}
+			order.EtaMin = duration
+		}
+	}
+
+	// минимум по тарифу зависит от цены, города, расстояния и типа машины
+	_, priceChanged := rev.Changes["price"]
+	_, truckChanged := rev.Changes["truck_type"]
+	_, distanceChanged := rev.Changes["distance_km"]
+	if priceChanged || fromAddrChanged || distanceChanged || truckChanged {
+		if _, err := h.checkTariffPrice(ctx, order.FromAddress, order.TruckType, order.DistanceKm, order.EtaMin, order.Price); err != nil {
+			return nil, err
+		}
+	}
+
+	return rev, nil
+}
+
+// refreshOrderBroadcasts редактирует уже разосланные водителям сообщения.
+// При повышении цены водители получают уведомление, а новые водители рядом — саму заявку.
+func (h *Handler) refreshOrderBroadcasts(ctx context.Context, b *bot.Bot, order *domain.DeliveryRequest, rev *domain.DeliveryRequestRevision) {
+	broadcasts, err := h.orderRepo.GetBroadcasts(ctx, order.ID)
+	if err != nil {
+		h.logger.Error("load broadcasts", zap.String("order_id", order.ID), zap.Error(err))
+		return
+	}
+
+	texts := h.newDriverOrderTexts(order)
+	priceRaised := rev.PriceRaised()
+
+	ticker := time.NewTicker(60 * time.Millisecond)
+	defer ticker.Stop()
+
+	notified := make(map[int64]bool, len(broadcasts))
+	edited := 0
+	for _, bc := range broadcasts {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		notified[bc.DriverTelegramID] = true
+		lang := h.userLocale(ctx, bc.DriverTelegramID)
+		text := texts.get(ctx, lang)
+		keyboard := orderBroadcastKeyboard(lang, order.ID)
+
+		var err error
+		if bc.HasPhoto {
+			_, err = b.EditMessageCaption(ctx, &bot.EditMessageCaptionParams{
+				ChatID:      bc.DriverTelegramID,
+				MessageID:   bc.MessageID,
+				Caption:     text,
+				ReplyMarkup: keyboard,
+			})
+		} else {
+			_, err = b.EditMessageText(ctx, &bot.EditMessageTextParams{
+				ChatID:      bc.DriverTelegramID,
+				MessageID:   bc.MessageID,
+				Text:        text,
+				ReplyMarkup: keyboard,
+			})
+		}
+		if err != nil {
+			h.logger.Warn("edit broadcast", zap.Int64("tg_id", bc.DriverTelegramID), zap.Error(err))
+			continue
+		}
+		edited++
+
+		if priceRaised {
+			change := rev.Changes["price"]
+			_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID:          bc.DriverTelegramID,
+				Text:            i18n.T(lang, "bot.order.price_raised", change.Old, change.New),
+				ReplyParameters: &models.ReplyParameters{MessageID: bc.MessageID, AllowSendingWithoutReply: true},
+			})
+			if err != nil {
+				h.logger.Warn("notify price raise", zap.Int64("tg_id", bc.DriverTelegramID), zap.Error(err))
+			}
+		}
+	}
+
+	h.logger.Info("broadcasts refreshed",
+		zap.String("order_id", order.ID),
+		zap.Int("edited", edited),
+		zap.Bool("price_raised", priceRaised))
+
+	if !priceRaised {
+		return
+	}
+
+	nearDrivers, err := h.findNearDrivers(ctx, order)
+	if err != nil {
+		h.logger.Error("NO DRIVERS", zap.Error(err))
+		return
+	}
+	var fresh []domain.Driver
+	for _, d := range nearDrivers {
+		if !notified[d.TelegramID] {
+			fresh = append(fresh, d)
+		}
+	}
+	h.broadcastOrder(ctx, b, order, fresh)
+}
+
+// notifyDriverOrderChanged сообщает назначенному водителю, что клиент изменил заявку
+func (h *Handler) notifyDriverOrderChanged(ctx context.Context, b *bot.Bot, order *domain.DeliveryRequest, rev *domain.DeliveryRequestRevision) {
+	var driverTelegramID int64
+	err := h.db.QueryRowContext(ctx, `
+		SELECT d.telegram_id
+		FROM delivery_requests r
+		JOIN drivers d ON d.id = COALESCE(r.driver_id, r.matched_driver_id)
+		WHERE r.id = ?`, order.ID).Scan(&driverTelegramID)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			h.logger.Error("load order driver", zap.String("order_id", order.ID), zap.Error(err))
+		}
+		return
+	}
+
+	lang := h.userLocale(ctx, driverTelegramID)
+	text := i18n.T(lang, "bot.order.changed") + "\n\n" + h.buildOrderText(lang, order)
+	if _, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: driverTelegramID,
+		Text:   text,
+	}); err != nil {
+		h.logger.Warn("notify driver order changed", zap.Int64("tg_id", driverTelegramID), zap.Error(err))
+	}
+}
+
+// handleOrderRevisions returns the edit history of an order to its owner
+func (h *Handler) handleOrderRevisions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	orderID := r.URL.Query().Get("order_id")
+	telegramID, _ := strconv.ParseInt(r.URL.Query().Get("telegram_id"), 10, 64)
+	if telegramID == 0 || orderID == "" {
+		h.sendErrorResponse(w, r, "telegram_id_and_order_id_required", http.StatusBadRequest)
+		return
+	}
+
+	order, err := h.getDeliveryOrderById(orderID)
+	if err != nil {
+		h.sendErrorResponse(w, r, "order_load_failed", http.StatusInternalServerError)
+		return
+	}
+	if order == nil {
+		h.sendErrorResponse(w, r, "order_not_found", http.StatusNotFound)
+		return
+	}
+	if order.TelegramID != telegramID && !h.isAdmin(telegramID) {
+		h.sendErrorResponse(w, r, "order_access_denied", http.StatusForbidden)
+		return
+	}
+
+	revisions, err := h.orderRepo.GetRevisions(r.Context(), orderID)
+	if err != nil {
+		h.sendErrorResponse(w, r, "edits_load_failed", http.StatusInternalServerError)
+		return
+	}
+
+	h.sendSuccessResponse(w, r, "order_edits_loaded", map[string]interface{}{
+		"order_id":  orderID,
+		"revisions": revisions,
+		"count":     len(revisions),
+	})
+}
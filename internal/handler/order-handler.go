@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"mime/multipart"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -18,6 +19,8 @@ import (
 	"time"
 
 	"tezjet/internal/domain"
+	"tezjet/internal/i18n"
+	"tezjet/internal/notifier"
 
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
@@ -43,6 +46,9 @@ type DriverTrip struct {
 	EtaMin           int       `json:"eta_min"`
 	Price            int       `json:"price"`
 	TruckType        string    `json:"truck_type"`
+	MaxWeight        int       `json:"max_weight"` // кг на этот рейс, 0 — как у машины
+	BookedWeight     float64   `json:"booked_weight"`
+	BookedVolume     float64   `json:"booked_volume"`
 	StartTime        string    `json:"start_time"`
 	Comment          string    `json:"comment"`
 	TruckPhoto       string    `json:"truck_photo"`
@@ -68,7 +74,7 @@ type DriverWithTrip struct {
 	IsVerified    bool   `json:"is_verified"`
 
 	// Trip details
-	TripID        int64   `json:"trip_id"`
+	TripID        string  `json:"trip_id"`
 	FromAddress   string  `json:"from_address"`
 	FromLat       float64 `json:"from_lat"`
 	FromLon       float64 `json:"from_lon"`
@@ -99,6 +105,18 @@ type DriverWithTrip struct {
 	IsOnline            bool    `json:"is_online,omitempty"`
 	LastSeenMin         int     `json:"last_seen_min,omitempty"`
 	ResponseTimeMin     int     `json:"response_time_min,omitempty"`
+
+	// Вместимость рейса и насколько груз клиента её заполняет (0..1)
+	Capacity    domain.VehicleCapacity `json:"capacity"`
+	CapacityFit float64                `json:"capacity_fit"`
+	FreeSpace   domain.TripSpace       `json:"free_space"` // свободно в рейсе с учётом броней
+
+	// Сглаженный рейтинг от клиентов и последние отзывы с комментарием
+	Rating      float64              `json:"rating"`
+	RatingCount int                  `json:"rating_count"`
+	Reviews     []domain.OrderReview `json:"reviews,omitempty"`
+
+	CancelCount int `json:"cancel_count"` // отказы от назначенных заявок
 }
 
 type DeliveryListRequest struct {
@@ -106,6 +124,8 @@ type DeliveryListRequest struct {
 	DriverLat  float64 `json:"driver_lat"`
 	DriverLon  float64 `json:"driver_lon"`
 	Radius     float64 `json:"radius"`
+	PickupFrom string  `json:"pickup_from"` // фильтр по времени подачи, местное время или RFC3339
+	PickupTo   string  `json:"pickup_to"`
 }
 
 type DeliveryListResponse struct {
@@ -113,6 +133,7 @@ type DeliveryListResponse struct {
 	TotalCount  int                      `json:"total_count"`
 	NearbyCount int                      `json:"nearby_count"`
 	AvgPrice    float64                  `json:"avg_price"`
+	HighDemand  []domain.SurgeZone       `json:"high_demand_zones,omitempty"`
 }
 
 type DriverRegistration struct {
@@ -133,6 +154,8 @@ type DriverRegistration struct {
 	IsVerified    bool      `json:"is_verified"`
 	Status        string    `json:"status"`
 	CreatedAt     time.Time `json:"created_at"`
+
+	Capacity domain.VehicleCapacity `json:"capacity"`
 }
 
 type DriverRequestParams struct {
@@ -144,6 +167,8 @@ type DriverRequestParams struct {
 	PriceRange int     `json:"price_range"`
 	TruckType  string  `json:"truck_type"`
 	RadiusKm   float64 `json:"radius_km"`
+
+	Cargo domain.CargoSpec `json:"cargo"`
 }
 
 type DriverMatchResponse struct {
@@ -164,22 +189,27 @@ type SearchStats struct {
 }
 
 type deliveryRequestJSON struct {
-	FromAddress string  `json:"from_address"`
-	FromLat     float64 `json:"from_lat"`
-	FromLon     float64 `json:"from_lon"`
-	ToAddress   string  `json:"to_address"`
-	ToLat       float64 `json:"to_lat"`
-	ToLon       float64 `json:"to_lon"`
-	Contact     string  `json:"contact"`
-	TruckType   string  `json:"truck_type"`
-	Comment     string  `json:"comment"`
-	TimeStart   string  `json:"time_start"`
-	Date        string  `json:"date"`
-	Time        string  `json:"time"`
-	Price       int     `json:"price"`
-	ETAMin      int     `json:"duration"`
-	DistanceKm  float64 `json:"distance"`
-	TelegramID  int64   `json:"telegram_id"`
+	FromAddress string     `json:"from_address"`
+	FromLat     float64    `json:"from_lat"`
+	FromLon     float64    `json:"from_lon"`
+	ToAddress   string     `json:"to_address"`
+	ToLat       float64    `json:"to_lat"`
+	ToLon       float64    `json:"to_lon"`
+	Contact     string     `json:"contact"`
+	TruckType   string     `json:"truck_type"`
+	Comment     string     `json:"comment"`
+	TimeStart   string     `json:"time_start"`
+	Date        string     `json:"date"`
+	Time        string     `json:"time"`
+	Price       int        `json:"price"`
+	ETAMin      int        `json:"duration"`
+	DistanceKm  float64    `json:"distance"`
+	TelegramID  int64      `json:"telegram_id"`
+	Stops       []stopJSON `json:"stops"` // несколько точек выгрузки, последняя заменяет to_*
+
+	Cargo domain.CargoSpec `json:"cargo"`
+
+	FavoriteDriverID string `json:"favorite_driver_id"` // сначала предложить избранному водителю
 }
 
 // =================================
@@ -209,6 +239,11 @@ func (h *Handler) HandleDriverListAPI(w http.ResponseWriter, r *http.Request) {
 	toLon, _ := strconv.ParseFloat(r.URL.Query().Get("to_lon"), 64)
 	radiusKm, _ := strconv.ParseFloat(r.URL.Query().Get("radius"), 64)
 	truckType := r.URL.Query().Get("truck_type")
+	cargo, err := parseCargoForm(func(key string) string { return strings.TrimSpace(r.URL.Query().Get(key)) })
+	if err != nil {
+		h.sendError(w, r, err, http.StatusBadRequest)
+		return
+	}
 
 	h.logger.Info("📊 Parsed query parameters",
 		zap.Float64("from_lat", fromLat),
@@ -221,7 +256,7 @@ func (h *Handler) HandleDriverListAPI(w http.ResponseWriter, r *http.Request) {
 	// Validate coordinates
 	if !h.isValidCoordinates(fromLat, fromLon) || !h.isValidCoordinates(toLat, toLon) {
 		h.logger.Error("❌ Invalid coordinates provided")
-		h.sendErrorResponse(w, "Некорректные координаты", http.StatusBadRequest)
+		h.sendErrorResponse(w, r, "invalid_coordinates", http.StatusBadRequest)
 		return
 	}
 
@@ -233,10 +268,10 @@ func (h *Handler) HandleDriverListAPI(w http.ResponseWriter, r *http.Request) {
 	startTime := time.Now()
 
 	// Find drivers using route-to-route matching
-	drivers, err := h.findDriversByRouteMatching(fromLat, fromLon, toLat, toLon, radiusKm, truckType)
+	drivers, err := h.findDriversByRouteMatching(fromLat, fromLon, toLat, toLon, radiusKm, truckType, cargo)
 	if err != nil {
 		h.logger.Error("❌ Failed to find drivers", zap.Error(err))
-		h.sendErrorResponse(w, "Ошибка поиска водителей", http.StatusInternalServerError)
+		h.sendErrorResponse(w, r, "driver_search_failed", http.StatusInternalServerError)
 		return
 	}
 
@@ -247,7 +282,7 @@ func (h *Handler) HandleDriverListAPI(w http.ResponseWriter, r *http.Request) {
 		zap.Duration("duration", searchDuration))
 
 	// Send response
-	h.sendSuccessResponse(w, "Водители найдены", map[string]interface{}{
+	h.sendSuccessResponse(w, r, "drivers_found", map[string]interface{}{
 		"drivers": drivers,
 		"count":   len(drivers),
 		"search_params": map[string]interface{}{
@@ -285,7 +320,7 @@ func (h *Handler) HandleDelivery(ctx context.Context, b *bot.Bot) http.HandlerFu
 			return
 		}
 		if r.Method != http.MethodPost {
-			h.sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+			h.sendErrorResponse(w, r, "method_not_allowed", http.StatusMethodNotAllowed)
 			return
 		}
 
@@ -307,14 +342,14 @@ func (h *Handler) HandleDelivery(ctx context.Context, b *bot.Bot) http.HandlerFu
 		case strings.Contains(ct, "multipart/form-data"):
 			if err = r.ParseMultipartForm(32 << 20); err != nil {
 				h.logger.Error("Failed to parse multipart form", zap.Error(err))
-				h.sendErrorResponse(w, "Ошибка обработки данных", http.StatusBadRequest)
+				h.sendErrorResponse(w, r, "invalid_data", http.StatusBadRequest)
 				return
 			}
 			req, err = h.parseDeliveryRequest(r)
 		default:
 			if err = r.ParseForm(); err != nil {
 				h.logger.Error("Failed to parse form", zap.Error(err))
-				h.sendErrorResponse(w, "Ошибка обработки данных", http.StatusBadRequest)
+				h.sendErrorResponse(w, r, "invalid_data", http.StatusBadRequest)
 				return
 			}
 			req, err = h.parseDeliveryRequest(r)
@@ -322,7 +357,13 @@ func (h *Handler) HandleDelivery(ctx context.Context, b *bot.Bot) http.HandlerFu
 
 		if err != nil {
 			h.logger.Error("Failed to parse delivery request", zap.Error(err))
-			h.sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+			h.sendError(w, r, err, http.StatusBadRequest)
+			return
+		}
+
+		if err := h.prepareClientOrder(r.Context(), req,
+			r.FormValue("telegram_username"), r.FormValue("telegram_first_name"), r.FormValue("telegram_last_name")); err != nil {
+			h.sendError(w, r, err, http.StatusBadRequest)
 			return
 		}
 
@@ -338,41 +379,71 @@ func (h *Handler) HandleDelivery(ctx context.Context, b *bot.Bot) http.HandlerFu
 			}
 		}
 
-		// Calculate route if missing
-		if req.DistanceKm == 0 || req.EtaMin == 0 {
-			distance, duration := h.calculateRoute(req.FromLat, req.FromLon, req.ToLat, req.ToLon)
-			if req.DistanceKm == 0 {
-				req.DistanceKm = distance
-			}
-			if req.EtaMin == 0 {
-				req.EtaMin = duration
-			}
+		quote, err := h.submitDeliveryRequest(ctx, b, req)
+		var te *tariffError
+		if errors.As(err, &te) {
+			h.sendError(w, r, te, http.StatusBadRequest)
+			return
 		}
-
-		// Save to database
-		if _, err := h.saveDeliveryRequest(req); err != nil {
+		if err != nil {
 			h.logger.Error("Failed to save delivery request", zap.Error(err))
-			h.sendErrorResponse(w, "Ошибка сохранения заявки", http.StatusInternalServerError)
+			h.sendErrorResponse(w, r, "order_save_failed", http.StatusInternalServerError)
 			return
 		}
 
-		req.Status = "pending"
-		req.CreatedAt = time.Now()
-
-		// Send notifications asynchronously
-		go h.sendConfirmationMessage(b, req, req.ID)
-		go h.SendToDriver(ctx, b, req)
-
-		h.sendSuccessResponse(w, "Заявка успешно создана", map[string]interface{}{
+		h.sendSuccessResponse(w, r, "order_created", map[string]interface{}{
 			"request_id": req.ID,
 			"status":     "pending",
 			"distance":   req.DistanceKm,
 			"eta":        req.EtaMin,
 			"photo":      req.CargoPhoto,
+			"pickup_at":  req.PickupAt,
+			"scheduled":  req.DispatchedAt == nil,
+
+			"min_price":         quote.MinPrice,
+			"recommended_price": quote.Recommended,
 		})
 	}
 }
 
+// submitDeliveryRequest — общий путь создания заявки: маршрут, проверка цены по тарифу,
+// сохранение, подтверждение клиенту и рассылка водителям. Используется формой, повтором и расписанием.
+// Цена ниже тарифа возвращается как *tariffError.
+func (h *Handler) submitDeliveryRequest(ctx context.Context, b *bot.Bot, req *domain.DeliveryRequest) (domain.PriceQuote, error) {
+	// Calculate route if missing; многоточечный маршрут всегда считаем сами
+	if req.IsMultiStop() {
+		req.DistanceKm, req.EtaMin = h.calculateRouteVia(routePoints(req))
+	} else if req.DistanceKm == 0 || req.EtaMin == 0 {
+		distance, duration := h.calculateRoute(req.FromLat, req.FromLon, req.ToLat, req.ToLon)
+		if req.DistanceKm == 0 {
+			req.DistanceKm = distance
+		}
+		if req.EtaMin == 0 {
+			req.EtaMin = duration
+		}
+	}
+
+	quote, err := h.checkTariffPrice(ctx, req.FromAddress, req.TruckType, req.DistanceKm, req.EtaMin, req.Price)
+	if err != nil {
+		return quote, err
+	}
+
+	// Save to database
+	if _, err := h.saveDeliveryRequest(req); err != nil {
+		return quote, err
+	}
+
+	req.Status = "pending"
+	req.CreatedAt = time.Now()
+
+	// Send notifications asynchronously
+	go h.sendConfirmationMessage(b, req, req.ID)
+	if req.DispatchedAt != nil {
+		go h.SendToDriver(ctx, b, req)
+	}
+	return quote, nil
+}
+
 // =================================
 // DELIVERY LIST FOR DRIVERS
 // =================================
@@ -394,13 +465,13 @@ func (h *Handler) HandleDeliveryList() http.HandlerFunc {
 		case http.MethodPost:
 			ct := strings.ToLower(r.Header.Get("Content-Type"))
 			if !strings.Contains(ct, "application/json") {
-				h.sendErrorResponse(w, "Content-Type должен быть application/json", http.StatusBadRequest)
+				h.sendErrorResponse(w, r, "content_type_json", http.StatusBadRequest)
 				return
 			}
 			dec := json.NewDecoder(r.Body)
 			dec.DisallowUnknownFields()
 			if err := dec.Decode(&req); err != nil {
-				h.sendErrorResponse(w, fmt.Sprintf("неверный JSON: %v", err), http.StatusBadRequest)
+				h.sendErrorResponse(w, r, "invalid_json", http.StatusBadRequest)
 				return
 			}
 		case http.MethodGet:
@@ -408,13 +479,15 @@ func (h *Handler) HandleDeliveryList() http.HandlerFunc {
 			req.DriverLat, _ = strconv.ParseFloat(r.URL.Query().Get("driver_lat"), 64)
 			req.DriverLon, _ = strconv.ParseFloat(r.URL.Query().Get("driver_lon"), 64)
 			req.Radius, _ = strconv.ParseFloat(r.URL.Query().Get("radius"), 64)
+			req.PickupFrom = r.URL.Query().Get("pickup_from")
+			req.PickupTo = r.URL.Query().Get("pickup_to")
 		default:
-			h.sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+			h.sendErrorResponse(w, r, "method_not_allowed", http.StatusMethodNotAllowed)
 			return
 		}
 
 		if req.DriverLat == 0 || req.DriverLon == 0 {
-			h.sendErrorResponse(w, "driver_lat и driver_lon обязательны", http.StatusBadRequest)
+			h.sendErrorResponse(w, r, "driver_coords_required", http.StatusBadRequest)
 			return
 		}
 		if req.Radius <= 0 {
@@ -424,12 +497,19 @@ func (h *Handler) HandleDeliveryList() http.HandlerFunc {
 			req.Radius = 200
 		}
 
+		pickupFrom, pickupTo, err := h.parsePickupWindow(req.PickupFrom, req.PickupTo)
+		if err != nil {
+			h.sendError(w, r, err, http.StatusBadRequest)
+			return
+		}
+
 		orders, totalCount, err := h.getPendingDeliveryRequestsLast24h()
 		if err != nil {
 			h.logger.Error("Failed to load delivery requests", zap.Error(err))
-			h.sendErrorResponse(w, "Ошибка получения заказов", http.StatusInternalServerError)
+			h.sendErrorResponse(w, r, "orders_load_failed", http.StatusInternalServerError)
 			return
 		}
+		orders = filterByPickupWindow(orders, pickupFrom, pickupTo)
 
 		type orderWithDist struct {
 			o    domain.DeliveryRequest
@@ -468,7 +548,7 @@ func (h *Handler) HandleDeliveryList() http.HandlerFunc {
 			AvgPrice:    avg,
 		}
 
-		h.sendSuccessResponse(w, "Список заказов получен успешно", resp)
+		h.sendSuccessResponse(w, r, "orders_list", resp)
 	}
 }
 
@@ -492,10 +572,14 @@ SELECT
   comment,
   item_photo_path,
   status,
-  created_at
+  pickup_at,
+  created_at,
+  ` + cargoColumns + `
 FROM delivery_requests
 WHERE
-  created_at >= datetime('now', '-24 hours')
+  dispatched_at IS NOT NULL
+  AND dispatched_at >= datetime('now', '-24 hours')
+  AND (preferred_driver_id IS NULL OR preferred_until <= datetime('now'))
   AND (LOWER(status) = 'pending' OR LOWER(status) = 'active')
 ORDER BY created_at DESC
 LIMIT 500;
@@ -529,15 +613,17 @@ LIMIT 500;
 			comment       sql.NullString
 			photoPath     sql.NullString
 			status        sql.NullString
+			pickupAt      sql.NullTime
 			createdAtText sql.NullString
 		)
 
-		if err := rows.Scan(
+		dest := []interface{}{
 			&id, &tgID, &fromAddr, &fromLat, &fromLon,
 			&toAddr, &toLat, &toLon, &distKm, &etaMin,
 			&price, &truckType, &contact, &timeStart,
-			&comment, &photoPath, &status, &createdAtText,
-		); err != nil {
+			&comment, &photoPath, &status, &pickupAt, &createdAtText,
+		}
+		if err := rows.Scan(append(dest, cargoScanDest(&o.Cargo)...)...); err != nil {
 			continue
 		}
 
@@ -592,6 +678,9 @@ LIMIT 500;
 		if status.Valid {
 			o.Status = strings.TrimSpace(status.String)
 		}
+		if pickupAt.Valid {
+			o.PickupAt = &pickupAt.Time
+		}
 
 		out = append(out, o)
 	}
@@ -612,44 +701,57 @@ func (h *Handler) parseDeliveryRequestJSON(r *http.Request) (*domain.DeliveryReq
 	dec := json.NewDecoder(r.Body)
 	dec.DisallowUnknownFields()
 	if err := dec.Decode(&in); err != nil {
-		return nil, fmt.Errorf("неверный JSON: %v", err)
+		return nil, i18n.Wrap(err, "invalid_json")
 	}
+	return h.deliveryRequestFromJSON(&in)
+}
 
+// deliveryRequestFromJSON validates the decoded form body; шаблоны проходят ту же проверку
+func (h *Handler) deliveryRequestFromJSON(in *deliveryRequestJSON) (*domain.DeliveryRequest, error) {
 	req := &domain.DeliveryRequest{}
 	req.FromAddress = strings.TrimSpace(in.FromAddress)
 	req.ToAddress = strings.TrimSpace(in.ToAddress)
 	req.Contact = strings.TrimSpace(in.Contact)
 	req.TruckType = strings.TrimSpace(in.TruckType)
 	req.Comment = strings.TrimSpace(in.Comment)
+	req.FromLat, req.FromLon = in.FromLat, in.FromLon
+	req.ToLat, req.ToLon = in.ToLat, in.ToLon
+
+	if err := h.applyStops(req, in.Stops); err != nil {
+		return nil, err
+	}
+	if err := validateCargo(in.Cargo); err != nil {
+		return nil, err
+	}
+	req.Cargo = in.Cargo
 
 	if req.FromAddress == "" {
-		return nil, fmt.Errorf("адрес отправления обязателен")
+		return nil, i18n.E("from_address_required")
 	}
 	if req.ToAddress == "" {
-		return nil, fmt.Errorf("адрес назначения обязателен")
+		return nil, i18n.E("to_address_required")
 	}
 	if req.Contact == "" {
-		return nil, fmt.Errorf("контактный номер обязателен")
+		return nil, i18n.E("contact_required")
 	}
 
-	req.FromLat, req.FromLon = in.FromLat, in.FromLon
-	req.ToLat, req.ToLon = in.ToLat, in.ToLon
-
 	req.Price = in.Price
-	if req.Price < 2000 {
-		return nil, fmt.Errorf("цена должна быть не менее 2000 тенге")
+	if req.Price <= 0 {
+		return nil, i18n.E("price_required")
 	}
 
 	req.DistanceKm = in.DistanceKm
 	req.EtaMin = in.ETAMin
 	req.TelegramID = in.TelegramID
+	req.PreferredDriverID = strings.TrimSpace(in.FavoriteDriverID)
 
 	if strings.TrimSpace(in.TimeStart) != "" {
 		req.TimeStart = strings.TrimSpace(in.TimeStart)
 	} else if strings.TrimSpace(in.Date) != "" && strings.TrimSpace(in.Time) != "" {
 		req.TimeStart = strings.TrimSpace(in.Date) + "T" + strings.TrimSpace(in.Time)
-	} else {
-		req.TimeStart = time.Now().Format("2006-01-02T15:04")
+	}
+	if err := h.resolvePickupTime(req); err != nil {
+		return nil, err
 	}
 
 	return req, nil
@@ -663,49 +765,59 @@ func (h *Handler) parseDeliveryRequest(r *http.Request) (*domain.DeliveryRequest
 	req.ToAddress = getValue("to_address")
 	req.Contact = getValue("contact")
 
+	stops, err := parseStopsField(getValue("stops"))
+	if err != nil {
+		return nil, err
+	}
+	if len(stops) > 0 {
+		req.ToAddress = stops[len(stops)-1].Address
+	}
+
 	if req.FromAddress == "" {
-		return nil, fmt.Errorf("адрес отправления обязателен")
+		return nil, i18n.E("from_address_required")
 	}
 	if req.ToAddress == "" {
-		return nil, fmt.Errorf("адрес назначения обязателен")
+		return nil, i18n.E("to_address_required")
 	}
 	if req.Contact == "" {
-		return nil, fmt.Errorf("контактный номер обязателен")
+		return nil, i18n.E("contact_required")
 	}
 
-	var err error
 	if latStr := getValue("from_lat"); latStr != "" {
 		req.FromLat, err = strconv.ParseFloat(latStr, 64)
 		if err != nil {
-			return nil, fmt.Errorf("неверная широта отправления: %v", err)
+			return nil, i18n.Wrap(err, "invalid_from_coordinates")
 		}
 	}
 	if lonStr := getValue("from_lon"); lonStr != "" {
 		req.FromLon, err = strconv.ParseFloat(lonStr, 64)
 		if err != nil {
-			return nil, fmt.Errorf("неверная долгота отправления: %v", err)
+			return nil, i18n.Wrap(err, "invalid_from_coordinates")
 		}
 	}
 	if latStr := getValue("to_lat"); latStr != "" {
 		req.ToLat, err = strconv.ParseFloat(latStr, 64)
 		if err != nil {
-			return nil, fmt.Errorf("неверная широта назначения: %v", err)
+			return nil, i18n.Wrap(err, "invalid_to_coordinates")
 		}
 	}
 	if lonStr := getValue("to_lon"); lonStr != "" {
 		req.ToLon, err = strconv.ParseFloat(lonStr, 64)
 		if err != nil {
-			return nil, fmt.Errorf("неверная долгота назначения: %v", err)
+			return nil, i18n.Wrap(err, "invalid_to_coordinates")
 		}
 	}
+	if err := h.applyStops(req, stops); err != nil {
+		return nil, err
+	}
 
 	if priceStr := getValue("price"); priceStr != "" {
 		req.Price, err = strconv.Atoi(priceStr)
-		if err != nil || req.Price < 2000 {
-			return nil, fmt.Errorf("цена должна быть не менее 2000 тенге")
+		if err != nil || req.Price <= 0 {
+			return nil, i18n.E("invalid_price")
 		}
 	} else {
-		return nil, fmt.Errorf("цена обязательна")
+		return nil, i18n.E("price_required")
 	}
 
 	if distStr := getValue("distance"); distStr != "" {
@@ -720,13 +832,20 @@ func (h *Handler) parseDeliveryRequest(r *http.Request) (*domain.DeliveryRequest
 
 	req.TruckType = getValue("truck_type")
 	req.Comment = getValue("comment")
+	req.PreferredDriverID = getValue("favorite_driver_id")
+	if req.Cargo, err = parseCargoForm(getValue); err != nil {
+		return nil, err
+	}
 
 	dateStr := getValue("date")
 	timeStr := getValue("time")
-	if dateStr != "" && timeStr != "" {
+	if ts := getValue("time_start"); ts != "" {
+		req.TimeStart = ts
+	} else if dateStr != "" && timeStr != "" {
 		req.TimeStart = dateStr + "T" + timeStr
-	} else {
-		req.TimeStart = time.Now().Format("2006-01-02T15:04")
+	}
+	if err := h.resolvePickupTime(req); err != nil {
+		return nil, err
 	}
 
 	return req, nil
@@ -738,20 +857,25 @@ func (h *Handler) saveUploadedPhoto(r *http.Request, requestID string) (string,
 		if errors.Is(err, http.ErrMissingFile) {
 			return "", nil
 		}
-		return "", fmt.Errorf("не удалось прочитать файл: %w", err)
+		return "", i18n.Wrap(err, "file_read_failed")
 	}
 	defer file.Close()
 
+	return h.storeImage(file, header, h.cfg.CargoPhoto, requestID)
+}
+
+// storeImage проверяет, что файл — картинка не больше MaxUploadSize, и пишет его как dir/name+ext
+func (h *Handler) storeImage(file multipart.File, header *multipart.FileHeader, dir, name string) (string, error) {
 	head := make([]byte, 512)
 	n, err := io.ReadFull(file, head)
 	if err != nil && err != io.ErrUnexpectedEOF {
-		return "", fmt.Errorf("ошибка чтения файла: %w", err)
+		return "", i18n.Wrap(err, "file_read_failed")
 	}
 	head = head[:n]
 
 	ctype := http.DetectContentType(head)
 	if !strings.HasPrefix(ctype, "image/") {
-		return "", fmt.Errorf("недопустимый тип файла: %s", ctype)
+		return "", i18n.E("invalid_file_type", ctype)
 	}
 
 	ext := strings.ToLower(filepath.Ext(header.Filename))
@@ -768,39 +892,39 @@ func (h *Handler) saveUploadedPhoto(r *http.Request, requestID string) (string,
 		}
 	}
 
-	fname := requestID + ext
-	dstPath := filepath.Join(h.cfg.CargoPhoto, fname)
+	fname := name + ext
+	dstPath := filepath.Join(dir, fname)
 
 	dst, err := os.Create(dstPath)
 	if err != nil {
-		return "", fmt.Errorf("не удалось создать файл: %w", err)
+		return "", i18n.Wrap(err, "file_save_failed")
 	}
 	defer func() { _ = dst.Close() }()
 
 	if _, err := dst.Write(head); err != nil {
-		return "", fmt.Errorf("ошибка записи файла: %w", err)
+		return "", i18n.Wrap(err, "file_save_failed")
 	}
 
 	remaining := h.cfg.MaxUploadSize - int64(len(head))
 	if remaining < 0 {
-		return "", fmt.Errorf("файл превышает 20MB")
+		return "", i18n.E("file_too_large", h.cfg.MaxUploadSize>>20)
 	}
 
 	written, err := io.Copy(dst, io.LimitReader(file, remaining))
 	if err != nil {
-		return "", fmt.Errorf("ошибка копирования файла: %w", err)
+		return "", i18n.Wrap(err, "file_save_failed")
 	}
 
 	if header.Size > 0 && header.Size > h.cfg.MaxUploadSize {
 		_ = os.Remove(dstPath)
-		return "", fmt.Errorf("файл слишком большой (>20MB)")
+		return "", i18n.E("file_too_large", h.cfg.MaxUploadSize>>20)
 	}
 
 	if header.Size == 0 && written == remaining {
 		buf := make([]byte, 1)
 		if _, err := file.Read(buf); err == nil {
 			_ = os.Remove(dstPath)
-			return "", fmt.Errorf("файл слишком большой (>20MB)")
+			return "", i18n.E("file_too_large", h.cfg.MaxUploadSize>>20)
 		}
 	}
 
@@ -808,10 +932,28 @@ func (h *Handler) saveUploadedPhoto(r *http.Request, requestID string) (string,
 }
 
 func (h *Handler) saveDeliveryRequest(req *domain.DeliveryRequest) (string, error) {
-	requestID := req.ID
-	if requestID == "" {
-		requestID = uuid.New().String()
-		req.ID = requestID
+	tx, err := h.db.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	if err := h.insertDeliveryRequest(tx, req); err != nil {
+		return "", err
+	}
+	if err := tx.Commit(); err != nil {
+		return "", err
+	}
+	for i := range req.Stops {
+		req.Stops[i].RequestID = req.ID
+	}
+	return req.ID, nil
+}
+
+// insertDeliveryRequest пишет заявку и её точки в открытой транзакции
+func (h *Handler) insertDeliveryRequest(tx *sql.Tx, req *domain.DeliveryRequest) error {
+	if req.ID == "" {
+		req.ID = uuid.New().String()
 	}
 
 	query := `
@@ -819,31 +961,65 @@ INSERT INTO delivery_requests (
     id, telegram_id, from_address, from_lat, from_lon,
     to_address, to_lat, to_lon, distance_km, eta_min,
     price, truck_type, contact, time_start, comment,
-    item_photo_path, status, created_at
+    item_photo_path, pickup_at, dispatched_at, status, created_at,
+    user_id, preferred_driver_id, recurring_id, trip_id,
+    ` + cargoColumns + `
 ) VALUES (
     ?, ?, ?, ?, ?,
     ?, ?, ?, ?, ?,
     ?, ?, ?, ?, ?,
-    ?, 'pending', CURRENT_TIMESTAMP
+    ?, ?, ?, 'pending', CURRENT_TIMESTAMP,
+    ?, ?, ?, ?,
+    ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
 )`
 
-	_, err := h.db.Exec(
-		query,
-		requestID, req.TelegramID, req.FromAddress, req.FromLat, req.FromLon,
+	// Отложенная заявка уходит водителям позже, в DispatchScheduledOrders
+	now := time.Now()
+	var pickupAt, dispatchedAt, recurringID interface{}
+	if req.PickupAt != nil {
+		pickupAt = sqliteTime(*req.PickupAt)
+	}
+	if !req.IsHeld(now, h.cfg.DispatchLeadTime) {
+		dispatchedAt = sqliteTime(now)
+		req.DispatchedAt = &now
+	}
+	if req.RecurringID != 0 {
+		recurringID = req.RecurringID
+	}
+
+	args := []interface{}{
+		req.ID, req.TelegramID, req.FromAddress, req.FromLat, req.FromLon,
 		req.ToAddress, req.ToLat, req.ToLon, req.DistanceKm, req.EtaMin,
 		req.Price, req.TruckType, req.Contact, req.TimeStart, req.Comment,
-		nullableString(req.CargoPhoto),
-	)
-	if err != nil {
-		return "", err
+		nullableString(req.CargoPhoto), pickupAt, dispatchedAt,
+		nullableString(req.UserID), nullableString(req.PreferredDriverID), recurringID, nullableString(req.TripID),
 	}
-	return requestID, nil
+	if _, err := tx.Exec(query, append(args, cargoArgs(req.Cargo)...)...); err != nil {
+		return err
+	}
+	return insertStops(tx, req.ID, req.Stops)
 }
 
 func (h *Handler) SendToDriver(ctx context.Context, b *bot.Bot, req *domain.DeliveryRequest) {
-	deltaLat := 30.0 / 111.32
+	if req.PreferredDriverID != "" && h.sendToFavoriteDriver(ctx, b, req) {
+		return
+	}
+
+	nearDrivers, err := h.findNearDrivers(ctx, req)
+	if err != nil {
+		h.logger.Error("NO DRIVERS", zap.Error(err))
+		return
+	}
+
+	h.broadcastOrder(ctx, b, req, nearDrivers)
+}
+
+// findNearDrivers — одобренные водители, чьи настройки (/settings) принимают заявку:
+// радиус от точки A, города, минимальная цена, типы машин, пауза, только межгород
+func (h *Handler) findNearDrivers(ctx context.Context, req *domain.DeliveryRequest) ([]domain.Driver, error) {
+	deltaLat := domain.MaxDriverRadiusKm / 111.32
 	latRad := req.FromLat * math.Pi / 180.0
-	deltaLon := 30.0 / (111.32 * math.Cos(latRad))
+	deltaLon := domain.MaxDriverRadiusKm / (111.32 * math.Cos(latRad))
 
 	minLat, maxLat := req.FromLat-deltaLat, req.FromLat+deltaLat
 	minLon, maxLon := req.FromLon-deltaLon, req.FromLon+deltaLon
@@ -855,26 +1031,46 @@ func (h *Handler) SendToDriver(ctx context.Context, b *bot.Bot, req *domain.Deli
 		MaxLong: maxLon,
 	}
 
-	nearDrivers, err := h.driverRepo.GetDriverNearA(ctx, nearADriver, req)
+	candidates, err := h.driverRepo.GetDriverNearA(ctx, nearADriver, req)
 	if err != nil {
-		h.logger.Error("NO DRIVERS", zap.Error(err))
-		return
+		return nil, err
 	}
 
-	text := buildKZOrderText(req)
-	replyMarkup := &models.InlineKeyboardMarkup{
-		InlineKeyboard: [][]models.InlineKeyboardButton{
-			{
-				{Text: "✅ Қабылдау", CallbackData: fmt.Sprintf("accept:%s", req.ID)},
-				{Text: "📍 Көру", CallbackData: fmt.Sprintf("view:%s", req.ID)},
-			},
-		},
+	dispatch := h.orderDispatch(req)
+	drivers := candidates[:0]
+	for _, d := range candidates {
+		if d.Prefs.Accepts(dispatch) {
+			drivers = append(drivers, d)
+		}
+	}
+	if skipped := len(candidates) - len(drivers); skipped > 0 {
+		h.logger.Info("drivers skipped by preferences",
+			zap.String("order_id", req.ID),
+			zap.Int("skipped", skipped),
+			zap.Int("left", len(drivers)))
 	}
+	return drivers, nil
+}
+
+func (h *Handler) orderDispatch(req *domain.DeliveryRequest) domain.OrderDispatch {
+	return domain.OrderDispatch{
+		Price:      req.Price,
+		TruckType:  strings.ToLower(strings.TrimSpace(req.TruckType)),
+		FromCity:   h.extractCityFromAddress(req.FromAddress),
+		DistanceKm: req.DistanceKm,
+		Now:        time.Now().In(h.cfg.Location()),
+	}
+}
+
+// broadcastOrder рассылает заявку водителям и запоминает message_id каждого сообщения
+func (h *Handler) broadcastOrder(ctx context.Context, b *bot.Bot, req *domain.DeliveryRequest, nearDrivers []domain.Driver) {
+	texts := h.newDriverOrderTexts(req)
 
 	ticker := time.NewTicker(60 * time.Millisecond)
 	defer ticker.Stop()
 
 	sent, failed := 0, 0
+	localNow := time.Now().In(h.cfg.Location())
 
 	for i := 0; i < len(nearDrivers); i++ {
 		select {
@@ -882,6 +1078,10 @@ func (h *Handler) SendToDriver(ctx context.Context, b *bot.Bot, req *domain.Deli
 			return
 		case <-ticker.C:
 			nearDriver := nearDrivers[i]
+			silent := nearDriver.Prefs.InQuietHours(localNow)
+			lang := h.userLocale(ctx, nearDriver.TelegramID)
+			text := texts.get(ctx, lang)
+			replyMarkup := orderBroadcastKeyboard(lang, req.ID)
 
 			if req.CargoPhoto != "" {
 				p := strings.TrimSpace(req.CargoPhoto)
@@ -890,14 +1090,15 @@ func (h *Handler) SendToDriver(ctx context.Context, b *bot.Bot, req *domain.Deli
 					if err != nil {
 						h.logger.Warn("open cargo photo", zap.String("path", req.CargoPhoto), zap.Error(err))
 					} else {
-						_, err = b.SendPhoto(ctx, &bot.SendPhotoParams{
+						msg, err := b.SendPhoto(ctx, &bot.SendPhotoParams{
 							ChatID: nearDriver.TelegramID,
 							Photo: &models.InputFileUpload{
 								Filename: filepath.Base(p),
 								Data:     file,
 							},
-							Caption:     text,
-							ReplyMarkup: replyMarkup,
+							Caption:             text,
+							ReplyMarkup:         replyMarkup,
+							DisableNotification: silent,
 						})
 						_ = file.Close()
 
@@ -907,15 +1108,17 @@ func (h *Handler) SendToDriver(ctx context.Context, b *bot.Bot, req *domain.Deli
 							continue
 						}
 						sent++
+						h.saveBroadcast(ctx, req.ID, nearDriver.TelegramID, msg.ID, true)
 						continue
 					}
 				}
 			}
 
-			_, err := b.SendMessage(ctx, &bot.SendMessageParams{
-				ChatID:      nearDriver.TelegramID,
-				Text:        text,
-				ReplyMarkup: replyMarkup,
+			msg, err := b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID:              nearDriver.TelegramID,
+				Text:                text,
+				ReplyMarkup:         replyMarkup,
+				DisableNotification: silent,
 			})
 			if err != nil {
 				failed++
@@ -923,6 +1126,7 @@ func (h *Handler) SendToDriver(ctx context.Context, b *bot.Bot, req *domain.Deli
 				continue
 			}
 			sent++
+			h.saveBroadcast(ctx, req.ID, nearDriver.TelegramID, msg.ID, false)
 		}
 	}
 
@@ -933,84 +1137,84 @@ func (h *Handler) SendToDriver(ctx context.Context, b *bot.Bot, req *domain.Deli
 	)
 }
 
-func (h *Handler) sendConfirmationMessage(b *bot.Bot, req *domain.DeliveryRequest, requestID string) {
-	if req.TelegramID == 0 {
-		h.logger.Warn("No Telegram ID provided, skipping confirmation message")
-		return
-	}
-
-	truckTypeText := h.formatTruckType(req.TruckType)
-	timeText := h.formatTimeStart(req.TimeStart)
-
-	message := fmt.Sprintf(`🚛 <b>Жеткізу тапсырысы берілді!</b>
-
-📋 <b>Өтінім нөмірі:</b> %s
-
-📍 <b>Қайдан:</b> %s
-🎯 <b>Қайда:</b> %s
-
-💰 <b>Бағасы:</b> %d ₸
-🚚 <b>Көлік түрі:</b> %s
-📱 <b>Байланыс:</b> %s
+// driverOrderTexts — текст заявки для рассылки, рендерится один раз на язык
+type driverOrderTexts struct {
+	h     *Handler
+	req   *domain.DeliveryRequest
+	texts map[i18n.Locale]string
+}
 
-🛣️ <b>Қашықтық:</b> %.1f км
-⏱️ <b>Болжамды уақыт:</b> %d мин
-%s`,
-		requestID,
-		req.FromAddress,
-		req.ToAddress,
-		req.Price,
-		truckTypeText,
-		req.Contact,
-		req.DistanceKm,
-		req.EtaMin,
-		timeText,
-	)
+func (h *Handler) newDriverOrderTexts(req *domain.DeliveryRequest) *driverOrderTexts {
+	return &driverOrderTexts{h: h, req: req, texts: make(map[i18n.Locale]string)}
+}
 
-	if req.Comment != "" {
-		message += fmt.Sprintf("\n💬 <b>Түсініктеме:</b> %s", req.Comment)
+func (t *driverOrderTexts) get(ctx context.Context, lang i18n.Locale) string {
+	text, ok := t.texts[lang]
+	if !ok {
+		text = t.h.orderTextForDrivers(ctx, lang, t.req)
+		t.texts[lang] = text
 	}
-	message += "\n\n✅ Сіздің өтініміңіз жүргізушілерге жіберілді!"
+	return text
+}
 
-	keyboard := &models.InlineKeyboardMarkup{
+func orderBroadcastKeyboard(lang i18n.Locale, requestID string) *models.InlineKeyboardMarkup {
+	// Быстрое предложение своей цены: +N% к цене клиента
+	var bidRow []models.InlineKeyboardButton
+	for _, p := range quickBidPercents {
+		bidRow = append(bidRow, models.InlineKeyboardButton{
+			Text:         fmt.Sprintf("💰 +%d%%", p),
+			CallbackData: fmt.Sprintf("bid:%s:%d", requestID, p),
+		})
+	}
+	return &models.InlineKeyboardMarkup{
 		InlineKeyboard: [][]models.InlineKeyboardButton{
 			{
-				{Text: "📋 Менің тапсырыстарым", CallbackData: "my_orders"},
-				{Text: "🔄 Жаңарту", CallbackData: fmt.Sprintf("refresh_order_%s", requestID)},
+				{Text: i18n.T(lang, "bot.order.btn_accept"), CallbackData: fmt.Sprintf("accept:%s", requestID)},
+				{Text: i18n.T(lang, "bot.order.btn_view"), CallbackData: fmt.Sprintf("view:%s", requestID)},
 			},
+			bidRow,
 		},
 	}
+}
+
+func (h *Handler) saveBroadcast(ctx context.Context, requestID string, driverTelegramID int64, messageID int, hasPhoto bool) {
+	if err := h.orderRepo.SaveBroadcast(ctx, domain.OrderBroadcast{
+		RequestID:        requestID,
+		DriverTelegramID: driverTelegramID,
+		MessageID:        messageID,
+		HasPhoto:         hasPhoto,
+	}); err != nil {
+		h.logger.Warn("save broadcast", zap.String("order_id", requestID), zap.Error(err))
+	}
+}
+
+func (h *Handler) sendConfirmationMessage(b *bot.Bot, req *domain.DeliveryRequest, requestID string) {
+	if req.TelegramID == 0 {
+		h.logger.Warn("No Telegram ID provided, skipping confirmation message")
+		return
+	}
 
 	ctx := context.Background()
+	lang := h.userLocale(ctx, req.TelegramID)
+	notice := h.newOrderNotice(lang, req)
+	notice.ID = requestID
 
-	if req.CargoPhoto != "" {
-		file, err := os.Open(req.CargoPhoto)
-		if err != nil {
-			h.logger.Error("Failed to open cargo photo", zap.Error(err))
-		} else {
-			defer file.Close()
-			_, err = b.SendPhoto(ctx, &bot.SendPhotoParams{
-				ChatID: req.TelegramID,
-				Photo: &models.InputFileUpload{
-					Filename: filepath.Base(file.Name()),
-					Data:     file,
-				},
-				Caption:     message,
-				ParseMode:   models.ParseModeHTML,
-				ReplyMarkup: keyboard,
-			})
-			if err == nil {
-				return
-			}
-			h.logger.Error("Failed to send confirmation photo", zap.Error(err))
-		}
+	keyboard := &models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{
+			{
+				{Text: i18n.T(lang, "bot.order.btn_my_orders"), CallbackData: "my_orders"},
+				{Text: i18n.T(lang, "bot.order.btn_refresh"), CallbackData: fmt.Sprintf("refresh_order_%s", requestID)},
+			},
+		},
 	}
 
-	_, err := b.SendMessage(ctx, &bot.SendMessageParams{
-		ChatID:      req.TelegramID,
-		Text:        message,
-		ParseMode:   models.ParseModeHTML,
-		ReplyMarkup: keyboard,
+	err := h.notifier.Send(ctx, notifier.Message{
+		Template: "order_created",
+		Locale:   lang,
+		Data:     notice,
+		To:       notifier.Recipient{TelegramID: req.TelegramID, Phone: req.Contact},
+		Markup:   keyboard,
+		Photo:    req.CargoPhoto,
 	})
 	if err != nil {
 		h.logger.Error("Failed to send confirmation message", zap.Error(err))
@@ -1018,25 +1222,39 @@ func (h *Handler) sendConfirmationMessage(b *bot.Bot, req *domain.DeliveryReques
 }
 
 func (h *Handler) calculateRoute(fromLat, fromLon, toLat, toLon float64) (float64, int) {
-	if fromLat == 0 || fromLon == 0 || toLat == 0 || toLon == 0 {
-		return 10.0, 30
+	return h.calculateRouteVia([]routePoint{{fromLat, fromLon}, {toLat, toLon}})
+}
+
+// calculateRouteVia считает маршрут через все точки по порядку
+func (h *Handler) calculateRouteVia(points []routePoint) (float64, int) {
+	for _, p := range points {
+		if p.Lat == 0 || p.Lon == 0 {
+			return 10.0, 30
+		}
 	}
 
-	actualDistance, actualDuration := h.getOSRMRoute(fromLat, fromLon, toLat, toLon)
+	actualDistance, actualDuration := h.getOSRMRoute(points)
 	if actualDistance > 0 {
 		return actualDistance, actualDuration
 	}
 
-	straightDistance := h.haversineDistance(fromLat, fromLon, toLat, toLon)
+	var straightDistance float64
+	for i := 1; i < len(points); i++ {
+		straightDistance += h.haversineDistance(points[i-1].Lat, points[i-1].Lon, points[i].Lat, points[i].Lon)
+	}
 	roadDistance := straightDistance * 1.3
 	drivingTimeMinutes := int((roadDistance / 35.0) * 60)
 
 	return roadDistance, drivingTimeMinutes
 }
 
-func (h *Handler) getOSRMRoute(fromLat, fromLon, toLat, toLon float64) (float64, int) {
-	url := fmt.Sprintf("http://router.project-osrm.org/route/v1/driving/%f,%f;%f,%f?overview=false&steps=false",
-		fromLon, fromLat, toLon, toLat)
+func (h *Handler) getOSRMRoute(points []routePoint) (float64, int) {
+	coords := make([]string, len(points))
+	for i, p := range points {
+		coords[i] = fmt.Sprintf("%f,%f", p.Lon, p.Lat)
+	}
+	url := fmt.Sprintf("http://router.project-osrm.org/route/v1/driving/%s?overview=false&steps=false",
+		strings.Join(coords, ";"))
 
 	client := &http.Client{Timeout: 5 * time.Second}
 	resp, err := client.Get(url)
@@ -1085,13 +1303,13 @@ func (h *Handler) handleDriverRequest(w http.ResponseWriter, r *http.Request) {
 	var params DriverRequestParams
 	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
 		h.logger.Error("❌ Failed to parse request body", zap.Error(err))
-		h.sendErrorResponse(w, "Неверные данные запроса", http.StatusBadRequest)
+		h.sendErrorResponse(w, r, "invalid_request", http.StatusBadRequest)
 		return
 	}
 
 	if !h.isValidCoordinates(params.PickupLat, params.PickupLon) ||
 		!h.isValidCoordinates(params.DropoffLat, params.DropoffLon) {
-		h.sendErrorResponse(w, "Некорректные координаты", http.StatusBadRequest)
+		h.sendErrorResponse(w, r, "invalid_coordinates", http.StatusBadRequest)
 		return
 	}
 
@@ -1102,15 +1320,15 @@ func (h *Handler) handleDriverRequest(w http.ResponseWriter, r *http.Request) {
 	drivers, err := h.findDriversByRouteMatching(
 		params.PickupLat, params.PickupLon,
 		params.DropoffLat, params.DropoffLon,
-		params.RadiusKm, params.TruckType,
+		params.RadiusKm, params.TruckType, params.Cargo,
 	)
 	if err != nil {
 		h.logger.Error("❌ Route matching failed", zap.Error(err))
-		h.sendErrorResponse(w, "Ошибка поиска водителей", http.StatusInternalServerError)
+		h.sendErrorResponse(w, r, "driver_search_failed", http.StatusInternalServerError)
 		return
 	}
 
-	h.sendSuccessResponse(w, "Водители найдены", map[string]interface{}{
+	h.sendSuccessResponse(w, r, "drivers_found", map[string]interface{}{
 		"drivers": drivers,
 		"count":   len(drivers),
 	})
@@ -1121,8 +1339,9 @@ func (h *Handler) handleDriverRequest(w http.ResponseWriter, r *http.Request) {
 // =================================
 
 // findDriversByRouteMatching finds drivers whose trip A→B matches client's route A→B
+// and whose vehicle can carry the cargo
 func (h *Handler) findDriversByRouteMatching(
-	clientFromLat, clientFromLon, clientToLat, clientToLon, radiusKm float64, truckType string,
+	clientFromLat, clientFromLon, clientToLat, clientToLon, radiusKm float64, truckType string, cargo domain.CargoSpec,
 ) ([]DriverWithTrip, error) {
 
 	h.logger.Info("🔍 Starting ROUTE-TO-ROUTE matching",
@@ -1141,7 +1360,11 @@ func (h *Handler) findDriversByRouteMatching(
 			dt.id, dt.from_address, dt.from_lat, dt.from_lon,
 			dt.to_address, dt.to_lat, dt.to_lon,
 			dt.price, dt.start_time, dt.comment, 
-			dt.distance_km, dt.eta_min, dt.truck_type
+			dt.distance_km, dt.eta_min, dt.truck_type,
+			COALESCE(d.truck_type, ''), COALESCE(dt.max_weight, 0),
+			COALESCE(dt.booked_weight, 0), COALESCE(dt.booked_volume, 0),
+			COALESCE(d.rating, 0), COALESCE(d.rating_count, 0), COALESCE(d.cancel_count, 0),
+			d.max_weight_kg, d.max_volume_m3, d.body_length_cm, d.body_width_cm, d.body_height_cm
 		FROM drivers d
 		INNER JOIN driver_trips dt ON d.id = dt.driver_id
 		WHERE d.status = 'approved'
@@ -1152,25 +1375,14 @@ func (h *Handler) findDriversByRouteMatching(
 		  AND dt.to_lon IS NOT NULL AND dt.to_lon != 0
 		  AND dt.from_address IS NOT NULL AND dt.from_address != ''
 		  AND dt.to_address IS NOT NULL AND dt.to_address != ''
-		  AND dt.created_at >= datetime('now', '-48 hours')
+		  AND dt.departure_time > ?
 	`
 
-	// Add truck_type filter if specified
-	if truckType != "" && truckType != "any" {
-		baseQuery += ` AND dt.truck_type = ?`
-	}
-
+	// truck_type больше не фильтруется в SQL: подходящую машину определяет вместимость
 	baseQuery += ` ORDER BY dt.created_at DESC LIMIT 200`
 
-	var rows *sql.Rows
-	var err error
-
-	if truckType != "" && truckType != "any" {
-		rows, err = h.db.Query(baseQuery, truckType)
-	} else {
-		rows, err = h.db.Query(baseQuery)
-	}
-
+	orderType := strings.ToLower(strings.TrimSpace(truckType))
+	rows, err := h.db.Query(baseQuery, h.tripExpiryCutoff())
 	if err != nil {
 		h.logger.Error("❌ Database query failed", zap.Error(err))
 		return nil, err
@@ -1183,14 +1395,22 @@ func (h *Handler) findDriversByRouteMatching(
 
 	for rows.Next() {
 		var driver DriverWithTrip
-		err := rows.Scan(
+		var vehicleType string
+		var tripMaxWeight int
+		var load domain.TripLoad
+		var vehicle domain.VehicleCapacity
+		dest := []interface{}{
 			&driver.ID, &driver.TelegramID, &driver.FirstName, &driver.LastName,
 			&driver.ContactNumber, &driver.ProfilePhoto,
 			&driver.TripID, &driver.FromAddress, &driver.FromLat, &driver.FromLon,
 			&driver.ToAddress, &driver.ToLat, &driver.ToLon,
 			&driver.Price, &driver.StartTime, &driver.Comment,
 			&driver.DistanceKm, &driver.EtaMin, &driver.TruckType,
-		)
+			&vehicleType, &tripMaxWeight,
+			&load.WeightKg, &load.VolumeM3,
+			&driver.Rating, &driver.RatingCount, &driver.CancelCount,
+		}
+		err := rows.Scan(append(dest, capacityScanDest(&vehicle)...)...)
 		if err != nil {
 			h.logger.Error("❌ Row scan error", zap.Error(err))
 			continue
@@ -1198,6 +1418,17 @@ func (h *Handler) findDriversByRouteMatching(
 
 		scannedCount++
 
+		driver.Capacity = tripCapacity(tripVehicleType(driver.TruckType, vehicleType), tripMaxWeight, vehicle)
+		if !driver.Capacity.CanCarry(orderType, cargo) {
+			continue
+		}
+		// Часть места уже занята бронями других клиентов
+		driver.FreeSpace = driver.Capacity.FreeSpace(load)
+		if !driver.FreeSpace.Fits(cargo) {
+			continue
+		}
+		driver.CapacityFit = driver.Capacity.FitScore(orderType, cargo)
+
 		// Calculate distances using Go's haversine (not SQL)
 		distancePickupToPickup := h.haversineDistance(
 			clientFromLat, clientFromLon,
@@ -1241,13 +1472,18 @@ func (h *Handler) findDriversByRouteMatching(
 		}
 	}
 
-	// Sort by combined distance score
+	// Sort by combined distance score; машина "впритык" по размеру выигрывает до 10 км,
+	// каждая звезда рейтинга — ещё 3 км, каждый отказ от заявки — 2 км
+	score := func(d DriverWithTrip) float64 {
+		return d.DistanceToPickupKm + d.DistanceToDropoffKm*0.5 + (1-d.CapacityFit)*10 +
+			(5-domain.EffectiveRating(d.Rating, d.RatingCount))*3 + cancelPenalty(d.CancelCount)
+	}
 	sort.Slice(matchedDrivers, func(i, j int) bool {
-		scoreI := matchedDrivers[i].DistanceToPickupKm + matchedDrivers[i].DistanceToDropoffKm*0.5
-		scoreJ := matchedDrivers[j].DistanceToPickupKm + matchedDrivers[j].DistanceToDropoffKm*0.5
-		return scoreI < scoreJ
+		return score(matchedDrivers[i]) < score(matchedDrivers[j])
 	})
 
+	h.attachDriverReviews(context.Background(), matchedDrivers)
+
 	h.logger.Info("🎯 Route-to-route matching completed",
 		zap.Int("scanned_count", scannedCount),
 		zap.Int("matched_count", matchedCount),
@@ -1353,18 +1589,53 @@ func (h *Handler) extractCityFromAddress(address string) string {
 // RESPONSE HELPERS
 // =================================
 
-func (h *Handler) sendErrorResponse(w http.ResponseWriter, message string, statusCode int) {
-	w.WriteHeader(statusCode)
-	_ = json.NewEncoder(w).Encode(Response{
+// errorResponse — ответ с кодом ошибки и текстом на языке запроса
+func errorResponse(r *http.Request, err i18n.Coded) Response {
+	return Response{
 		Success: false,
-		Message: message,
-	})
+		Code:    err.ErrorCode(),
+		Params:  err.ErrorParams(),
+		Message: err.Localize(i18n.FromContext(r.Context())),
+	}
+}
+
+// sendErrorResponse отвечает ошибкой из каталога: code — ключ "error.<code>", params — для текста
+func (h *Handler) sendErrorResponse(w http.ResponseWriter, r *http.Request, code string, statusCode int, params ...interface{}) {
+	h.writeErrorResponse(w, r, i18n.E(code, params...), statusCode)
 }
 
-func (h *Handler) sendSuccessResponse(w http.ResponseWriter, message string, data ...interface{}) {
+// sendError отвечает ошибкой валидации. Ошибки без кода не показываем как есть —
+// логируем и отдаём общий invalid_request/internal.
+func (h *Handler) sendError(w http.ResponseWriter, r *http.Request, err error, statusCode int) {
+	var coded i18n.Coded
+	if !errors.As(err, &coded) {
+		h.logger.Warn("Uncoded error in API response", zap.String("path", r.URL.Path), zap.Error(err))
+		code := "invalid_request"
+		if statusCode >= http.StatusInternalServerError {
+			code = "internal"
+		}
+		coded = i18n.E(code)
+	} else if errors.Unwrap(coded) != nil {
+		h.logger.Warn("API error", zap.String("path", r.URL.Path), zap.Error(err))
+	}
+	h.writeErrorResponse(w, r, coded, statusCode)
+}
+
+func (h *Handler) writeErrorResponse(w http.ResponseWriter, r *http.Request, err i18n.Coded, statusCode int) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(errorResponse(r, err))
+}
+
+// successText — текст успешного ответа из каталога: code — ключ "success.<code>"
+func successText(r *http.Request, code string) string {
+	return i18n.T(i18n.FromContext(r.Context()), "success."+code)
+}
+
+func (h *Handler) sendSuccessResponse(w http.ResponseWriter, r *http.Request, code string, data ...interface{}) {
 	response := Response{
 		Success: true,
-		Message: message,
+		Message: successText(r, code),
 	}
 	if len(data) > 0 {
 		response.Data = data[0]
@@ -1376,25 +1647,21 @@ func (h *Handler) sendSuccessResponse(w http.ResponseWriter, message string, dat
 // TEXT FORMATTING HELPERS
 // =================================
 
-func (h *Handler) formatTruckType(truckType string) string {
-	types := map[string]string{
-		"small":        "Кіші көлік (до 1.5т) | Малый (до 1.5т)",
-		"medium":       "Орташа көлік (до 5т) | Средний (до 5т)",
-		"large":        "Үлкен көлік (до 20т) | Большой (до 20т)",
-		"refrigerator": "Рефрижератор | Рефрижератор",
-		"tow":          "Эвакуатор | Эвакуатор",
-		"any":          "Кез келген көлік | Любой транспорт",
-		"":             "Көрсетілмеген | Не указан",
+func (h *Handler) formatTruckType(lang i18n.Locale, truckType string) string {
+	key := "bot.truck." + truckType
+	if truckType == "" {
+		key = "bot.truck.none"
 	}
-	if formatted, exists := types[truckType]; exists {
-		return formatted
+	if i18n.Has(key) {
+		return i18n.T(lang, key)
 	}
-	return fmt.Sprintf("%s | %s", truckType, truckType)
+	return truckType
 }
 
-func (h *Handler) formatTimeStart(timeStart string) string {
+// timeStartLabel — время подачи без разметки для шаблонов
+func (h *Handler) timeStartLabel(lang i18n.Locale, timeStart string) string {
 	if timeStart == "" {
-		return "🕐 <b>Кету уақыты:</b> Қазір | Сейчас"
+		return i18n.T(lang, "bot.time.now")
 	}
 
 	t, err := time.Parse("2006-01-02T15:04", timeStart)
@@ -1402,56 +1669,31 @@ func (h *Handler) formatTimeStart(timeStart string) string {
 		if t2, err2 := time.Parse("2006-01-02 15:04", timeStart); err2 == nil {
 			t = t2
 		} else {
-			return fmt.Sprintf("🕐 <b>Кету уақыты:</b> %s", timeStart)
+			return timeStart
 		}
 	}
 
 	now := time.Now()
 	if t.Format("2006-01-02") == now.Format("2006-01-02") {
-		return fmt.Sprintf("🕐 <b>Кету уақыты:</b> Бүгін %s | Сегодня %s",
-			t.Format("15:04"), t.Format("15:04"))
+		return i18n.T(lang, "bot.time.today", t.Format("15:04"))
 	}
 
 	if t.After(now) && t.Before(now.Add(24*time.Hour)) && t.Day() != now.Day() {
-		return fmt.Sprintf("🕐 <b>Кету уақыты:</b> Ертең %s | Завтра %s",
-			t.Format("15:04"), t.Format("15:04"))
+		return i18n.T(lang, "bot.time.tomorrow", t.Format("15:04"))
 	}
 
-	return fmt.Sprintf("🕐 <b>Кету уақыты:</b> %s %s",
+	return fmt.Sprintf("%s %s",
 		t.Format("02.01.2006"), t.Format("15:04"))
 }
 
-func buildKZOrderText(r *domain.DeliveryRequest) string {
-	comment := r.Comment
-	if comment == "" {
-		comment = "—"
-	}
-
-	return fmt.Sprintf(
-		`Жаңа тапсырыс!
-Бастау: %s
-Мекенжай: %s
-Қашықтық: %.1f км
-ETA: %d мин
-Тасымал түрі: %s
-Байланыс: %s
-Баға: %d ₸
-Ескерту: %s
-
-Қабылдағыңыз келсе, төмендегі «Қабылдау» батырмасын басыңыз.`,
-		nonEmpty(r.FromAddress, "A нүктесі"),
-		nonEmpty(r.ToAddress, "B нүктесі"),
-		r.DistanceKm, r.EtaMin,
-		nonEmpty(r.TruckType, "кез келген"),
-		r.Contact, r.Price, comment,
-	)
-}
-
-func nonEmpty(s, def string) string {
-	if s == "" {
-		return def
+// buildOrderText — текст заявки для водителей без разметки (шаблон order_broadcast)
+func (h *Handler) buildOrderText(lang i18n.Locale, r *domain.DeliveryRequest) string {
+	rendered, err := h.notifier.Render("order_broadcast", lang, notifier.FormatPlain, h.newOrderNotice(lang, r))
+	if err != nil {
+		h.logger.Error("Failed to render order text", zap.String("order_id", r.ID), zap.Error(err))
+		return i18n.T(lang, "bot.order.new_short", r.FromAddress, r.ToAddress, r.Price)
 	}
-	return s
+	return rendered.Text
 }
 
 func nullableString(s string) interface{} {
@@ -0,0 +1,104 @@
+// price-handler.go
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"tezjet/internal/domain"
+
+	"go.uber.org/zap"
+)
+
+// suggestPrice оценивает по прошлым заявкам той же корзины (тип машины, расстояние,
+// часть суток), с какой цены заявку скорее всего примут. Если истории мало —
+// сначала расширяем корзину на любое время суток, затем отдаём цену по тарифу.
+func (h *Handler) suggestPrice(ctx context.Context, fromAddress, truckType string, distanceKm float64, etaMin int, pickupAt time.Time) domain.PriceSuggestion {
+	quote := h.quotePrice(ctx, fromAddress, truckType, distanceKm, etaMin)
+	sug := domain.PriceSuggestion{
+		Source:         "tariff",
+		SuggestedPrice: quote.Recommended,
+		Target:         domain.PriceSuggestTarget,
+		Quote:          quote,
+	}
+
+	lo, hi := domain.DistanceBucket(distanceKm)
+	samples, err := h.orderRepo.GetPriceSamples(ctx, truckType, lo, hi, time.Now().Add(-domain.PriceHistoryWindow))
+	if err != nil {
+		h.logger.Error("load price samples", zap.Error(err))
+		return sug
+	}
+
+	loc := h.cfg.Location()
+	bucket := domain.TimeBucket(pickupAt.In(loc))
+	var sameTime []domain.PriceSample
+	for _, s := range samples {
+		if domain.TimeBucket(s.PickupAt.In(loc)) == bucket {
+			sameTime = append(sameTime, s)
+		}
+	}
+	pool := sameTime
+	if len(pool) < domain.MinPriceSamples {
+		pool = samples
+	}
+	sug.Samples = len(pool)
+	if len(pool) < domain.MinPriceSamples {
+		return sug
+	}
+
+	sug.Curve = domain.AcceptanceCurve(pool)
+	price := domain.PriceForProbability(sug.Curve, domain.PriceSuggestTarget)
+	if price == 0 {
+		// в этой корзине плохо принимают при любой цене — история не помогает
+		return sug
+	}
+	if price < quote.MinPrice {
+		price = quote.MinPrice
+	}
+	sug.Source = "history"
+	sug.SuggestedPrice = price
+	return sug
+}
+
+// handleSuggestPrice — подсказка "скорее всего примут от X ₸" для формы Mini App.
+// GET /api/price/suggest?from_address=&truck_type=&distance=&duration=&time_start=
+// Без distance маршрут считается по from_lat/from_lon/to_lat/to_lon, без time_start — сейчас.
+// По from_lat/from_lon добавляется подсказка повышенного спроса в зоне подачи.
+func (h *Handler) handleSuggestPrice(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	q := r.URL.Query()
+	distance, duration, ok := h.routeFromQuery(q)
+	if !ok {
+		h.sendErrorResponse(w, r, "route_distance_required", http.StatusBadRequest)
+		return
+	}
+	pickupAt := time.Now()
+	if ts := strings.TrimSpace(q.Get("time_start")); ts != "" {
+		t, err := h.parsePickupTime(ts)
+		if err != nil {
+			h.sendError(w, r, err, http.StatusBadRequest)
+			return
+		}
+		pickupAt = t
+	}
+
+	sug := h.suggestPrice(r.Context(), strings.TrimSpace(q.Get("from_address")), strings.TrimSpace(q.Get("truck_type")),
+		distance, duration, pickupAt)
+	fromLat, _ := strconv.ParseFloat(q.Get("from_lat"), 64)
+	fromLon, _ := strconv.ParseFloat(q.Get("from_lon"), 64)
+	sug.Surge = h.surgeUplift(r.Context(), fromLat, fromLon, sug.SuggestedPrice, pickupAt)
+	h.sendSuccessResponse(w, r, "price_calculated", map[string]interface{}{
+		"suggestion": sug,
+		"distance":   distance,
+		"eta":        duration,
+	})
+}
@@ -0,0 +1,521 @@
+// profile-handler.go
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"tezjet/internal/domain"
+	"tezjet/internal/i18n"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"go.uber.org/zap"
+)
+
+// ensureClientProfile — профиль клиента в users, создаётся при первом обращении
+func (h *Handler) ensureClientProfile(ctx context.Context, telegramID int64, username, firstName, lastName string) (*domain.User, error) {
+	return h.userRepo.EnsureUser(ctx, &domain.CreateUserRequest{
+		TelegramID:       telegramID,
+		TelegramUsername: strings.TrimSpace(username),
+		FirstName:        strings.TrimSpace(firstName),
+		LastName:         strings.TrimSpace(lastName),
+	})
+}
+
+// prepareClientOrder привязывает заявку к профилю клиента (создаётся при первой заявке)
+// и проверяет избранного водителя. Ошибка — из каталога, её можно показать клиенту.
+func (h *Handler) prepareClientOrder(ctx context.Context, req *domain.DeliveryRequest, username, firstName, lastName string) error {
+	if req.TelegramID != 0 {
+		user, err := h.ensureClientProfile(ctx, req.TelegramID, username, firstName, lastName)
+		if err != nil {
+			h.logger.Warn("Failed to ensure client profile", zap.Int64("telegram_id", req.TelegramID), zap.Error(err))
+		} else {
+			req.UserID = user.ID
+		}
+	}
+	if req.PreferredDriverID != "" {
+		return h.checkFavoriteForOrder(ctx, req.UserID, req.PreferredDriverID)
+	}
+	return nil
+}
+
+// favoriteDriverTarget returns the telegram id and status of a driver for personal dispatch
+func (h *Handler) favoriteDriverTarget(ctx context.Context, driverID string) (int64, string, error) {
+	var tgID int64
+	var status string
+	err := h.db.QueryRowContext(ctx, `SELECT telegram_id, status FROM drivers WHERE id = ?`, driverID).Scan(&tgID, &status)
+	if err == sql.ErrNoRows {
+		return 0, "", nil
+	}
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to load favorite driver: %w", err)
+	}
+	return tgID, status, nil
+}
+
+// checkFavoriteForOrder проверяет, можно ли отправить заявку избранному водителю.
+// Ошибка — из каталога, её можно показать клиенту; nil — всё в порядке.
+func (h *Handler) checkFavoriteForOrder(ctx context.Context, userID, driverID string) error {
+	if userID == "" {
+		return i18n.E("profile_not_found")
+	}
+	fav, err := h.userRepo.IsFavoriteDriver(ctx, userID, driverID)
+	if err != nil {
+		h.logger.Error("Failed to check favorite driver", zap.Error(err))
+		return i18n.E("favorite_check_failed")
+	}
+	if !fav {
+		return i18n.E("favorite_not_found")
+	}
+	_, status, err := h.favoriteDriverTarget(ctx, driverID)
+	if err != nil {
+		h.logger.Error("Failed to load favorite driver", zap.Error(err))
+		return i18n.E("favorite_check_failed")
+	}
+	if status != "approved" || h.checkDispatchCooldown(ctx, driverID) != nil {
+		return i18n.E("favorite_unavailable")
+	}
+	return nil
+}
+
+// sendToFavoriteDriver отправляет заявку только избранному водителю и открывает окно ожидания.
+// Returns false if the order should go to the usual dispatch right away.
+func (h *Handler) sendToFavoriteDriver(ctx context.Context, b *bot.Bot, req *domain.DeliveryRequest) bool {
+	until := time.Now().Add(h.cfg.FavoriteDriverTimeout)
+	started, err := h.orderRepo.StartPreferredWindow(ctx, req.ID, until)
+	if err != nil {
+		h.logger.Error("start preferred window", zap.String("order_id", req.ID), zap.Error(err))
+	}
+	if err != nil || !started {
+		// окно уже было — второй раз не ждём, снимаем закрепление и рассылаем всем
+		h.releasePreferred(ctx, req.ID)
+		return false
+	}
+
+	tgID, status, err := h.favoriteDriverTarget(ctx, req.PreferredDriverID)
+	if err != nil || tgID == 0 || status != "approved" {
+		h.logger.Warn("favorite driver unavailable, dispatching to all",
+			zap.String("order_id", req.ID), zap.String("driver_id", req.PreferredDriverID), zap.Error(err))
+		h.releasePreferred(ctx, req.ID)
+		return false
+	}
+	// водитель на паузе или в отпуске — не ждём его, рассылаем всем
+	prefs, err := h.prefsRepo.GetPreferences(ctx, tgID)
+	if err == nil && prefs.IsPaused(time.Now()) {
+		h.logger.Info("favorite driver paused, dispatching to all",
+			zap.String("order_id", req.ID), zap.String("driver_id", req.PreferredDriverID))
+		h.releasePreferred(ctx, req.ID)
+		return false
+	}
+	req.PreferredUntil = &until
+
+	minutes := int(h.cfg.FavoriteDriverTimeout.Minutes())
+	if _, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    tgID,
+		Text:      i18n.N(h.userLocale(ctx, tgID), "bot.favorite.driver_chosen", minutes),
+		ParseMode: models.ParseModeHTML,
+	}); err != nil {
+		h.logger.Warn("notify favorite driver", zap.Int64("tg_id", tgID), zap.Error(err))
+	}
+	h.broadcastOrder(ctx, b, req, []domain.Driver{{ID: req.PreferredDriverID, TelegramID: tgID, Prefs: prefs}})
+
+	if req.TelegramID != 0 {
+		if _, err := b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:    req.TelegramID,
+			Text:      i18n.N(h.userLocale(ctx, req.TelegramID), "bot.favorite.client_sent", minutes, req.ID),
+			ParseMode: models.ParseModeHTML,
+		}); err != nil {
+			h.logger.Warn("notify client favorite dispatch", zap.Int64("tg_id", req.TelegramID), zap.Error(err))
+		}
+	}
+
+	h.logger.Info("order sent to favorite driver",
+		zap.String("order_id", req.ID),
+		zap.String("driver_id", req.PreferredDriverID),
+		zap.Time("until", until))
+	return true
+}
+
+func (h *Handler) releasePreferred(ctx context.Context, orderID string) {
+	if _, _, err := h.orderRepo.ReleasePreferred(ctx, orderID); err != nil {
+		h.logger.Error("release preferred driver", zap.String("order_id", orderID), zap.Error(err))
+	}
+}
+
+// releaseFavoriteOrders — избранный водитель не принял заявку вовремя: открываем её всем
+func (h *Handler) releaseFavoriteOrders(ctx context.Context, b *bot.Bot) {
+	ids, err := h.orderRepo.GetPreferredExpired(ctx)
+	if err != nil {
+		h.logger.Error("load expired favorite orders", zap.Error(err))
+		return
+	}
+	for _, id := range ids {
+		driverID, ok, err := h.orderRepo.ReleasePreferred(ctx, id)
+		if err != nil {
+			h.logger.Error("release preferred driver", zap.String("order_id", id), zap.Error(err))
+			continue
+		}
+		if !ok {
+			continue
+		}
+
+		order, err := h.getDeliveryOrderById(id)
+		if err != nil || order == nil {
+			h.logger.Error("load released order", zap.String("order_id", id), zap.Error(err))
+			continue
+		}
+		if order.Status != domain.DeliveryStatusPending || order.DispatchedAt == nil {
+			continue
+		}
+		if assigned, err := h.orderHasDriver(ctx, id); err != nil || assigned {
+			continue
+		}
+		h.logger.Info("favorite driver window expired, dispatching to all", zap.String("order_id", id))
+
+		if order.TelegramID != 0 {
+			if _, err := b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID:    order.TelegramID,
+				Text:      i18n.T(h.userLocale(ctx, order.TelegramID), "bot.favorite.expired", order.ID),
+				ParseMode: models.ParseModeHTML,
+			}); err != nil {
+				h.logger.Warn("notify client favorite expired", zap.Int64("tg_id", order.TelegramID), zap.Error(err))
+			}
+		}
+
+		favTgID, _, _ := h.favoriteDriverTarget(ctx, driverID)
+		h.redispatchOrder(ctx, b, order, favTgID)
+	}
+}
+
+// handleUserProfile — профиль клиента с адресами и избранными водителями.
+// GET /api/user/profile?telegram_id=
+// POST /api/user/profile {telegram_id, telegram_username, first_name, last_name, phone_number, language_code}
+func (h *Handler) handleUserProfile(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	var user *domain.User
+	switch r.Method {
+	case http.MethodGet:
+		telegramID, _ := strconv.ParseInt(r.URL.Query().Get("telegram_id"), 10, 64)
+		if telegramID == 0 {
+			h.sendErrorResponse(w, r, "telegram_id_required", http.StatusBadRequest)
+			return
+		}
+		exists, _, err := h.userRepo.CheckUserExists(telegramID)
+		if err != nil {
+			h.sendErrorResponse(w, r, "profile_load_failed", http.StatusInternalServerError)
+			return
+		}
+		if !exists {
+			// профиля ещё нет — он появится с первой заявкой
+			h.sendSuccessResponse(w, r, "client_profile", map[string]interface{}{
+				"profile":   nil,
+				"addresses": []domain.SavedAddress{},
+				"favorites": []domain.FavoriteDriver{},
+			})
+			return
+		}
+		if user, err = h.userRepo.GetUserByTelegramID(telegramID); err != nil {
+			h.sendErrorResponse(w, r, "profile_load_failed", http.StatusInternalServerError)
+			return
+		}
+
+	default:
+		var reqData struct {
+			TelegramID       int64  `json:"telegram_id"`
+			TelegramUsername string `json:"telegram_username"`
+			FirstName        string `json:"first_name"`
+			LastName         string `json:"last_name"`
+			PhoneNumber      string `json:"phone_number"`
+			LanguageCode     string `json:"language_code"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+			h.logger.Error("Failed to parse request body", zap.Error(err))
+			h.sendErrorResponse(w, r, "invalid_request", http.StatusBadRequest)
+			return
+		}
+		if reqData.TelegramID == 0 {
+			h.sendErrorResponse(w, r, "telegram_id_required", http.StatusBadRequest)
+			return
+		}
+
+		var err error
+		user, err = h.ensureClientProfile(r.Context(), reqData.TelegramID, reqData.TelegramUsername, reqData.FirstName, reqData.LastName)
+		if err != nil {
+			h.logger.Error("Failed to ensure client profile", zap.Int64("telegram_id", reqData.TelegramID), zap.Error(err))
+			h.sendErrorResponse(w, r, "profile_save_failed", http.StatusInternalServerError)
+			return
+		}
+
+		updates := map[string]interface{}{}
+		if v := strings.TrimSpace(reqData.FirstName); v != "" {
+			updates["first_name"] = v
+		}
+		if v := strings.TrimSpace(reqData.LastName); v != "" {
+			updates["last_name"] = v
+		}
+		if v := strings.TrimSpace(reqData.PhoneNumber); v != "" {
+			updates["phone_number"] = v
+		}
+		if loc, ok := i18n.Match(reqData.LanguageCode); ok {
+			updates["language_code"] = string(loc)
+		}
+		if len(updates) > 0 {
+			if err := h.userRepo.UpdateUser(reqData.TelegramID, updates); err != nil {
+				h.sendErrorResponse(w, r, "profile_save_failed", http.StatusInternalServerError)
+				return
+			}
+			if user, err = h.userRepo.GetUserByTelegramID(reqData.TelegramID); err != nil {
+				h.sendErrorResponse(w, r, "profile_load_failed", http.StatusInternalServerError)
+				return
+			}
+		}
+	}
+
+	addresses, err := h.userRepo.ListAddresses(r.Context(), user.ID)
+	if err != nil {
+		h.sendErrorResponse(w, r, "addresses_load_failed", http.StatusInternalServerError)
+		return
+	}
+	favorites, err := h.userRepo.ListFavoriteDrivers(r.Context(), user.ID)
+	if err != nil {
+		h.sendErrorResponse(w, r, "favorites_load_failed", http.StatusInternalServerError)
+		return
+	}
+
+	h.sendSuccessResponse(w, r, "client_profile", map[string]interface{}{
+		"profile":   user,
+		"addresses": addresses,
+		"favorites": favorites,
+	})
+}
+
+// handleSaveAddress сохраняет именованный адрес; адрес с тем же названием перезаписывается.
+// POST /api/user/addresses {telegram_id, name, address, lat, lon}
+func (h *Handler) handleSaveAddress(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	var reqData struct {
+		TelegramID int64   `json:"telegram_id"`
+		Name       string  `json:"name"`
+		Address    string  `json:"address"`
+		Lat        float64 `json:"lat"`
+		Lon        float64 `json:"lon"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+		h.logger.Error("Failed to parse request body", zap.Error(err))
+		h.sendErrorResponse(w, r, "invalid_request", http.StatusBadRequest)
+		return
+	}
+	reqData.Name = strings.TrimSpace(reqData.Name)
+	reqData.Address = strings.TrimSpace(reqData.Address)
+	if reqData.TelegramID == 0 || reqData.Name == "" || reqData.Address == "" {
+		h.sendErrorResponse(w, r, "telegram_id_name_address_required", http.StatusBadRequest)
+		return
+	}
+	if utf8.RuneCountInString(reqData.Name) > domain.MaxAddressNameLen ||
+		utf8.RuneCountInString(reqData.Address) > domain.MaxSavedAddressLen {
+		h.sendErrorResponse(w, r, "address_name_too_long", http.StatusBadRequest)
+		return
+	}
+	if !validLatLon(reqData.Lat, reqData.Lon) {
+		h.sendErrorResponse(w, r, "invalid_coordinates", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.ensureClientProfile(r.Context(), reqData.TelegramID, "", "", "")
+	if err != nil {
+		h.logger.Error("Failed to ensure client profile", zap.Int64("telegram_id", reqData.TelegramID), zap.Error(err))
+		h.sendErrorResponse(w, r, "address_save_failed", http.StatusInternalServerError)
+		return
+	}
+
+	ok, err := h.userRepo.SaveAddress(r.Context(), &domain.SavedAddress{
+		UserID:  user.ID,
+		Name:    reqData.Name,
+		Address: reqData.Address,
+		Lat:     reqData.Lat,
+		Lon:     reqData.Lon,
+	})
+	if err != nil {
+		h.sendErrorResponse(w, r, "address_save_failed", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		h.sendErrorResponse(w, r, "addresses_limit", http.StatusConflict, domain.MaxSavedAddresses)
+		return
+	}
+
+	addresses, err := h.userRepo.ListAddresses(r.Context(), user.ID)
+	if err != nil {
+		h.sendErrorResponse(w, r, "addresses_load_failed", http.StatusInternalServerError)
+		return
+	}
+	h.sendSuccessResponse(w, r, "address_saved", map[string]interface{}{
+		"addresses": addresses,
+	})
+}
+
+// handleDeleteAddress — POST /api/user/addresses/delete {telegram_id, id}
+func (h *Handler) handleDeleteAddress(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	var reqData struct {
+		TelegramID int64 `json:"telegram_id"`
+		ID         int64 `json:"id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+		h.logger.Error("Failed to parse request body", zap.Error(err))
+		h.sendErrorResponse(w, r, "invalid_request", http.StatusBadRequest)
+		return
+	}
+	if reqData.TelegramID == 0 || reqData.ID == 0 {
+		h.sendErrorResponse(w, r, "telegram_id_and_address_id_required", http.StatusBadRequest)
+		return
+	}
+
+	userID, err := h.userRepo.GetUserIDByTelegramID(reqData.TelegramID)
+	if err != nil {
+		h.sendErrorResponse(w, r, "address_not_found", http.StatusNotFound)
+		return
+	}
+	ok, err := h.userRepo.DeleteAddress(r.Context(), userID, reqData.ID)
+	if err != nil {
+		h.sendErrorResponse(w, r, "address_delete_failed", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		h.sendErrorResponse(w, r, "address_not_found", http.StatusNotFound)
+		return
+	}
+	h.sendSuccessResponse(w, r, "address_deleted", map[string]interface{}{
+		"id": reqData.ID,
+	})
+}
+
+// handleAddFavoriteDriver добавляет в избранное водителя завершённой заявки клиента.
+// POST /api/user/favorites {telegram_id, order_id}
+func (h *Handler) handleAddFavoriteDriver(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	var reqData struct {
+		TelegramID int64  `json:"telegram_id"`
+		OrderID    string `json:"order_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+		h.logger.Error("Failed to parse request body", zap.Error(err))
+		h.sendErrorResponse(w, r, "invalid_request", http.StatusBadRequest)
+		return
+	}
+	if reqData.TelegramID == 0 || reqData.OrderID == "" {
+		h.sendErrorResponse(w, r, "telegram_id_and_order_id_required", http.StatusBadRequest)
+		return
+	}
+
+	// в избранное попадают только водители, с которыми клиент уже работал
+	var driverID string
+	err := h.db.QueryRowContext(r.Context(), `
+		SELECT COALESCE(driver_id, matched_driver_id, '')
+		FROM delivery_requests
+		WHERE id = ? AND telegram_id = ? AND status = 'completed'`,
+		reqData.OrderID, reqData.TelegramID).Scan(&driverID)
+	if err != nil && err != sql.ErrNoRows {
+		h.logger.Error("Failed to load order driver", zap.String("order_id", reqData.OrderID), zap.Error(err))
+		h.sendErrorResponse(w, r, "order_load_failed", http.StatusInternalServerError)
+		return
+	}
+	if driverID == "" {
+		h.sendErrorResponse(w, r, "completed_order_not_found", http.StatusNotFound)
+		return
+	}
+
+	user, err := h.ensureClientProfile(r.Context(), reqData.TelegramID, "", "", "")
+	if err != nil {
+		h.logger.Error("Failed to ensure client profile", zap.Int64("telegram_id", reqData.TelegramID), zap.Error(err))
+		h.sendErrorResponse(w, r, "favorite_add_failed", http.StatusInternalServerError)
+		return
+	}
+	ok, err := h.userRepo.AddFavoriteDriver(r.Context(), user.ID, driverID)
+	if err != nil {
+		h.sendErrorResponse(w, r, "favorite_add_failed", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		h.sendErrorResponse(w, r, "favorites_limit", http.StatusConflict, domain.MaxFavoriteDrivers)
+		return
+	}
+
+	h.sendSuccessResponse(w, r, "favorite_added", map[string]interface{}{
+		"driver_id": driverID,
+	})
+}
+
+// handleRemoveFavoriteDriver — POST /api/user/favorites/remove {telegram_id, driver_id}
+func (h *Handler) handleRemoveFavoriteDriver(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	var reqData struct {
+		TelegramID int64  `json:"telegram_id"`
+		DriverID   string `json:"driver_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+		h.logger.Error("Failed to parse request body", zap.Error(err))
+		h.sendErrorResponse(w, r, "invalid_request", http.StatusBadRequest)
+		return
+	}
+	if reqData.TelegramID == 0 || reqData.DriverID == "" {
+		h.sendErrorResponse(w, r, "telegram_id_and_driver_id_required", http.StatusBadRequest)
+		return
+	}
+
+	userID, err := h.userRepo.GetUserIDByTelegramID(reqData.TelegramID)
+	if err != nil {
+		h.sendErrorResponse(w, r, "favorite_not_found", http.StatusNotFound)
+		return
+	}
+	ok, err := h.userRepo.RemoveFavoriteDriver(r.Context(), userID, reqData.DriverID)
+	if err != nil {
+		h.sendErrorResponse(w, r, "favorite_delete_failed", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		h.sendErrorResponse(w, r, "favorite_not_found", http.StatusNotFound)
+		return
+	}
+	h.sendSuccessResponse(w, r, "favorite_removed", map[string]interface{}{
+		"driver_id": reqData.DriverID,
+	})
+}
@@ -0,0 +1,446 @@
+// proof-handler.go
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"tezjet/internal/domain"
+	"tezjet/internal/i18n"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+const (
+	confirmCodeDigits  = 4  // код, который клиент передаёт получателю
+	maxConfirmAttempts = 5  // дальше — новый код или завершение заявки через админку
+	maxProofPhotos     = 10 // фото за один этап
+	proofPhotoSubdir   = "proof"
+)
+
+// generateConfirmCode — случайный цифровой код подтверждения доставки
+func generateConfirmCode() (string, error) {
+	var sb strings.Builder
+	for i := 0; i < confirmCodeDigits; i++ {
+		n, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		sb.WriteByte(byte('0' + n.Int64()))
+	}
+	return sb.String(), nil
+}
+
+func (h *Handler) proofPhotoDir() string {
+	return filepath.Join(h.cfg.CargoPhoto, proofPhotoSubdir)
+}
+
+// saveProofPhotos сохраняет фото из поля photos тем же способом, что и фото груза заявки
+func (h *Handler) saveProofPhotos(r *http.Request, orderID, kind string) ([]string, error) {
+	if r.MultipartForm == nil || len(r.MultipartForm.File["photos"]) == 0 {
+		return nil, i18n.E("proof_photo_required")
+	}
+	return h.saveFormPhotos(r, h.proofPhotoDir(), fmt.Sprintf("%s_%s", orderID, kind), maxProofPhotos)
+}
+
+// saveFormPhotos сохраняет до max фото из поля photos в dir; имена файлов начинаются с prefix
+func (h *Handler) saveFormPhotos(r *http.Request, dir, prefix string, max int) ([]string, error) {
+	if r.MultipartForm == nil {
+		return nil, nil
+	}
+	files := r.MultipartForm.File["photos"]
+	if len(files) == 0 {
+		return nil, nil
+	}
+	if len(files) > max {
+		return nil, i18n.E("proof_photo_limit", max)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, i18n.Wrap(err, "file_save_failed")
+	}
+
+	stamp := time.Now().Unix()
+	var paths []string
+	for i, header := range files {
+		file, err := header.Open()
+		if err != nil {
+			removeFiles(paths)
+			return nil, i18n.Wrap(err, "file_read_failed")
+		}
+		path, err := h.storeImage(file, header, dir, fmt.Sprintf("%s_%d_%d", prefix, stamp, i+1))
+		file.Close()
+		if err != nil {
+			removeFiles(paths)
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+func removeFiles(paths []string) {
+	for _, p := range paths {
+		_ = os.Remove(p)
+	}
+}
+
+// publicProofs оставляет только имя файла, как и для фото груза
+func publicProofs(proofs []domain.OrderProof) []domain.OrderProof {
+	for i := range proofs {
+		proofs[i].PhotoPath = filepath.Base(proofs[i].PhotoPath)
+	}
+	return proofs
+}
+
+// proofForm — общие поля форм погрузки и выгрузки
+func (h *Handler) proofForm(w http.ResponseWriter, r *http.Request) (*DriverRegistration, string, bool) {
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		h.sendErrorResponse(w, r, "invalid_form", http.StatusBadRequest)
+		return nil, "", false
+	}
+	telegramID, _ := strconv.ParseInt(r.FormValue("telegram_id"), 10, 64)
+	orderID := strings.TrimSpace(r.FormValue("order_id"))
+	if telegramID == 0 || orderID == "" {
+		h.sendErrorResponse(w, r, "telegram_id_and_order_id_required", http.StatusBadRequest)
+		return nil, "", false
+	}
+	// order_id попадает в имя файла
+	if _, err := uuid.Parse(orderID); err != nil {
+		h.sendErrorResponse(w, r, "invalid_order_id", http.StatusBadRequest)
+		return nil, "", false
+	}
+
+	driver, err := h.CheckDriverExist(telegramID)
+	if err != nil {
+		h.logger.Error("Failed to check driver", zap.Int64("telegram_id", telegramID), zap.Error(err))
+		h.sendErrorResponse(w, r, "driver_check_failed", http.StatusInternalServerError)
+		return nil, "", false
+	}
+	if driver == nil {
+		h.sendErrorResponse(w, r, "driver_not_found", http.StatusNotFound)
+		return nil, "", false
+	}
+	return driver, orderID, true
+}
+
+// handleDriverPickup — водитель забрал груз: фото погрузки, заявка в пути,
+// клиенту уходит код подтверждения для получателя.
+func (h *Handler) handleDriverPickup(b *bot.Bot) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		driver, orderID, ok := h.proofForm(w, r)
+		if !ok {
+			return
+		}
+
+		paths, err := h.saveProofPhotos(r, orderID, domain.ProofKindPickup)
+		if err != nil {
+			h.sendError(w, r, err, http.StatusBadRequest)
+			return
+		}
+
+		code, err := generateConfirmCode()
+		if err != nil {
+			removeFiles(paths)
+			h.logger.Error("Failed to generate confirm code", zap.Error(err))
+			h.sendErrorResponse(w, r, "code_generate_failed", http.StatusInternalServerError)
+			return
+		}
+
+		started, err := h.orderRepo.StartPickup(r.Context(), orderID, driver.ID, code)
+		if err != nil {
+			removeFiles(paths)
+			h.sendErrorResponse(w, r, "order_update_failed", http.StatusInternalServerError)
+			return
+		}
+		if !started {
+			removeFiles(paths)
+			h.sendErrorResponse(w, r, "order_not_pickable", http.StatusConflict)
+			return
+		}
+
+		if err := h.orderRepo.AddProofs(r.Context(), orderID, driver.ID, domain.ProofKindPickup, paths); err != nil {
+			h.logger.Error("Failed to attach pickup photos", zap.String("order_id", orderID), zap.Error(err))
+		}
+
+		h.logger.Info("Order picked up",
+			zap.String("order_id", orderID),
+			zap.String("driver_id", driver.ID),
+			zap.Int("photos", len(paths)))
+
+		order, err := h.getDeliveryOrderById(orderID)
+		if err == nil && order != nil && order.TelegramID != 0 {
+			h.sendConfirmCode(r.Context(), b, order, code)
+		}
+
+		h.sendSuccessResponse(w, r, "cargo_picked_up", map[string]interface{}{
+			"order_id": orderID,
+			"status":   domain.DeliveryStatusInProgress,
+			"photos":   len(paths),
+		})
+	}
+}
+
+// sendConfirmCode — код клиенту; он передаёт его получателю, водитель вводит при выгрузке
+func (h *Handler) sendConfirmCode(ctx context.Context, b *bot.Bot, order *domain.DeliveryRequest, code string) {
+	text := i18n.T(h.userLocale(ctx, order.TelegramID), "bot.proof.confirm_code", order.ID, code)
+
+	if _, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    order.TelegramID,
+		Text:      text,
+		ParseMode: models.ParseModeHTML,
+	}); err != nil {
+		h.logger.Warn("send confirm code", zap.Int64("tg_id", order.TelegramID), zap.Error(err))
+	}
+}
+
+// handleDriverDeliver — выгрузка: код получателя и фото. Заявка завершается
+// только при совпадении кода.
+func (h *Handler) handleDriverDeliver(b *bot.Bot) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		driver, orderID, ok := h.proofForm(w, r)
+		if !ok {
+			return
+		}
+		code := strings.TrimSpace(r.FormValue("code"))
+		if code == "" {
+			h.sendErrorResponse(w, r, "delivery_code_required", http.StatusBadRequest)
+			return
+		}
+
+		paths, err := h.saveProofPhotos(r, orderID, domain.ProofKindDelivery)
+		if err != nil {
+			h.sendError(w, r, err, http.StatusBadRequest)
+			return
+		}
+
+		result, left, err := h.orderRepo.ConfirmDelivery(r.Context(), orderID, driver.ID, code, maxConfirmAttempts)
+		if err != nil {
+			removeFiles(paths)
+			h.logger.Error("Failed to confirm delivery", zap.String("order_id", orderID), zap.Error(err))
+			h.sendErrorResponse(w, r, "delivery_confirm_failed", http.StatusInternalServerError)
+			return
+		}
+
+		switch result {
+		case domain.ConfirmNotAllowed:
+			removeFiles(paths)
+			h.sendErrorResponse(w, r, "order_not_in_transit", http.StatusConflict)
+			return
+		case domain.ConfirmLocked:
+			removeFiles(paths)
+			h.logger.Warn("Confirm code attempts exhausted", zap.String("order_id", orderID), zap.String("driver_id", driver.ID))
+			h.sendErrorResponse(w, r, "delivery_code_locked", http.StatusTooManyRequests)
+			return
+		case domain.ConfirmWrongCode:
+			removeFiles(paths)
+			h.sendErrorResponse(w, r, "delivery_code_invalid", http.StatusForbidden, left)
+			return
+		}
+
+		if err := h.orderRepo.AddProofs(r.Context(), orderID, driver.ID, domain.ProofKindDelivery, paths); err != nil {
+			h.logger.Error("Failed to attach delivery photos", zap.String("order_id", orderID), zap.Error(err))
+		}
+
+		h.logger.Info("Order delivered",
+			zap.String("order_id", orderID),
+			zap.String("driver_id", driver.ID),
+			zap.Int("photos", len(paths)))
+
+		order, err := h.getDeliveryOrderById(orderID)
+		if err == nil && order != nil && order.TelegramID != 0 {
+			if _, err := b.SendMessage(r.Context(), &bot.SendMessageParams{
+				ChatID:    order.TelegramID,
+				Text:      i18n.T(h.userLocale(r.Context(), order.TelegramID), "bot.proof.delivered", order.ID),
+				ParseMode: models.ParseModeHTML,
+			}); err != nil {
+				h.logger.Warn("notify client delivered", zap.Int64("tg_id", order.TelegramID), zap.Error(err))
+			}
+		}
+		go h.askForRatings(context.Background(), b, orderID)
+		go h.sendBackhaulDigest(context.Background(), b, driver, orderID)
+
+		h.sendSuccessResponse(w, r, "delivery_confirmed", map[string]interface{}{
+			"order_id": orderID,
+			"status":   domain.DeliveryStatusCompleted,
+			"photos":   len(paths),
+		})
+	}
+}
+
+// handleAdminResetConfirmCode — новый код получателя для заявки, у которой водитель
+// исчерпал попытки. POST /api/admin/orders/{id}/reset-code?telegram_id=
+func (h *Handler) handleAdminResetConfirmCode(b *bot.Bot) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		adminID, ok := h.adminFromRequest(w, r)
+		if !ok {
+			return
+		}
+		orderID := strings.TrimSpace(mux.Vars(r)["id"])
+
+		code, err := generateConfirmCode()
+		if err != nil {
+			h.logger.Error("Failed to generate confirm code", zap.Error(err))
+			h.sendErrorResponse(w, r, "code_generate_failed", http.StatusInternalServerError)
+			return
+		}
+
+		reset, err := h.orderRepo.ResetConfirmCode(r.Context(), orderID, code)
+		if err != nil {
+			h.sendErrorResponse(w, r, "order_update_failed", http.StatusInternalServerError)
+			return
+		}
+		if !reset {
+			h.sendErrorResponse(w, r, "order_not_on_the_way", http.StatusConflict)
+			return
+		}
+
+		h.logger.Info("Confirm code reset by admin", zap.String("order_id", orderID), zap.Int64("admin_id", adminID))
+
+		order, err := h.getDeliveryOrderById(orderID)
+		if err == nil && order != nil && order.TelegramID != 0 {
+			if _, err := b.SendMessage(r.Context(), &bot.SendMessageParams{
+				ChatID:    order.TelegramID,
+				Text:      i18n.T(h.userLocale(r.Context(), order.TelegramID), "bot.proof.code_reset", order.ID, code),
+				ParseMode: models.ParseModeHTML,
+			}); err != nil {
+				h.logger.Warn("send reset confirm code", zap.Int64("tg_id", order.TelegramID), zap.Error(err))
+			}
+		}
+
+		h.sendSuccessResponse(w, r, "confirm_code_reset", map[string]interface{}{
+			"order_id": orderID,
+		})
+	}
+}
+
+// handleAdminCompleteDelivery — завершить заявку в пути без кода получателя.
+// POST /api/admin/orders/{id}/complete?telegram_id=
+func (h *Handler) handleAdminCompleteDelivery(b *bot.Bot) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		adminID, ok := h.adminFromRequest(w, r)
+		if !ok {
+			return
+		}
+		orderID := strings.TrimSpace(mux.Vars(r)["id"])
+
+		completed, err := h.orderRepo.CompleteDeliveryByAdmin(r.Context(), orderID)
+		if err != nil {
+			h.sendErrorResponse(w, r, "order_update_failed", http.StatusInternalServerError)
+			return
+		}
+		if !completed {
+			h.sendErrorResponse(w, r, "order_not_on_the_way", http.StatusConflict)
+			return
+		}
+
+		h.logger.Info("Order completed by admin", zap.String("order_id", orderID), zap.Int64("admin_id", adminID))
+
+		order, err := h.getDeliveryOrderById(orderID)
+		if err == nil && order != nil && order.TelegramID != 0 {
+			if _, err := b.SendMessage(r.Context(), &bot.SendMessageParams{
+				ChatID:    order.TelegramID,
+				Text:      i18n.T(h.userLocale(r.Context(), order.TelegramID), "bot.proof.delivered_by_admin", order.ID),
+				ParseMode: models.ParseModeHTML,
+			}); err != nil {
+				h.logger.Warn("notify client delivered", zap.Int64("tg_id", order.TelegramID), zap.Error(err))
+			}
+		}
+		go h.askForRatings(context.Background(), b, orderID)
+
+		h.sendSuccessResponse(w, r, "delivery_completed_by_admin", map[string]interface{}{
+			"order_id": orderID,
+			"status":   domain.DeliveryStatusCompleted,
+		})
+	}
+}
+
+// handleOrderProofs — фото погрузки/выгрузки заявки для клиента и водителя
+func (h *Handler) handleOrderProofs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	orderID := strings.TrimSpace(r.URL.Query().Get("order_id"))
+	telegramID, _ := strconv.ParseInt(r.URL.Query().Get("telegram_id"), 10, 64)
+	if orderID == "" || telegramID == 0 {
+		h.sendErrorResponse(w, r, "telegram_id_and_order_id_required", http.StatusBadRequest)
+		return
+	}
+
+	var clientTelegramID int64
+	var driverID string
+	err := h.db.QueryRow(`
+		SELECT telegram_id, COALESCE(driver_id, matched_driver_id, '')
+		FROM delivery_requests WHERE id = ?`, orderID).Scan(&clientTelegramID, &driverID)
+	if err != nil {
+		h.sendErrorResponse(w, r, "order_not_found", http.StatusNotFound)
+		return
+	}
+
+	allowed := clientTelegramID == telegramID
+	if !allowed && driverID != "" {
+		if driver, err := h.CheckDriverExist(telegramID); err == nil && driver != nil {
+			allowed = driver.ID == driverID
+		}
+	}
+	if !allowed {
+		h.sendErrorResponse(w, r, "order_access_denied", http.StatusForbidden)
+		return
+	}
+
+	proofs, err := h.orderRepo.GetProofs(r.Context(), orderID)
+	if err != nil {
+		h.logger.Error("Failed to load order proofs", zap.String("order_id", orderID), zap.Error(err))
+		h.sendErrorResponse(w, r, "photo_upload_failed", http.StatusInternalServerError)
+		return
+	}
+
+	h.sendSuccessResponse(w, r, "order_proofs", map[string]interface{}{
+		"order_id": orderID,
+		"proofs":   publicProofs(proofs),
+	})
+}
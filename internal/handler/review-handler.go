@@ -0,0 +1,370 @@
+// review-handler.go
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"tezjet/internal/domain"
+	"tezjet/internal/i18n"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"go.uber.org/zap"
+)
+
+const (
+	maxReviewCommentLen = 500
+	reviewsOnDriverCard = 3  // последние отзывы в карточке водителя
+	maxDriverReviews    = 20 // для /api/driver/reviews
+)
+
+var (
+	errReviewNotAllowed = i18n.E("review_not_allowed")
+	errReviewDuplicate  = i18n.E("review_duplicate")
+)
+
+// orderParties — клиент и назначенный водитель заявки (для отзывов и жалоб)
+type orderParties struct {
+	OrderID          string
+	Status           string
+	ClientTelegramID int64
+	DriverID         string
+	DriverTelegramID int64
+}
+
+func (h *Handler) getOrderParties(ctx context.Context, orderID string) (*orderParties, error) {
+	p := &orderParties{OrderID: orderID}
+	err := h.db.QueryRowContext(ctx, `
+		SELECT dr.status, dr.telegram_id, COALESCE(d.id, ''), COALESCE(d.telegram_id, 0)
+		FROM delivery_requests dr
+		LEFT JOIN drivers d ON d.id = COALESCE(dr.driver_id, dr.matched_driver_id)
+		WHERE dr.id = ?`, orderID).Scan(&p.Status, &p.ClientTelegramID, &p.DriverID, &p.DriverTelegramID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// roleOf определяет, кто оценивает: клиент (оценивает водителя) или водитель (оценивает клиента)
+func (p *orderParties) roleOf(telegramID int64) string {
+	switch {
+	case p.ClientTelegramID == telegramID:
+		return domain.ReviewerClient
+	case p.DriverTelegramID != 0 && p.DriverTelegramID == telegramID:
+		return domain.ReviewerDriver
+	}
+	return ""
+}
+
+// submitReview проверяет заявку и сохраняет оценку одной из сторон
+func (h *Handler) submitReview(ctx context.Context, telegramID int64, orderID string, rating int, comment string) (*domain.OrderReview, error) {
+	p, err := h.getOrderParties(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil || p.Status != domain.DeliveryStatusCompleted || p.DriverID == "" {
+		return nil, errReviewNotAllowed
+	}
+	role := p.roleOf(telegramID)
+	if role == "" {
+		return nil, errReviewNotAllowed
+	}
+
+	rv := &domain.OrderReview{
+		RequestID:        orderID,
+		RaterRole:        role,
+		RaterTelegramID:  telegramID,
+		DriverID:         p.DriverID,
+		ClientTelegramID: p.ClientTelegramID,
+		Rating:           rating,
+		Comment:          comment,
+	}
+	saved, err := h.reviewRepo.SaveReview(ctx, rv)
+	if err != nil {
+		return nil, err
+	}
+	if !saved {
+		return nil, errReviewDuplicate
+	}
+
+	h.logger.Info("Order reviewed",
+		zap.String("order_id", orderID),
+		zap.String("rater_role", role),
+		zap.Int("rating", rating))
+	return rv, nil
+}
+
+func ratingKeyboard(orderID string) *models.InlineKeyboardMarkup {
+	row := make([]models.InlineKeyboardButton, 0, 5)
+	for i := 1; i <= 5; i++ {
+		row = append(row, models.InlineKeyboardButton{
+			Text:         fmt.Sprintf("%d⭐", i),
+			CallbackData: fmt.Sprintf("rate:%s:%d", orderID, i),
+		})
+	}
+	return &models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{row}}
+}
+
+// askForRatings — после завершения заявки просим обе стороны оценить друг друга
+func (h *Handler) askForRatings(ctx context.Context, b *bot.Bot, orderID string) {
+	p, err := h.getOrderParties(ctx, orderID)
+	if err != nil || p == nil || p.DriverID == "" {
+		h.logger.Warn("Cannot ask for ratings", zap.String("order_id", orderID), zap.Error(err))
+		return
+	}
+
+	prompts := []struct {
+		chatID int64
+		key    string
+	}{
+		{p.ClientTelegramID, "bot.review.rate_driver"},
+		{p.DriverTelegramID, "bot.review.rate_client"},
+	}
+	for _, pr := range prompts {
+		if pr.chatID == 0 {
+			continue
+		}
+		if _, err := b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:      pr.chatID,
+			Text:        i18n.T(h.userLocale(ctx, pr.chatID), pr.key, orderID),
+			ParseMode:   models.ParseModeHTML,
+			ReplyMarkup: ratingKeyboard(orderID),
+		}); err != nil {
+			h.logger.Warn("Failed to send rating prompt", zap.Int64("tg_id", pr.chatID), zap.Error(err))
+		}
+	}
+}
+
+// rateByCallback — кнопка "N⭐". Аргумент: "<order_id>:<rating>"
+func (h *Handler) rateByCallback(ctx context.Context, b *bot.Bot, cq *models.CallbackQuery, arg string) string {
+	i := strings.LastIndex(arg, ":")
+	if i < 0 {
+		return tr(ctx, "bot.error")
+	}
+	orderID := arg[:i]
+	rating, err := strconv.Atoi(arg[i+1:])
+	if err != nil || !domain.IsValidRating(rating) {
+		return tr(ctx, "bot.error")
+	}
+
+	rv, err := h.submitReview(ctx, cq.From.ID, orderID, rating, "")
+	switch {
+	case errors.Is(err, errReviewDuplicate):
+		h.clearCallbackKeyboard(ctx, b, cq)
+		return tr(ctx, "bot.review.duplicate")
+	case errors.Is(err, errReviewNotAllowed):
+		h.clearCallbackKeyboard(ctx, b, cq)
+		return tr(ctx, "bot.review.not_allowed")
+	case err != nil:
+		h.logger.Error("Failed to save review", zap.String("order_id", orderID), zap.Error(err))
+		return tr(ctx, "bot.error")
+	}
+	h.clearCallbackKeyboard(ctx, b, cq)
+
+	// Комментарий — ответом на это сообщение, его ловит DefaultHandler
+	msg, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    cq.From.ID,
+		Text:      tr(ctx, "bot.review.comment_prompt"),
+		ParseMode: models.ParseModeHTML,
+		ReplyMarkup: &models.ForceReply{
+			ForceReply:            true,
+			InputFieldPlaceholder: tr(ctx, "bot.review.comment_placeholder"),
+		},
+	})
+	if err != nil {
+		h.logger.Warn("Failed to send review comment prompt", zap.Int64("tg_id", cq.From.ID), zap.Error(err))
+	} else if err := h.reviewRepo.SetReviewPrompt(ctx, orderID, rv.RaterRole, msg.ID); err != nil {
+		h.logger.Error("Failed to save review prompt", zap.String("order_id", orderID), zap.Error(err))
+	}
+
+	return tr(ctx, "bot.review.thanks", rating)
+}
+
+// saveReviewComment сохраняет ответ на запрос отзыва. Возвращает false, если это не ответ на такой запрос.
+func (h *Handler) saveReviewComment(ctx context.Context, b *bot.Bot, msg *models.Message) bool {
+	if msg.From == nil || msg.ReplyToMessage == nil {
+		return false
+	}
+	comment := strings.TrimSpace(msg.Text)
+	if comment == "" {
+		return false
+	}
+	if utf8.RuneCountInString(comment) > maxReviewCommentLen {
+		comment = string([]rune(comment)[:maxReviewCommentLen])
+	}
+
+	ok, err := h.reviewRepo.SetCommentByPrompt(ctx, msg.From.ID, msg.ReplyToMessage.ID, comment)
+	if err != nil {
+		h.logger.Error("Failed to save review comment", zap.Int64("tg_id", msg.From.ID), zap.Error(err))
+		return false
+	}
+	if !ok {
+		return false
+	}
+
+	if _, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: msg.Chat.ID,
+		Text:   tr(ctx, "bot.review.comment_saved"),
+	}); err != nil {
+		h.logger.Warn("Failed to confirm review comment", zap.Int64("tg_id", msg.From.ID), zap.Error(err))
+	}
+	return true
+}
+
+// orderTextForDrivers — текст заявки для рассылки водителям с рейтингом клиента
+func (h *Handler) orderTextForDrivers(ctx context.Context, lang i18n.Locale, req *domain.DeliveryRequest) string {
+	text := h.buildOrderText(lang, req)
+	rating, count, err := h.reviewRepo.GetClientRating(ctx, req.TelegramID)
+	if err != nil {
+		h.logger.Warn("Failed to load client rating", zap.Int64("tg_id", req.TelegramID), zap.Error(err))
+		return text
+	}
+	if count == 0 {
+		return text
+	}
+	return text + "\n" + i18n.T(lang, "bot.review.client_rating", rating, count)
+}
+
+// attachDriverReviews добавляет в карточки водителей последние отзывы клиентов
+func (h *Handler) attachDriverReviews(ctx context.Context, drivers []DriverWithTrip) {
+	ids := make([]string, 0, len(drivers))
+	seen := make(map[string]bool, len(drivers))
+	for _, d := range drivers {
+		if d.RatingCount > 0 && !seen[d.ID] {
+			seen[d.ID] = true
+			ids = append(ids, d.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+
+	reviews, err := h.reviewRepo.GetDriverReviews(ctx, ids, reviewsOnDriverCard)
+	if err != nil {
+		h.logger.Warn("Failed to load driver reviews", zap.Error(err))
+		return
+	}
+	for i := range drivers {
+		drivers[i].Reviews = reviews[drivers[i].ID]
+	}
+}
+
+// handleSubmitReview — оценка из Mini App. Если оценка уже стоит, можно дописать комментарий.
+func (h *Handler) handleSubmitReview(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	var reqData struct {
+		TelegramID int64  `json:"telegram_id"`
+		OrderID    string `json:"order_id"`
+		Rating     int    `json:"rating"`
+		Comment    string `json:"comment"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+		h.logger.Error("Failed to parse request body", zap.Error(err))
+		h.sendErrorResponse(w, r, "invalid_request", http.StatusBadRequest)
+		return
+	}
+	if reqData.TelegramID == 0 || reqData.OrderID == "" {
+		h.sendErrorResponse(w, r, "telegram_id_and_order_id_required", http.StatusBadRequest)
+		return
+	}
+	if !domain.IsValidRating(reqData.Rating) {
+		h.sendErrorResponse(w, r, "rating_out_of_range", http.StatusBadRequest)
+		return
+	}
+	reqData.Comment = strings.TrimSpace(reqData.Comment)
+	if utf8.RuneCountInString(reqData.Comment) > maxReviewCommentLen {
+		h.sendErrorResponse(w, r, "comment_too_long", http.StatusBadRequest, maxReviewCommentLen)
+		return
+	}
+
+	rv, err := h.submitReview(r.Context(), reqData.TelegramID, reqData.OrderID, reqData.Rating, reqData.Comment)
+	switch {
+	case errors.Is(err, errReviewDuplicate):
+		// оценка уже поставлена кнопкой в боте — допишем комментарий
+		if reqData.Comment != "" {
+			if p, perr := h.getOrderParties(r.Context(), reqData.OrderID); perr == nil && p != nil {
+				ok, cerr := h.reviewRepo.SetComment(r.Context(), reqData.OrderID, p.roleOf(reqData.TelegramID), reqData.TelegramID, reqData.Comment)
+				if cerr == nil && ok {
+					h.sendSuccessResponse(w, r, "review_saved", map[string]interface{}{"order_id": reqData.OrderID})
+					return
+				}
+			}
+		}
+		h.sendErrorResponse(w, r, "review_duplicate", http.StatusConflict)
+		return
+	case errors.Is(err, errReviewNotAllowed):
+		h.sendErrorResponse(w, r, "review_not_allowed", http.StatusForbidden)
+		return
+	case err != nil:
+		h.logger.Error("Failed to save review", zap.String("order_id", reqData.OrderID), zap.Error(err))
+		h.sendErrorResponse(w, r, "review_save_failed", http.StatusInternalServerError)
+		return
+	}
+
+	h.sendSuccessResponse(w, r, "review_thanks", map[string]interface{}{
+		"order_id":   rv.RequestID,
+		"rater_role": rv.RaterRole,
+		"rating":     rv.Rating,
+	})
+}
+
+// handleDriverReviews — рейтинг и последние отзывы о водителе
+func (h *Handler) handleDriverReviews(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	driverID := strings.TrimSpace(r.URL.Query().Get("driver_id"))
+	if driverID == "" {
+		h.sendErrorResponse(w, r, "driver_id_required", http.StatusBadRequest)
+		return
+	}
+
+	var rating float64
+	var count int
+	err := h.db.QueryRowContext(r.Context(), `
+		SELECT COALESCE(rating, 0), COALESCE(rating_count, 0) FROM drivers WHERE id = ?`, driverID).Scan(&rating, &count)
+	if err == sql.ErrNoRows {
+		h.sendErrorResponse(w, r, "driver_not_found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to get driver rating", zap.String("driver_id", driverID), zap.Error(err))
+		h.sendErrorResponse(w, r, "reviews_load_failed", http.StatusInternalServerError)
+		return
+	}
+
+	reviews, err := h.reviewRepo.GetDriverReviews(r.Context(), []string{driverID}, maxDriverReviews)
+	if err != nil {
+		h.logger.Error("Failed to get driver reviews", zap.String("driver_id", driverID), zap.Error(err))
+		h.sendErrorResponse(w, r, "reviews_load_failed", http.StatusInternalServerError)
+		return
+	}
+
+	h.sendSuccessResponse(w, r, "driver_reviews", map[string]interface{}{
+		"driver_id":    driverID,
+		"rating":       rating,
+		"rating_count": count,
+		"reviews":      reviews[driverID],
+	})
+}
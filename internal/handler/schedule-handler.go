@@ -0,0 +1,208 @@
+// schedule-handler.go
+package handler
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"tezjet/internal/domain"
+	"tezjet/internal/i18n"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"go.uber.org/zap"
+)
+
+// pickupGrace — насколько время подачи может быть в прошлом (форма отправляется не мгновенно)
+const pickupGrace = 15 * time.Minute
+
+// Форматы, которые присылают формы Mini App и бот. Всё, кроме RFC3339,
+// трактуется как местное время в cfg.TimeZone.
+var pickupLayouts = []string{
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"02.01.2006 15:04",
+}
+
+// parsePickupTime parses client pickup time in the business time zone
+func (h *Handler) parsePickupTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, i18n.E("pickup_required")
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	loc := h.cfg.Location()
+	for _, layout := range pickupLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, i18n.E("invalid_pickup_time", s)
+}
+
+// validatePickupTime rejects pickup times in the past or too far ahead
+func (h *Handler) validatePickupTime(t, now time.Time) error {
+	if t.Before(now.Add(-pickupGrace)) {
+		return i18n.E("pickup_in_past")
+	}
+	if t.After(now.Add(h.cfg.MaxScheduleAhead)) {
+		return i18n.E("pickup_too_far", int(h.cfg.MaxScheduleAhead.Hours()/24))
+	}
+	return nil
+}
+
+// resolvePickupTime заполняет req.PickupAt по req.TimeStart (пустое — "сейчас")
+func (h *Handler) resolvePickupTime(req *domain.DeliveryRequest) error {
+	now := time.Now()
+	if strings.TrimSpace(req.TimeStart) == "" {
+		req.TimeStart = now.In(h.cfg.Location()).Format("2006-01-02T15:04")
+	}
+	pickupAt, err := h.parsePickupTime(req.TimeStart)
+	if err != nil {
+		return err
+	}
+	if err := h.validatePickupTime(pickupAt, now); err != nil {
+		return err
+	}
+	pickupAt = pickupAt.UTC()
+	req.PickupAt = &pickupAt
+	return nil
+}
+
+// parsePickupWindow разбирает фильтр ленты водителя по времени подачи
+func (h *Handler) parsePickupWindow(from, to string) (*time.Time, *time.Time, error) {
+	var fromT, toT *time.Time
+	if strings.TrimSpace(from) != "" {
+		t, err := h.parsePickupTime(from)
+		if err != nil {
+			return nil, nil, err
+		}
+		fromT = &t
+	}
+	if strings.TrimSpace(to) != "" {
+		t, err := h.parsePickupTime(to)
+		if err != nil {
+			return nil, nil, err
+		}
+		toT = &t
+	}
+	if fromT != nil && toT != nil && toT.Before(*fromT) {
+		return nil, nil, i18n.E("pickup_range_invalid")
+	}
+	return fromT, toT, nil
+}
+
+func filterByPickupWindow(orders []domain.DeliveryRequest, from, to *time.Time) []domain.DeliveryRequest {
+	if from == nil && to == nil {
+		return orders
+	}
+	out := make([]domain.DeliveryRequest, 0, len(orders))
+	for _, o := range orders {
+		if o.PickupWithin(from, to) {
+			out = append(out, o)
+		}
+	}
+	return out
+}
+
+// sqliteTime — формат CURRENT_TIMESTAMP, чтобы сравнения с datetime('now') работали как строки
+func sqliteTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04:05")
+}
+
+// formatPickupLocal — время подачи в часовом поясе бизнеса
+func (h *Handler) formatPickupLocal(t time.Time) string {
+	return t.In(h.cfg.Location()).Format("02.01.2006 15:04")
+}
+
+// DispatchScheduledOrders рассылает отложенные заявки за DispatchLeadTime до подачи
+// и напоминает клиенту и водителю о подаче за PickupReminderBefore.
+func (h *Handler) DispatchScheduledOrders(ctx context.Context, b *bot.Bot) {
+	h.logger.Info("started scheduled orders service",
+		zap.Duration("lead_time", h.cfg.DispatchLeadTime),
+		zap.Duration("reminder_before", h.cfg.PickupReminderBefore))
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			h.logger.Info("scheduled orders service stopped")
+			return
+		case <-ticker.C:
+			h.dispatchDueOrders(ctx, b)
+			h.releaseFavoriteOrders(ctx, b)
+			h.runRecurringOrders(ctx, b)
+			h.sendPickupReminders(ctx, b)
+		}
+	}
+}
+
+func (h *Handler) dispatchDueOrders(ctx context.Context, b *bot.Bot) {
+	ids, err := h.orderRepo.GetOrdersDueForDispatch(ctx, h.cfg.DispatchLeadTime)
+	if err != nil {
+		h.logger.Error("load orders due for dispatch", zap.Error(err))
+		return
+	}
+	for _, id := range ids {
+		ok, err := h.orderRepo.MarkDispatched(ctx, id)
+		if err != nil || !ok {
+			continue
+		}
+		order, err := h.getDeliveryOrderById(id)
+		if err != nil || order == nil {
+			h.logger.Error("load dispatched order", zap.String("order_id", id), zap.Error(err))
+			continue
+		}
+		h.logger.Info("scheduled order dispatched", zap.String("order_id", id))
+
+		if order.TelegramID != 0 {
+			if _, err := b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID:    order.TelegramID,
+				Text:      i18n.T(h.userLocale(ctx, order.TelegramID), "bot.schedule.dispatched", order.ID),
+				ParseMode: models.ParseModeHTML,
+			}); err != nil {
+				h.logger.Warn("notify client dispatch", zap.Int64("tg_id", order.TelegramID), zap.Error(err))
+			}
+		}
+		h.SendToDriver(ctx, b, order)
+	}
+}
+
+func (h *Handler) sendPickupReminders(ctx context.Context, b *bot.Bot) {
+	reminders, err := h.orderRepo.GetDuePickupReminders(ctx, h.cfg.PickupReminderBefore)
+	if err != nil {
+		h.logger.Error("load pickup reminders", zap.Error(err))
+		return
+	}
+	for _, rem := range reminders {
+		ok, err := h.orderRepo.MarkReminderSent(ctx, rem.RequestID)
+		if err != nil || !ok {
+			continue
+		}
+		when := h.formatPickupLocal(rem.PickupAt)
+
+		clientText := i18n.T(h.userLocale(ctx, rem.ClientTelegramID), "bot.schedule.reminder_client",
+			rem.RequestID, when, rem.FromAddress, rem.ToAddress, rem.DriverName, rem.DriverPhone)
+
+		driverText := i18n.T(h.userLocale(ctx, rem.DriverTelegramID), "bot.schedule.reminder_driver",
+			rem.RequestID, when, rem.FromAddress, rem.ToAddress, rem.Contact)
+
+		for chatID, text := range map[int64]string{rem.ClientTelegramID: clientText, rem.DriverTelegramID: driverText} {
+			if chatID == 0 {
+				continue
+			}
+			if _, err := b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID:    chatID,
+				Text:      text,
+				ParseMode: models.ParseModeHTML,
+			}); err != nil {
+				h.logger.Warn("send pickup reminder", zap.Int64("tg_id", chatID), zap.Error(err))
+			}
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"tezjet/config"
+	"tezjet/internal/i18n"
+)
+
+// errCode возвращает код i18n-ошибки или "" для nil
+func errCode(t *testing.T, err error) string {
+	t.Helper()
+	if err == nil {
+		return ""
+	}
+	var e *i18n.Error
+	if !errors.As(err, &e) {
+		t.Fatalf("error %v is not an i18n error", err)
+	}
+	return e.Code
+}
+
+func TestParsePickupTime(t *testing.T) {
+	h := &Handler{cfg: &config.Config{TimeZone: "Asia/Almaty"}}
+	loc := h.cfg.Location()
+	local := time.Date(2026, 3, 8, 14, 30, 0, 0, loc)
+
+	tests := []struct {
+		name string
+		in   string
+		want time.Time
+		code string
+	}{
+		{"mini app", "2026-03-08T14:30", local, ""},
+		{"space separated", "2026-03-08 14:30", local, ""},
+		{"with seconds", "2026-03-08T14:30:00", local, ""},
+		{"bot format", " 08.03.2026 14:30 ", local, ""},
+		{"rfc3339 keeps its offset", "2026-03-08T09:30:00Z", time.Date(2026, 3, 8, 9, 30, 0, 0, time.UTC), ""},
+		{"empty", "  ", time.Time{}, "pickup_required"},
+		{"garbage", "завтра утром", time.Time{}, "invalid_pickup_time"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := h.parsePickupTime(tt.in)
+			if code := errCode(t, err); code != tt.code {
+				t.Fatalf("parsePickupTime(%q) error = %q, want %q", tt.in, code, tt.code)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("parsePickupTime(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidatePickupTime(t *testing.T) {
+	h := &Handler{cfg: &config.Config{MaxScheduleAhead: 30 * 24 * time.Hour}}
+	now := time.Date(2026, 3, 8, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		at   time.Time
+		code string
+	}{
+		{"now", now, ""},
+		{"within grace", now.Add(-pickupGrace + time.Minute), ""},
+		{"past grace", now.Add(-pickupGrace - time.Minute), "pickup_in_past"},
+		{"tomorrow", now.Add(24 * time.Hour), ""},
+		{"at the limit", now.Add(30 * 24 * time.Hour), ""},
+		{"too far", now.Add(30*24*time.Hour + time.Minute), "pickup_too_far"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := errCode(t, h.validatePickupTime(tt.at, now)); code != tt.code {
+				t.Errorf("validatePickupTime(%v) error = %q, want %q", tt.at, code, tt.code)
+			}
+		})
+	}
+}
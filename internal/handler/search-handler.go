@@ -0,0 +1,398 @@
+// search-handler.go
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"tezjet/internal/domain"
+	"tezjet/internal/i18n"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// tripVehicleType — у рейса тип "any" по умолчанию, тогда берём тип машины из профиля
+func tripVehicleType(tripType, vehicleType string) string {
+	t := strings.ToLower(strings.TrimSpace(tripType))
+	if t == "" || t == "any" {
+		t = strings.ToLower(strings.TrimSpace(vehicleType))
+	}
+	return t
+}
+
+func validSearchTruckType(t string) bool {
+	if t == "any" {
+		return true
+	}
+	for _, tt := range domain.DriverTruckTypes {
+		if tt == t {
+			return true
+		}
+	}
+	return false
+}
+
+// handleSavedSearches — сохранённые поиски водителей.
+// GET /api/user/saved-searches?telegram_id=
+// POST /api/user/saved-searches {telegram_id, from_address, from_lat, from_lon, to_address, to_lat, to_lon,
+// truck_type, contact, radius_km, date_from, date_to}; даты — "2006-01-02", местные.
+func (h *Handler) handleSavedSearches(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		telegramID, _ := strconv.ParseInt(r.URL.Query().Get("telegram_id"), 10, 64)
+		if telegramID == 0 {
+			h.sendErrorResponse(w, r, "telegram_id_required", http.StatusBadRequest)
+			return
+		}
+		searches, err := h.searchRepo.ListSearches(r.Context(), telegramID)
+		if err != nil {
+			h.sendErrorResponse(w, r, "searches_load_failed", http.StatusInternalServerError)
+			return
+		}
+		h.sendSuccessResponse(w, r, "searches_loaded", map[string]interface{}{
+			"searches": searches,
+		})
+		return
+	}
+
+	var reqData struct {
+		TelegramID  int64   `json:"telegram_id"`
+		FromAddress string  `json:"from_address"`
+		FromLat     float64 `json:"from_lat"`
+		FromLon     float64 `json:"from_lon"`
+		ToAddress   string  `json:"to_address"`
+		ToLat       float64 `json:"to_lat"`
+		ToLon       float64 `json:"to_lon"`
+		TruckType   string  `json:"truck_type"`
+		Contact     string  `json:"contact"`
+		RadiusKm    float64 `json:"radius_km"`
+		DateFrom    string  `json:"date_from"`
+		DateTo      string  `json:"date_to"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+		h.logger.Error("Failed to parse request body", zap.Error(err))
+		h.sendErrorResponse(w, r, "invalid_request", http.StatusBadRequest)
+		return
+	}
+
+	s := &domain.SavedSearch{
+		TelegramID:  reqData.TelegramID,
+		FromAddress: strings.TrimSpace(reqData.FromAddress),
+		FromLat:     reqData.FromLat,
+		FromLon:     reqData.FromLon,
+		ToAddress:   strings.TrimSpace(reqData.ToAddress),
+		ToLat:       reqData.ToLat,
+		ToLon:       reqData.ToLon,
+		TruckType:   strings.ToLower(strings.TrimSpace(reqData.TruckType)),
+		Contact:     strings.TrimSpace(reqData.Contact),
+		RadiusKm:    reqData.RadiusKm,
+	}
+	if s.TruckType == "" {
+		s.TruckType = "any"
+	}
+	switch {
+	case s.TelegramID == 0:
+		h.sendErrorResponse(w, r, "telegram_id_required", http.StatusBadRequest)
+		return
+	case s.FromAddress == "" || s.ToAddress == "":
+		h.sendErrorResponse(w, r, "addresses_required", http.StatusBadRequest)
+		return
+	case !h.isValidCoordinates(s.FromLat, s.FromLon) || !h.isValidCoordinates(s.ToLat, s.ToLon):
+		h.sendErrorResponse(w, r, "invalid_coordinates", http.StatusBadRequest)
+		return
+	case s.Contact == "":
+		h.sendErrorResponse(w, r, "contact_required", http.StatusBadRequest)
+		return
+	case !validSearchTruckType(s.TruckType):
+		h.sendErrorResponse(w, r, "invalid_truck_type", http.StatusBadRequest)
+		return
+	case s.RadiusKm < 0 || s.RadiusKm > domain.MaxSearchRadiusKm:
+		h.sendErrorResponse(w, r, "search_radius_too_large", http.StatusBadRequest, domain.MaxSearchRadiusKm)
+		return
+	}
+
+	var err error
+	s.DateFrom, s.DateTo, err = domain.ParseSearchWindow(reqData.DateFrom, reqData.DateTo, time.Now(), h.cfg.Location())
+	if err != nil {
+		h.sendError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	id, err := h.searchRepo.CreateSearch(r.Context(), s)
+	if err != nil {
+		h.sendErrorResponse(w, r, "search_save_failed", http.StatusInternalServerError)
+		return
+	}
+	if id == 0 {
+		h.sendErrorResponse(w, r, "searches_limit", http.StatusConflict, domain.MaxSavedSearches)
+		return
+	}
+
+	h.logger.Info("Saved search created",
+		zap.Int64("search_id", id),
+		zap.Int64("telegram_id", s.TelegramID),
+		zap.Time("date_from", s.DateFrom),
+		zap.Time("date_to", s.DateTo))
+	created, err := h.searchRepo.GetSearch(r.Context(), id, s.TelegramID)
+	if err != nil || created == nil {
+		h.sendErrorResponse(w, r, "search_load_failed", http.StatusInternalServerError)
+		return
+	}
+	h.sendSuccessResponse(w, r, "search_saved", map[string]interface{}{
+		"search": created,
+	})
+}
+
+// handleDeleteSavedSearch — POST /api/user/saved-searches/delete {telegram_id, id}
+func (h *Handler) handleDeleteSavedSearch(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	var reqData struct {
+		TelegramID int64 `json:"telegram_id"`
+		ID         int64 `json:"id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+		h.logger.Error("Failed to parse request body", zap.Error(err))
+		h.sendErrorResponse(w, r, "invalid_request", http.StatusBadRequest)
+		return
+	}
+	if reqData.TelegramID == 0 || reqData.ID == 0 {
+		h.sendErrorResponse(w, r, "telegram_id_and_search_id_required", http.StatusBadRequest)
+		return
+	}
+
+	ok, err := h.searchRepo.DeleteSearch(r.Context(), reqData.ID, reqData.TelegramID)
+	if err != nil {
+		h.sendErrorResponse(w, r, "search_delete_failed", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		h.sendErrorResponse(w, r, "search_not_found", http.StatusNotFound)
+		return
+	}
+	h.sendSuccessResponse(w, r, "search_deleted", map[string]interface{}{
+		"id": reqData.ID,
+	})
+}
+
+// tripDeparture — время выезда по рейсу; пустое или непонятное — сейчас
+func (h *Handler) tripDeparture(startTime string) time.Time {
+	if t, err := h.parsePickupTime(startTime); err == nil {
+		return t
+	}
+	return time.Now()
+}
+
+// notifySavedSearches сообщает клиентам, чей сохранённый поиск совпал с новым рейсом:
+// обе точки в радиусе поиска, выезд в окне дат, машина подходит по типу.
+func (h *Handler) notifySavedSearches(b *bot.Bot, trip *DriverTrip, driver *DriverRegistration) {
+	ctx := context.Background()
+	departure := h.tripDeparture(trip.StartTime)
+
+	searches, err := h.searchRepo.GetSearchesAt(ctx, departure, trip.TelegramID)
+	if err != nil || len(searches) == 0 {
+		return
+	}
+
+	capacity := tripCapacity(tripVehicleType(trip.TruckType, driver.TruckType), trip.MaxWeight, driver.Capacity)
+	notified := 0
+	for i := range searches {
+		s := &searches[i]
+		if !s.AnyTruck() && !capacity.CanCarry(s.TruckType, domain.CargoSpec{}) {
+			continue
+		}
+		radius := s.EffectiveRadius()
+		if h.haversineDistance(s.FromLat, s.FromLon, trip.FromLat, trip.FromLon) > radius ||
+			h.haversineDistance(s.ToLat, s.ToLon, trip.ToLat, trip.ToLon) > radius {
+			continue
+		}
+
+		lang := h.userLocale(ctx, s.TelegramID)
+		if _, err := b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:      s.TelegramID,
+			Text:        h.formatSearchMatch(lang, trip, driver),
+			ParseMode:   models.ParseModeHTML,
+			ReplyMarkup: savedSearchKeyboard(lang, s.ID, trip.ID),
+		}); err != nil {
+			h.logger.Warn("notify saved search", zap.Int64("tg_id", s.TelegramID), zap.Int64("search_id", s.ID), zap.Error(err))
+			continue
+		}
+		if err := h.searchRepo.MarkNotified(ctx, s.ID, time.Now()); err != nil {
+			h.logger.Warn("mark saved search notified", zap.Int64("search_id", s.ID), zap.Error(err))
+		}
+		notified++
+	}
+
+	if notified > 0 {
+		h.logger.Info("saved searches notified about trip",
+			zap.String("trip_id", trip.ID),
+			zap.Int("clients", notified))
+	}
+}
+
+// formatSearchMatch — карточка водителя и рейса для клиента
+func (h *Handler) formatSearchMatch(lang i18n.Locale, trip *DriverTrip, driver *DriverRegistration) string {
+	truck := i18n.T(lang, "bot.search.any_truck")
+	if key, ok := truckTypeShort[tripVehicleType(trip.TruckType, driver.TruckType)]; ok {
+		truck = i18n.T(lang, key)
+	}
+	name := strings.TrimSpace(driver.FirstName + " " + driver.LastName)
+
+	text := i18n.T(lang, "bot.search.match",
+		html.EscapeString(name),
+		truck,
+		html.EscapeString(trip.FromAddress),
+		html.EscapeString(trip.ToAddress),
+		trip.Price,
+		h.formatTripStartTime(lang, trip.StartTime),
+	)
+	if trip.Comment != "" {
+		text += "\n" + i18n.T(lang, "bot.booking.comment", html.EscapeString(trip.Comment))
+	}
+	return text
+}
+
+func savedSearchKeyboard(lang i18n.Locale, searchID int64, tripID string) *models.InlineKeyboardMarkup {
+	return &models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{
+			{{Text: i18n.T(lang, "bot.search.btn_order"), CallbackData: fmt.Sprintf("ss:%d:req:%s", searchID, tripID)}},
+			{{Text: i18n.T(lang, "bot.search.btn_stop"), CallbackData: fmt.Sprintf("ss:%d:stop", searchID)}},
+		},
+	}
+}
+
+// savedSearchByCallback handles ss:<id>:req:<trip_id> and ss:<id>:stop
+func (h *Handler) savedSearchByCallback(ctx context.Context, b *bot.Bot, cq *models.CallbackQuery, arg string) string {
+	idStr, rest, _ := strings.Cut(arg, ":")
+	action, tripID, _ := strings.Cut(rest, ":")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		return tr(ctx, "bot.error")
+	}
+
+	switch action {
+	case "stop":
+		ok, err := h.searchRepo.DeleteSearch(ctx, id, cq.From.ID)
+		if err != nil {
+			return tr(ctx, "bot.error")
+		}
+		h.clearCallbackKeyboard(ctx, b, cq)
+		if !ok {
+			return tr(ctx, "bot.search.not_found")
+		}
+		return tr(ctx, "bot.search.stopped")
+	case "req":
+		return h.requestTripFromSearch(ctx, b, cq, id, tripID)
+	}
+	return tr(ctx, "bot.error")
+}
+
+// requestTripFromSearch создаёт заявку по сохранённому поиску по цене рейса и отправляет её
+// сначала этому водителю — как избранному: он сам опубликовал поездку, быть в избранном не обязан.
+func (h *Handler) requestTripFromSearch(ctx context.Context, b *bot.Bot, cq *models.CallbackQuery, searchID int64, tripID string) string {
+	s, err := h.searchRepo.GetSearch(ctx, searchID, cq.From.ID)
+	if err != nil {
+		h.logger.Error("Failed to load saved search", zap.Int64("search_id", searchID), zap.Error(err))
+		return tr(ctx, "bot.error")
+	}
+	if s == nil || s.Status != domain.SavedSearchActive {
+		h.clearCallbackKeyboard(ctx, b, cq)
+		return tr(ctx, "bot.search.not_found")
+	}
+	trip, capacity, err := h.getActiveDriverTrip(ctx, tripID)
+	if err != nil {
+		h.logger.Error("Failed to load trip for saved search", zap.String("trip_id", tripID), zap.Error(err))
+		return tr(ctx, "bot.error")
+	}
+	if trip == nil {
+		h.clearCallbackKeyboard(ctx, b, cq)
+		return tr(ctx, "bot.search.trip_stale")
+	}
+
+	truckType := s.TruckType
+	if s.AnyTruck() {
+		truckType = capacity.TruckType
+	}
+	in := deliveryRequestJSON{
+		FromAddress: s.FromAddress,
+		FromLat:     s.FromLat,
+		FromLon:     s.FromLon,
+		ToAddress:   s.ToAddress,
+		ToLat:       s.ToLat,
+		ToLon:       s.ToLon,
+		Contact:     s.Contact,
+		TruckType:   truckType,
+		Price:       trip.Price,
+		TelegramID:  cq.From.ID,
+	}
+	if departure := h.tripDeparture(trip.StartTime); departure.After(time.Now()) {
+		in.TimeStart = departure.In(h.cfg.Location()).Format("2006-01-02T15:04")
+	}
+
+	req, err := h.deliveryRequestFromJSON(&in)
+	if err != nil {
+		return h.errText(i18n.FromContext(ctx), err)
+	}
+	h.prepareClientOrder(ctx, req, cq.From.Username, cq.From.FirstName, cq.From.LastName)
+	req.PreferredDriverID = trip.DriverID
+	req.ID = uuid.New().String()
+
+	if _, err := h.submitDeliveryRequest(ctx, b, req); err != nil {
+		var te *tariffError
+		if errors.As(err, &te) {
+			return te.Localize(i18n.FromContext(ctx))
+		}
+		h.logger.Error("Failed to save order from saved search", zap.Int64("search_id", searchID), zap.Error(err))
+		return tr(ctx, "bot.error")
+	}
+
+	h.clearCallbackKeyboard(ctx, b, cq)
+	h.logger.Info("Order created from saved search",
+		zap.Int64("search_id", searchID),
+		zap.String("trip_id", trip.ID),
+		zap.String("order_id", req.ID))
+	return tr(ctx, "bot.search.order_sent")
+}
+
+// ExpireSavedSearches закрывает поиски, у которых прошло окно дат
+func (h *Handler) ExpireSavedSearches(ctx context.Context) {
+	h.logger.Info("started saved search expiry service")
+	ticker := time.NewTicker(h.cfg.OrderExpiryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			h.logger.Info("saved search expiry service stopped")
+			return
+		case <-ticker.C:
+			n, err := h.searchRepo.ExpireSearches(ctx)
+			if err != nil {
+				continue
+			}
+			if n > 0 {
+				h.logger.Info("saved searches expired", zap.Int64("count", n))
+			}
+		}
+	}
+}
@@ -0,0 +1,291 @@
+// settings-handler.go
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"tezjet/internal/domain"
+	"tezjet/internal/i18n"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"go.uber.org/zap"
+)
+
+// Ключи начала текста подсказок с ForceReply: по нему DefaultHandler узнаёт, какую настройку вводят
+const (
+	settingsPromptCities   = "bot.settings.prompt_cities"
+	settingsPromptQuiet    = "bot.settings.prompt_quiet"
+	settingsPromptVacation = "bot.settings.prompt_vacation"
+)
+
+// truckTypeShort — ключи коротких названий машин для кнопок /settings
+var truckTypeShort = map[string]string{
+	domain.TruckTypeSmall:        "bot.truck_short.small",
+	domain.TruckTypeMedium:       "bot.truck_short.medium",
+	domain.TruckTypeLarge:        "bot.truck_short.large",
+	domain.TruckTypeRefrigerator: "bot.truck_short.refrigerator",
+	domain.TruckTypeTow:          "bot.truck_short.tow",
+}
+
+func onOff(v bool) string {
+	if v {
+		return "✅"
+	}
+	return "▫️"
+}
+
+func formatDriverSettings(lang i18n.Locale, p *domain.DriverPreferences, loc *time.Location) string {
+	var sb strings.Builder
+	sb.WriteString(i18n.T(lang, "bot.settings.title") + "\n\n")
+	sb.WriteString(i18n.T(lang, "bot.settings.radius", p.EffectiveRadius()) + "\n")
+
+	cities := i18n.T(lang, "bot.settings.any")
+	if len(p.Cities) > 0 {
+		cities = strings.Join(p.Cities, ", ")
+	}
+	sb.WriteString(i18n.T(lang, "bot.settings.cities", cities) + "\n")
+
+	minPrice := i18n.T(lang, "bot.settings.none")
+	if p.MinPrice > 0 {
+		minPrice = fmt.Sprintf("%d ₸", p.MinPrice)
+	}
+	sb.WriteString(i18n.T(lang, "bot.settings.min_price", minPrice) + "\n")
+
+	types := i18n.T(lang, "bot.settings.any")
+	if len(p.TruckTypes) > 0 {
+		names := make([]string, 0, len(p.TruckTypes))
+		for _, t := range p.TruckTypes {
+			names = append(names, i18n.T(lang, truckTypeShort[t]))
+		}
+		types = strings.Join(names, ", ")
+	}
+	sb.WriteString(i18n.T(lang, "bot.settings.trucks", types) + "\n")
+
+	quiet := i18n.T(lang, "bot.settings.none")
+	if p.QuietFrom != "" {
+		quiet = i18n.T(lang, "bot.settings.quiet_range", p.QuietFrom, p.QuietTo)
+	}
+	sb.WriteString(i18n.T(lang, "bot.settings.quiet", quiet) + "\n")
+	sb.WriteString(i18n.T(lang, "bot.settings.intercity", onOff(p.IntercityOnly)) + "\n")
+
+	switch {
+	case p.Paused:
+		sb.WriteString("\n" + i18n.T(lang, "bot.settings.paused"))
+	case p.IsPaused(time.Now()):
+		sb.WriteString("\n" + i18n.T(lang, "bot.settings.vacation_until", p.PausedUntil.In(loc).Format("02.01 15:04")))
+	}
+	return sb.String()
+}
+
+func driverSettingsKeyboard(lang i18n.Locale, p *domain.DriverPreferences) *models.InlineKeyboardMarkup {
+	rows := [][]models.InlineKeyboardButton{
+		{
+			{Text: i18n.T(lang, "bot.settings.btn_radius", p.EffectiveRadius()), CallbackData: "dset:radius"},
+			{Text: i18n.T(lang, "bot.settings.btn_price", p.MinPrice), CallbackData: "dset:price"},
+		},
+	}
+	var typeRow []models.InlineKeyboardButton
+	for _, t := range domain.DriverTruckTypes {
+		typeRow = append(typeRow, models.InlineKeyboardButton{
+			Text:         onOff(p.HasTruckType(t)) + " " + i18n.T(lang, truckTypeShort[t]),
+			CallbackData: "dset:truck:" + t,
+		})
+		if len(typeRow) == 3 {
+			rows = append(rows, typeRow)
+			typeRow = nil
+		}
+	}
+	if len(typeRow) > 0 {
+		rows = append(rows, typeRow)
+	}
+
+	pause := models.InlineKeyboardButton{Text: i18n.T(lang, "bot.settings.btn_pause"), CallbackData: "dset:pause"}
+	if p.Paused {
+		pause = models.InlineKeyboardButton{Text: i18n.T(lang, "bot.settings.btn_resume"), CallbackData: "dset:pause"}
+	}
+	vacation := models.InlineKeyboardButton{Text: i18n.T(lang, "bot.settings.btn_vacation"), CallbackData: "dset:vacation"}
+	if p.PausedUntil != nil {
+		vacation = models.InlineKeyboardButton{Text: i18n.T(lang, "bot.settings.btn_vacation_off"), CallbackData: "dset:vacation_off"}
+	}
+	rows = append(rows,
+		[]models.InlineKeyboardButton{
+			{Text: i18n.T(lang, "bot.settings.btn_cities"), CallbackData: "dset:cities"},
+			{Text: i18n.T(lang, "bot.settings.btn_quiet"), CallbackData: "dset:quiet"},
+		},
+		[]models.InlineKeyboardButton{
+			{Text: onOff(p.IntercityOnly) + " " + i18n.T(lang, "bot.settings.btn_intercity"), CallbackData: "dset:intercity"},
+		},
+		[]models.InlineKeyboardButton{pause, vacation},
+	)
+	return &models.InlineKeyboardMarkup{InlineKeyboard: rows}
+}
+
+// isDriver — настройки рассылки есть только у зарегистрированных водителей
+func (h *Handler) isDriver(telegramID int64) bool {
+	driver, err := h.CheckDriverExist(telegramID)
+	if err != nil {
+		h.logger.Warn("check driver for settings", zap.Int64("tg_id", telegramID), zap.Error(err))
+		return false
+	}
+	return driver != nil
+}
+
+// sendDriverSettings отвечает на /settings
+func (h *Handler) sendDriverSettings(ctx context.Context, b *bot.Bot, chatID int64) {
+	lang := i18n.FromContext(ctx)
+	params := &bot.SendMessageParams{ChatID: chatID, ParseMode: models.ParseModeHTML}
+	if !h.isDriver(chatID) {
+		params.Text = i18n.T(lang, "bot.settings.drivers_only")
+	} else if p, err := h.prefsRepo.GetPreferences(ctx, chatID); err != nil {
+		params.Text = i18n.T(lang, "bot.error")
+	} else {
+		params.Text = formatDriverSettings(lang, &p, h.cfg.Location())
+		params.ReplyMarkup = driverSettingsKeyboard(lang, &p)
+	}
+	if _, err := b.SendMessage(ctx, params); err != nil {
+		h.logger.Warn("send driver settings", zap.Int64("tg_id", chatID), zap.Error(err))
+	}
+}
+
+// driverSettingsByCallback handles dset:<radius|price|truck:type|intercity|pause|vacation_off|cities|quiet|vacation>
+func (h *Handler) driverSettingsByCallback(ctx context.Context, b *bot.Bot, cq *models.CallbackQuery, arg string) string {
+	if !h.isDriver(cq.From.ID) {
+		return tr(ctx, "bot.settings.drivers_only")
+	}
+	p, err := h.prefsRepo.GetPreferences(ctx, cq.From.ID)
+	if err != nil {
+		return tr(ctx, "bot.error")
+	}
+
+	action, value, _ := strings.Cut(arg, ":")
+	switch action {
+	case "radius":
+		p.RadiusKm = domain.NextOption(domain.DriverRadiusOptions, p.EffectiveRadius())
+	case "price":
+		p.MinPrice = domain.NextOption(domain.DriverMinPriceOptions, p.MinPrice)
+	case "truck":
+		if _, ok := truckTypeShort[value]; !ok {
+			return tr(ctx, "bot.error")
+		}
+		p.ToggleTruckType(value)
+	case "intercity":
+		p.IntercityOnly = !p.IntercityOnly
+	case "pause":
+		p.Paused = !p.Paused
+	case "vacation_off":
+		p.PausedUntil = nil
+	case "cities":
+		return h.sendSettingsPrompt(ctx, b, cq.From.ID,
+			tr(ctx, settingsPromptCities)+"\n"+tr(ctx, "bot.settings.hint_cities"), "Алматы, Қонаев")
+	case "quiet":
+		return h.sendSettingsPrompt(ctx, b, cq.From.ID,
+			tr(ctx, settingsPromptQuiet)+"\n"+tr(ctx, "bot.settings.hint_quiet"), "22:00-07:00")
+	case "vacation":
+		return h.sendSettingsPrompt(ctx, b, cq.From.ID,
+			tr(ctx, settingsPromptVacation)+"\n"+tr(ctx, "bot.settings.hint_vacation"), "7")
+	default:
+		return tr(ctx, "bot.error")
+	}
+
+	if err := h.prefsRepo.SavePreferences(ctx, &p); err != nil {
+		return tr(ctx, "bot.error")
+	}
+	h.editDriverSettings(ctx, b, cq, &p)
+	return tr(ctx, "bot.saved")
+}
+
+func (h *Handler) editDriverSettings(ctx context.Context, b *bot.Bot, cq *models.CallbackQuery, p *domain.DriverPreferences) {
+	msg := cq.Message.Message
+	if msg == nil {
+		return
+	}
+	if _, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:      msg.Chat.ID,
+		MessageID:   msg.ID,
+		Text:        formatDriverSettings(i18n.FromContext(ctx), p, h.cfg.Location()),
+		ParseMode:   models.ParseModeHTML,
+		ReplyMarkup: driverSettingsKeyboard(i18n.FromContext(ctx), p),
+	}); err != nil {
+		h.logger.Debug("edit driver settings", zap.Error(err))
+	}
+}
+
+// sendSettingsPrompt просит ввести значение ответом на сообщение
+func (h *Handler) sendSettingsPrompt(ctx context.Context, b *bot.Bot, chatID int64, text, placeholder string) string {
+	if _, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatID,
+		Text:   text,
+		ReplyMarkup: &models.ForceReply{
+			ForceReply:            true,
+			InputFieldPlaceholder: placeholder,
+		},
+	}); err != nil {
+		h.logger.Warn("send settings prompt", zap.Int64("tg_id", chatID), zap.Error(err))
+		return tr(ctx, "bot.error")
+	}
+	return tr(ctx, "bot.settings.write_reply")
+}
+
+// saveDriverSettingsReply принимает ответ на подсказку /settings; false — это не такой ответ
+func (h *Handler) saveDriverSettingsReply(ctx context.Context, b *bot.Bot, msg *models.Message) bool {
+	reply := msg.ReplyToMessage
+	if msg.From == nil || reply == nil || reply.From == nil || !reply.From.IsBot {
+		return false
+	}
+
+	field, _, ok := promptKey(reply.Text, settingsPromptCities, settingsPromptQuiet, settingsPromptVacation)
+	if !ok {
+		return false
+	}
+	if !h.isDriver(msg.From.ID) {
+		return true
+	}
+
+	p, err := h.prefsRepo.GetPreferences(ctx, msg.From.ID)
+	if err != nil {
+		h.replySettingsError(ctx, b, msg.Chat.ID, tr(ctx, "bot.error"))
+		return true
+	}
+
+	input := strings.TrimSpace(msg.Text)
+	switch field {
+	case settingsPromptCities:
+		p.Cities = nil
+		for _, c := range domain.ParseCities(input) {
+			// к тому же виду, в каком город определяется из адреса заявки
+			p.Cities = append(p.Cities, h.extractCityFromAddress(c))
+		}
+	case settingsPromptQuiet:
+		from, to, err := domain.ParseQuietHours(input)
+		if err != nil {
+			h.replySettingsError(ctx, b, msg.Chat.ID, "❌ "+h.errText(i18n.FromContext(ctx), err))
+			return true
+		}
+		p.QuietFrom, p.QuietTo = from, to
+	case settingsPromptVacation:
+		until, err := domain.ParseVacation(input, time.Now(), h.cfg.Location())
+		if err != nil {
+			h.replySettingsError(ctx, b, msg.Chat.ID, "❌ "+h.errText(i18n.FromContext(ctx), err))
+			return true
+		}
+		p.PausedUntil = until
+	}
+
+	if err := h.prefsRepo.SavePreferences(ctx, &p); err != nil {
+		h.replySettingsError(ctx, b, msg.Chat.ID, tr(ctx, "bot.error"))
+		return true
+	}
+	h.logger.Info("Driver preferences updated", zap.Int64("tg_id", msg.From.ID), zap.String("field", field))
+	h.sendDriverSettings(ctx, b, msg.Chat.ID)
+	return true
+}
+
+func (h *Handler) replySettingsError(ctx context.Context, b *bot.Bot, chatID int64, text string) {
+	if _, err := b.SendMessage(ctx, &bot.SendMessageParams{ChatID: chatID, Text: text}); err != nil {
+		h.logger.Warn("send settings error", zap.Int64("tg_id", chatID), zap.Error(err))
+	}
+}
@@ -0,0 +1,318 @@
+// stops-handler.go
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"tezjet/internal/domain"
+	"tezjet/internal/i18n"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"go.uber.org/zap"
+)
+
+// maxOrderStops — максимум точек выгрузки в одной заявке
+const maxOrderStops = 10
+
+var (
+	errStopNotAllowed = i18n.E("stop_not_allowed")
+	errStopNoDriver   = i18n.E("driver_not_found")
+)
+
+// stopJSON — точка выгрузки из формы заявки
+type stopJSON struct {
+	Address string  `json:"address"`
+	Lat     float64 `json:"lat"`
+	Lon     float64 `json:"lon"`
+	Contact string  `json:"contact"`
+}
+
+// routePoint — точка маршрута для OSRM
+type routePoint struct {
+	Lat, Lon float64
+}
+
+// applyStops переносит точки выгрузки в заявку. Последняя точка становится to_*,
+// чтобы поиск водителей и лента работали как для обычной A→B заявки.
+// Одна точка — это обычная заявка, order_stops не создаются.
+func (h *Handler) applyStops(req *domain.DeliveryRequest, in []stopJSON) error {
+	if len(in) == 0 {
+		return nil
+	}
+	if len(in) > maxOrderStops {
+		return i18n.E("stops_limit", maxOrderStops)
+	}
+
+	stops := make([]domain.OrderStop, 0, len(in))
+	for i, s := range in {
+		addr := strings.TrimSpace(s.Address)
+		if addr == "" {
+			return i18n.E("stop_address_required", i+1)
+		}
+		if !h.isValidCoordinates(s.Lat, s.Lon) {
+			return i18n.E("stop_invalid_coordinates", i+1)
+		}
+		stops = append(stops, domain.OrderStop{
+			Seq:     i + 1,
+			Address: addr,
+			Lat:     s.Lat,
+			Lon:     s.Lon,
+			Contact: strings.TrimSpace(s.Contact),
+			Status:  domain.StopStatusPending,
+		})
+	}
+
+	last := stops[len(stops)-1]
+	req.ToAddress, req.ToLat, req.ToLon = last.Address, last.Lat, last.Lon
+	if len(stops) > 1 {
+		req.Stops = stops
+	}
+	return nil
+}
+
+// parseStopsField — в multipart форме точки приходят JSON-строкой в поле stops
+func parseStopsField(raw string) ([]stopJSON, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+	var stops []stopJSON
+	if err := json.Unmarshal([]byte(raw), &stops); err != nil {
+		return nil, i18n.Wrap(err, "invalid_stops")
+	}
+	return stops, nil
+}
+
+// routePoints returns pickup followed by every drop-off in order
+func routePoints(req *domain.DeliveryRequest) []routePoint {
+	points := []routePoint{{req.FromLat, req.FromLon}}
+	if !req.IsMultiStop() {
+		return append(points, routePoint{req.ToLat, req.ToLon})
+	}
+	for _, s := range req.Stops {
+		points = append(points, routePoint{s.Lat, s.Lon})
+	}
+	return points
+}
+
+// insertStops пишет точки выгрузки в той же транзакции, что и заявку
+func insertStops(tx *sql.Tx, requestID string, stops []domain.OrderStop) error {
+	for _, s := range stops {
+		if _, err := tx.Exec(`
+			INSERT INTO order_stops (request_id, seq, address, lat, lon, contact, status)
+			VALUES (?, ?, ?, ?, ?, ?, 'pending')`,
+			requestID, s.Seq, s.Address, s.Lat, s.Lon, s.Contact,
+		); err != nil {
+			return fmt.Errorf("failed to insert stop %d: %w", s.Seq, err)
+		}
+	}
+	return nil
+}
+
+// loadStops подгружает точки выгрузки в заявку
+func (h *Handler) loadStops(ctx context.Context, order *domain.DeliveryRequest) {
+	stops, err := h.orderRepo.GetStops(ctx, order.ID)
+	if err != nil {
+		h.logger.Warn("load order stops", zap.String("order_id", order.ID), zap.Error(err))
+		return
+	}
+	order.Stops = stops
+}
+
+// formatStopsText — список точек для сообщений бота, пусто для обычной заявки
+func formatStopsText(lang i18n.Locale, order *domain.DeliveryRequest, withStatus bool) string {
+	if !order.IsMultiStop() {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("\n" + i18n.T(lang, "bot.stops.title"))
+	for _, s := range order.Stops {
+		mark := ""
+		if withStatus {
+			mark = "⬜️ "
+			if s.IsDone() {
+				mark = "✅ "
+			}
+		}
+		sb.WriteString(fmt.Sprintf("\n  %s%d. %s", mark, s.Seq, s.Address))
+		if s.Contact != "" {
+			sb.WriteString(fmt.Sprintf(" (📱 %s)", s.Contact))
+		}
+	}
+	return sb.String()
+}
+
+// acceptedOrderKeyboard — кнопки связи с клиентом и отметки точек для водителя
+func acceptedOrderKeyboard(lang i18n.Locale, order *domain.DeliveryRequest) *models.InlineKeyboardMarkup {
+	rows := [][]models.InlineKeyboardButton{
+		{
+			{Text: i18n.T(lang, "bot.btn.call"), URL: "tel:" + order.Contact},
+			{Text: "💬 WhatsApp", URL: "https://wa.me/" + onlyDigits(order.Contact)},
+		},
+	}
+	if order.IsMultiStop() {
+		for _, s := range order.PendingStops() {
+			rows = append(rows, []models.InlineKeyboardButton{{
+				Text:         i18n.T(lang, "bot.stops.btn_done", s.Seq),
+				CallbackData: fmt.Sprintf("stop_done:%s:%d", order.ID, s.Seq),
+			}})
+		}
+	}
+	rows = append(rows, []models.InlineKeyboardButton{{
+		Text:         i18n.T(lang, "bot.btn.refuse"),
+		CallbackData: "dcancel:" + order.ID,
+	}})
+	return &models.InlineKeyboardMarkup{InlineKeyboard: rows}
+}
+
+func onlyDigits(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] >= '0' && s[i] <= '9' {
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// completeStop отмечает точку выгрузки водителем и уведомляет клиента
+func (h *Handler) completeStop(ctx context.Context, b *bot.Bot, driverTelegramID int64, orderID string, seq int) (*domain.DeliveryRequest, error) {
+	driver, err := h.CheckDriverExist(driverTelegramID)
+	if err != nil {
+		return nil, err
+	}
+	if driver == nil {
+		return nil, errStopNoDriver
+	}
+
+	ok, err := h.orderRepo.MarkStopDone(ctx, orderID, seq, driver.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errStopNotAllowed
+	}
+
+	order, err := h.getDeliveryOrderById(orderID)
+	if err != nil || order == nil {
+		return nil, fmt.Errorf("failed to reload order %s: %v", orderID, err)
+	}
+
+	h.logger.Info("Order stop delivered",
+		zap.String("order_id", orderID),
+		zap.Int("seq", seq),
+		zap.Int("pending", len(order.PendingStops())))
+
+	if order.TelegramID != 0 {
+		var addr string
+		for _, s := range order.Stops {
+			if s.Seq == seq {
+				addr = s.Address
+			}
+		}
+		lang := h.userLocale(ctx, order.TelegramID)
+		text := i18n.T(lang, "bot.stops.delivered", order.ID, seq, html.EscapeString(addr))
+		if len(order.PendingStops()) == 0 {
+			text += "\n\n" + i18n.T(lang, "bot.stops.all_delivered")
+		}
+		if _, err := b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:    order.TelegramID,
+			Text:      text,
+			ParseMode: models.ParseModeHTML,
+		}); err != nil {
+			h.logger.Warn("notify client stop done", zap.Int64("tg_id", order.TelegramID), zap.Error(err))
+		}
+	}
+
+	return order, nil
+}
+
+// markStopDoneByCallback — кнопка "N-нүкте жеткізілді" в сообщении водителю
+func (h *Handler) markStopDoneByCallback(ctx context.Context, b *bot.Bot, cq *models.CallbackQuery, arg string) string {
+	i := strings.LastIndex(arg, ":")
+	if i < 0 {
+		return tr(ctx, "bot.error")
+	}
+	orderID := arg[:i]
+	seq, err := strconv.Atoi(arg[i+1:])
+	if err != nil {
+		return tr(ctx, "bot.error")
+	}
+
+	order, err := h.completeStop(ctx, b, cq.From.ID, orderID, seq)
+	if err != nil {
+		if errors.Is(err, errStopNotAllowed) || errors.Is(err, errStopNoDriver) {
+			return tr(ctx, "bot.stops.not_found")
+		}
+		h.logger.Error("Failed to complete stop", zap.String("order_id", orderID), zap.Error(err))
+		return tr(ctx, "bot.error")
+	}
+
+	if msg := cq.Message.Message; msg != nil {
+		if _, err := b.EditMessageReplyMarkup(ctx, &bot.EditMessageReplyMarkupParams{
+			ChatID:      msg.Chat.ID,
+			MessageID:   msg.ID,
+			ReplyMarkup: acceptedOrderKeyboard(i18n.FromContext(ctx), order),
+		}); err != nil {
+			h.logger.Debug("Failed to refresh stops keyboard", zap.Error(err))
+		}
+	}
+
+	return tr(ctx, "bot.stops.marked", seq)
+}
+
+// handleDriverStopDone — то же самое из Mini App водителя
+func (h *Handler) handleDriverStopDone(b *bot.Bot) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		var reqData struct {
+			TelegramID int64  `json:"telegram_id"`
+			OrderID    string `json:"order_id"`
+			Seq        int    `json:"seq"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+			h.logger.Error("Failed to parse request body", zap.Error(err))
+			h.sendErrorResponse(w, r, "invalid_request", http.StatusBadRequest)
+			return
+		}
+		if reqData.TelegramID == 0 || reqData.OrderID == "" || reqData.Seq <= 0 {
+			h.sendErrorResponse(w, r, "telegram_id_order_id_seq_required", http.StatusBadRequest)
+			return
+		}
+
+		order, err := h.completeStop(r.Context(), b, reqData.TelegramID, reqData.OrderID, reqData.Seq)
+		switch {
+		case errors.Is(err, errStopNoDriver):
+			h.sendErrorResponse(w, r, "driver_not_found", http.StatusNotFound)
+			return
+		case errors.Is(err, errStopNotAllowed):
+			h.sendErrorResponse(w, r, "stop_not_allowed", http.StatusConflict)
+			return
+		case err != nil:
+			h.logger.Error("Failed to complete stop", zap.String("order_id", reqData.OrderID), zap.Error(err))
+			h.sendErrorResponse(w, r, "stop_mark_failed", http.StatusInternalServerError)
+			return
+		}
+
+		h.sendSuccessResponse(w, r, "stop_marked", map[string]interface{}{
+			"order_id":      order.ID,
+			"stops":         order.Stops,
+			"pending_stops": len(order.PendingStops()),
+		})
+	}
+}
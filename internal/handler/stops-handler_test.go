@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"testing"
+
+	"tezjet/internal/domain"
+)
+
+func TestParseStopsField(t *testing.T) {
+	tests := []struct {
+		name  string
+		raw   string
+		count int
+		code  string
+	}{
+		{"empty", "", 0, ""},
+		{"blank", "  \n", 0, ""},
+		{"two stops", `[{"address":"A","lat":43.2,"lon":76.9},{"address":"B","lat":43.3,"lon":76.8,"contact":"+7"}]`, 2, ""},
+		{"broken json", `[{"address":`, 0, "invalid_stops"},
+		{"not an array", `{"address":"A"}`, 0, "invalid_stops"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stops, err := parseStopsField(tt.raw)
+			if code := errCode(t, err); code != tt.code {
+				t.Fatalf("parseStopsField() error = %q, want %q", code, tt.code)
+			}
+			if len(stops) != tt.count {
+				t.Errorf("parseStopsField() returned %d stops, want %d", len(stops), tt.count)
+			}
+		})
+	}
+}
+
+func TestApplyStops(t *testing.T) {
+	h := &Handler{}
+	almaty := stopJSON{Address: " Абая 1 ", Lat: 43.24, Lon: 76.91, Contact: " +77010000000 "}
+	kaskelen := stopJSON{Address: "Каскелен", Lat: 43.2, Lon: 76.62}
+
+	tooMany := make([]stopJSON, maxOrderStops+1)
+	for i := range tooMany {
+		tooMany[i] = kaskelen
+	}
+
+	tests := []struct {
+		name      string
+		in        []stopJSON
+		code      string
+		wantTo    string
+		wantStops int
+	}{
+		{"no stops keeps to", nil, "", "старый адрес", 0},
+		{"single stop is a plain order", []stopJSON{kaskelen}, "", "Каскелен", 0},
+		{"last stop becomes to", []stopJSON{almaty, kaskelen}, "", "Каскелен", 2},
+		{"limit", tooMany, "stops_limit", "старый адрес", 0},
+		{"missing address", []stopJSON{almaty, {Lat: 43.2, Lon: 76.6}}, "stop_address_required", "старый адрес", 0},
+		{"outside kazakhstan", []stopJSON{{Address: "Москва", Lat: 55.75, Lon: 37.61}}, "stop_invalid_coordinates", "старый адрес", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &domain.DeliveryRequest{ToAddress: "старый адрес"}
+			err := h.applyStops(req, tt.in)
+			if code := errCode(t, err); code != tt.code {
+				t.Fatalf("applyStops() error = %q, want %q", code, tt.code)
+			}
+			if req.ToAddress != tt.wantTo {
+				t.Errorf("ToAddress = %q, want %q", req.ToAddress, tt.wantTo)
+			}
+			if len(req.Stops) != tt.wantStops {
+				t.Fatalf("len(Stops) = %d, want %d", len(req.Stops), tt.wantStops)
+			}
+			for i, s := range req.Stops {
+				if s.Seq != i+1 || s.Status != domain.StopStatusPending {
+					t.Errorf("stop %d = seq %d status %q", i, s.Seq, s.Status)
+				}
+			}
+		})
+	}
+
+	req := &domain.DeliveryRequest{}
+	if err := h.applyStops(req, []stopJSON{almaty, kaskelen}); err != nil {
+		t.Fatal(err)
+	}
+	if first := req.Stops[0]; first.Address != "Абая 1" || first.Contact != "+77010000000" {
+		t.Errorf("stop fields not trimmed: %+v", first)
+	}
+	if req.ToLat != kaskelen.Lat || req.ToLon != kaskelen.Lon {
+		t.Errorf("to coordinates = %v,%v, want last stop", req.ToLat, req.ToLon)
+	}
+}
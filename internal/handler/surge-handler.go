@@ -0,0 +1,151 @@
+// surge-handler.go
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"tezjet/internal/domain"
+
+	"go.uber.org/zap"
+)
+
+// RecomputeSurge пересчитывает спрос по зонам и кладёт результат в Redis.
+// Клиенту зона даёт подсказку поднять цену, водителю — сигнал "здесь высокий спрос".
+func (h *Handler) RecomputeSurge(ctx context.Context) {
+	h.logger.Info("started surge service",
+		zap.Duration("interval", h.cfg.SurgeInterval),
+		zap.Float64("max_multiplier", h.cfg.SurgeMaxMultiplier))
+	h.recomputeSurge(ctx)
+
+	ticker := time.NewTicker(h.cfg.SurgeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			h.logger.Info("surge service stopped")
+			return
+		case <-ticker.C:
+			h.recomputeSurge(ctx)
+		}
+	}
+}
+
+func (h *Handler) recomputeSurge(ctx context.Context) {
+	orders, err := h.orderRepo.GetPendingOrigins(ctx)
+	if err != nil {
+		h.logger.Error("load pending orders for surge", zap.Error(err))
+		return
+	}
+	positions, err := h.redisRepo.ListDriverPositions(ctx)
+	if err != nil {
+		h.logger.Error("load driver positions for surge", zap.Error(err))
+		return
+	}
+
+	drivers := make([]domain.GeoPoint, 0, len(positions))
+	for _, pos := range positions {
+		drivers = append(drivers, domain.GeoPoint{Lat: pos.Lat, Lon: pos.Lon})
+	}
+	zones := domain.ComputeSurgeZones(orders, drivers, domain.SurgeGeohashPrecision, h.cfg.SurgeMaxMultiplier, time.Now().UTC())
+
+	// кэш переживает пару пропущенных тиков, дальше зоны пропадают сами
+	if err := h.redisRepo.SaveSurgeZones(ctx, zones, 3*h.cfg.SurgeInterval); err != nil {
+		h.logger.Error("save surge zones", zap.Error(err))
+		return
+	}
+
+	hot := 0
+	for _, z := range zones {
+		if z.HighDemand {
+			hot++
+		}
+	}
+	if hot > 0 {
+		h.logger.Info("surge recomputed",
+			zap.Int("zones", len(zones)),
+			zap.Int("high_demand", hot),
+			zap.Int("pending", len(orders)),
+			zap.Int("drivers_online", len(drivers)))
+	}
+}
+
+// surgeZones returns cached zones; on Redis errors there is simply no surge
+func (h *Handler) surgeZones(ctx context.Context) []domain.SurgeZone {
+	zones, err := h.redisRepo.GetSurgeZones(ctx)
+	if err != nil {
+		h.logger.Warn("load surge zones", zap.Error(err))
+		return nil
+	}
+	return zones
+}
+
+// surgeUplift — подсказка клиенту для точки подачи. Только для заявок "на сейчас":
+// к дальней подаче текущий спрос отношения не имеет.
+func (h *Handler) surgeUplift(ctx context.Context, lat, lon float64, price int, pickupAt time.Time) *domain.SurgeUplift {
+	if !validLatLon(lat, lon) || time.Until(pickupAt) > h.cfg.DispatchLeadTime {
+		return nil
+	}
+	hash := domain.GeohashEncode(lat, lon, domain.SurgeGeohashPrecision)
+	for _, z := range h.surgeZones(ctx) {
+		if z.Geohash != hash {
+			continue
+		}
+		if z.Multiplier <= 1 {
+			return nil
+		}
+		return &domain.SurgeUplift{
+			Geohash:        z.Geohash,
+			Multiplier:     z.Multiplier,
+			SuggestedPrice: domain.ApplySurge(price, z.Multiplier),
+		}
+	}
+	return nil
+}
+
+// highDemandNear returns high-demand zones whose center is within radiusKm
+func (h *Handler) highDemandNear(ctx context.Context, lat, lon, radiusKm float64) []domain.SurgeZone {
+	var out []domain.SurgeZone
+	for _, z := range h.surgeZones(ctx) {
+		if !z.HighDemand {
+			continue
+		}
+		if radiusKm > 0 && h.haversineDistance(lat, lon, z.Lat, z.Lon) > radiusKm {
+			continue
+		}
+		out = append(out, z)
+	}
+	return out
+}
+
+// handleSurgeZones — зоны высокого спроса для карты водителя.
+// GET /api/surge/zones?lat=&lon=&radius= (без координат — все зоны)
+func (h *Handler) handleSurgeZones(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	q := r.URL.Query()
+	lat, _ := strconv.ParseFloat(q.Get("lat"), 64)
+	lon, _ := strconv.ParseFloat(q.Get("lon"), 64)
+	radius, _ := strconv.ParseFloat(q.Get("radius"), 64)
+	if !validLatLon(lat, lon) {
+		radius = 0
+	} else if radius <= 0 {
+		radius = h.cfg.MaxDistance
+	}
+
+	zones := h.highDemandNear(r.Context(), lat, lon, radius)
+	if zones == nil {
+		zones = []domain.SurgeZone{}
+	}
+	h.sendSuccessResponse(w, r, "surge_zones", map[string]interface{}{
+		"zones":          zones,
+		"max_multiplier": h.cfg.SurgeMaxMultiplier,
+	})
+}
@@ -0,0 +1,195 @@
+// tariff-handler.go
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"tezjet/internal/domain"
+	"tezjet/internal/i18n"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+// tariffError — цена ниже минимальной по тарифу; текст показывается клиенту/водителю
+type tariffError struct {
+	quote domain.PriceQuote
+}
+
+func (e *tariffError) Error() string { return e.Localize(i18n.Default) }
+
+func (e *tariffError) ErrorCode() string { return "price_below_tariff" }
+
+func (e *tariffError) ErrorParams() []interface{} {
+	return []interface{}{e.quote.MinPrice, e.quote.Recommended}
+}
+
+func (e *tariffError) Localize(loc i18n.Locale) string {
+	return i18n.T(loc, "error."+e.ErrorCode(), e.ErrorParams()...)
+}
+
+// quotePrice считает минимальную и рекомендуемую цену по тарифу города точки A.
+// Без подходящего тарифа действует cfg.MinPrice.
+func (h *Handler) quotePrice(ctx context.Context, fromAddress, truckType string, distanceKm float64, etaMin int) domain.PriceQuote {
+	quote := domain.PriceQuote{
+		City:        h.extractCityFromAddress(fromAddress),
+		TruckType:   truckType,
+		MinPrice:    h.cfg.MinPrice,
+		Recommended: h.cfg.MinPrice,
+	}
+
+	tariffs, err := h.tariffRepo.ListTariffs(ctx)
+	if err != nil {
+		h.logger.Error("load tariffs, falling back to min price", zap.Error(err))
+		return quote
+	}
+	if t := domain.MatchTariff(tariffs, quote.City, truckType); t != nil {
+		quote.TariffID = t.ID
+		quote.MinPrice = t.MinPrice
+		quote.Recommended = t.Quote(distanceKm, etaMin)
+	}
+	return quote
+}
+
+// checkTariffPrice returns *tariffError if price is below the tariff minimum
+func (h *Handler) checkTariffPrice(ctx context.Context, fromAddress, truckType string, distanceKm float64, etaMin, price int) (domain.PriceQuote, error) {
+	quote := h.quotePrice(ctx, fromAddress, truckType, distanceKm, etaMin)
+	if price < quote.MinPrice {
+		return quote, &tariffError{quote: quote}
+	}
+	return quote, nil
+}
+
+// routeFromQuery берёт distance/duration из запроса, а без них считает маршрут по координатам
+func (h *Handler) routeFromQuery(q url.Values) (float64, int, bool) {
+	distance, _ := strconv.ParseFloat(q.Get("distance"), 64)
+	duration, _ := strconv.Atoi(q.Get("duration"))
+	if distance > 0 {
+		return distance, duration, true
+	}
+
+	fromLat, _ := strconv.ParseFloat(q.Get("from_lat"), 64)
+	fromLon, _ := strconv.ParseFloat(q.Get("from_lon"), 64)
+	toLat, _ := strconv.ParseFloat(q.Get("to_lat"), 64)
+	toLon, _ := strconv.ParseFloat(q.Get("to_lon"), 64)
+	if !h.isValidCoordinates(fromLat, fromLon) || !h.isValidCoordinates(toLat, toLon) {
+		return 0, 0, false
+	}
+	distance, duration = h.calculateRoute(fromLat, fromLon, toLat, toLon)
+	return distance, duration, true
+}
+
+// handleTariffQuote — цена по тарифу для формы Mini App.
+// GET /api/tariff/quote?from_address=&truck_type=&distance=&duration=
+// Без distance маршрут считается по from_lat/from_lon/to_lat/to_lon.
+func (h *Handler) handleTariffQuote(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	q := r.URL.Query()
+	distance, duration, ok := h.routeFromQuery(q)
+	if !ok {
+		h.sendErrorResponse(w, r, "route_distance_required", http.StatusBadRequest)
+		return
+	}
+
+	quote := h.quotePrice(r.Context(), strings.TrimSpace(q.Get("from_address")), strings.TrimSpace(q.Get("truck_type")), distance, duration)
+	h.sendSuccessResponse(w, r, "tariff_calculated", map[string]interface{}{
+		"quote":    quote,
+		"distance": distance,
+		"eta":      duration,
+	})
+}
+
+// handleAdminTariffs — список и сохранение тарифов.
+// GET /api/admin/tariffs?telegram_id=
+// POST /api/admin/tariffs?telegram_id= {city, truck_type, base_fare, per_km, per_min, min_price}
+func (h *Handler) handleAdminTariffs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	adminID, ok := h.adminFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if r.Method == http.MethodPost {
+		var t domain.Tariff
+		if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+			h.logger.Error("Failed to parse request body", zap.Error(err))
+			h.sendErrorResponse(w, r, "invalid_request", http.StatusBadRequest)
+			return
+		}
+		t.City = strings.TrimSpace(t.City)
+		t.TruckType = strings.TrimSpace(t.TruckType)
+		if t.MinPrice <= 0 || t.BaseFare < 0 || t.PerKm < 0 || t.PerMin < 0 {
+			h.sendErrorResponse(w, r, "tariff_invalid", http.StatusBadRequest)
+			return
+		}
+		if err := h.tariffRepo.SaveTariff(r.Context(), &t); err != nil {
+			h.sendErrorResponse(w, r, "tariff_save_failed", http.StatusInternalServerError)
+			return
+		}
+		h.logger.Info("Tariff saved",
+			zap.Int64("admin_id", adminID),
+			zap.Int64("tariff_id", t.ID),
+			zap.String("city", t.City),
+			zap.String("truck_type", t.TruckType),
+			zap.Int("min_price", t.MinPrice))
+	}
+
+	tariffs, err := h.tariffRepo.ListTariffs(r.Context())
+	if err != nil {
+		h.sendErrorResponse(w, r, "tariffs_load_failed", http.StatusInternalServerError)
+		return
+	}
+	h.sendSuccessResponse(w, r, "tariffs", map[string]interface{}{
+		"tariffs":   tariffs,
+		"min_price": h.cfg.MinPrice,
+	})
+}
+
+// handleAdminDeleteTariff — POST /api/admin/tariffs/{id}/delete?telegram_id=
+func (h *Handler) handleAdminDeleteTariff(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	adminID, ok := h.adminFromRequest(w, r)
+	if !ok {
+		return
+	}
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil || id <= 0 {
+		h.sendErrorResponse(w, r, "invalid_tariff_id", http.StatusBadRequest)
+		return
+	}
+
+	deleted, err := h.tariffRepo.DeleteTariff(r.Context(), id)
+	if err != nil {
+		h.sendErrorResponse(w, r, "tariff_delete_failed", http.StatusInternalServerError)
+		return
+	}
+	if !deleted {
+		h.sendErrorResponse(w, r, "tariff_not_found", http.StatusNotFound)
+		return
+	}
+	h.logger.Info("Tariff deleted", zap.Int64("admin_id", adminID), zap.Int64("tariff_id", id))
+	h.sendSuccessResponse(w, r, "tariff_deleted", map[string]interface{}{
+		"id": id,
+	})
+}
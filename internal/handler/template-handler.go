@@ -0,0 +1,747 @@
+// template-handler.go
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"tezjet/internal/domain"
+	"tezjet/internal/i18n"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// orderToJSON собирает тело формы заявки из сохранённого заказа — для повтора и шаблонов.
+// Время подачи, клиент и избранный водитель не переносятся, маршрут пересчитывается заново.
+func orderToJSON(order *domain.DeliveryRequest) deliveryRequestJSON {
+	in := deliveryRequestJSON{
+		FromAddress: order.FromAddress,
+		FromLat:     order.FromLat,
+		FromLon:     order.FromLon,
+		ToAddress:   order.ToAddress,
+		ToLat:       order.ToLat,
+		ToLon:       order.ToLon,
+		Contact:     order.Contact,
+		TruckType:   order.TruckType,
+		Comment:     order.Comment,
+		Price:       order.Price,
+		Cargo:       order.Cargo,
+	}
+	for _, s := range order.Stops {
+		in.Stops = append(in.Stops, stopJSON{Address: s.Address, Lat: s.Lat, Lon: s.Lon, Contact: s.Contact})
+	}
+	return in
+}
+
+// decodeTemplatePayload разбирает тело шаблона так же строго, как HandleDelivery
+func decodeTemplatePayload(payload []byte) (*deliveryRequestJSON, error) {
+	var in deliveryRequestJSON
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&in); err != nil {
+		return nil, i18n.Wrap(err, "invalid_template")
+	}
+	return &in, nil
+}
+
+// templatePayload validates the form body and strips per-order fields before saving
+func (h *Handler) templatePayload(in *deliveryRequestJSON) (json.RawMessage, error) {
+	in.TimeStart, in.Date, in.Time = "", "", ""
+	in.TelegramID = 0
+	in.DistanceKm, in.ETAMin = 0, 0
+	if _, err := h.deliveryRequestFromJSON(in); err != nil {
+		return nil, err
+	}
+	return json.Marshal(in)
+}
+
+// requestFromTemplate builds a new order from a template payload with the given pickup time
+func (h *Handler) requestFromTemplate(payload []byte, telegramID int64, timeStart string) (*domain.DeliveryRequest, error) {
+	in, err := decodeTemplatePayload(payload)
+	if err != nil {
+		return nil, err
+	}
+	in.TelegramID = telegramID
+	in.TimeStart = strings.TrimSpace(timeStart)
+	in.Date, in.Time = "", ""
+	return h.deliveryRequestFromJSON(in)
+}
+
+// loadClientOrder returns the client's own order, nil if it belongs to someone else
+func (h *Handler) loadClientOrder(orderID string, telegramID int64) (*domain.DeliveryRequest, error) {
+	order, err := h.getDeliveryOrderById(orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order == nil || order.TelegramID != telegramID {
+		return nil, nil
+	}
+	return order, nil
+}
+
+// createOrderFromJSON проводит заявку через тот же путь, что и HandleDelivery
+func (h *Handler) createOrderFromJSON(ctx context.Context, b *bot.Bot, w http.ResponseWriter, r *http.Request, in *deliveryRequestJSON) {
+	req, err := h.deliveryRequestFromJSON(in)
+	if err != nil {
+		h.sendError(w, r, err, http.StatusBadRequest)
+		return
+	}
+	if err := h.prepareClientOrder(ctx, req, "", "", ""); err != nil {
+		h.sendError(w, r, err, http.StatusBadRequest)
+		return
+	}
+	req.ID = uuid.New().String()
+	quote, err := h.submitDeliveryRequest(ctx, b, req)
+	var te *tariffError
+	if errors.As(err, &te) {
+		h.sendError(w, r, te, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to save delivery request", zap.Error(err))
+		h.sendErrorResponse(w, r, "order_save_failed", http.StatusInternalServerError)
+		return
+	}
+
+	h.sendSuccessResponse(w, r, "order_created", map[string]interface{}{
+		"request_id": req.ID,
+		"status":     "pending",
+		"distance":   req.DistanceKm,
+		"eta":        req.EtaMin,
+		"pickup_at":  req.PickupAt,
+		"scheduled":  req.DispatchedAt == nil,
+
+		"min_price":         quote.MinPrice,
+		"recommended_price": quote.Recommended,
+	})
+}
+
+// handleRepeatOrder создаёт новую заявку по одному из прошлых заказов клиента.
+// POST /api/user/repeat-order {telegram_id, order_id, time_start}
+func (h *Handler) handleRepeatOrder(ctx context.Context, b *bot.Bot) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		var reqData struct {
+			TelegramID int64  `json:"telegram_id"`
+			OrderID    string `json:"order_id"`
+			TimeStart  string `json:"time_start"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+			h.logger.Error("Failed to parse request body", zap.Error(err))
+			h.sendErrorResponse(w, r, "invalid_request", http.StatusBadRequest)
+			return
+		}
+		if reqData.TelegramID == 0 || reqData.OrderID == "" {
+			h.sendErrorResponse(w, r, "telegram_id_and_order_id_required", http.StatusBadRequest)
+			return
+		}
+
+		order, err := h.loadClientOrder(reqData.OrderID, reqData.TelegramID)
+		if err != nil {
+			h.logger.Error("Failed to load order", zap.String("order_id", reqData.OrderID), zap.Error(err))
+			h.sendErrorResponse(w, r, "order_load_failed", http.StatusInternalServerError)
+			return
+		}
+		if order == nil {
+			h.sendErrorResponse(w, r, "order_not_found", http.StatusNotFound)
+			return
+		}
+
+		in := orderToJSON(order)
+		in.TelegramID = reqData.TelegramID
+		in.TimeStart = strings.TrimSpace(reqData.TimeStart)
+		h.logger.Info("Repeat order", zap.String("source_order_id", order.ID), zap.Int64("telegram_id", reqData.TelegramID))
+		h.createOrderFromJSON(ctx, b, w, r, &in)
+	}
+}
+
+// handleTemplates — шаблоны заявок клиента.
+// GET /api/user/templates?telegram_id=
+// POST /api/user/templates {telegram_id, name, order_id | payload}
+func (h *Handler) handleTemplates(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		telegramID, _ := strconv.ParseInt(r.URL.Query().Get("telegram_id"), 10, 64)
+		if telegramID == 0 {
+			h.sendErrorResponse(w, r, "telegram_id_required", http.StatusBadRequest)
+			return
+		}
+		templates, err := h.templateRepo.ListTemplates(r.Context(), telegramID)
+		if err != nil {
+			h.sendErrorResponse(w, r, "templates_load_failed", http.StatusInternalServerError)
+			return
+		}
+		recurring, err := h.templateRepo.ListRecurring(r.Context(), telegramID)
+		if err != nil {
+			h.sendErrorResponse(w, r, "recurring_list_load_failed", http.StatusInternalServerError)
+			return
+		}
+		h.sendSuccessResponse(w, r, "templates_loaded", map[string]interface{}{
+			"templates": templates,
+			"recurring": recurring,
+		})
+		return
+	}
+
+	var reqData struct {
+		TelegramID int64           `json:"telegram_id"`
+		Name       string          `json:"name"`
+		OrderID    string          `json:"order_id"`
+		Payload    json.RawMessage `json:"payload"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+		h.logger.Error("Failed to parse request body", zap.Error(err))
+		h.sendErrorResponse(w, r, "invalid_request", http.StatusBadRequest)
+		return
+	}
+	reqData.Name = strings.TrimSpace(reqData.Name)
+	if reqData.TelegramID == 0 || reqData.Name == "" {
+		h.sendErrorResponse(w, r, "telegram_id_and_name_required", http.StatusBadRequest)
+		return
+	}
+	if utf8.RuneCountInString(reqData.Name) > domain.MaxTemplateNameLen {
+		h.sendErrorResponse(w, r, "template_name_too_long", http.StatusBadRequest)
+		return
+	}
+
+	var in *deliveryRequestJSON
+	switch {
+	case reqData.OrderID != "":
+		order, err := h.loadClientOrder(reqData.OrderID, reqData.TelegramID)
+		if err != nil {
+			h.logger.Error("Failed to load order", zap.String("order_id", reqData.OrderID), zap.Error(err))
+			h.sendErrorResponse(w, r, "order_load_failed", http.StatusInternalServerError)
+			return
+		}
+		if order == nil {
+			h.sendErrorResponse(w, r, "order_not_found", http.StatusNotFound)
+			return
+		}
+		fromOrder := orderToJSON(order)
+		in = &fromOrder
+	case len(reqData.Payload) > 0:
+		var err error
+		if in, err = decodeTemplatePayload(reqData.Payload); err != nil {
+			h.sendError(w, r, err, http.StatusBadRequest)
+			return
+		}
+	default:
+		h.sendErrorResponse(w, r, "order_or_draft_required", http.StatusBadRequest)
+		return
+	}
+
+	payload, err := h.templatePayload(in)
+	if err != nil {
+		h.sendError(w, r, err, http.StatusBadRequest)
+		return
+	}
+	ok, err := h.templateRepo.SaveTemplate(r.Context(), &domain.OrderTemplate{
+		TelegramID: reqData.TelegramID,
+		Name:       reqData.Name,
+		Payload:    payload,
+	})
+	if err != nil {
+		h.sendErrorResponse(w, r, "template_save_failed", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		h.sendErrorResponse(w, r, "templates_limit", http.StatusConflict, domain.MaxOrderTemplates)
+		return
+	}
+
+	templates, err := h.templateRepo.ListTemplates(r.Context(), reqData.TelegramID)
+	if err != nil {
+		h.sendErrorResponse(w, r, "templates_load_failed", http.StatusInternalServerError)
+		return
+	}
+	h.sendSuccessResponse(w, r, "template_saved", map[string]interface{}{
+		"templates": templates,
+	})
+}
+
+// handleDeleteTemplate — POST /api/user/templates/delete {telegram_id, id}.
+// Расписания по шаблону удаляются вместе с ним.
+func (h *Handler) handleDeleteTemplate(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	var reqData struct {
+		TelegramID int64 `json:"telegram_id"`
+		ID         int64 `json:"id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+		h.logger.Error("Failed to parse request body", zap.Error(err))
+		h.sendErrorResponse(w, r, "invalid_request", http.StatusBadRequest)
+		return
+	}
+	if reqData.TelegramID == 0 || reqData.ID == 0 {
+		h.sendErrorResponse(w, r, "telegram_id_and_template_id_required", http.StatusBadRequest)
+		return
+	}
+
+	ok, err := h.templateRepo.DeleteTemplate(r.Context(), reqData.ID, reqData.TelegramID)
+	if err != nil {
+		h.sendErrorResponse(w, r, "template_delete_failed", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		h.sendErrorResponse(w, r, "template_not_found", http.StatusNotFound)
+		return
+	}
+	h.sendSuccessResponse(w, r, "template_deleted", map[string]interface{}{
+		"id": reqData.ID,
+	})
+}
+
+// handleOrderFromTemplate — POST /api/user/templates/order {telegram_id, template_id, time_start}
+func (h *Handler) handleOrderFromTemplate(ctx context.Context, b *bot.Bot) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		var reqData struct {
+			TelegramID int64  `json:"telegram_id"`
+			TemplateID int64  `json:"template_id"`
+			TimeStart  string `json:"time_start"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+			h.logger.Error("Failed to parse request body", zap.Error(err))
+			h.sendErrorResponse(w, r, "invalid_request", http.StatusBadRequest)
+			return
+		}
+		if reqData.TelegramID == 0 || reqData.TemplateID == 0 {
+			h.sendErrorResponse(w, r, "telegram_id_and_template_id_required", http.StatusBadRequest)
+			return
+		}
+
+		t, err := h.templateRepo.GetTemplate(r.Context(), reqData.TemplateID, reqData.TelegramID)
+		if err != nil {
+			h.sendErrorResponse(w, r, "template_load_failed", http.StatusInternalServerError)
+			return
+		}
+		if t == nil {
+			h.sendErrorResponse(w, r, "template_not_found", http.StatusNotFound)
+			return
+		}
+		in, err := decodeTemplatePayload(t.Payload)
+		if err != nil {
+			h.sendError(w, r, err, http.StatusBadRequest)
+			return
+		}
+		in.TelegramID = reqData.TelegramID
+		in.TimeStart = strings.TrimSpace(reqData.TimeStart)
+		h.createOrderFromJSON(ctx, b, w, r, in)
+	}
+}
+
+// handleRecurring — расписания повторяющихся заявок.
+// GET /api/user/recurring?telegram_id=
+// POST /api/user/recurring {telegram_id, template_id, weekdays: [1..7], time_of_day: "09:00"}
+func (h *Handler) handleRecurring(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		telegramID, _ := strconv.ParseInt(r.URL.Query().Get("telegram_id"), 10, 64)
+		if telegramID == 0 {
+			h.sendErrorResponse(w, r, "telegram_id_required", http.StatusBadRequest)
+			return
+		}
+		recurring, err := h.templateRepo.ListRecurring(r.Context(), telegramID)
+		if err != nil {
+			h.sendErrorResponse(w, r, "recurring_list_load_failed", http.StatusInternalServerError)
+			return
+		}
+		h.sendSuccessResponse(w, r, "recurring_loaded", map[string]interface{}{
+			"recurring": recurring,
+		})
+		return
+	}
+
+	var reqData struct {
+		TelegramID int64  `json:"telegram_id"`
+		TemplateID int64  `json:"template_id"`
+		Weekdays   []int  `json:"weekdays"`
+		TimeOfDay  string `json:"time_of_day"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+		h.logger.Error("Failed to parse request body", zap.Error(err))
+		h.sendErrorResponse(w, r, "invalid_request", http.StatusBadRequest)
+		return
+	}
+	if reqData.TelegramID == 0 || reqData.TemplateID == 0 {
+		h.sendErrorResponse(w, r, "telegram_id_and_template_id_required", http.StatusBadRequest)
+		return
+	}
+
+	mask, err := domain.WeekdayMask(reqData.Weekdays)
+	if err != nil {
+		h.sendError(w, r, err, http.StatusBadRequest)
+		return
+	}
+	ro := &domain.RecurringOrder{
+		TelegramID: reqData.TelegramID,
+		TemplateID: reqData.TemplateID,
+		Weekdays:   mask,
+		TimeOfDay:  strings.TrimSpace(reqData.TimeOfDay),
+	}
+	next, err := ro.NextOccurrence(time.Now(), h.cfg.Location())
+	if err != nil {
+		h.sendError(w, r, err, http.StatusBadRequest)
+		return
+	}
+	ro.NextPickupAt = &next
+
+	t, err := h.templateRepo.GetTemplate(r.Context(), reqData.TemplateID, reqData.TelegramID)
+	if err != nil {
+		h.sendErrorResponse(w, r, "template_load_failed", http.StatusInternalServerError)
+		return
+	}
+	if t == nil {
+		h.sendErrorResponse(w, r, "template_not_found", http.StatusNotFound)
+		return
+	}
+
+	id, err := h.templateRepo.CreateRecurring(r.Context(), ro)
+	if err != nil {
+		h.sendErrorResponse(w, r, "recurring_save_failed", http.StatusInternalServerError)
+		return
+	}
+	if id == 0 {
+		h.sendErrorResponse(w, r, "recurring_limit", http.StatusConflict, domain.MaxRecurringOrders)
+		return
+	}
+
+	h.logger.Info("Recurring order created",
+		zap.Int64("recurring_id", id),
+		zap.Int64("telegram_id", ro.TelegramID),
+		zap.Time("next_pickup_at", next))
+	created, err := h.templateRepo.GetRecurring(r.Context(), id, ro.TelegramID)
+	if err != nil || created == nil {
+		h.sendErrorResponse(w, r, "recurring_load_failed", http.StatusInternalServerError)
+		return
+	}
+	h.sendSuccessResponse(w, r, "recurring_created", map[string]interface{}{
+		"recurring": created,
+	})
+}
+
+// handleRecurringStatus — POST /api/user/recurring/status {telegram_id, id, status: active|paused|cancelled}
+func (h *Handler) handleRecurringStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	var reqData struct {
+		TelegramID int64  `json:"telegram_id"`
+		ID         int64  `json:"id"`
+		Status     string `json:"status"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+		h.logger.Error("Failed to parse request body", zap.Error(err))
+		h.sendErrorResponse(w, r, "invalid_request", http.StatusBadRequest)
+		return
+	}
+	if reqData.TelegramID == 0 || reqData.ID == 0 {
+		h.sendErrorResponse(w, r, "telegram_id_and_recurring_id_required", http.StatusBadRequest)
+		return
+	}
+	switch reqData.Status {
+	case domain.RecurringActive, domain.RecurringPaused, domain.RecurringCancelled:
+	default:
+		h.sendErrorResponse(w, r, "invalid_recurring_status", http.StatusBadRequest)
+		return
+	}
+
+	ro, err := h.setRecurringStatus(r.Context(), reqData.ID, reqData.TelegramID, reqData.Status)
+	if err != nil {
+		h.sendErrorResponse(w, r, "recurring_update_failed", http.StatusInternalServerError)
+		return
+	}
+	if ro == nil {
+		h.sendErrorResponse(w, r, "recurring_not_changed", http.StatusConflict)
+		return
+	}
+	h.sendSuccessResponse(w, r, "recurring_updated", map[string]interface{}{
+		"recurring": ro,
+	})
+}
+
+// setRecurringStatus меняет статус расписания; при возобновлении дата считается от текущего момента.
+// Returns nil if the schedule was not found or already has this status.
+func (h *Handler) setRecurringStatus(ctx context.Context, id, telegramID int64, status string) (*domain.RecurringOrder, error) {
+	ro, err := h.templateRepo.GetRecurring(ctx, id, telegramID)
+	if err != nil || ro == nil {
+		return nil, err
+	}
+
+	var next *time.Time
+	if status == domain.RecurringActive {
+		t, err := ro.NextOccurrence(time.Now(), h.cfg.Location())
+		if err != nil {
+			return nil, err
+		}
+		next = &t
+	}
+	ok, err := h.templateRepo.SetRecurringStatus(ctx, id, telegramID, status, next)
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	h.logger.Info("Recurring order status changed", zap.Int64("recurring_id", id), zap.String("status", status))
+	ro.Status = status
+	ro.NextPickupAt = next
+	return ro, nil
+}
+
+// runRecurringOrders создаёт заявки по расписаниям за RecurringOrderAhead до подачи,
+// чтобы они прошли обычную отложенную рассылку.
+func (h *Handler) runRecurringOrders(ctx context.Context, b *bot.Bot) {
+	due, err := h.templateRepo.GetDueRecurring(ctx, h.cfg.RecurringOrderAhead)
+	if err != nil {
+		h.logger.Error("load due recurring orders", zap.Error(err))
+		return
+	}
+	loc := h.cfg.Location()
+	for i := range due {
+		ro := &due[i]
+		pickupAt := *ro.NextPickupAt
+		next, err := ro.NextOccurrence(pickupAt, loc)
+		if err != nil {
+			h.logger.Error("compute next recurring pickup", zap.Int64("recurring_id", ro.ID), zap.Error(err))
+			continue
+		}
+		ok, err := h.templateRepo.AdvanceRecurring(ctx, ro.ID, pickupAt, next)
+		if err != nil {
+			h.logger.Error("advance recurring order", zap.Int64("recurring_id", ro.ID), zap.Error(err))
+			continue
+		}
+		if !ok {
+			continue
+		}
+		// сервис стоял дольше допустимого — пропущенную подачу не создаём задним числом
+		if pickupAt.Before(time.Now().Add(-pickupGrace)) {
+			h.logger.Warn("recurring pickup missed, skipping",
+				zap.Int64("recurring_id", ro.ID), zap.Time("pickup_at", pickupAt))
+			continue
+		}
+		h.createRecurringOrder(ctx, b, ro, pickupAt)
+	}
+}
+
+func (h *Handler) createRecurringOrder(ctx context.Context, b *bot.Bot, ro *domain.RecurringOrder, pickupAt time.Time) {
+	timeStart := pickupAt.In(h.cfg.Location()).Format("2006-01-02T15:04")
+	req, err := h.requestFromTemplate(ro.Payload, ro.TelegramID, timeStart)
+	if err != nil {
+		h.logger.Error("build recurring order", zap.Int64("recurring_id", ro.ID), zap.Error(err))
+		h.notifyRecurringFailed(ctx, b, ro, err)
+		return
+	}
+	if err := h.prepareClientOrder(ctx, req, "", "", ""); err != nil {
+		// избранный водитель недоступен — заявка всё равно должна уйти, просто всем
+		h.logger.Warn("recurring order favorite driver dropped",
+			zap.Int64("recurring_id", ro.ID), zap.String("driver_id", req.PreferredDriverID), zap.Error(err))
+		req.PreferredDriverID = ""
+	}
+	req.ID = uuid.New().String()
+	req.RecurringID = ro.ID
+
+	if _, err := h.submitDeliveryRequest(ctx, b, req); err != nil {
+		h.logger.Error("save recurring order", zap.Int64("recurring_id", ro.ID), zap.Error(err))
+		var te *tariffError
+		if !errors.As(err, &te) {
+			err = i18n.E("order_save_failed")
+		}
+		h.notifyRecurringFailed(ctx, b, ro, err)
+		return
+	}
+	if err := h.templateRepo.SetLastRequest(ctx, ro.ID, req.ID); err != nil {
+		h.logger.Warn("set recurring last request", zap.Int64("recurring_id", ro.ID), zap.Error(err))
+	}
+	h.logger.Info("recurring order created",
+		zap.Int64("recurring_id", ro.ID),
+		zap.String("order_id", req.ID),
+		zap.Time("pickup_at", pickupAt))
+
+	lang := h.userLocale(ctx, ro.TelegramID)
+	if _, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      ro.TelegramID,
+		Text:        i18n.T(lang, "bot.recurring.created", html.EscapeString(ro.TemplateName), req.ID, timeStart),
+		ParseMode:   models.ParseModeHTML,
+		ReplyMarkup: recurringKeyboard(lang, ro.ID, domain.RecurringActive),
+	}); err != nil {
+		h.logger.Warn("notify client recurring order", zap.Int64("tg_id", ro.TelegramID), zap.Error(err))
+	}
+}
+
+func (h *Handler) notifyRecurringFailed(ctx context.Context, b *bot.Bot, ro *domain.RecurringOrder, reason error) {
+	lang := h.userLocale(ctx, ro.TelegramID)
+	if _, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: ro.TelegramID,
+		Text: i18n.T(lang, "bot.recurring.failed",
+			html.EscapeString(ro.TemplateName), html.EscapeString(h.errText(lang, reason))),
+		ParseMode:   models.ParseModeHTML,
+		ReplyMarkup: recurringKeyboard(lang, ro.ID, ro.Status),
+	}); err != nil {
+		h.logger.Warn("notify client recurring failed", zap.Int64("tg_id", ro.TelegramID), zap.Error(err))
+	}
+}
+
+// recurringKeyboard — кнопки управления расписанием в боте
+func recurringKeyboard(lang i18n.Locale, id int64, status string) *models.InlineKeyboardMarkup {
+	toggle := models.InlineKeyboardButton{
+		Text:         i18n.T(lang, "bot.recurring.btn_pause"),
+		CallbackData: fmt.Sprintf("rec:%d:pause", id),
+	}
+	if status == domain.RecurringPaused {
+		toggle = models.InlineKeyboardButton{
+			Text:         i18n.T(lang, "bot.recurring.btn_resume"),
+			CallbackData: fmt.Sprintf("rec:%d:resume", id),
+		}
+	}
+	return &models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{{
+			toggle,
+			{Text: i18n.T(lang, "bot.recurring.btn_cancel"), CallbackData: fmt.Sprintf("rec:%d:cancel", id)},
+		}},
+	}
+}
+
+// weekdayNames — сокращённые дни недели, индекс 1 = понедельник
+func weekdayNames(lang i18n.Locale) []string {
+	return append([]string{""}, strings.Split(i18n.T(lang, "bot.weekdays"), ",")...)
+}
+
+func formatRecurring(lang i18n.Locale, ro *domain.RecurringOrder, loc *time.Location) string {
+	names := weekdayNames(lang)
+	days := make([]string, 0, len(ro.Days))
+	for _, d := range ro.Days {
+		if d > 0 && d < len(names) {
+			days = append(days, names[d])
+		}
+	}
+	text := fmt.Sprintf("🔁 <b>%s</b>\n%s, %s", html.EscapeString(ro.TemplateName), strings.Join(days, ", "), ro.TimeOfDay)
+	if ro.Status == domain.RecurringPaused {
+		text += "\n" + i18n.T(lang, "bot.recurring.paused")
+	} else if ro.NextPickupAt != nil {
+		text += "\n" + i18n.T(lang, "bot.recurring.next", ro.NextPickupAt.In(loc).Format("02.01 15:04"))
+	}
+	return text
+}
+
+// sendRecurringList отвечает на /recurring: каждое расписание отдельным сообщением с кнопками
+func (h *Handler) sendRecurringList(ctx context.Context, b *bot.Bot, chatID int64) {
+	list, err := h.templateRepo.ListRecurring(ctx, chatID)
+	if err != nil {
+		h.logger.Error("list recurring orders", zap.Int64("tg_id", chatID), zap.Error(err))
+		return
+	}
+	if len(list) == 0 {
+		if _, err := b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   tr(ctx, "bot.recurring.empty"),
+		}); err != nil {
+			h.logger.Warn("send recurring list", zap.Int64("tg_id", chatID), zap.Error(err))
+		}
+		return
+	}
+	for i := range list {
+		if _, err := b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:      chatID,
+			Text:        formatRecurring(i18n.FromContext(ctx), &list[i], h.cfg.Location()),
+			ParseMode:   models.ParseModeHTML,
+			ReplyMarkup: recurringKeyboard(i18n.FromContext(ctx), list[i].ID, list[i].Status),
+		}); err != nil {
+			h.logger.Warn("send recurring list", zap.Int64("tg_id", chatID), zap.Error(err))
+		}
+	}
+}
+
+// recurringByCallback handles rec:<id>:<pause|resume|cancel>
+func (h *Handler) recurringByCallback(ctx context.Context, b *bot.Bot, cq *models.CallbackQuery, arg string) string {
+	idStr, action, _ := strings.Cut(arg, ":")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		return tr(ctx, "bot.error")
+	}
+
+	var status, answer string
+	switch action {
+	case "pause":
+		status, answer = domain.RecurringPaused, tr(ctx, "bot.recurring.answer_paused")
+	case "resume":
+		status, answer = domain.RecurringActive, tr(ctx, "bot.recurring.answer_resumed")
+	case "cancel":
+		status, answer = domain.RecurringCancelled, tr(ctx, "bot.recurring.answer_cancelled")
+	default:
+		return tr(ctx, "bot.error")
+	}
+
+	ro, err := h.setRecurringStatus(ctx, id, cq.From.ID, status)
+	if err != nil {
+		h.logger.Error("Failed to update recurring order", zap.Int64("recurring_id", id), zap.Error(err))
+		return tr(ctx, "bot.error")
+	}
+	if ro == nil {
+		h.clearCallbackKeyboard(ctx, b, cq)
+		return tr(ctx, "bot.recurring.not_found")
+	}
+
+	if msg := cq.Message.Message; msg != nil {
+		lang := i18n.FromContext(ctx)
+		params := &bot.EditMessageTextParams{
+			ChatID:    msg.Chat.ID,
+			MessageID: msg.ID,
+			Text:      formatRecurring(lang, ro, h.cfg.Location()),
+			ParseMode: models.ParseModeHTML,
+		}
+		if status != domain.RecurringCancelled {
+			params.ReplyMarkup = recurringKeyboard(lang, ro.ID, status)
+		} else {
+			params.Text += "\n" + i18n.T(lang, "bot.recurring.cancelled")
+		}
+		if _, err := b.EditMessageText(ctx, params); err != nil {
+			h.logger.Debug("Failed to edit recurring message", zap.Error(err))
+		}
+	}
+	return answer
+}
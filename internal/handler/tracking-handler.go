@@ -0,0 +1,253 @@
+// tracking-handler.go
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"tezjet/internal/domain"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+const (
+	trackingTokenBytes = 16               // 128 бит — ссылку невозможно подобрать
+	driverPositionTTL  = 15 * time.Minute // старше — водитель перестал транслировать геолокацию
+)
+
+// newTrackingToken — случайный токен для публичной ссылки, безопасный для URL
+func newTrackingToken() (string, error) {
+	buf := make([]byte, trackingTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func (h *Handler) trackingURL(token string) string {
+	return h.cfg.BaseURL + "/t/" + token
+}
+
+// handleTrackingLink выдаёт (или создаёт) ссылку отслеживания для получателя.
+// POST /api/user/tracking-link {telegram_id, order_id}
+func (h *Handler) handleTrackingLink(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	var reqData struct {
+		TelegramID int64  `json:"telegram_id"`
+		OrderID    string `json:"order_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+		h.logger.Error("Failed to parse request body", zap.Error(err))
+		h.sendErrorResponse(w, r, "invalid_request", http.StatusBadRequest)
+		return
+	}
+	if reqData.TelegramID == 0 || reqData.OrderID == "" {
+		h.sendErrorResponse(w, r, "telegram_id_and_order_id_required", http.StatusBadRequest)
+		return
+	}
+
+	newToken, err := newTrackingToken()
+	if err != nil {
+		h.logger.Error("Failed to generate tracking token", zap.Error(err))
+		h.sendErrorResponse(w, r, "link_create_failed", http.StatusInternalServerError)
+		return
+	}
+
+	token, err := h.orderRepo.EnsureTrackingToken(r.Context(), reqData.OrderID, reqData.TelegramID, newToken)
+	if err != nil {
+		h.logger.Error("Failed to ensure tracking token", zap.String("order_id", reqData.OrderID), zap.Error(err))
+		h.sendErrorResponse(w, r, "link_create_failed", http.StatusInternalServerError)
+		return
+	}
+	if token == "" {
+		h.sendErrorResponse(w, r, "order_not_found_or_completed", http.StatusNotFound)
+		return
+	}
+
+	h.sendSuccessResponse(w, r, "tracking_link", map[string]interface{}{
+		"order_id": reqData.OrderID,
+		"url":      h.trackingURL(token),
+	})
+}
+
+// handleRevokeTrackingLink отключает ссылку отслеживания.
+// POST /api/user/tracking-link/revoke {telegram_id, order_id}
+func (h *Handler) handleRevokeTrackingLink(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	var reqData struct {
+		TelegramID int64  `json:"telegram_id"`
+		OrderID    string `json:"order_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+		h.logger.Error("Failed to parse request body", zap.Error(err))
+		h.sendErrorResponse(w, r, "invalid_request", http.StatusBadRequest)
+		return
+	}
+	if reqData.TelegramID == 0 || reqData.OrderID == "" {
+		h.sendErrorResponse(w, r, "telegram_id_and_order_id_required", http.StatusBadRequest)
+		return
+	}
+
+	ok, err := h.orderRepo.RevokeTrackingToken(r.Context(), reqData.OrderID, reqData.TelegramID)
+	if err != nil {
+		h.logger.Error("Failed to revoke tracking token", zap.String("order_id", reqData.OrderID), zap.Error(err))
+		h.sendErrorResponse(w, r, "link_revoke_failed", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		h.sendErrorResponse(w, r, "link_not_found", http.StatusNotFound)
+		return
+	}
+
+	h.sendSuccessResponse(w, r, "tracking_link_revoked", map[string]interface{}{
+		"order_id": reqData.OrderID,
+	})
+}
+
+// trackingPageHandler — публичная страница /t/{token}, открывается без Telegram
+func (h *Handler) trackingPageHandler(w http.ResponseWriter, r *http.Request) {
+	path := "./static/track.html"
+	w.Header().Set("Content-Type", "text/html")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	http.ServeFile(w, r, path)
+}
+
+// handleTrackingAPI — данные для страницы отслеживания.
+// GET /api/track/{token}
+func (h *Handler) handleTrackingAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+
+	token := mux.Vars(r)["token"]
+	if token == "" {
+		h.sendErrorResponse(w, r, "link_invalid", http.StatusNotFound)
+		return
+	}
+
+	t, err := h.orderRepo.GetTrackingByToken(r.Context(), token)
+	if err != nil {
+		h.logger.Error("Failed to get tracking", zap.Error(err))
+		h.sendErrorResponse(w, r, "order_load_failed", http.StatusInternalServerError)
+		return
+	}
+	if t == nil || t.TrackingExpired(time.Now(), h.cfg.TrackingLinkTTL) {
+		h.sendErrorResponse(w, r, "link_invalid", http.StatusNotFound)
+		return
+	}
+
+	t.Timeline = t.BuildTimeline()
+	if t.Status == domain.DeliveryStatusInProgress && t.DriverID != "" {
+		pos, err := h.redisRepo.GetDriverPosition(r.Context(), t.DriverID)
+		if err != nil {
+			h.logger.Warn("Failed to get driver position", zap.String("driver_id", t.DriverID), zap.Error(err))
+		}
+		t.Position = pos
+	}
+
+	h.sendSuccessResponse(w, r, "tracking", t)
+}
+
+// handleDriverLocation — геолокация водителя из Mini App.
+// POST /api/driver/location {telegram_id, lat, lon}
+func (h *Handler) handleDriverLocation(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	var reqData struct {
+		TelegramID int64   `json:"telegram_id"`
+		Lat        float64 `json:"lat"`
+		Lon        float64 `json:"lon"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&reqData); err != nil {
+		h.logger.Error("Failed to parse request body", zap.Error(err))
+		h.sendErrorResponse(w, r, "invalid_request", http.StatusBadRequest)
+		return
+	}
+	if reqData.TelegramID == 0 || !validLatLon(reqData.Lat, reqData.Lon) {
+		h.sendErrorResponse(w, r, "telegram_id_and_coords_required", http.StatusBadRequest)
+		return
+	}
+
+	driver, err := h.CheckDriverExist(reqData.TelegramID)
+	if err != nil {
+		h.logger.Error("Failed to check driver existence", zap.Error(err))
+		h.sendErrorResponse(w, r, "driver_check_failed", http.StatusInternalServerError)
+		return
+	}
+	if driver == nil {
+		h.sendErrorResponse(w, r, "driver_not_found", http.StatusNotFound)
+		return
+	}
+
+	if err := h.saveDriverPosition(r.Context(), driver.ID, reqData.Lat, reqData.Lon); err != nil {
+		h.sendErrorResponse(w, r, "location_save_failed", http.StatusInternalServerError)
+		return
+	}
+	h.sendSuccessResponse(w, r, "location_updated")
+}
+
+// saveDriverLocationMessage принимает геолокацию (в том числе живую трансляцию) водителя из бота.
+// Returns false if the sender is not a driver, so the message goes through the usual flow.
+func (h *Handler) saveDriverLocationMessage(ctx context.Context, b *bot.Bot, msg *models.Message, edited bool) bool {
+	if msg.From == nil || msg.Location == nil {
+		return false
+	}
+	driver, err := h.CheckDriverExist(msg.From.ID)
+	if err != nil || driver == nil {
+		return false
+	}
+
+	if err := h.saveDriverPosition(ctx, driver.ID, msg.Location.Latitude, msg.Location.Longitude); err != nil {
+		return true
+	}
+
+	// обновления живой трансляции приходят как edited_message — на них не отвечаем
+	if !edited {
+		if _, err := b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: msg.Chat.ID,
+			Text:   tr(ctx, "bot.tracking.location_received"),
+		}); err != nil {
+			h.logger.Warn("Failed to confirm driver location", zap.Int64("tg_id", msg.From.ID), zap.Error(err))
+		}
+	}
+	return true
+}
+
+func (h *Handler) saveDriverPosition(ctx context.Context, driverID string, lat, lon float64) error {
+	err := h.redisRepo.SaveDriverPosition(ctx, driverID, domain.DriverPosition{
+		Lat:       lat,
+		Lon:       lon,
+		UpdatedAt: time.Now().UTC(),
+	}, driverPositionTTL)
+	if err != nil {
+		h.logger.Error("Failed to save driver position", zap.String("driver_id", driverID), zap.Error(err))
+	}
+	return err
+}
+
+func validLatLon(lat, lon float64) bool {
+	return lat >= -90 && lat <= 90 && lon >= -180 && lon <= 180 && (lat != 0 || lon != 0)
+}
@@ -0,0 +1,811 @@
+// trip-edit-handler.go
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"tezjet/internal/domain"
+	"tezjet/internal/i18n"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"go.uber.org/zap"
+)
+
+// Ключи начала текста подсказок для правки рейса; дальше идёт " #<id рейса>"
+const (
+	tripPromptPrice   = "bot.trip.prompt_price"
+	tripPromptTime    = "bot.trip.prompt_time"
+	tripPromptComment = "bot.trip.prompt_comment"
+	tripPromptRepost  = "bot.trip.prompt_repost"
+)
+
+const (
+	maxTripCommentLen = 500
+	maxOpenTripsShown = 5 // столько открытых рейсов показывает /trips
+)
+
+var (
+	errTripNotFound     = i18n.E("trip_not_found")
+	errTripClosed       = i18n.E("trip_closed")
+	errTripCargoOnBoard = i18n.E("trip_cargo_on_board")
+	errTripSave         = i18n.E("trip_save_failed")
+)
+
+// tripUpdateJSON — правка рейса водителем; nil значит "не менять"
+type tripUpdateJSON struct {
+	TelegramID int64   `json:"telegram_id"`
+	TripID     string  `json:"trip_id"`
+	Price      *int    `json:"price"`
+	StartTime  *string `json:"start_time"`
+	Comment    *string `json:"comment"`
+}
+
+// tripActionJSON — отмена, завершение и повтор рейса. StartTime и Price нужны только повтору:
+// пустое время — выезд сейчас, 0 — прежняя цена.
+type tripActionJSON struct {
+	TelegramID int64  `json:"telegram_id"`
+	TripID     string `json:"trip_id"`
+	StartTime  string `json:"start_time"`
+	Price      int    `json:"price"`
+}
+
+// tripExpiryCutoff — рейсы с выездом раньше этого момента считаются завершёнными
+func (h *Handler) tripExpiryCutoff() string {
+	return sqliteTime(time.Now().Add(-h.cfg.TripExpiryGrace))
+}
+
+// loadOwnTrip returns the driver's trip or errTripNotFound
+func (h *Handler) loadOwnTrip(ctx context.Context, telegramID int64, tripID string) (*DriverTrip, error) {
+	trip, _, err := h.loadDriverTrip(ctx, `dt.id = ? AND dt.telegram_id = ?`, tripID, telegramID)
+	if err != nil {
+		return nil, err
+	}
+	if trip == nil {
+		return nil, errTripNotFound
+	}
+	return trip, nil
+}
+
+// parseTripStartTime разбирает время выезда: пустое — сейчас, "15:04" — ближайшее такое время,
+// иначе форматы parsePickupTime. Возвращает значение для start_time.
+func (h *Handler) parseTripStartTime(s string, now time.Time) (string, error) {
+	loc := h.cfg.Location()
+	s = strings.TrimSpace(s)
+
+	var t time.Time
+	if s == "" {
+		t = now
+	} else if clock, err := time.ParseInLocation("15:04", s, loc); err == nil {
+		local := now.In(loc)
+		t = time.Date(local.Year(), local.Month(), local.Day(), clock.Hour(), clock.Minute(), 0, 0, loc)
+		if t.Before(now.Add(-pickupGrace)) {
+			t = t.AddDate(0, 0, 1)
+		}
+	} else if t, err = h.parsePickupTime(s); err != nil {
+		return "", i18n.E("invalid_departure_time", s)
+	}
+
+	if t.Before(now.Add(-pickupGrace)) {
+		return "", i18n.E("departure_in_past")
+	}
+	if t.After(now.Add(h.cfg.MaxScheduleAhead)) {
+		return "", i18n.E("departure_too_far", int(h.cfg.MaxScheduleAhead.Hours()/24))
+	}
+	return t.In(loc).Format("2006-01-02T15:04"), nil
+}
+
+// applyTripUpdate переносит правку в trip и проверяет её. Возвращает изменённые поля.
+func (h *Handler) applyTripUpdate(ctx context.Context, trip *DriverTrip, in *tripUpdateJSON) ([]string, error) {
+	var changed []string
+	if in.Price != nil && *in.Price != trip.Price {
+		if *in.Price <= 0 {
+			return nil, i18n.E("price_must_be_positive")
+		}
+		if _, err := h.checkTariffPrice(ctx, trip.FromAddress, trip.TruckType, trip.DistanceKm, trip.EtaMin, *in.Price); err != nil {
+			return nil, err
+		}
+		trip.Price = *in.Price
+		changed = append(changed, "price")
+	}
+	if in.StartTime != nil {
+		startTime, err := h.parseTripStartTime(*in.StartTime, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		if startTime != trip.StartTime {
+			trip.StartTime = startTime
+			changed = append(changed, "start_time")
+		}
+	}
+	if in.Comment != nil {
+		comment := strings.TrimSpace(*in.Comment)
+		if utf8.RuneCountInString(comment) > maxTripCommentLen {
+			return nil, i18n.E("comment_too_long", maxTripCommentLen)
+		}
+		if comment != trip.Comment {
+			trip.Comment = comment
+			changed = append(changed, "comment")
+		}
+	}
+	return changed, nil
+}
+
+// saveTripUpdate сохраняет цену, время и комментарий, пока рейс открыт
+func (h *Handler) saveTripUpdate(ctx context.Context, trip *DriverTrip) (bool, error) {
+	result, err := h.db.ExecContext(ctx, `
+		UPDATE driver_trips
+		SET price = ?, start_time = ?, departure_time = ?, comment = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND status IN ('active', 'full')`,
+		trip.Price, trip.StartTime, sqliteTime(h.tripDeparture(trip.StartTime)), trip.Comment, trip.ID)
+	if err != nil {
+		return false, fmt.Errorf("failed to update driver trip: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return n > 0, nil
+}
+
+// updateDriverTrip правит открытый рейс и сообщает клиентам рейса о новом времени выезда
+func (h *Handler) updateDriverTrip(ctx context.Context, b *bot.Bot, trip *DriverTrip, in *tripUpdateJSON) ([]string, error) {
+	if !domain.TripIsOpen(trip.Status) {
+		return nil, errTripClosed
+	}
+	changed, err := h.applyTripUpdate(ctx, trip, in)
+	if err != nil || len(changed) == 0 {
+		return changed, err
+	}
+	ok, err := h.saveTripUpdate(ctx, trip)
+	if err != nil {
+		h.logger.Error("Failed to save trip update", zap.String("trip_id", trip.ID), zap.Error(err))
+		return nil, errTripSave
+	}
+	if !ok {
+		return nil, errTripClosed
+	}
+
+	h.logger.Info("Driver trip updated",
+		zap.String("trip_id", trip.ID),
+		zap.Strings("fields", changed))
+	for _, f := range changed {
+		if f == "start_time" {
+			go h.notifyTripTimeChanged(context.Background(), b, trip)
+		}
+	}
+	return changed, nil
+}
+
+// notifyTripTimeChanged сообщает клиентам, чей груз ещё не забран, новое время выезда
+func (h *Handler) notifyTripTimeChanged(ctx context.Context, b *bot.Bot, trip *DriverTrip) {
+	orders, err := h.getTripOrders(ctx, trip.ID)
+	if err != nil {
+		return
+	}
+	for i := range orders {
+		o := &orders[i]
+		if !o.IsOnTrip() || o.PickedUpAt != nil || o.TelegramID == 0 {
+			continue
+		}
+		h.notifyBookingUser(ctx, b, o.TelegramID, "bot.trip.time_changed",
+			o.ID, html.EscapeString(o.FromAddress), html.EscapeString(o.ToAddress),
+			h.formatTripStartTime(h.userLocale(ctx, o.TelegramID), trip.StartTime))
+	}
+}
+
+// closeDriverTrip завершает или отменяет открытый рейс. Ждущие брони закрываются с уведомлением.
+// При отмене заявки рейса, груз которых ещё не забран, возвращаются в поиск как отказ водителя.
+func (h *Handler) closeDriverTrip(ctx context.Context, b *bot.Bot, trip *DriverTrip, status string) error {
+	if !domain.TripIsOpen(trip.Status) {
+		return errTripClosed
+	}
+	orders, err := h.getTripOrders(ctx, trip.ID)
+	if err != nil {
+		return err
+	}
+	var dropped []string
+	for i := range orders {
+		if !orders[i].IsOnTrip() {
+			continue
+		}
+		if orders[i].PickedUpAt != nil {
+			if status == domain.TripStatusCancelled {
+				return errTripCargoOnBoard
+			}
+			continue
+		}
+		dropped = append(dropped, orders[i].ID)
+	}
+
+	result, err := h.db.ExecContext(ctx, `
+		UPDATE driver_trips SET status = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND status IN ('active', 'full')`, status, trip.ID)
+	if err != nil {
+		return fmt.Errorf("failed to close driver trip: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return errTripClosed
+	}
+	trip.Status = status
+
+	h.logger.Info("Driver trip closed",
+		zap.String("trip_id", trip.ID),
+		zap.String("status", status),
+		zap.Int("orders", len(dropped)))
+
+	reason := "bot.booking.reason_completed"
+	if status == domain.TripStatusCancelled {
+		reason = "bot.booking.reason_cancelled"
+	}
+	h.closeTripBookings(ctx, b, trip.ID, domain.BookingDeclined, reason)
+
+	if status != domain.TripStatusCancelled || len(dropped) == 0 {
+		return nil
+	}
+	driver, err := h.CheckDriverExist(trip.TelegramID)
+	if err != nil || driver == nil {
+		h.logger.Error("Failed to load driver of cancelled trip", zap.String("trip_id", trip.ID), zap.Error(err))
+		return nil
+	}
+	for _, orderID := range dropped {
+		if err := h.releaseTripOrder(ctx, b, driver, orderID); err != nil {
+			h.logger.Warn("Failed to drop order of cancelled trip",
+				zap.String("trip_id", trip.ID),
+				zap.String("order_id", orderID),
+				zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// releaseTripOrder возвращает заявку отменённого рейса в поиск. Это не отказ водителя:
+// cancel_count и пауза на рассылку не меняются.
+func (h *Handler) releaseTripOrder(ctx context.Context, b *bot.Bot, driver *DriverRegistration, orderID string) error {
+	ok, err := h.orderRepo.ReleaseByDriver(ctx, orderID, driver.ID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errCancelNotAllowed
+	}
+	if err := h.releaseTripSpace(ctx, orderID); err != nil {
+		h.logger.Error("Failed to release trip space", zap.String("order_id", orderID), zap.Error(err))
+	}
+
+	order, err := h.getDeliveryOrderById(orderID)
+	if err != nil || order == nil {
+		h.logger.Error("Failed to reload released order", zap.String("order_id", orderID), zap.Error(err))
+		return nil
+	}
+	go func() {
+		bg := context.Background()
+		h.notifyClientDriverCancelled(bg, b, order, domain.CancelReasonOther)
+		h.redispatchOrder(bg, b, order, driver.TelegramID)
+	}()
+	return nil
+}
+
+// repostDriverTrip публикует копию рейса как новый активный рейс
+func (h *Handler) repostDriverTrip(ctx context.Context, b *bot.Bot, trip *DriverTrip, startTime string, price int) (*DriverTrip, error) {
+	driver, err := h.CheckDriverExist(trip.TelegramID)
+	if err != nil {
+		h.logger.Error("Failed to check driver for repost", zap.Int64("tg_id", trip.TelegramID), zap.Error(err))
+		return nil, errTripSave
+	}
+	if driver == nil || driver.Status != "approved" {
+		return nil, errBidDriverDenied
+	}
+
+	next := &DriverTrip{
+		DriverID:    driver.ID,
+		TelegramID:  trip.TelegramID,
+		FromAddress: trip.FromAddress,
+		FromLat:     trip.FromLat,
+		FromLon:     trip.FromLon,
+		ToAddress:   trip.ToAddress,
+		ToLat:       trip.ToLat,
+		ToLon:       trip.ToLon,
+		DistanceKm:  trip.DistanceKm,
+		EtaMin:      trip.EtaMin,
+		Price:       trip.Price,
+		TruckType:   trip.TruckType,
+		MaxWeight:   trip.MaxWeight,
+		Comment:     trip.Comment,
+	}
+	if price > 0 {
+		next.Price = price
+	}
+	if next.StartTime, err = h.parseTripStartTime(startTime, time.Now()); err != nil {
+		return nil, err
+	}
+	if _, err := h.checkTariffPrice(ctx, next.FromAddress, next.TruckType, next.DistanceKm, next.EtaMin, next.Price); err != nil {
+		return nil, err
+	}
+
+	if next.ID, err = h.saveDriverTrip(next); err != nil {
+		return nil, errTripSave
+	}
+	next.Status = domain.TripStatusActive
+	next.CreatedAt = time.Now()
+
+	h.logger.Info("Driver trip reposted",
+		zap.String("trip_id", trip.ID),
+		zap.String("new_trip_id", next.ID),
+		zap.String("start_time", next.StartTime))
+	go h.sendDriverTripConfirmation(b, next, driver)
+	go h.notifySavedSearches(b, next, driver)
+	return next, nil
+}
+
+// tripErrorStatus — HTTP-код для ошибок правки рейса; прочие ошибки — неверный ввод
+func tripErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, errTripNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, errTripClosed), errors.Is(err, errTripCargoOnBoard):
+		return http.StatusConflict
+	case errors.Is(err, errBidDriverDenied):
+		return http.StatusForbidden
+	case errors.Is(err, errTripSave):
+		return http.StatusInternalServerError
+	}
+	return http.StatusBadRequest
+}
+
+// handleDriverTripUpdate — POST /api/driver/trip/update: цена, время выезда и комментарий открытого рейса
+func (h *Handler) handleDriverTripUpdate(b *bot.Bot) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		var in tripUpdateJSON
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			h.logger.Error("Failed to parse request body", zap.Error(err))
+			h.sendErrorResponse(w, r, "invalid_request", http.StatusBadRequest)
+			return
+		}
+		in.TripID = strings.TrimSpace(in.TripID)
+		if in.TelegramID == 0 || in.TripID == "" {
+			h.sendErrorResponse(w, r, "telegram_id_and_trip_id_required", http.StatusBadRequest)
+			return
+		}
+
+		ctx := r.Context()
+		trip, err := h.loadOwnTrip(ctx, in.TelegramID, in.TripID)
+		if err != nil && !errors.Is(err, errTripNotFound) {
+			h.logger.Error("Failed to load trip", zap.String("trip_id", in.TripID), zap.Error(err))
+			h.sendErrorResponse(w, r, "trip_load_failed", http.StatusInternalServerError)
+			return
+		}
+		if err == nil {
+			var changed []string
+			changed, err = h.updateDriverTrip(ctx, b, trip, &in)
+			if err == nil && len(changed) == 0 {
+				h.sendErrorResponse(w, r, "no_changes", http.StatusBadRequest)
+				return
+			}
+		}
+		if err != nil {
+			h.sendError(w, r, err, tripErrorStatus(err))
+			return
+		}
+
+		h.sendSuccessResponse(w, r, "trip_updated", map[string]interface{}{
+			"trip": trip,
+		})
+	}
+}
+
+// handleDriverTripClose — POST /api/driver/trip/cancel и /api/driver/trip/complete
+func (h *Handler) handleDriverTripClose(b *bot.Bot, status string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		var in tripActionJSON
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			h.logger.Error("Failed to parse request body", zap.Error(err))
+			h.sendErrorResponse(w, r, "invalid_request", http.StatusBadRequest)
+			return
+		}
+		in.TripID = strings.TrimSpace(in.TripID)
+		if in.TelegramID == 0 || in.TripID == "" {
+			h.sendErrorResponse(w, r, "telegram_id_and_trip_id_required", http.StatusBadRequest)
+			return
+		}
+
+		ctx := r.Context()
+		trip, err := h.loadOwnTrip(ctx, in.TelegramID, in.TripID)
+		if err == nil {
+			err = h.closeDriverTrip(ctx, b, trip, status)
+		}
+		switch {
+		case errors.Is(err, errTripNotFound), errors.Is(err, errTripClosed), errors.Is(err, errTripCargoOnBoard):
+			h.sendError(w, r, err, tripErrorStatus(err))
+			return
+		case err != nil:
+			h.logger.Error("Failed to close trip", zap.String("trip_id", in.TripID), zap.Error(err))
+			h.sendErrorResponse(w, r, "trip_close_failed", http.StatusInternalServerError)
+			return
+		}
+
+		code := "trip_completed"
+		if status == domain.TripStatusCancelled {
+			code = "trip_cancelled"
+		}
+		h.sendSuccessResponse(w, r, code, map[string]interface{}{
+			"trip_id": trip.ID,
+			"status":  trip.Status,
+		})
+	}
+}
+
+// handleDriverTripRepost — POST /api/driver/trip/repost: тот же маршрут новым рейсом
+func (h *Handler) handleDriverTripRepost(b *bot.Bot) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		var in tripActionJSON
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			h.logger.Error("Failed to parse request body", zap.Error(err))
+			h.sendErrorResponse(w, r, "invalid_request", http.StatusBadRequest)
+			return
+		}
+		in.TripID = strings.TrimSpace(in.TripID)
+		if in.TelegramID == 0 || in.TripID == "" {
+			h.sendErrorResponse(w, r, "telegram_id_and_trip_id_required", http.StatusBadRequest)
+			return
+		}
+		if in.Price < 0 {
+			h.sendErrorResponse(w, r, "price_must_be_positive", http.StatusBadRequest)
+			return
+		}
+
+		ctx := r.Context()
+		trip, err := h.loadOwnTrip(ctx, in.TelegramID, in.TripID)
+		if err != nil && !errors.Is(err, errTripNotFound) {
+			h.logger.Error("Failed to load trip", zap.String("trip_id", in.TripID), zap.Error(err))
+			h.sendErrorResponse(w, r, "trip_load_failed", http.StatusInternalServerError)
+			return
+		}
+		var next *DriverTrip
+		if err == nil {
+			next, err = h.repostDriverTrip(ctx, b, trip, in.StartTime, in.Price)
+		}
+		if err != nil {
+			h.sendError(w, r, err, tripErrorStatus(err))
+			return
+		}
+
+		h.sendSuccessResponse(w, r, "trip_reposted", map[string]interface{}{
+			"trip_id":    next.ID,
+			"status":     next.Status,
+			"start_time": next.StartTime,
+			"price":      next.Price,
+		})
+	}
+}
+
+// tripManageKeyboard — кнопки управления рейсом в боте: "trip:<id>:<действие>"
+func tripManageKeyboard(lang i18n.Locale, tripID string) *models.InlineKeyboardMarkup {
+	cb := func(action string) string { return "trip:" + tripID + ":" + action }
+	return &models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{
+		{
+			{Text: i18n.T(lang, "bot.trip.btn_price"), CallbackData: cb("price")},
+			{Text: i18n.T(lang, "bot.trip.btn_time"), CallbackData: cb("time")},
+			{Text: i18n.T(lang, "bot.trip.btn_comment"), CallbackData: cb("comment")},
+		},
+		{
+			{Text: i18n.T(lang, "bot.trip.btn_done"), CallbackData: cb("done")},
+			{Text: i18n.T(lang, "bot.trip.btn_cancel"), CallbackData: cb("cancel")},
+		},
+		{
+			{Text: i18n.T(lang, "bot.trip.btn_repost"), CallbackData: cb("repost")},
+			{Text: i18n.T(lang, "bot.trip.btn_my_trips"), CallbackData: "my_trips"},
+		},
+	}}
+}
+
+func tripCancelConfirmKeyboard(lang i18n.Locale, tripID string) *models.InlineKeyboardMarkup {
+	return &models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{{
+		{Text: i18n.T(lang, "bot.trip.btn_cancel_yes"), CallbackData: "trip:" + tripID + ":cancel_yes"},
+		{Text: i18n.T(lang, "bot.trip.btn_back"), CallbackData: "trip:" + tripID + ":back"},
+	}}}
+}
+
+func tripRepostKeyboard(lang i18n.Locale, tripID string) *models.InlineKeyboardMarkup {
+	return &models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{{
+		{Text: i18n.T(lang, "bot.trip.btn_repost"), CallbackData: "trip:" + tripID + ":repost"},
+	}}}
+}
+
+// formatTripCard — короткая карточка рейса для /trips
+func (h *Handler) formatTripCard(lang i18n.Locale, trip *DriverTrip) string {
+	var sb strings.Builder
+	sb.WriteString(i18n.T(lang, "bot.trip.card",
+		trip.ID, html.EscapeString(trip.FromAddress), html.EscapeString(trip.ToAddress),
+		trip.Price, h.formatTripStartTime(lang, trip.StartTime)))
+	if trip.Comment != "" {
+		fmt.Fprintf(&sb, "\n💬 %s", html.EscapeString(trip.Comment))
+	}
+	if trip.Status == domain.TripStatusFull {
+		sb.WriteString("\n" + i18n.T(lang, "bot.trip.full"))
+	}
+	return sb.String()
+}
+
+// sendDriverOpenTrips отвечает на /trips и кнопку "Менің сапарларым": по карточке на открытый рейс
+func (h *Handler) sendDriverOpenTrips(ctx context.Context, b *bot.Bot, chatID int64) {
+	rows, err := h.db.QueryContext(ctx, `
+		SELECT id FROM driver_trips
+		WHERE telegram_id = ? AND status IN ('active', 'full')
+		ORDER BY departure_time, created_at
+		LIMIT ?`, chatID, maxOpenTripsShown)
+	if err != nil {
+		h.logger.Error("Failed to list open trips", zap.Int64("tg_id", chatID), zap.Error(err))
+		h.replySettingsError(ctx, b, chatID, tr(ctx, "bot.error"))
+		return
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	rows.Close()
+
+	if len(ids) == 0 {
+		h.replySettingsError(ctx, b, chatID, tr(ctx, "bot.trip.none_open"))
+		return
+	}
+	for _, id := range ids {
+		trip, err := h.loadOwnTrip(ctx, chatID, id)
+		if err != nil {
+			continue
+		}
+		if _, err := b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:      chatID,
+			Text:        h.formatTripCard(i18n.FromContext(ctx), trip),
+			ParseMode:   models.ParseModeHTML,
+			ReplyMarkup: tripManageKeyboard(i18n.FromContext(ctx), trip.ID),
+		}); err != nil {
+			h.logger.Warn("send trip card", zap.String("trip_id", id), zap.Error(err))
+		}
+	}
+}
+
+// tripByCallback handles trip:<id>:<price|time|comment|done|cancel|cancel_yes|back|repost>
+func (h *Handler) tripByCallback(ctx context.Context, b *bot.Bot, cq *models.CallbackQuery, arg string) string {
+	tripID, action, _ := strings.Cut(arg, ":")
+	trip, err := h.loadOwnTrip(ctx, cq.From.ID, tripID)
+	if errors.Is(err, errTripNotFound) {
+		return tr(ctx, "bot.trip.not_found")
+	}
+	if err != nil {
+		return tr(ctx, "bot.error")
+	}
+	// повторить можно и закрытый рейс, остальное — только открытый
+	if action != "repost" && !domain.TripIsOpen(trip.Status) {
+		h.clearCallbackKeyboard(ctx, b, cq)
+		return tr(ctx, "bot.trip.closed")
+	}
+
+	lang := i18n.FromContext(ctx)
+	prompt := func(key, text, placeholder string) string {
+		return h.sendSettingsPrompt(ctx, b, cq.From.ID, tr(ctx, key)+" #"+trip.ID+"\n"+text, placeholder)
+	}
+	switch action {
+	case "price":
+		return prompt(tripPromptPrice, tr(ctx, "bot.trip.hint_price", trip.Price), "15000")
+	case "time":
+		return prompt(tripPromptTime, tr(ctx, "bot.trip.hint_time"), "18:30")
+	case "comment":
+		return prompt(tripPromptComment, tr(ctx, "bot.trip.hint_comment"), "")
+	case "repost":
+		return prompt(tripPromptRepost, tr(ctx, "bot.trip.hint_repost", trip.FromAddress, trip.ToAddress), "18:30")
+	case "cancel":
+		h.editTripKeyboard(ctx, b, cq, tripCancelConfirmKeyboard(lang, trip.ID))
+		return tr(ctx, "bot.trip.confirm_cancel")
+	case "back":
+		h.editTripKeyboard(ctx, b, cq, tripManageKeyboard(lang, trip.ID))
+		return ""
+	case "done", "cancel_yes":
+		status := domain.TripStatusCompleted
+		if action == "cancel_yes" {
+			status = domain.TripStatusCancelled
+		}
+		err := h.closeDriverTrip(ctx, b, trip, status)
+		switch {
+		case errors.Is(err, errTripCargoOnBoard):
+			h.editTripKeyboard(ctx, b, cq, tripManageKeyboard(lang, trip.ID))
+			return h.errText(lang, err)
+		case errors.Is(err, errTripClosed):
+			h.clearCallbackKeyboard(ctx, b, cq)
+			return tr(ctx, "bot.trip.closed")
+		case err != nil:
+			h.logger.Error("Failed to close trip", zap.String("trip_id", trip.ID), zap.Error(err))
+			return tr(ctx, "bot.error")
+		}
+		h.editTripKeyboard(ctx, b, cq, tripRepostKeyboard(lang, trip.ID))
+		if status == domain.TripStatusCancelled {
+			return tr(ctx, "bot.trip.cancelled")
+		}
+		return tr(ctx, "bot.trip.completed")
+	}
+	return tr(ctx, "bot.error")
+}
+
+func (h *Handler) editTripKeyboard(ctx context.Context, b *bot.Bot, cq *models.CallbackQuery, markup *models.InlineKeyboardMarkup) {
+	msg := cq.Message.Message
+	if msg == nil {
+		return
+	}
+	if _, err := b.EditMessageReplyMarkup(ctx, &bot.EditMessageReplyMarkupParams{
+		ChatID:      msg.Chat.ID,
+		MessageID:   msg.ID,
+		ReplyMarkup: markup,
+	}); err != nil {
+		h.logger.Debug("edit trip keyboard", zap.Error(err))
+	}
+}
+
+// saveTripEditReply принимает ответ на подсказку правки рейса; false — это не такой ответ
+func (h *Handler) saveTripEditReply(ctx context.Context, b *bot.Bot, msg *models.Message) bool {
+	reply := msg.ReplyToMessage
+	if msg.From == nil || reply == nil || reply.From == nil || !reply.From.IsBot {
+		return false
+	}
+
+	field, rest, ok := promptKey(reply.Text, tripPromptPrice, tripPromptTime, tripPromptComment, tripPromptRepost)
+	if !ok || !strings.HasPrefix(rest, " #") {
+		return false
+	}
+	firstLine, _, _ := strings.Cut(strings.TrimPrefix(rest, " #"), "\n")
+	tripID := strings.TrimSpace(firstLine)
+
+	lang := i18n.FromContext(ctx)
+	trip, err := h.loadOwnTrip(ctx, msg.From.ID, tripID)
+	if err != nil {
+		h.replySettingsError(ctx, b, msg.Chat.ID, i18n.T(lang, "bot.trip.not_found"))
+		return true
+	}
+
+	input := strings.TrimSpace(msg.Text)
+	in := tripUpdateJSON{TelegramID: msg.From.ID, TripID: trip.ID}
+	switch field {
+	case tripPromptPrice:
+		price, err := strconv.Atoi(strings.Join(strings.Fields(input), ""))
+		if err != nil {
+			h.replySettingsError(ctx, b, msg.Chat.ID, i18n.T(lang, "bot.trip.price_number"))
+			return true
+		}
+		in.Price = &price
+	case tripPromptTime:
+		in.StartTime = &input
+	case tripPromptComment:
+		if input == "-" {
+			input = ""
+		}
+		in.Comment = &input
+	case tripPromptRepost:
+		if input == "-" {
+			input = ""
+		}
+		next, err := h.repostDriverTrip(ctx, b, trip, input, 0)
+		if err != nil {
+			h.logger.Warn("Failed to repost trip from bot", zap.String("trip_id", trip.ID), zap.Error(err))
+			h.replySettingsError(ctx, b, msg.Chat.ID, "❌ "+h.errText(lang, err))
+			return true
+		}
+		h.logger.Info("Driver trip reposted from bot", zap.String("new_trip_id", next.ID))
+		return true
+	}
+
+	if _, err := h.updateDriverTrip(ctx, b, trip, &in); err != nil {
+		h.replySettingsError(ctx, b, msg.Chat.ID, "❌ "+h.errText(lang, err))
+		return true
+	}
+	if _, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      msg.Chat.ID,
+		Text:        i18n.T(lang, "bot.saved") + "\n\n" + h.formatTripCard(lang, trip),
+		ParseMode:   models.ParseModeHTML,
+		ReplyMarkup: tripManageKeyboard(lang, trip.ID),
+	}); err != nil {
+		h.logger.Warn("send trip card", zap.String("trip_id", trip.ID), zap.Error(err))
+	}
+	return true
+}
+
+// ExpireDriverTrips завершает рейсы через TripExpiryGrace после времени выезда,
+// закрывает их брони и предлагает водителю повторить рейс
+func (h *Handler) ExpireDriverTrips(ctx context.Context, b *bot.Bot) {
+	h.logger.Info("started driver trip expiry service", zap.Duration("grace", h.cfg.TripExpiryGrace))
+	ticker := time.NewTicker(h.cfg.OrderExpiryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			h.logger.Info("driver trip expiry service stopped")
+			return
+		case <-ticker.C:
+			h.expireDriverTrips(ctx, b)
+		}
+	}
+}
+
+func (h *Handler) expireDriverTrips(ctx context.Context, b *bot.Bot) {
+	rows, err := h.db.QueryContext(ctx, `
+		SELECT id, telegram_id, from_address, to_address
+		FROM driver_trips
+		WHERE status IN ('active', 'full') AND departure_time <= ?
+		LIMIT 100`, h.tripExpiryCutoff())
+	if err != nil {
+		h.logger.Error("Failed to get expired trips", zap.Error(err))
+		return
+	}
+	var trips []DriverTrip
+	for rows.Next() {
+		var t DriverTrip
+		if err := rows.Scan(&t.ID, &t.TelegramID, &t.FromAddress, &t.ToAddress); err != nil {
+			h.logger.Error("Failed to scan expired trip", zap.Error(err))
+			continue
+		}
+		trips = append(trips, t)
+	}
+	rows.Close()
+
+	expired := 0
+	for i := range trips {
+		trip := &trips[i]
+		result, err := h.db.ExecContext(ctx, `
+			UPDATE driver_trips SET status = 'completed', updated_at = CURRENT_TIMESTAMP
+			WHERE id = ? AND status IN ('active', 'full')`, trip.ID)
+		if err != nil {
+			h.logger.Error("Failed to expire trip", zap.String("trip_id", trip.ID), zap.Error(err))
+			continue
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			continue
+		}
+		expired++
+		h.closeTripBookings(ctx, b, trip.ID, domain.BookingExpired, "bot.booking.reason_departed")
+
+		lang := h.userLocale(ctx, trip.TelegramID)
+		if _, err := b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: trip.TelegramID,
+			Text: i18n.T(lang, "bot.trip.expired",
+				html.EscapeString(trip.FromAddress), html.EscapeString(trip.ToAddress)),
+			ParseMode:   models.ParseModeHTML,
+			ReplyMarkup: tripRepostKeyboard(lang, trip.ID),
+		}); err != nil {
+			h.logger.Debug("notify driver trip expired", zap.String("trip_id", trip.ID), zap.Error(err))
+		}
+	}
+	if expired > 0 {
+		h.logger.Info("driver trips expired", zap.Int("count", expired))
+	}
+}
@@ -0,0 +1,296 @@
+// trip-handler.go
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"tezjet/internal/domain"
+	"tezjet/internal/i18n"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"go.uber.org/zap"
+)
+
+// load — сколько места в рейсе уже занято заявками
+func (t *DriverTrip) load() domain.TripLoad {
+	return domain.TripLoad{WeightKg: t.BookedWeight, VolumeM3: t.BookedVolume}
+}
+
+// loadDriverTrip returns a trip matching cond with what the vehicle can carry, nil if not found
+func (h *Handler) loadDriverTrip(ctx context.Context, cond string, args ...interface{}) (*DriverTrip, domain.VehicleCapacity, error) {
+	var trip DriverTrip
+	var vehicleType string
+	var vehicle domain.VehicleCapacity
+	dest := []interface{}{
+		&trip.ID, &trip.DriverID, &trip.TelegramID, &trip.FromAddress, &trip.FromLat, &trip.FromLon,
+		&trip.ToAddress, &trip.ToLat, &trip.ToLon, &trip.DistanceKm, &trip.EtaMin, &trip.Price, &trip.TruckType, &trip.MaxWeight,
+		&trip.BookedWeight, &trip.BookedVolume, &trip.StartTime, &trip.Comment, &trip.Status, &vehicleType,
+	}
+	err := h.db.QueryRowContext(ctx, `
+		SELECT dt.id, dt.driver_id, dt.telegram_id, dt.from_address, dt.from_lat, dt.from_lon,
+			   dt.to_address, dt.to_lat, dt.to_lon, COALESCE(dt.distance_km, 0), COALESCE(dt.eta_min, 0),
+			   dt.price, COALESCE(dt.truck_type, 'any'), COALESCE(dt.max_weight, 0),
+			   COALESCE(dt.booked_weight, 0), COALESCE(dt.booked_volume, 0),
+			   COALESCE(dt.start_time, ''), COALESCE(dt.comment, ''), dt.status, COALESCE(d.truck_type, ''),
+			   d.max_weight_kg, d.max_volume_m3, d.body_length_cm, d.body_width_cm, d.body_height_cm
+		FROM driver_trips dt
+		INNER JOIN drivers d ON d.id = dt.driver_id
+		WHERE `+cond, args...).
+		Scan(append(dest, capacityScanDest(&vehicle)...)...)
+	if err == sql.ErrNoRows {
+		return nil, vehicle, nil
+	}
+	if err != nil {
+		return nil, vehicle, fmt.Errorf("failed to load driver trip: %w", err)
+	}
+	return &trip, tripCapacity(tripVehicleType(trip.TruckType, vehicleType), trip.MaxWeight, vehicle), nil
+}
+
+// getActiveDriverTrip returns an active trip of an approved driver with what it can carry, nil if gone
+func (h *Handler) getActiveDriverTrip(ctx context.Context, tripID string) (*DriverTrip, domain.VehicleCapacity, error) {
+	return h.loadDriverTrip(ctx, `dt.id = ? AND dt.status = 'active' AND d.status = 'approved'`, tripID)
+}
+
+// occupyTripSpace занимает место под груз в открытой транзакции. Вместимость проверяется
+// в том же UPDATE, поэтому параллельные брони не перегрузят машину. Груз не влез — errTripFull.
+// full — после погрузки места не осталось, рейс закрыт для новых броней.
+func occupyTripSpace(ctx context.Context, tx *sql.Tx, tripID string, capacity domain.VehicleCapacity, cargo domain.CargoSpec) (full bool, err error) {
+	c := capacity.Effective()
+	result, err := tx.ExecContext(ctx, `
+		UPDATE driver_trips
+		SET booked_weight = COALESCE(booked_weight, 0) + ?, booked_volume = COALESCE(booked_volume, 0) + ?
+		WHERE id = ? AND status = 'active'
+		  AND (? <= 0 OR COALESCE(booked_weight, 0) + ? <= ?)
+		  AND (? <= 0 OR COALESCE(booked_volume, 0) + ? <= ?)`,
+		cargo.WeightKg, cargo.VolumeM3, tripID,
+		c.MaxWeightKg, cargo.WeightKg, c.MaxWeightKg,
+		c.MaxVolumeM3, cargo.VolumeM3, c.MaxVolumeM3)
+	if err != nil {
+		return false, fmt.Errorf("failed to book trip capacity: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return false, errTripFull
+	}
+
+	var load domain.TripLoad
+	if err := tx.QueryRowContext(ctx, `
+		SELECT COALESCE(booked_weight, 0), COALESCE(booked_volume, 0) FROM driver_trips WHERE id = ?`,
+		tripID).Scan(&load.WeightKg, &load.VolumeM3); err != nil {
+		return false, fmt.Errorf("failed to read trip load: %w", err)
+	}
+	if !capacity.FreeSpace(load).IsFull() {
+		return false, nil
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE driver_trips SET status = 'full' WHERE id = ? AND status = 'active'`, tripID); err != nil {
+		return false, fmt.Errorf("failed to close full trip: %w", err)
+	}
+	return true, nil
+}
+
+// releaseTripSpace открепляет заявку от рейса и возвращает её место.
+// Заполненный рейс снова принимает брони, если место появилось.
+func (h *Handler) releaseTripSpace(ctx context.Context, orderID string) error {
+	var tripID string
+	if err := h.db.QueryRowContext(ctx, `
+		SELECT COALESCE(trip_id, '') FROM delivery_requests WHERE id = ?`, orderID).Scan(&tripID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return fmt.Errorf("failed to load order trip: %w", err)
+	}
+	if tripID == "" {
+		return nil
+	}
+	_, capacity, err := h.loadDriverTrip(ctx, `dt.id = ?`, tripID)
+	if err != nil {
+		return err
+	}
+
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var cargo domain.CargoSpec
+	err = tx.QueryRowContext(ctx, `
+		UPDATE delivery_requests SET trip_id = NULL
+		WHERE id = ? AND trip_id = ?
+		RETURNING cargo_weight_kg, cargo_volume_m3`, orderID, tripID).Scan(&cargo.WeightKg, &cargo.VolumeM3)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to detach order from trip: %w", err)
+	}
+
+	var load domain.TripLoad
+	var status string
+	if err := tx.QueryRowContext(ctx, `
+		UPDATE driver_trips
+		SET booked_weight = MAX(0, COALESCE(booked_weight, 0) - ?), booked_volume = MAX(0, COALESCE(booked_volume, 0) - ?)
+		WHERE id = ?
+		RETURNING booked_weight, booked_volume, status`,
+		cargo.WeightKg, cargo.VolumeM3, tripID).Scan(&load.WeightKg, &load.VolumeM3, &status); err != nil {
+		return fmt.Errorf("failed to release trip capacity: %w", err)
+	}
+	if status == domain.TripStatusFull && !capacity.FreeSpace(load).IsFull() {
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE driver_trips SET status = 'active' WHERE id = ? AND status = 'full'`, tripID); err != nil {
+			return fmt.Errorf("failed to reopen trip: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit trip release: %w", err)
+	}
+
+	h.logger.Info("Order detached from trip",
+		zap.String("order_id", orderID),
+		zap.String("trip_id", tripID),
+		zap.Float64("weight_kg", cargo.WeightKg))
+	return nil
+}
+
+// getTripOrders returns orders attached to the trip, кроме отменённых, в порядке принятия
+func (h *Handler) getTripOrders(ctx context.Context, tripID string) ([]domain.TripOrder, error) {
+	rows, err := h.db.QueryContext(ctx, `
+		SELECT id, COALESCE(telegram_id, 0), from_address, from_lat, from_lon, to_address, to_lat, to_lon,
+			   price, contact, status, picked_up_at, `+cargoColumns+`
+		FROM delivery_requests
+		WHERE trip_id = ? AND status != 'cancelled'
+		ORDER BY COALESCE(accepted_at, created_at), id`, tripID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load trip orders: %w", err)
+	}
+	defer rows.Close()
+
+	orders := []domain.TripOrder{}
+	for rows.Next() {
+		var o domain.TripOrder
+		var pickedUp sql.NullTime
+		dest := []interface{}{
+			&o.ID, &o.TelegramID, &o.FromAddress, &o.From.Lat, &o.From.Lon, &o.ToAddress, &o.To.Lat, &o.To.Lon,
+			&o.Price, &o.Contact, &o.Status, &pickedUp,
+		}
+		if err := rows.Scan(append(dest, cargoScanDest(&o.Cargo)...)...); err != nil {
+			h.logger.Error("Failed to scan trip order", zap.Error(err))
+			continue
+		}
+		if pickedUp.Valid {
+			o.PickedUpAt = &pickedUp.Time
+		}
+		orders = append(orders, o)
+	}
+	return orders, rows.Err()
+}
+
+// handleDriverTripOrders — GET /api/driver/trip-orders?telegram_id=&trip_id=
+// Заявки рейса, свободное место и порядок погрузок/выгрузок по маршруту.
+func (h *Handler) handleDriverTripOrders(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	telegramID, _ := strconv.ParseInt(r.URL.Query().Get("telegram_id"), 10, 64)
+	tripID := strings.TrimSpace(r.URL.Query().Get("trip_id"))
+	if telegramID == 0 || tripID == "" {
+		h.sendErrorResponse(w, r, "telegram_id_and_trip_id_required", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	trip, capacity, err := h.loadDriverTrip(ctx, `dt.id = ? AND dt.telegram_id = ?`, tripID, telegramID)
+	if err != nil {
+		h.logger.Error("Failed to load trip", zap.String("trip_id", tripID), zap.Error(err))
+		h.sendErrorResponse(w, r, "trip_load_failed", http.StatusInternalServerError)
+		return
+	}
+	if trip == nil {
+		h.sendErrorResponse(w, r, "trip_not_found", http.StatusNotFound)
+		return
+	}
+	orders, err := h.getTripOrders(ctx, tripID)
+	if err != nil {
+		h.logger.Error("Failed to load trip orders", zap.String("trip_id", tripID), zap.Error(err))
+		h.sendErrorResponse(w, r, "trip_orders_load_failed", http.StatusInternalServerError)
+		return
+	}
+
+	stops := domain.SequenceTripStops(
+		domain.GeoPoint{Lat: trip.FromLat, Lon: trip.FromLon},
+		domain.GeoPoint{Lat: trip.ToLat, Lon: trip.ToLon},
+		orders)
+	h.sendSuccessResponse(w, r, "trip_orders", map[string]interface{}{
+		"trip":       trip,
+		"capacity":   capacity.Effective(),
+		"load":       trip.load(),
+		"free_space": capacity.FreeSpace(trip.load()),
+		"orders":     orders,
+		"stops":      stops,
+	})
+}
+
+// sendTripPlan присылает водителю план рейса после новой заявки
+func (h *Handler) sendTripPlan(ctx context.Context, b *bot.Bot, tripID string) {
+	trip, capacity, err := h.loadDriverTrip(ctx, `dt.id = ?`, tripID)
+	if err != nil || trip == nil {
+		return
+	}
+	orders, err := h.getTripOrders(ctx, tripID)
+	if err != nil {
+		return
+	}
+	stops := domain.SequenceTripStops(
+		domain.GeoPoint{Lat: trip.FromLat, Lon: trip.FromLon},
+		domain.GeoPoint{Lat: trip.ToLat, Lon: trip.ToLon},
+		orders)
+	if len(stops) == 0 {
+		return
+	}
+
+	// Заявки нумеруем по порядку принятия — короче, чем UUID
+	num := make(map[string]int, len(orders))
+	for i := range orders {
+		num[orders[i].ID] = i + 1
+	}
+	lang := h.userLocale(ctx, trip.TelegramID)
+	var sb strings.Builder
+	sb.WriteString(i18n.T(lang, "bot.trip.plan_title",
+		html.EscapeString(trip.FromAddress), html.EscapeString(trip.ToAddress)))
+	for _, s := range stops {
+		icon := "📦"
+		if s.Kind == domain.TripStopDropoff {
+			icon = "🎯"
+		}
+		sb.WriteString(i18n.T(lang, "bot.trip.plan_stop", s.Seq, icon, html.EscapeString(s.Address), num[s.OrderID], s.LoadKg))
+	}
+	free := capacity.FreeSpace(trip.load())
+	if free.WeightKg >= 0 {
+		sb.WriteString(i18n.T(lang, "bot.trip.plan_free", free.WeightKg))
+		if free.VolumeM3 >= 0 {
+			sb.WriteString(i18n.T(lang, "bot.trip.plan_free_volume", free.VolumeM3))
+		}
+	}
+	if trip.Status == domain.TripStatusFull {
+		sb.WriteString("\n" + i18n.T(lang, "bot.trip.plan_full"))
+	}
+
+	if _, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    trip.TelegramID,
+		Text:      sb.String(),
+		ParseMode: models.ParseModeHTML,
+	}); err != nil {
+		h.logger.Debug("send trip plan", zap.String("trip_id", tripID), zap.Error(err))
+	}
+}
@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"tezjet/internal/domain"
+	"tezjet/traits/database"
+
+	_ "github.com/mattn/go-sqlite3"
+	"go.uber.org/zap"
+)
+
+// newTripTestHandler — схема в памяти, водитель с малой машиной и его рейс на 1000 кг
+func newTripTestHandler(t *testing.T) *Handler {
+	t.Helper()
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = db.Close() })
+	if err := database.CreateTables(db, zap.NewNop()); err != nil {
+		t.Fatal(err)
+	}
+	for _, q := range []string{
+		`INSERT INTO drivers (id, telegram_id, first_name, last_name, birthday, contact_number,
+			start_city, latitude, longitude, profile_photo, license_front, license_back, truck_type, status)
+		VALUES ('d1', 100, 'Test', 'Driver', '1990-01-01', '+77010000000', 'Алматы', 43.24, 76.91, '', '', '', 'small', 'approved')`,
+		`INSERT INTO driver_trips (id, driver_id, telegram_id, price, truck_type, max_weight)
+		VALUES ('t1', 'd1', 100, 10000, 'small', 1000)`,
+	} {
+		if _, err := db.Exec(q); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return &Handler{db: db, logger: zap.NewNop()}
+}
+
+// bookTestOrder добавляет заявку с грузом и занимает под неё место в рейсе
+func bookTestOrder(t *testing.T, h *Handler, orderID string, cargo domain.CargoSpec) (bool, error) {
+	t.Helper()
+	ctx := context.Background()
+	_, capacity, err := h.loadDriverTrip(ctx, `dt.id = ?`, "t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	full, err := occupyTripSpace(ctx, tx, "t1", capacity, cargo)
+	if err != nil {
+		return full, err
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO delivery_requests (id, telegram_id, from_address, from_lat, from_lon,
+			to_address, to_lat, to_lon, price, contact, status, trip_id, cargo_weight_kg, cargo_volume_m3)
+		VALUES (?, 1, 'Абая 1', 43.24, 76.91, 'Толе би 2', 43.25, 76.92, 5000, '+77010000000', 'matched', 't1', ?, ?)`,
+		orderID, cargo.WeightKg, cargo.VolumeM3); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	return full, nil
+}
+
+func tripState(t *testing.T, h *Handler) (status string, weight float64) {
+	t.Helper()
+	if err := h.db.QueryRow(`SELECT status, booked_weight FROM driver_trips WHERE id = 't1'`).Scan(&status, &weight); err != nil {
+		t.Fatal(err)
+	}
+	return status, weight
+}
+
+func TestTripSpaceFullCycle(t *testing.T) {
+	h := newTripTestHandler(t)
+	ctx := context.Background()
+
+	steps := []struct {
+		name       string
+		book       string // заявка для брони
+		release    string // заявка, которую снимают с рейса
+		cargo      domain.CargoSpec
+		wantFull   bool
+		wantErr    error
+		wantStatus string
+		wantWeight float64
+	}{
+		{name: "first booking", book: "o1", cargo: domain.CargoSpec{WeightKg: 600}, wantStatus: domain.TripStatusActive, wantWeight: 600},
+		{name: "overweight booking", book: "o2", cargo: domain.CargoSpec{WeightKg: 500}, wantErr: errTripFull, wantStatus: domain.TripStatusActive, wantWeight: 600},
+		{name: "booking leaves less than minimum", book: "o3", cargo: domain.CargoSpec{WeightKg: 390}, wantFull: true, wantStatus: domain.TripStatusFull, wantWeight: 990},
+		{name: "full trip refuses bookings", book: "o4", cargo: domain.CargoSpec{WeightKg: 5}, wantErr: errTripFull, wantStatus: domain.TripStatusFull, wantWeight: 990},
+		{name: "release reopens trip", release: "o3", wantStatus: domain.TripStatusActive, wantWeight: 600},
+		{name: "second release is a no-op", release: "o3", wantStatus: domain.TripStatusActive, wantWeight: 600},
+		{name: "unknown order", release: "missing", wantStatus: domain.TripStatusActive, wantWeight: 600},
+		{name: "fill again", book: "o5", cargo: domain.CargoSpec{WeightKg: 400}, wantFull: true, wantStatus: domain.TripStatusFull, wantWeight: 1000},
+		{name: "releasing the other order reopens", release: "o1", wantStatus: domain.TripStatusActive, wantWeight: 400},
+	}
+	for _, s := range steps {
+		if s.book != "" {
+			full, err := bookTestOrder(t, h, s.book, s.cargo)
+			if !errors.Is(err, s.wantErr) {
+				t.Fatalf("%s: occupyTripSpace() error = %v, want %v", s.name, err, s.wantErr)
+			}
+			if full != s.wantFull {
+				t.Errorf("%s: occupyTripSpace() full = %v, want %v", s.name, full, s.wantFull)
+			}
+		} else if err := h.releaseTripSpace(ctx, s.release); err != nil {
+			t.Fatalf("%s: releaseTripSpace() error = %v", s.name, err)
+		}
+
+		status, weight := tripState(t, h)
+		if status != s.wantStatus || weight != s.wantWeight {
+			t.Errorf("%s: trip = %s %.0f kg, want %s %.0f kg", s.name, status, weight, s.wantStatus, s.wantWeight)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+// Package i18n — каталог сообщений бота и API на казахском, русском и английском.
+// Ключи стабильны: "error.<code>" — ошибки API, код уходит клиенту вместе с текстом.
+package i18n
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type Locale string
+
+const (
+	KK Locale = "kk"
+	RU Locale = "ru"
+	EN Locale = "en"
+
+	// Default — язык, если ни настройка пользователя, ни Telegram ничего не дали
+	Default = RU
+)
+
+var Locales = []Locale{KK, RU, EN}
+
+// Match maps a Telegram language_code or a BCP 47 tag to a supported locale
+func Match(code string) (Locale, bool) {
+	code = strings.ToLower(strings.TrimSpace(code))
+	if i := strings.IndexAny(code, "-_"); i >= 0 {
+		code = code[:i]
+	}
+	switch code {
+	case "kk", "kz":
+		return KK, true
+	case "ru":
+		return RU, true
+	case "en":
+		return EN, true
+	}
+	return "", false
+}
+
+// Resolve returns the first supported locale among codes, Default if none
+func Resolve(codes ...string) Locale {
+	for _, c := range codes {
+		if loc, ok := Match(c); ok {
+			return loc
+		}
+	}
+	return Default
+}
+
+// ParseAcceptLanguage returns tags from an Accept-Language header, best first
+func ParseAcceptLanguage(header string) []string {
+	type tag struct {
+		code string
+		q    float64
+	}
+	var tags []tag
+	for _, part := range strings.Split(header, ",") {
+		code, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if code == "" || code == "*" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		tags = append(tags, tag{code, q})
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+	out := make([]string, 0, len(tags))
+	for _, t := range tags {
+		out = append(out, t.code)
+	}
+	return out
+}
+
+type ctxKey struct{}
+
+func WithLocale(ctx context.Context, loc Locale) context.Context {
+	return context.WithValue(ctx, ctxKey{}, loc)
+}
+
+// FromContext returns the request locale, Default if none was set
+func FromContext(ctx context.Context) Locale {
+	if loc, ok := ctx.Value(ctxKey{}).(Locale); ok {
+		return loc
+	}
+	return Default
+}
+
+// ==================== CATALOG ====================
+
+// message — строка или формы множественного числа (one/few/many/other)
+type message struct {
+	text  string
+	forms map[string]string
+}
+
+func (m *message) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &m.text); err == nil {
+		return nil
+	}
+	return json.Unmarshal(data, &m.forms)
+}
+
+//go:embed locales/*.json
+var localeFS embed.FS
+
+var catalog = mustLoad()
+
+func mustLoad() map[Locale]map[string]message {
+	c := make(map[Locale]map[string]message)
+	for _, loc := range Locales {
+		data, err := localeFS.ReadFile("locales/" + string(loc) + ".json")
+		if err != nil {
+			panic(fmt.Sprintf("i18n: %v", err))
+		}
+		m := make(map[string]message)
+		if err := json.Unmarshal(data, &m); err != nil {
+			panic(fmt.Sprintf("i18n: locales/%s.json: %v", loc, err))
+		}
+		c[loc] = m
+	}
+	return c
+}
+
+// Has reports whether the key exists in the default locale
+func Has(key string) bool {
+	_, ok := catalog[Default][key]
+	return ok
+}
+
+// Missing returns keys of the default locale that loc lacks
+func Missing(loc Locale) []string {
+	var out []string
+	for key := range catalog[Default] {
+		if _, ok := catalog[loc][key]; !ok {
+			out = append(out, key)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func lookup(loc Locale, key string) (message, bool) {
+	if m, ok := catalog[loc][key]; ok {
+		return m, true
+	}
+	m, ok := catalog[Default][key]
+	return m, ok
+}
+
+// T returns the message for key formatted with args; the key itself if it is unknown
+func T(loc Locale, key string, args ...interface{}) string {
+	m, ok := lookup(loc, key)
+	if !ok {
+		return key
+	}
+	text := m.text
+	if m.forms != nil {
+		text = m.forms["other"]
+	}
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
+
+// N returns the plural form of key for n; n is passed to the format as the first argument
+func N(loc Locale, key string, n int, args ...interface{}) string {
+	m, ok := lookup(loc, key)
+	if !ok {
+		return key
+	}
+	text := m.text
+	if m.forms != nil {
+		text = m.forms[pluralForm(loc, n)]
+		if text == "" {
+			text = m.forms["other"]
+		}
+	}
+	return fmt.Sprintf(text, append([]interface{}{n}, args...)...)
+}
+
+// pluralForm — правила CLDR для целых чисел
+func pluralForm(loc Locale, n int) string {
+	if n < 0 {
+		n = -n
+	}
+	if loc != RU {
+		if n == 1 {
+			return "one"
+		}
+		return "other"
+	}
+	switch mod10, mod100 := n%10, n%100; {
+	case mod10 == 1 && mod100 != 11:
+		return "one"
+	case mod10 >= 2 && mod10 <= 4 && (mod100 < 12 || mod100 > 14):
+		return "few"
+	default:
+		return "many"
+	}
+}
+
+// ==================== ERRORS ====================
+
+// Coded — ошибка, которую можно показать пользователю на его языке.
+// ErrorCode стабилен: по нему фронтенд может подставить свой перевод.
+type Coded interface {
+	error
+	ErrorCode() string
+	ErrorParams() []interface{}
+	Localize(loc Locale) string
+}
+
+// Error — ошибка из каталога: текст берётся по ключу "error.<Code>".
+// Если первый параметр — int, а сообщение во множественных формах, он выбирает форму.
+// Err — исходная причина для логов, пользователю не показывается.
+type Error struct {
+	Code   string
+	Params []interface{}
+	Err    error
+}
+
+// E creates a catalog error
+func E(code string, params ...interface{}) *Error {
+	return &Error{Code: code, Params: params}
+}
+
+// Wrap creates a catalog error that keeps err as the cause
+func Wrap(err error, code string, params ...interface{}) *Error {
+	return &Error{Code: code, Params: params, Err: err}
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Localize(Default) + ": " + e.Err.Error()
+	}
+	return e.Localize(Default)
+}
+
+func (e *Error) Unwrap() error              { return e.Err }
+func (e *Error) ErrorCode() string          { return e.Code }
+func (e *Error) ErrorParams() []interface{} { return e.Params }
+
+func (e *Error) Localize(loc Locale) string {
+	key := "error." + e.Code
+	if m, ok := lookup(loc, key); ok && m.forms != nil && len(e.Params) > 0 {
+		if n, ok := e.Params[0].(int); ok {
+			return N(loc, key, n, e.Params[1:]...)
+		}
+	}
+	return T(loc, key, e.Params...)
+}
@@ -0,0 +1,207 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"tezjet/internal/domain"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// Channel доставляет отрендеренный шаблон по одному каналу
+type Channel interface {
+	Name() string
+	Format() Format
+	// Address returns where to deliver, "" — у получателя нет адреса для канала
+	Address(to Recipient) string
+	Deliver(ctx context.Context, address string, r Rendered, msg *Message) error
+}
+
+// ==================== TELEGRAM ====================
+
+type telegramChannel struct {
+	bot *bot.Bot
+}
+
+func NewTelegram(b *bot.Bot) Channel {
+	return &telegramChannel{bot: b}
+}
+
+func (c *telegramChannel) Name() string   { return domain.NotifyChannelTelegram }
+func (c *telegramChannel) Format() Format { return FormatHTML }
+
+func (c *telegramChannel) Address(to Recipient) string {
+	if to.TelegramID == 0 {
+		return ""
+	}
+	return strconv.FormatInt(to.TelegramID, 10)
+}
+
+// Deliver sends the text, or the photo with the text as caption. Если фото не ушло
+// (нет файла, подпись длиннее лимита Telegram) — отправляем просто текст.
+func (c *telegramChannel) Deliver(ctx context.Context, address string, r Rendered, msg *Message) error {
+	chatID, err := strconv.ParseInt(address, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid telegram id %q", address)
+	}
+	if msg.Photo != "" {
+		if file, err := os.Open(msg.Photo); err == nil {
+			_, err = c.bot.SendPhoto(ctx, &bot.SendPhotoParams{
+				ChatID:              chatID,
+				Photo:               &models.InputFileUpload{Filename: filepath.Base(file.Name()), Data: file},
+				Caption:             r.Text,
+				ParseMode:           models.ParseModeHTML,
+				ReplyMarkup:         msg.Markup,
+				DisableNotification: msg.Silent,
+			})
+			file.Close()
+			if err == nil {
+				return nil
+			}
+		}
+	}
+	_, err = c.bot.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:              chatID,
+		Text:                r.Text,
+		ParseMode:           models.ParseModeHTML,
+		ReplyMarkup:         msg.Markup,
+		DisableNotification: msg.Silent,
+	})
+	return err
+}
+
+// ==================== SMS ====================
+
+// smsChannel шлёт POST {"to": "+77...", "text": "..."} провайдеру; любой 2xx — принято
+type smsChannel struct {
+	url    string
+	client *http.Client
+}
+
+func NewSMS(url string, timeout time.Duration) Channel {
+	return &smsChannel{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+func (c *smsChannel) Name() string   { return domain.NotifyChannelSMS }
+func (c *smsChannel) Format() Format { return FormatPlain }
+
+func (c *smsChannel) Address(to Recipient) string {
+	return NormalizePhone(to.Phone)
+}
+
+func (c *smsChannel) Deliver(ctx context.Context, address string, r Rendered, _ *Message) error {
+	body, err := json.Marshal(map[string]string{"to": address, "text": r.Text})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sms provider: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 200))
+		return fmt.Errorf("sms provider returned %d: %s", resp.StatusCode, strings.TrimSpace(string(snippet)))
+	}
+	return nil
+}
+
+// NormalizePhone приводит казахстанский номер к +7XXXXXXXXXX, "" — не похоже на номер
+func NormalizePhone(phone string) string {
+	var digits strings.Builder
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			digits.WriteRune(r)
+		}
+	}
+	d := digits.String()
+	if len(d) == 11 && d[0] == '8' {
+		d = "7" + d[1:]
+	}
+	if len(d) == 10 {
+		d = "7" + d
+	}
+	if len(d) != 11 || d[0] != '7' {
+		return ""
+	}
+	return "+" + d
+}
+
+// ==================== EMAIL ====================
+
+// SMTPConfig — параметры SMTP-сервера; без Username отправляем без авторизации
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+type emailChannel struct {
+	cfg SMTPConfig
+}
+
+func NewEmail(cfg SMTPConfig) Channel {
+	return &emailChannel{cfg: cfg}
+}
+
+func (c *emailChannel) Name() string   { return domain.NotifyChannelEmail }
+func (c *emailChannel) Format() Format { return FormatPlain }
+
+func (c *emailChannel) Address(to Recipient) string {
+	return strings.TrimSpace(to.Email)
+}
+
+func (c *emailChannel) Deliver(ctx context.Context, address string, r Rendered, _ *Message) error {
+	subject := r.Subject
+	if subject == "" {
+		subject = "Alash-Go"
+	}
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", c.cfg.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", address)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	msg.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(r.Text, "\n", "\r\n"))
+	msg.WriteString("\r\n")
+
+	var auth smtp.Auth
+	if c.cfg.Username != "" {
+		auth = smtp.PlainAuth("", c.cfg.Username, c.cfg.Password, c.cfg.Host)
+	}
+	addr := fmt.Sprintf("%s:%d", c.cfg.Host, c.cfg.Port)
+
+	// net/smtp не принимает context — ждём в горутине, чтобы не зависнуть дольше ctx
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(addr, auth, c.cfg.From, []string{address}, []byte(msg.String()))
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
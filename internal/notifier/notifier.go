@@ -0,0 +1,219 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"tezjet/internal/domain"
+	"tezjet/internal/i18n"
+
+	"github.com/go-telegram/bot/models"
+	"go.uber.org/zap"
+)
+
+// Store — где Notifier берёт выбор каналов и пишет журнал доставки
+type Store interface {
+	GetNotificationSettings(ctx context.Context, telegramID int64) (domain.NotificationSettings, error)
+	CreateNotification(ctx context.Context, n *domain.Notification) error
+	MarkNotification(ctx context.Context, id int64, status, errText string) error
+}
+
+// Recipient — известные адреса получателя. Phone/Email из настроек пользователя важнее.
+type Recipient struct {
+	TelegramID int64
+	Phone      string
+	Email      string
+}
+
+// Message — именованный шаблон с данными и то, что понимает только Telegram
+type Message struct {
+	Template string
+	Locale   i18n.Locale // язык получателя, пустой — i18n.Default
+	Data     interface{}
+	To       Recipient
+	Markup   models.ReplyMarkup // кнопки: с ними Telegram получает сообщение всегда
+	Photo    string             // путь к файлу, текст уходит подписью
+	Silent   bool
+}
+
+// Notifier рендерит шаблоны и доставляет их по каналам, выбранным пользователем.
+// Каждая попытка — строка в журнале со статусом pending → sent/failed или skipped.
+type Notifier struct {
+	logger    *zap.Logger
+	store     Store
+	templates *Templates
+
+	mu       sync.RWMutex
+	channels map[string]Channel
+}
+
+func New(logger *zap.Logger, store Store, templates *Templates, channels ...Channel) *Notifier {
+	n := &Notifier{
+		logger:    logger,
+		store:     store,
+		templates: templates,
+		channels:  make(map[string]Channel),
+	}
+	for _, ch := range channels {
+		n.Register(ch)
+	}
+	return n
+}
+
+// Register adds or replaces a channel backend
+func (n *Notifier) Register(ch Channel) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.channels[ch.Name()] = ch
+}
+
+func (n *Notifier) channel(name string) (Channel, bool) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	ch, ok := n.channels[name]
+	return ch, ok
+}
+
+// Available returns configured channels in domain.NotifyChannels order
+func (n *Notifier) Available() []string {
+	out := []string{}
+	for _, name := range domain.NotifyChannels {
+		if _, ok := n.channel(name); ok {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+// Render renders a template without sending, for texts the caller sends itself
+func (n *Notifier) Render(name string, loc i18n.Locale, format Format, data interface{}) (Rendered, error) {
+	return n.templates.Render(name, loc, format, data)
+}
+
+// Send delivers the message to every channel the recipient chose. Если ни один
+// канал не сработал, пробуем Telegram. Ошибка — сообщение не доставлено никуда.
+func (n *Notifier) Send(ctx context.Context, msg Message) error {
+	if !n.templates.Has(msg.Template) {
+		return fmt.Errorf("unknown template %q", msg.Template)
+	}
+	if msg.Locale == "" {
+		msg.Locale = i18n.Default
+	}
+
+	settings := domain.DefaultNotificationSettings(msg.To.TelegramID)
+	if msg.To.TelegramID != 0 {
+		if s, err := n.store.GetNotificationSettings(ctx, msg.To.TelegramID); err == nil {
+			settings = s
+		}
+	}
+	to := msg.To
+	if settings.Phone != "" {
+		to.Phone = settings.Phone
+	}
+	if settings.Email != "" {
+		to.Email = settings.Email
+	}
+
+	channels := settings.Channels
+	if msg.Markup != nil && !settings.HasChannel(domain.NotifyChannelTelegram) {
+		channels = append([]string{domain.NotifyChannelTelegram}, channels...)
+	}
+
+	rendered := make(map[Format]Rendered)
+	delivered := false
+	var lastErr error
+	for _, name := range channels {
+		err := n.deliver(ctx, name, to, &msg, rendered)
+		if err == nil {
+			delivered = true
+		} else if err != errSkipped {
+			lastErr = err
+		}
+	}
+	if !delivered && !settings.HasChannel(domain.NotifyChannelTelegram) && msg.Markup == nil {
+		if err := n.deliver(ctx, domain.NotifyChannelTelegram, to, &msg, rendered); err == nil {
+			delivered = true
+		} else if err != errSkipped {
+			lastErr = err
+		}
+	}
+	if delivered {
+		return nil
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no channel available for %s", msg.Template)
+	}
+	return lastErr
+}
+
+var errSkipped = errors.New("channel skipped")
+
+// deliver sends by one channel and records the attempt
+func (n *Notifier) deliver(ctx context.Context, name string, to Recipient, msg *Message, rendered map[Format]Rendered) error {
+	rec := &domain.Notification{
+		TelegramID: to.TelegramID,
+		Template:   msg.Template,
+		Channel:    name,
+		Status:     domain.NotificationPending,
+	}
+	ch, ok := n.channel(name)
+	if !ok {
+		n.skip(ctx, rec, "channel not configured")
+		return errSkipped
+	}
+	rec.Recipient = ch.Address(to)
+	if rec.Recipient == "" {
+		n.skip(ctx, rec, "no address")
+		return errSkipped
+	}
+
+	r, ok := rendered[ch.Format()]
+	if !ok {
+		var err error
+		if r, err = n.templates.Render(msg.Template, msg.Locale, ch.Format(), msg.Data); err != nil {
+			rec.Status = domain.NotificationFailed
+			rec.Error = err.Error()
+			n.record(ctx, rec)
+			n.logger.Error("Failed to render notification", zap.String("template", msg.Template), zap.Error(err))
+			return err
+		}
+		rendered[ch.Format()] = r
+	}
+
+	n.record(ctx, rec)
+	if err := ch.Deliver(ctx, rec.Recipient, r, msg); err != nil {
+		n.mark(ctx, rec, domain.NotificationFailed, err.Error())
+		n.logger.Warn("Notification failed",
+			zap.String("template", msg.Template),
+			zap.String("channel", name),
+			zap.Int64("telegram_id", to.TelegramID),
+			zap.Error(err))
+		return err
+	}
+	n.mark(ctx, rec, domain.NotificationSent, "")
+	return nil
+}
+
+func (n *Notifier) skip(ctx context.Context, rec *domain.Notification, reason string) {
+	rec.Status = domain.NotificationSkipped
+	rec.Error = reason
+	n.record(ctx, rec)
+}
+
+// record и mark не мешают доставке: журнал вторичен
+func (n *Notifier) record(ctx context.Context, rec *domain.Notification) {
+	if err := n.store.CreateNotification(ctx, rec); err != nil {
+		n.logger.Warn("Failed to record notification", zap.String("template", rec.Template), zap.Error(err))
+	}
+}
+
+func (n *Notifier) mark(ctx context.Context, rec *domain.Notification, status, errText string) {
+	if rec.ID == 0 {
+		return
+	}
+	if err := n.store.MarkNotification(ctx, rec.ID, status, errText); err != nil {
+		n.logger.Warn("Failed to mark notification", zap.Int64("id", rec.ID), zap.Error(err))
+	}
+}
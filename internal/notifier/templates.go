@@ -0,0 +1,183 @@
+package notifier
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html"
+	"path"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"tezjet/internal/i18n"
+)
+
+// Format — разметка, в которой канал показывает текст
+type Format string
+
+const (
+	FormatHTML  Format = "html"  // Telegram, ParseMode HTML
+	FormatPlain Format = "plain" // SMS, email и сообщения бота без разметки
+)
+
+// Safe — уже размеченный текст, esc его не трогает
+type Safe string
+
+// Rendered — текст шаблона для одного формата. Subject берётся из {{define "subject"}}.
+type Rendered struct {
+	Subject string
+	Text    string
+}
+
+//go:embed templates/*/*.tmpl
+var templateFS embed.FS
+
+// Templates — шаблоны из templates/<locale>/*.tmpl, разобранные отдельно для каждого
+// формата. Имя шаблона — имя файла без расширения. Шаблона нет на языке — берём i18n.Default.
+type Templates struct {
+	sets map[i18n.Locale]map[Format]map[string]*template.Template
+}
+
+// LoadTemplates parses the embedded templates. Every action that prints a value
+// gets esc appended, so data is escaped for the format unless it is Safe.
+func LoadTemplates() (*Templates, error) {
+	t := &Templates{sets: make(map[i18n.Locale]map[Format]map[string]*template.Template)}
+	for _, loc := range i18n.Locales {
+		dir := "templates/" + string(loc)
+		files, err := templateFS.ReadDir(dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read templates %s: %w", loc, err)
+		}
+		t.sets[loc] = make(map[Format]map[string]*template.Template)
+		for _, format := range []Format{FormatHTML, FormatPlain} {
+			t.sets[loc][format] = make(map[string]*template.Template)
+			for _, f := range files {
+				src, err := templateFS.ReadFile(dir + "/" + f.Name())
+				if err != nil {
+					return nil, fmt.Errorf("failed to read template %s/%s: %w", loc, f.Name(), err)
+				}
+				name := strings.TrimSuffix(f.Name(), path.Ext(f.Name()))
+				tmpl, err := template.New(name).Funcs(formatFuncs(format)).Option("missingkey=error").Parse(string(src))
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse template %s/%s: %w", loc, f.Name(), err)
+				}
+				for _, sub := range tmpl.Templates() {
+					if sub.Tree != nil {
+						escapeList(sub.Tree.Root)
+					}
+				}
+				t.sets[loc][format][name] = tmpl
+			}
+		}
+	}
+	return t, nil
+}
+
+// Has reports whether a template with the name exists in the default locale
+func (t *Templates) Has(name string) bool {
+	_, ok := t.sets[i18n.Default][FormatPlain][name]
+	return ok
+}
+
+// Render executes the template for the locale and format
+func (t *Templates) Render(name string, loc i18n.Locale, format Format, data interface{}) (Rendered, error) {
+	tmpl, ok := t.sets[loc][format][name]
+	if !ok {
+		tmpl, ok = t.sets[i18n.Default][format][name]
+	}
+	if !ok {
+		return Rendered{}, fmt.Errorf("unknown template %q for format %s", name, format)
+	}
+	var out Rendered
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return out, fmt.Errorf("failed to render template %s: %w", name, err)
+	}
+	out.Text = strings.TrimSpace(buf.String())
+	if subject := tmpl.Lookup("subject"); subject != nil {
+		buf.Reset()
+		if err := subject.Execute(&buf, data); err != nil {
+			return out, fmt.Errorf("failed to render subject of %s: %w", name, err)
+		}
+		out.Subject = strings.TrimSpace(buf.String())
+	}
+	return out, nil
+}
+
+// formatFuncs — функции шаблонов: esc/raw для экранирования, b/i для выделения
+func formatFuncs(format Format) template.FuncMap {
+	escape := func(v interface{}) string { return toString(v) }
+	wrap := func(tag string) func(interface{}) Safe {
+		return func(v interface{}) Safe { return Safe(escape(v)) }
+	}
+	if format == FormatHTML {
+		escape = func(v interface{}) string {
+			if s, ok := v.(Safe); ok {
+				return string(s)
+			}
+			return html.EscapeString(toString(v))
+		}
+		wrap = func(tag string) func(interface{}) Safe {
+			return func(v interface{}) Safe { return Safe("<" + tag + ">" + escape(v) + "</" + tag + ">") }
+		}
+	}
+	return template.FuncMap{
+		"esc": escape,
+		"raw": func(v interface{}) Safe { return Safe(toString(v)) },
+		"b":   wrap("b"),
+		"i":   wrap("i"),
+	}
+}
+
+func toString(v interface{}) string {
+	switch s := v.(type) {
+	case Safe:
+		return string(s)
+	case string:
+		return s
+	case nil:
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+// escapeList дописывает "| esc" ко всем выводящим действиям, включая ветки if/range/with
+func escapeList(list *parse.ListNode) {
+	if list == nil {
+		return
+	}
+	for _, node := range list.Nodes {
+		switch n := node.(type) {
+		case *parse.ActionNode:
+			escapePipe(n.Pipe)
+		case *parse.IfNode:
+			escapeList(n.List)
+			escapeList(n.ElseList)
+		case *parse.RangeNode:
+			escapeList(n.List)
+			escapeList(n.ElseList)
+		case *parse.WithNode:
+			escapeList(n.List)
+			escapeList(n.ElseList)
+		}
+	}
+}
+
+func escapePipe(pipe *parse.PipeNode) {
+	// {{$x := ...}} ничего не выводит
+	if pipe == nil || len(pipe.Decl) > 0 || len(pipe.Cmds) == 0 {
+		return
+	}
+	last := pipe.Cmds[len(pipe.Cmds)-1]
+	if len(last.Args) > 0 {
+		if id, ok := last.Args[0].(*parse.IdentifierNode); ok && id.Ident == "esc" {
+			return
+		}
+	}
+	pipe.Cmds = append(pipe.Cmds, &parse.CommandNode{
+		NodeType: parse.NodeCommand,
+		Pos:      last.Pos,
+		Args:     []parse.Node{parse.NewIdentifier("esc").SetPos(last.Pos)},
+	})
+}
@@ -0,0 +1,106 @@
+package notifier
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"text/template"
+
+	"tezjet/internal/i18n"
+)
+
+// renderInline разбирает src так же, как LoadTemplates, и выполняет его
+func renderInline(t *testing.T, src string, format Format, data interface{}) string {
+	t.Helper()
+	tmpl, err := template.New("t").Funcs(formatFuncs(format)).Parse(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	escapeList(tmpl.Tree.Root)
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestTemplateEscaping(t *testing.T) {
+	data := map[string]interface{}{
+		"Name":  `<Иван & "Ко">`,
+		"Safe":  Safe("<b>ok</b>"),
+		"Price": 5000,
+		"List":  []string{"<a>", "b&c"},
+	}
+
+	tests := []struct {
+		name  string
+		src   string
+		html  string
+		plain string
+	}{
+		{"plain value", `{{.Name}}`, `&lt;Иван &amp; &#34;Ко&#34;&gt;`, `<Иван & "Ко">`},
+		{"number", `{{.Price}} ₸`, `5000 ₸`, `5000 ₸`},
+		{"safe value is kept", `{{.Safe}}`, `<b>ok</b>`, `<b>ok</b>`},
+		{"raw is kept", `{{raw .Name}}`, `<Иван & "Ко">`, `<Иван & "Ко">`},
+		{"explicit esc is not doubled", `{{esc .Name}}`, `&lt;Иван &amp; &#34;Ко&#34;&gt;`, `<Иван & "Ко">`},
+		{"bold", `{{b .Name}}`, `<b>&lt;Иван &amp; &#34;Ко&#34;&gt;</b>`, `<Иван & "Ко">`},
+		{"italic literal", `{{i "a<b"}}`, `<i>a&lt;b</i>`, `a<b`},
+		{"inside if", `{{if .Name}}{{.Name}}{{else}}-{{end}}`, `&lt;Иван &amp; &#34;Ко&#34;&gt;`, `<Иван & "Ко">`},
+		{"inside range", `{{range .List}}[{{.}}]{{end}}`, `[&lt;a&gt;][b&amp;c]`, `[<a>][b&c]`},
+		{"inside with", `{{with .Name}}{{.}}{{end}}`, `&lt;Иван &amp; &#34;Ко&#34;&gt;`, `<Иван & "Ко">`},
+		{"declaration prints nothing", `{{$n := .Name}}{{$n}}`, `&lt;Иван &amp; &#34;Ко&#34;&gt;`, `<Иван & "Ко">`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderInline(t, tt.src, FormatHTML, data); got != tt.html {
+				t.Errorf("html: got %q, want %q", got, tt.html)
+			}
+			if got := renderInline(t, tt.src, FormatPlain, data); got != tt.plain {
+				t.Errorf("plain: got %q, want %q", got, tt.plain)
+			}
+		})
+	}
+}
+
+func TestRenderEmbeddedTemplate(t *testing.T) {
+	templates, err := LoadTemplates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := map[string]interface{}{
+		"FirstName":    "<script>",
+		"Reason":       "custom",
+		"CustomReason": "Фото & документы",
+	}
+
+	for _, loc := range i18n.Locales {
+		html, err := templates.Render("driver_blocked", loc, FormatHTML, data)
+		if err != nil {
+			t.Fatalf("%s html: %v", loc, err)
+		}
+		if strings.Contains(html.Text, "<script>") || !strings.Contains(html.Text, "<b>&lt;script&gt;</b>") {
+			t.Errorf("%s html: name not escaped: %q", loc, html.Text)
+		}
+		if !strings.Contains(html.Text, "Фото &amp; документы") {
+			t.Errorf("%s html: reason not escaped: %q", loc, html.Text)
+		}
+		if html.Subject == "" {
+			t.Errorf("%s html: empty subject", loc)
+		}
+
+		plain, err := templates.Render("driver_blocked", loc, FormatPlain, data)
+		if err != nil {
+			t.Fatalf("%s plain: %v", loc, err)
+		}
+		if strings.Contains(plain.Text, "<b>") || strings.Contains(plain.Text, "&amp;") {
+			t.Errorf("%s plain: markup leaked: %q", loc, plain.Text)
+		}
+		if !strings.Contains(plain.Text, "<script>") {
+			t.Errorf("%s plain: name missing: %q", loc, plain.Text)
+		}
+	}
+
+	if _, err := templates.Render("no_such_template", i18n.Default, FormatPlain, data); err == nil {
+		t.Error("Render() of an unknown template succeeded")
+	}
+}
@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"tezjet/internal/domain"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// BookingRepository хранит брони мест в рейсах водителей
+type BookingRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewBookingRepository(db *sql.DB, logger *zap.Logger) *BookingRepository {
+	return &BookingRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+const bookingColumns = `
+	id, trip_id, driver_id, driver_telegram_id, telegram_id, from_address, to_address,
+	price, weight_kg, COALESCE(volume_m3, 0), status, COALESCE(request_id, ''), expires_at, decided_at, created_at, payload`
+
+func scanBooking(scan func(dest ...interface{}) error) (*domain.TripBooking, error) {
+	var b domain.TripBooking
+	var decided sql.NullTime
+	var payload string
+	if err := scan(&b.ID, &b.TripID, &b.DriverID, &b.DriverTelegramID, &b.TelegramID, &b.FromAddress, &b.ToAddress,
+		&b.Price, &b.WeightKg, &b.VolumeM3, &b.Status, &b.RequestID, &b.ExpiresAt, &decided, &b.CreatedAt, &payload); err != nil {
+		return nil, err
+	}
+	if decided.Valid {
+		b.DecidedAt = &decided.Time
+	}
+	b.Payload = []byte(payload)
+	return &b, nil
+}
+
+func (r *BookingRepository) queryBookings(ctx context.Context, query string, args ...interface{}) ([]domain.TripBooking, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []domain.TripBooking{}
+	for rows.Next() {
+		b, err := scanBooking(rows.Scan)
+		if err != nil {
+			r.logger.Error("Failed to scan trip booking", zap.Error(err))
+			continue
+		}
+		out = append(out, *b)
+	}
+	return out, rows.Err()
+}
+
+// CreateBooking saves a booking request. Returns 0 when the client already waits on this trip
+// or has MaxOpenBookings open bookings.
+func (r *BookingRepository) CreateBooking(ctx context.Context, b *domain.TripBooking) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `
+		INSERT INTO trip_bookings (trip_id, driver_id, driver_telegram_id, telegram_id, from_address, to_address,
+			price, weight_kg, volume_m3, payload, status, expires_at)
+		SELECT ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 'requested', ?
+		WHERE (SELECT COUNT(1) FROM trip_bookings WHERE telegram_id = ? AND status = 'requested') < ?
+		  AND NOT EXISTS (SELECT 1 FROM trip_bookings WHERE telegram_id = ? AND trip_id = ? AND status = 'requested')`,
+		b.TripID, b.DriverID, b.DriverTelegramID, b.TelegramID, b.FromAddress, b.ToAddress,
+		b.Price, b.WeightKg, b.VolumeM3, string(b.Payload), sqliteTime(&b.ExpiresAt),
+		b.TelegramID, domain.MaxOpenBookings, b.TelegramID, b.TripID,
+	)
+	if err != nil {
+		r.logger.Error("Failed to create trip booking", zap.Error(err), zap.Int64("telegram_id", b.TelegramID))
+		return 0, fmt.Errorf("failed to create trip booking: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return 0, nil
+	}
+	return result.LastInsertId()
+}
+
+// GetBooking returns a booking by id, nil if not found
+func (r *BookingRepository) GetBooking(ctx context.Context, id int64) (*domain.TripBooking, error) {
+	b, err := scanBooking(r.db.QueryRowContext(ctx, `SELECT `+bookingColumns+`
+		FROM trip_bookings WHERE id = ?`, id).Scan)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trip booking: %w", err)
+	}
+	return b, nil
+}
+
+// ListClientBookings returns the client's recent bookings, newest first
+func (r *BookingRepository) ListClientBookings(ctx context.Context, telegramID int64) ([]domain.TripBooking, error) {
+	out, err := r.queryBookings(ctx, `SELECT `+bookingColumns+`
+		FROM trip_bookings
+		WHERE telegram_id = ?
+		ORDER BY created_at DESC, id DESC
+		LIMIT 50`, telegramID)
+	if err != nil {
+		r.logger.Error("Failed to list trip bookings", zap.Error(err), zap.Int64("telegram_id", telegramID))
+		return nil, fmt.Errorf("failed to list trip bookings: %w", err)
+	}
+	return out, nil
+}
+
+// DeclineBooking closes an open booking on behalf of the trip's driver
+func (r *BookingRepository) DeclineBooking(ctx context.Context, id, driverTelegramID int64) (bool, error) {
+	return r.closeBooking(ctx, `driver_telegram_id = ?`, domain.BookingDeclined, id, driverTelegramID)
+}
+
+// CancelBooking closes an open booking on behalf of the client
+func (r *BookingRepository) CancelBooking(ctx context.Context, id, telegramID int64) (bool, error) {
+	return r.closeBooking(ctx, `telegram_id = ?`, domain.BookingCancelled, id, telegramID)
+}
+
+// ExpireBooking closes a booking the driver did not answer in time
+func (r *BookingRepository) ExpireBooking(ctx context.Context, id int64) (bool, error) {
+	now := time.Now()
+	return r.closeBooking(ctx, `expires_at <= ?`, domain.BookingExpired, id, sqliteTime(&now))
+}
+
+// CloseTripBookings closes all open bookings of a trip with status and returns the closed ones
+func (r *BookingRepository) CloseTripBookings(ctx context.Context, tripID, status string) ([]domain.TripBooking, error) {
+	open, err := r.queryBookings(ctx, `SELECT `+bookingColumns+`
+		FROM trip_bookings
+		WHERE trip_id = ? AND status = 'requested'`, tripID)
+	if err != nil {
+		r.logger.Error("Failed to get open trip bookings", zap.Error(err), zap.String("trip_id", tripID))
+		return nil, fmt.Errorf("failed to get open trip bookings: %w", err)
+	}
+
+	closed := open[:0]
+	for _, b := range open {
+		ok, err := r.closeBooking(ctx, `trip_id = ?`, status, b.ID, tripID)
+		if err != nil {
+			return closed, err
+		}
+		if ok {
+			closed = append(closed, b)
+		}
+	}
+	return closed, nil
+}
+
+func (r *BookingRepository) closeBooking(ctx context.Context, cond, status string, id int64, arg interface{}) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE trip_bookings SET status = ?, decided_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND status = 'requested' AND `+cond, status, id, arg)
+	if err != nil {
+		r.logger.Error("Failed to close trip booking", zap.Error(err), zap.Int64("booking_id", id), zap.String("status", status))
+		return false, fmt.Errorf("failed to close trip booking: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rowsAffected > 0, nil
+}
+
+// GetExpiredBookings returns open bookings past their deadline
+func (r *BookingRepository) GetExpiredBookings(ctx context.Context) ([]domain.TripBooking, error) {
+	now := time.Now()
+	out, err := r.queryBookings(ctx, `SELECT `+bookingColumns+`
+		FROM trip_bookings
+		WHERE status = 'requested' AND expires_at <= ?
+		LIMIT 100`, sqliteTime(&now))
+	if err != nil {
+		r.logger.Error("Failed to get expired trip bookings", zap.Error(err))
+		return nil, fmt.Errorf("failed to get expired trip bookings: %w", err)
+	}
+	return out, nil
+}
@@ -36,13 +36,30 @@ func (r *UserRepository) ExistsJust(ctx context.Context, userId int64) (bool, er
 // InsertJust вставляет запись в таблицу just с учетом новых полей (SQLite version)
 func (r *UserRepository) InsertJust(ctx context.Context, e domain.JustEntry) error {
 	const q = `
-		INSERT OR REPLACE INTO just (id_user, userName, dataRegistred, updated_at)
-		VALUES (?, ?, ?, datetime('now'));
+		INSERT OR REPLACE INTO just (id_user, userName, dataRegistred, language_code, updated_at)
+		VALUES (?, ?, ?, ?, datetime('now'));
 	`
-	_, err := r.db.ExecContext(ctx, q, e.UserId, e.UserName, e.DateRegistered)
+	_, err := r.db.ExecContext(ctx, q, e.UserId, e.UserName, e.DateRegistered, e.LanguageCode)
 	return err
 }
 
+// SetJustLanguage запоминает language_code, который прислал Telegram
+func (r *UserRepository) SetJustLanguage(ctx context.Context, userId int64, code string) error {
+	const q = `UPDATE just SET language_code = ?, updated_at = datetime('now') WHERE id_user = ? AND COALESCE(language_code, '') != ?`
+	_, err := r.db.ExecContext(ctx, q, code, userId, code)
+	return err
+}
+
+// GetLanguage returns the language chosen in the client profile and the one Telegram reported.
+// Пустая строка — значения нет.
+func (r *UserRepository) GetLanguage(ctx context.Context, telegramID int64) (profile, telegram string, err error) {
+	const q = `
+		SELECT COALESCE((SELECT language_code FROM users WHERE telegram_id = ?), ''),
+			COALESCE((SELECT language_code FROM just WHERE id_user = ?), '')`
+	err = r.db.QueryRowContext(ctx, q, telegramID, telegramID).Scan(&profile, &telegram)
+	return profile, telegram, err
+}
+
 // CreateUser creates a new user in the database
 func (r *UserRepository) CreateUser(req *domain.CreateUserRequest) (*domain.User, error) {
 	userID := uuid.New().String() // Generate UUID for the user
@@ -567,3 +584,289 @@ func (r *UserRepository) GetUserIDByTelegramID(telegramID int64) (string, error)
 
 	return userID, nil
 }
+
+// StaleOrdersPageSize — сколько заявок отдаёт одна страница GetStalePendingDeliveryRequests
+const StaleOrdersPageSize = 500
+
+// GetStalePendingDeliveryRequests returns unassigned pending requests dispatched to drivers more than minAge ago,
+// one page after the given request (nil — from the start), oldest first.
+// Точный TTL (по городу / типу авто) проверяется вызывающей стороной, поэтому читать нужно все страницы.
+func (r *UserRepository) GetStalePendingDeliveryRequests(ctx context.Context, minAge time.Duration, after *domain.DeliveryRequest) ([]*domain.DeliveryRequest, error) {
+	// Курсор — (dispatched_at, id) последней заявки предыдущей страницы
+	afterAt, afterID := "", ""
+	if after != nil && after.DispatchedAt != nil {
+		afterAt, afterID = after.DispatchedAt.UTC().Format("2006-01-02 15:04:05"), after.ID
+	}
+	query := `
+		SELECT id, telegram_id, from_address, to_address, price,
+			   COALESCE(truck_type, ''), created_at, dispatched_at
+		FROM delivery_requests
+		WHERE status = 'pending'
+		  AND driver_id IS NULL
+		  AND dispatched_at IS NOT NULL
+		  AND dispatched_at <= datetime('now', ?)
+		  AND (? = '' OR datetime(dispatched_at) > datetime(?)
+		       OR (datetime(dispatched_at) = datetime(?) AND id > ?))
+		ORDER BY datetime(dispatched_at) ASC, id ASC
+		LIMIT ?`
+
+	rows, err := r.db.QueryContext(ctx, query, fmt.Sprintf("-%d seconds", int64(minAge.Seconds())),
+		afterAt, afterAt, afterAt, afterID, StaleOrdersPageSize)
+	if err != nil {
+		r.logger.Error("Failed to get stale delivery requests", zap.Error(err))
+		return nil, fmt.Errorf("failed to get stale delivery requests: %w", err)
+	}
+	defer rows.Close()
+
+	var requests []*domain.DeliveryRequest
+	for rows.Next() {
+		request := &domain.DeliveryRequest{Status: domain.DeliveryStatusPending}
+		var dispatchedAt time.Time
+		if err := rows.Scan(
+			&request.ID, &request.TelegramID, &request.FromAddress, &request.ToAddress,
+			&request.Price, &request.TruckType, &request.CreatedAt, &dispatchedAt,
+		); err != nil {
+			r.logger.Error("Failed to scan stale delivery request", zap.Error(err))
+			continue
+		}
+		request.DispatchedAt = &dispatchedAt
+		requests = append(requests, request)
+	}
+
+	return requests, rows.Err()
+}
+
+// ExpireDeliveryRequest moves a pending request to expired. Returns false if it was already taken or cancelled.
+func (r *UserRepository) ExpireDeliveryRequest(ctx context.Context, requestID string) (bool, error) {
+	query := `
+		UPDATE delivery_requests
+		SET status = 'expired', expired_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND status = 'pending'`
+
+	result, err := r.db.ExecContext(ctx, query, requestID)
+	if err != nil {
+		r.logger.Error("Failed to expire delivery request", zap.Error(err), zap.String("request_id", requestID))
+		return false, fmt.Errorf("failed to expire delivery request: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rowsAffected > 0, nil
+}
+
+// RepostDeliveryRequest publishes an expired request again with a new price.
+// created_at сбрасывается, чтобы TTL и ленты водителей считали заявку новой.
+func (r *UserRepository) RepostDeliveryRequest(ctx context.Context, requestID string, telegramID int64, newPrice int) (bool, error) {
+	query := `
+		UPDATE delivery_requests
+		SET status = 'pending', price = ?, expired_at = NULL, dispatched_at = CURRENT_TIMESTAMP,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND telegram_id = ? AND status = 'expired'`
+
+	result, err := r.db.ExecContext(ctx, query, newPrice, requestID, telegramID)
+	if err != nil {
+		r.logger.Error("Failed to repost delivery request", zap.Error(err), zap.String("request_id", requestID))
+		return false, fmt.Errorf("failed to repost delivery request: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rowsAffected > 0, nil
+}
+
+// CancelExpiredDeliveryRequest cancels an expired (or still pending) request owned by telegramID
+func (r *UserRepository) CancelExpiredDeliveryRequest(ctx context.Context, requestID string, telegramID int64) (bool, error) {
+	query := `
+		UPDATE delivery_requests
+		SET status = 'cancelled', updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND telegram_id = ? AND status IN ('expired', 'pending')`
+
+	result, err := r.db.ExecContext(ctx, query, requestID, telegramID)
+	if err != nil {
+		r.logger.Error("Failed to cancel expired delivery request", zap.Error(err), zap.String("request_id", requestID))
+		return false, fmt.Errorf("failed to cancel delivery request: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rowsAffected > 0, nil
+}
+
+// EnsureUser возвращает профиль клиента, создавая его при первом обращении.
+// Пустые имя/username у существующего профиля дополняются из Telegram.
+func (r *UserRepository) EnsureUser(ctx context.Context, req *domain.CreateUserRequest) (*domain.User, error) {
+	exists, _, err := r.CheckUserExists(req.TelegramID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		user, err := r.CreateUser(req)
+		if err == nil {
+			return user, nil
+		}
+		// параллельный запрос мог успеть создать профиль — перечитываем
+		r.logger.Warn("Create user failed, re-reading profile", zap.Int64("telegram_id", req.TelegramID), zap.Error(err))
+	}
+
+	const fill = `
+		UPDATE users
+		SET telegram_username = CASE WHEN COALESCE(telegram_username,'') = '' THEN ? ELSE telegram_username END,
+			first_name = CASE WHEN COALESCE(first_name,'') = '' THEN ? ELSE first_name END,
+			last_name = CASE WHEN COALESCE(last_name,'') = '' THEN ? ELSE last_name END
+		WHERE telegram_id = ?`
+	if _, err := r.db.ExecContext(ctx, fill, req.TelegramUsername, req.FirstName, req.LastName, req.TelegramID); err != nil {
+		r.logger.Warn("Failed to fill user profile", zap.Int64("telegram_id", req.TelegramID), zap.Error(err))
+	}
+
+	return r.GetUserByTelegramID(req.TelegramID)
+}
+
+// ListAddresses returns saved addresses of the user, newest first
+func (r *UserRepository) ListAddresses(ctx context.Context, userID string) ([]domain.SavedAddress, error) {
+	const q = `
+		SELECT id, user_id, name, address, lat, lon, created_at
+		FROM client_addresses
+		WHERE user_id = ?
+		ORDER BY updated_at DESC, id DESC`
+
+	rows, err := r.db.QueryContext(ctx, q, userID)
+	if err != nil {
+		r.logger.Error("Failed to list saved addresses", zap.Error(err), zap.String("user_id", userID))
+		return nil, fmt.Errorf("failed to list saved addresses: %w", err)
+	}
+	defer rows.Close()
+
+	out := []domain.SavedAddress{}
+	for rows.Next() {
+		var a domain.SavedAddress
+		if err := rows.Scan(&a.ID, &a.UserID, &a.Name, &a.Address, &a.Lat, &a.Lon, &a.CreatedAt); err != nil {
+			r.logger.Error("Failed to scan saved address", zap.Error(err))
+			continue
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+// SaveAddress создаёт адрес или перезаписывает существующий с тем же названием.
+// Returns false when the user already has MaxSavedAddresses and the name is new.
+func (r *UserRepository) SaveAddress(ctx context.Context, a *domain.SavedAddress) (bool, error) {
+	const q = `
+		INSERT INTO client_addresses (user_id, name, address, lat, lon)
+		SELECT ?, ?, ?, ?, ?
+		WHERE (SELECT COUNT(1) FROM client_addresses WHERE user_id = ?) < ?
+		   OR EXISTS (SELECT 1 FROM client_addresses WHERE user_id = ? AND name = ?)
+		ON CONFLICT(user_id, name) DO UPDATE SET
+			address = excluded.address, lat = excluded.lat, lon = excluded.lon,
+			updated_at = CURRENT_TIMESTAMP`
+
+	result, err := r.db.ExecContext(ctx, q,
+		a.UserID, a.Name, a.Address, a.Lat, a.Lon,
+		a.UserID, domain.MaxSavedAddresses, a.UserID, a.Name,
+	)
+	if err != nil {
+		r.logger.Error("Failed to save address", zap.Error(err), zap.String("user_id", a.UserID))
+		return false, fmt.Errorf("failed to save address: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rowsAffected > 0, nil
+}
+
+// DeleteAddress removes a saved address owned by the user
+func (r *UserRepository) DeleteAddress(ctx context.Context, userID string, addressID int64) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM client_addresses WHERE id = ? AND user_id = ?`, addressID, userID)
+	if err != nil {
+		r.logger.Error("Failed to delete address", zap.Error(err), zap.Int64("address_id", addressID))
+		return false, fmt.Errorf("failed to delete address: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rowsAffected > 0, nil
+}
+
+// ListFavoriteDrivers returns the user's favourite drivers with their public card
+func (r *UserRepository) ListFavoriteDrivers(ctx context.Context, userID string) ([]domain.FavoriteDriver, error) {
+	const q = `
+		SELECT d.id, d.first_name, d.last_name, COALESCE(d.truck_type,''), d.profile_photo,
+			   COALESCE(d.rating,0), COALESCE(d.rating_count,0), d.status, f.created_at
+		FROM favorite_drivers f
+		JOIN drivers d ON d.id = f.driver_id
+		WHERE f.user_id = ?
+		ORDER BY f.created_at DESC`
+
+	rows, err := r.db.QueryContext(ctx, q, userID)
+	if err != nil {
+		r.logger.Error("Failed to list favorite drivers", zap.Error(err), zap.String("user_id", userID))
+		return nil, fmt.Errorf("failed to list favorite drivers: %w", err)
+	}
+	defer rows.Close()
+
+	out := []domain.FavoriteDriver{}
+	for rows.Next() {
+		var f domain.FavoriteDriver
+		if err := rows.Scan(&f.DriverID, &f.FirstName, &f.LastName, &f.TruckType, &f.ProfilePhoto,
+			&f.Rating, &f.RatingCount, &f.Status, &f.CreatedAt); err != nil {
+			r.logger.Error("Failed to scan favorite driver", zap.Error(err))
+			continue
+		}
+		out = append(out, f)
+	}
+	return out, rows.Err()
+}
+
+// AddFavoriteDriver добавляет водителя в избранное. Returns false when the list is full.
+func (r *UserRepository) AddFavoriteDriver(ctx context.Context, userID, driverID string) (bool, error) {
+	const q = `
+		INSERT INTO favorite_drivers (user_id, driver_id)
+		SELECT ?, ?
+		WHERE (SELECT COUNT(1) FROM favorite_drivers WHERE user_id = ?) < ?
+		   OR EXISTS (SELECT 1 FROM favorite_drivers WHERE user_id = ? AND driver_id = ?)
+		ON CONFLICT(user_id, driver_id) DO NOTHING`
+
+	if _, err := r.db.ExecContext(ctx, q, userID, driverID, userID, domain.MaxFavoriteDrivers, userID, driverID); err != nil {
+		r.logger.Error("Failed to add favorite driver", zap.Error(err), zap.String("driver_id", driverID))
+		return false, fmt.Errorf("failed to add favorite driver: %w", err)
+	}
+	return r.IsFavoriteDriver(ctx, userID, driverID)
+}
+
+// RemoveFavoriteDriver removes the driver from the user's favourites
+func (r *UserRepository) RemoveFavoriteDriver(ctx context.Context, userID, driverID string) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM favorite_drivers WHERE user_id = ? AND driver_id = ?`, userID, driverID)
+	if err != nil {
+		r.logger.Error("Failed to remove favorite driver", zap.Error(err), zap.String("driver_id", driverID))
+		return false, fmt.Errorf("failed to remove favorite driver: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rowsAffected > 0, nil
+}
+
+// IsFavoriteDriver reports whether the driver is in the user's favourites
+func (r *UserRepository) IsFavoriteDriver(ctx context.Context, userID, driverID string) (bool, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx,
+		`SELECT EXISTS(SELECT 1 FROM favorite_drivers WHERE user_id = ? AND driver_id = ?)`,
+		userID, driverID,
+	).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check favorite driver: %w", err)
+	}
+	return exists, nil
+}
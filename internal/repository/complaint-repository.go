@@ -0,0 +1,284 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"tezjet/internal/domain"
+
+	"go.uber.org/zap"
+)
+
+// ComplaintRepository хранит жалобы по заявкам, вложения и заметки администратора
+type ComplaintRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewComplaintRepository(db *sql.DB, logger *zap.Logger) *ComplaintRepository {
+	return &ComplaintRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+const complaintSelect = `
+	SELECT id, request_id, filed_by, filed_by_telegram_id, COALESCE(driver_id, ''), client_telegram_id,
+	       category, description, status, COALESCE(assigned_to, 0), COALESCE(resolution, ''), COALESCE(outcome, ''),
+	       created_at, updated_at, resolved_at
+	FROM complaints`
+
+func scanComplaint(scan func(dest ...interface{}) error) (*domain.Complaint, error) {
+	var c domain.Complaint
+	var resolvedAt sql.NullTime
+	if err := scan(&c.ID, &c.RequestID, &c.FiledBy, &c.FiledByTelegramID, &c.DriverID, &c.ClientTelegramID,
+		&c.Category, &c.Description, &c.Status, &c.AssignedTo, &c.Resolution, &c.Outcome,
+		&c.CreatedAt, &c.UpdatedAt, &resolvedAt); err != nil {
+		return nil, err
+	}
+	if resolvedAt.Valid {
+		c.ResolvedAt = &resolvedAt.Time
+	}
+	return &c, nil
+}
+
+// CreateComplaint saves the complaint together with its attachments and fills c.ID
+func (r *ComplaintRepository) CreateComplaint(ctx context.Context, c *domain.Complaint) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO complaints (request_id, filed_by, filed_by_telegram_id, driver_id, client_telegram_id, category, description, status)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		c.RequestID, c.FiledBy, c.FiledByTelegramID, c.DriverID, c.ClientTelegramID,
+		c.Category, c.Description, domain.ComplaintStatusOpen)
+	if err != nil {
+		return fmt.Errorf("failed to insert complaint: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get complaint id: %w", err)
+	}
+
+	for _, p := range c.Attachments {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO complaint_attachments (complaint_id, photo_path) VALUES (?, ?)`, id, p); err != nil {
+			return fmt.Errorf("failed to insert attachment: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit complaint: %w", err)
+	}
+	c.ID = id
+	c.Status = domain.ComplaintStatusOpen
+	return nil
+}
+
+// HasOpenComplaint — у стороны уже есть неразобранная жалоба по этой заявке
+func (r *ComplaintRepository) HasOpenComplaint(ctx context.Context, requestID string, telegramID int64) (bool, error) {
+	var n int
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM complaints
+		WHERE request_id = ? AND filed_by_telegram_id = ? AND status IN ('open', 'investigating')`,
+		requestID, telegramID).Scan(&n)
+	if err != nil {
+		return false, fmt.Errorf("failed to check open complaints: %w", err)
+	}
+	return n > 0, nil
+}
+
+// GetComplaint returns the complaint with attachments and comments, or nil if not found
+func (r *ComplaintRepository) GetComplaint(ctx context.Context, id int64) (*domain.Complaint, error) {
+	c, err := scanComplaint(r.db.QueryRowContext(ctx, complaintSelect+` WHERE id = ?`, id).Scan)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get complaint: %w", err)
+	}
+
+	attachments, err := r.getAttachments(ctx, []int64{id})
+	if err != nil {
+		return nil, err
+	}
+	c.Attachments = attachments[id]
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, complaint_id, author_telegram_id, body, created_at
+		FROM complaint_comments WHERE complaint_id = ? ORDER BY created_at, id`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query complaint comments: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var cm domain.ComplaintComment
+		if err := rows.Scan(&cm.ID, &cm.ComplaintID, &cm.AuthorTelegramID, &cm.Body, &cm.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan complaint comment: %w", err)
+		}
+		c.Comments = append(c.Comments, cm)
+	}
+	return c, rows.Err()
+}
+
+// ListComplaints — для админки; пустой status означает все жалобы
+func (r *ComplaintRepository) ListComplaints(ctx context.Context, status string, limit int) ([]domain.Complaint, error) {
+	query := complaintSelect
+	var args []interface{}
+	if status != "" {
+		query += ` WHERE status = ?`
+		args = append(args, status)
+	}
+	query += ` ORDER BY created_at DESC, id DESC LIMIT ?`
+	args = append(args, limit)
+	return r.listComplaints(ctx, query, args...)
+}
+
+// ListComplaintsByTelegramID — жалобы, поданные пользователем
+func (r *ComplaintRepository) ListComplaintsByTelegramID(ctx context.Context, telegramID int64) ([]domain.Complaint, error) {
+	return r.listComplaints(ctx, complaintSelect+`
+		WHERE filed_by_telegram_id = ? ORDER BY created_at DESC, id DESC LIMIT 100`, telegramID)
+}
+
+func (r *ComplaintRepository) listComplaints(ctx context.Context, query string, args ...interface{}) ([]domain.Complaint, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query complaints: %w", err)
+	}
+	defer rows.Close()
+
+	var complaints []domain.Complaint
+	var ids []int64
+	for rows.Next() {
+		c, err := scanComplaint(rows.Scan)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan complaint: %w", err)
+		}
+		complaints = append(complaints, *c)
+		ids = append(ids, c.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	attachments, err := r.getAttachments(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range complaints {
+		complaints[i].Attachments = attachments[complaints[i].ID]
+	}
+	return complaints, nil
+}
+
+func (r *ComplaintRepository) getAttachments(ctx context.Context, ids []int64) (map[int64][]string, error) {
+	result := make(map[int64][]string)
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT complaint_id, photo_path FROM complaint_attachments
+		WHERE complaint_id IN (?`+strings.Repeat(",?", len(ids)-1)+`)
+		ORDER BY id`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query complaint attachments: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		var path string
+		if err := rows.Scan(&id, &path); err != nil {
+			return nil, fmt.Errorf("failed to scan complaint attachment: %w", err)
+		}
+		result[id] = append(result[id], path)
+	}
+	return result, rows.Err()
+}
+
+// AssignComplaint назначает ответственного и переводит открытую жалобу в работу.
+// Returns false if the complaint is already closed or missing.
+func (r *ComplaintRepository) AssignComplaint(ctx context.Context, id, adminTelegramID int64) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE complaints SET assigned_to = ?, status = 'investigating'
+		WHERE id = ? AND status IN ('open', 'investigating')`, adminTelegramID, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to assign complaint: %w", err)
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+// AddComment добавляет заметку администратора
+func (r *ComplaintRepository) AddComment(ctx context.Context, id, authorTelegramID int64, body string) (*domain.ComplaintComment, error) {
+	result, err := r.db.ExecContext(ctx, `
+		INSERT INTO complaint_comments (complaint_id, author_telegram_id, body) VALUES (?, ?, ?)`,
+		id, authorTelegramID, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert complaint comment: %w", err)
+	}
+	commentID, _ := result.LastInsertId()
+
+	cm := &domain.ComplaintComment{ID: commentID}
+	if err := r.db.QueryRowContext(ctx, `
+		SELECT complaint_id, author_telegram_id, body, created_at FROM complaint_comments WHERE id = ?`, commentID).
+		Scan(&cm.ComplaintID, &cm.AuthorTelegramID, &cm.Body, &cm.CreatedAt); err != nil {
+		return nil, fmt.Errorf("failed to load complaint comment: %w", err)
+	}
+	return cm, nil
+}
+
+// CloseComplaint resolves or rejects an open complaint. With the driver_suspended outcome
+// the driver gets status 'suspended' in the same transaction.
+// Returns false if the complaint is already closed or missing.
+func (r *ComplaintRepository) CloseComplaint(ctx context.Context, id int64, status, resolution, outcome string, adminTelegramID int64) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var driverID string
+	err = tx.QueryRowContext(ctx, `
+		SELECT COALESCE(driver_id, '') FROM complaints
+		WHERE id = ? AND status IN ('open', 'investigating')`, id).Scan(&driverID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to load complaint: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE complaints
+		SET status = ?, resolution = ?, outcome = ?, resolved_at = CURRENT_TIMESTAMP,
+		    assigned_to = CASE WHEN COALESCE(assigned_to, 0) = 0 THEN ? ELSE assigned_to END
+		WHERE id = ?`, status, resolution, outcome, adminTelegramID, id); err != nil {
+		return false, fmt.Errorf("failed to close complaint: %w", err)
+	}
+
+	if outcome == domain.ComplaintOutcomeDriverSuspended {
+		if driverID == "" {
+			return false, fmt.Errorf("complaint %d has no driver to suspend", id)
+		}
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE drivers SET status = 'suspended', updated_at = CURRENT_TIMESTAMP
+			WHERE id = ?`, driverID); err != nil {
+			return false, fmt.Errorf("failed to suspend driver: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit complaint: %w", err)
+	}
+	return true, nil
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"fmt"
 	"math"
+	"sort"
 	"strings"
 	"tezjet/internal/domain"
 	"time"
@@ -296,11 +297,15 @@ func (r *DriverRepository) GetDriverNearA(
 ) ([]domain.Driver, error) {
 
 	const q = `
-	    SELECT id, telegram_id, first_name, last_name, latitude, longitude, profile_photo, truck_type
-		FROM drivers
-		WHERE status = 'approved'
-		  AND latitude  BETWEEN ? AND ?
-		  AND longitude BETWEEN ? AND ?
+	    SELECT d.id, d.telegram_id, d.first_name, d.last_name, d.latitude, d.longitude, d.profile_photo, d.truck_type,
+		       d.max_weight_kg, d.max_volume_m3, d.body_length_cm, d.body_width_cm, d.body_height_cm,
+		       ` + preferencesColumns + `
+		FROM drivers d
+		LEFT JOIN driver_preferences p ON p.telegram_id = d.telegram_id
+		WHERE d.status = 'approved'
+		  AND d.latitude  BETWEEN ? AND ?
+		  AND d.longitude BETWEEN ? AND ?
+		  AND (d.dispatch_cooldown_until IS NULL OR d.dispatch_cooldown_until <= datetime('now'))
 	`
 
 	rows, err := r.db.QueryContext(ctx, q, near.MinLat, near.MaxLat, near.MinLong, near.MaxLong)
@@ -312,7 +317,9 @@ func (r *DriverRepository) GetDriverNearA(
 	var candidates []domain.Driver
 	for rows.Next() {
 		var d domain.Driver
-		if err := rows.Scan(
+		var ps preferencesScan
+		d.Prefs = domain.DefaultDriverPreferences(0)
+		dest := []interface{}{
 			&d.ID,
 			&d.TelegramID,
 			&d.FirstName,
@@ -321,18 +328,27 @@ func (r *DriverRepository) GetDriverNearA(
 			&d.Longitude,
 			&d.ProfilePhotoPath,
 			&d.TruckType,
-		); err != nil {
+			&d.Capacity.MaxWeightKg,
+			&d.Capacity.MaxVolumeM3,
+			&d.Capacity.BodyLengthCm,
+			&d.Capacity.BodyWidthCm,
+			&d.Capacity.BodyHeightCm,
+		}
+		if err := rows.Scan(append(dest, preferencesScanDest(&d.Prefs, &ps)...)...); err != nil {
 			// можно логировать: r.logger.Warn("scan driver", zap.Error(err))
 			continue
 		}
+		d.Prefs.TelegramID = d.TelegramID
+		ps.apply(&d.Prefs)
+		d.Capacity.TruckType = strings.ToLower(strings.TrimSpace(d.TruckType))
 
-		// Фильтр по типу авто, если заказ требует конкретный
-		if req.TruckType != "" && !equalsCI(req.TruckType, d.TruckType) {
+		// Машина должна выдержать груз; точное совпадение типа нужно только для спецтехники
+		if !d.Capacity.CanCarry(strings.ToLower(strings.TrimSpace(req.TruckType)), req.Cargo) {
 			continue
 		}
 
-		// Точное расстояние по ховерсайну
-		if dist := haversineKm(req.FromLat, req.FromLon, d.Latitude, d.Longitude); dist <= 30.0 {
+		// Точное расстояние по ховерсайну, радиус — из настроек водителя
+		if dist := haversineKm(req.FromLat, req.FromLon, d.Latitude, d.Longitude); dist <= d.Prefs.EffectiveRadius() {
 			candidates = append(candidates, d)
 		}
 	}
@@ -340,6 +356,18 @@ func (r *DriverRepository) GetDriverNearA(
 		return nil, fmt.Errorf("rows err: %w", err)
 	}
 
+	// Сначала машины, которые лучше подходят по размеру, затем ближайшие
+	orderType := strings.ToLower(strings.TrimSpace(req.TruckType))
+	sort.SliceStable(candidates, func(i, j int) bool {
+		fi := candidates[i].Capacity.FitScore(orderType, req.Cargo)
+		fj := candidates[j].Capacity.FitScore(orderType, req.Cargo)
+		if fi != fj {
+			return fi > fj
+		}
+		return haversineKm(req.FromLat, req.FromLon, candidates[i].Latitude, candidates[i].Longitude) <
+			haversineKm(req.FromLat, req.FromLon, candidates[j].Latitude, candidates[j].Longitude)
+	})
+
 	return candidates, nil
 }
 
@@ -355,11 +383,6 @@ func haversineKm(lat1, lon1, lat2, lon2 float64) float64 {
 	return earthRadiusKm * c
 }
 
-// сравнение строк без учета регистра и лишних пробелов
-func equalsCI(a, b string) bool {
-	return strings.EqualFold(strings.TrimSpace(a), strings.TrimSpace(b))
-}
-
 // GetDriverRoutes retrieves all routes for a driver
 func (r *DriverRepository) GetDriverRoutes(telegramID int64, limit, offset int) ([]*domain.DriverRoute, error) {
 	query := `
@@ -641,14 +664,17 @@ func (r *DriverRepository) CreateDriverMatch(match *domain.DriverMatch) (*domain
 	query := `
 		INSERT INTO driver_matches (
 			id, driver_id, driver_route_id, delivery_request_id, client_telegram_id,
-			status, proposed_price, driver_comment, created_at, updated_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+			status, proposed_price, eta_min, driver_comment, expires_at, created_at, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)`
 
-	now := time.Now()
+	var expiresAt interface{}
+	if match.ExpiresAt != nil {
+		expiresAt = match.ExpiresAt.UTC().Format("2006-01-02 15:04:05")
+	}
 
 	_, err := r.db.Exec(query,
 		matchID, match.DriverID, match.DriverRouteID, match.DeliveryRequestID, match.ClientTelegramID,
-		domain.MatchStatusPending, match.ProposedPrice, match.DriverComment, now, now,
+		domain.MatchStatusPending, match.ProposedPrice, match.EtaMin, match.DriverComment, expiresAt,
 	)
 
 	if err != nil {
@@ -664,21 +690,21 @@ func (r *DriverRepository) GetDriverMatchByID(matchID string) (*domain.DriverMat
 	query := `
 		SELECT id, driver_id, driver_route_id, delivery_request_id, client_telegram_id,
 			   status, proposed_price, final_price, pickup_time, delivery_time,
-			   driver_comment, client_comment, driver_rating, client_rating,
-			   created_at, updated_at, completed_at
+			   COALESCE(driver_comment, ''), COALESCE(client_comment, ''), driver_rating, client_rating,
+			   created_at, updated_at, completed_at, COALESCE(eta_min, 0), expires_at
 		FROM driver_matches 
 		WHERE id = ?`
 
 	match := &domain.DriverMatch{}
 	var finalPrice sql.NullInt64
-	var pickupTime, deliveryTime, completedAt sql.NullTime
+	var pickupTime, deliveryTime, completedAt, expiresAt sql.NullTime
 	var driverRating, clientRating sql.NullInt64
 
 	err := r.db.QueryRow(query, matchID).Scan(
 		&match.ID, &match.DriverID, &match.DriverRouteID, &match.DeliveryRequestID, &match.ClientTelegramID,
 		&match.Status, &match.ProposedPrice, &finalPrice, &pickupTime, &deliveryTime,
 		&match.DriverComment, &match.ClientComment, &driverRating, &clientRating,
-		&match.CreatedAt, &match.UpdatedAt, &completedAt,
+		&match.CreatedAt, &match.UpdatedAt, &completedAt, &match.EtaMin, &expiresAt,
 	)
 
 	if err != nil {
@@ -702,6 +728,9 @@ func (r *DriverRepository) GetDriverMatchByID(matchID string) (*domain.DriverMat
 	if completedAt.Valid {
 		match.CompletedAt = &completedAt.Time
 	}
+	if expiresAt.Valid {
+		match.ExpiresAt = &expiresAt.Time
+	}
 	if driverRating.Valid {
 		driverRatingInt := int(driverRating.Int64)
 		match.DriverRating = &driverRatingInt
@@ -739,6 +768,235 @@ func (r *DriverRepository) UpdateDriverMatchStatus(matchID string, status string
 	return nil
 }
 
+// GetPendingBid returns the driver's open bid on the order, nil if there is none
+func (r *DriverRepository) GetPendingBid(ctx context.Context, requestID, driverID string) (*domain.DriverMatch, error) {
+	var matchID string
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id FROM driver_matches
+		WHERE delivery_request_id = ? AND driver_id = ? AND status = 'pending'`,
+		requestID, driverID).Scan(&matchID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending bid: %w", err)
+	}
+	return r.GetDriverMatchByID(matchID)
+}
+
+// UpdateBidOffer — водитель изменил своё предложение; срок действия продлевается
+func (r *DriverRepository) UpdateBidOffer(ctx context.Context, match *domain.DriverMatch) (bool, error) {
+	var expiresAt interface{}
+	if match.ExpiresAt != nil {
+		expiresAt = match.ExpiresAt.UTC().Format("2006-01-02 15:04:05")
+	}
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE driver_matches
+		SET proposed_price = ?, eta_min = ?, driver_comment = ?, expires_at = ?
+		WHERE id = ? AND status = 'pending'`,
+		match.ProposedPrice, match.EtaMin, match.DriverComment, expiresAt, match.ID)
+	if err != nil {
+		r.logger.Error("Failed to update bid", zap.Error(err), zap.String("match_id", match.ID))
+		return false, fmt.Errorf("failed to update bid: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rowsAffected > 0, nil
+}
+
+const bidSelect = `
+	SELECT m.id, m.driver_id, COALESCE(m.driver_route_id, ''), m.delivery_request_id, m.client_telegram_id,
+		   m.status, m.proposed_price, COALESCE(m.eta_min, 0), COALESCE(m.driver_comment, ''),
+		   m.expires_at, m.created_at,
+		   d.telegram_id, d.first_name, d.last_name, d.contact_number,
+		   COALESCE(d.truck_type, ''), d.profile_photo, d.is_verified
+	FROM driver_matches m
+	JOIN drivers d ON d.id = m.driver_id`
+
+func scanBid(scan func(dest ...interface{}) error) (domain.DriverBid, error) {
+	var b domain.DriverBid
+	var firstName, lastName string
+	var expiresAt sql.NullTime
+	err := scan(
+		&b.ID, &b.DriverID, &b.DriverRouteID, &b.DeliveryRequestID, &b.ClientTelegramID,
+		&b.Status, &b.ProposedPrice, &b.EtaMin, &b.DriverComment,
+		&expiresAt, &b.CreatedAt,
+		&b.DriverTelegramID, &firstName, &lastName, &b.DriverPhone,
+		&b.TruckType, &b.ProfilePhoto, &b.IsVerified,
+	)
+	if err != nil {
+		return b, err
+	}
+	if expiresAt.Valid {
+		b.ExpiresAt = &expiresAt.Time
+	}
+	b.DriverName = strings.TrimSpace(firstName + " " + lastName)
+	return b, nil
+}
+
+// GetBidsForRequest returns open bids ranked for the client:
+// дешевле — выше, при равной цене — кто быстрее подаст машину.
+func (r *DriverRepository) GetBidsForRequest(ctx context.Context, requestID string) ([]domain.DriverBid, error) {
+	rows, err := r.db.QueryContext(ctx, bidSelect+`
+		WHERE m.delivery_request_id = ? AND m.status = 'pending'
+		  AND (m.expires_at IS NULL OR m.expires_at > datetime('now'))
+		ORDER BY m.proposed_price ASC,
+		         CASE WHEN m.eta_min > 0 THEN m.eta_min ELSE 1000000 END ASC,
+		         m.created_at ASC`, requestID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bids: %w", err)
+	}
+	defer rows.Close()
+
+	var bids []domain.DriverBid
+	for rows.Next() {
+		b, err := scanBid(rows.Scan)
+		if err != nil {
+			r.logger.Error("Failed to scan bid", zap.Error(err))
+			continue
+		}
+		bids = append(bids, b)
+	}
+	return bids, rows.Err()
+}
+
+// AcceptBid атомарно назначает водителя по выбранному предложению и отклоняет остальные.
+// Возвращает nil, если предложение уже неактуально. rejected — telegram_id водителей,
+// чьи предложения отклонены.
+func (r *DriverRepository) AcceptBid(ctx context.Context, matchID string, clientTelegramID int64) (*domain.DriverBid, []int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	bid, err := scanBid(tx.QueryRowContext(ctx, bidSelect+`
+		WHERE m.id = ? AND m.client_telegram_id = ? AND m.status = 'pending'
+		  AND (m.expires_at IS NULL OR m.expires_at > datetime('now'))`,
+		matchID, clientTelegramID).Scan)
+	if err == sql.ErrNoRows {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load bid: %w", err)
+	}
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE delivery_requests
+		SET status = 'matched', driver_id = ?, matched_driver_id = ?, price = ?, accepted_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND telegram_id = ? AND status = 'pending' AND driver_id IS NULL`,
+		bid.DriverID, bid.DriverID, bid.ProposedPrice, bid.DeliveryRequestID, clientTelegramID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to match order: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return nil, nil, nil
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE driver_matches SET status = 'accepted', final_price = proposed_price
+		WHERE id = ?`, matchID); err != nil {
+		return nil, nil, fmt.Errorf("failed to accept bid: %w", err)
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT d.telegram_id FROM driver_matches m
+		JOIN drivers d ON d.id = m.driver_id
+		WHERE m.delivery_request_id = ? AND m.id <> ? AND m.status = 'pending'`,
+		bid.DeliveryRequestID, matchID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load other bids: %w", err)
+	}
+	var rejected []int64
+	for rows.Next() {
+		var tgID int64
+		if err := rows.Scan(&tgID); err == nil {
+			rejected = append(rejected, tgID)
+		}
+	}
+	rows.Close()
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE driver_matches SET status = 'rejected'
+		WHERE delivery_request_id = ? AND id <> ? AND status = 'pending'`,
+		bid.DeliveryRequestID, matchID); err != nil {
+		return nil, nil, fmt.Errorf("failed to reject other bids: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit: %w", err)
+	}
+
+	final := bid.ProposedPrice
+	bid.Status = domain.MatchStatusAccepted
+	bid.FinalPrice = &final
+	return &bid, rejected, nil
+}
+
+// ExpireBids закрывает просроченные предложения и предложения по заявкам,
+// которые больше не ждут водителя (приняты, отменены, истекли).
+func (r *DriverRepository) ExpireBids(ctx context.Context) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE driver_matches
+		SET status = 'expired'
+		WHERE status = 'pending'
+		  AND (
+			(expires_at IS NOT NULL AND expires_at <= datetime('now'))
+			OR delivery_request_id IN (
+				SELECT id FROM delivery_requests WHERE status <> 'pending' OR driver_id IS NOT NULL
+			)
+		  )`)
+	if err != nil {
+		r.logger.Error("Failed to expire bids", zap.Error(err))
+		return 0, fmt.Errorf("failed to expire bids: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// GetDispatchCooldown returns the end of the driver's dispatch pause, or nil if there is none
+func (r *DriverRepository) GetDispatchCooldown(ctx context.Context, driverID string) (*time.Time, error) {
+	var until sql.NullTime
+	err := r.db.QueryRowContext(ctx, `
+		SELECT dispatch_cooldown_until FROM drivers
+		WHERE id = ? AND dispatch_cooldown_until > datetime('now')`, driverID).Scan(&until)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dispatch cooldown: %w", err)
+	}
+	if !until.Valid {
+		return nil, nil
+	}
+	return &until.Time, nil
+}
+
+// GetCancellations — последние отказы водителя от заявок
+func (r *DriverRepository) GetCancellations(ctx context.Context, driverID string, limit int) ([]domain.DriverCancellation, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, request_id, driver_id, reason, COALESCE(comment, ''), created_at
+		FROM driver_cancellations
+		WHERE driver_id = ?
+		ORDER BY created_at DESC, id DESC
+		LIMIT ?`, driverID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query cancellations: %w", err)
+	}
+	defer rows.Close()
+
+	var list []domain.DriverCancellation
+	for rows.Next() {
+		var c domain.DriverCancellation
+		if err := rows.Scan(&c.ID, &c.RequestID, &c.DriverID, &c.Reason, &c.Comment, &c.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan cancellation: %w", err)
+		}
+		list = append(list, c)
+	}
+	return list, rows.Err()
+}
+
 // GetDriverStatistics retrieves driver statistics
 func (r *DriverRepository) GetDriverStatistics(telegramID int64) (*domain.DriverStatistics, error) {
 	query := `
@@ -956,3 +1214,42 @@ func (r *DriverRepository) DeactivateExpiredRoutes() error {
 
 	return nil
 }
+
+// GetActiveTripRoutes returns active trips for the map layer. Рейсы с прошедшим временем
+// выезда закрывает ExpireDriverTrips. Поездки с типом машины 'any' подходят под любой фильтр по типу.
+func (r *DriverRepository) GetActiveTripRoutes(ctx context.Context, f domain.MapFilter) ([]domain.TripRoute, error) {
+	query := `
+		SELECT id, from_lat, from_lon, to_lat, to_lon, price, COALESCE(truck_type, 'any'), start_time
+		FROM driver_trips
+		WHERE status = 'active'
+		  AND from_lat != 0 AND from_lon != 0
+		  AND to_lat != 0 AND to_lon != 0`
+	var args []interface{}
+	if f.City != "" {
+		query += ` AND from_address LIKE '%' || ? || '%'`
+		args = append(args, f.City)
+	}
+	if f.TruckType != "" {
+		query += ` AND truck_type IN (?, 'any')`
+		args = append(args, f.TruckType)
+	}
+	query += ` ORDER BY created_at DESC LIMIT 500`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("Failed to get active trip routes", zap.Error(err))
+		return nil, fmt.Errorf("failed to get active trip routes: %w", err)
+	}
+	defer rows.Close()
+
+	var out []domain.TripRoute
+	for rows.Next() {
+		var t domain.TripRoute
+		if err := rows.Scan(&t.ID, &t.From.Lat, &t.From.Lon, &t.To.Lat, &t.To.Lon, &t.Price, &t.TruckType, &t.StartTime); err != nil {
+			r.logger.Error("Failed to scan trip route", zap.Error(err))
+			continue
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
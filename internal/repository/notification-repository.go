@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"tezjet/internal/domain"
+
+	"go.uber.org/zap"
+)
+
+// NotificationRepository хранит выбор каналов уведомлений и журнал доставки
+type NotificationRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewNotificationRepository(db *sql.DB, logger *zap.Logger) *NotificationRepository {
+	return &NotificationRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// GetNotificationSettings returns user channels, Telegram only if the user never changed them
+func (r *NotificationRepository) GetNotificationSettings(ctx context.Context, telegramID int64) (domain.NotificationSettings, error) {
+	s := domain.DefaultNotificationSettings(telegramID)
+	var channels string
+	err := r.db.QueryRowContext(ctx, `
+		SELECT channels, phone, email FROM notification_settings WHERE telegram_id = ?`, telegramID).
+		Scan(&channels, &s.Phone, &s.Email)
+	if err == sql.ErrNoRows {
+		return s, nil
+	}
+	if err != nil {
+		r.logger.Error("Failed to get notification settings", zap.Error(err), zap.Int64("telegram_id", telegramID))
+		return s, fmt.Errorf("failed to get notification settings: %w", err)
+	}
+	if list := splitList(channels); len(list) > 0 {
+		s.Channels = list
+	}
+	return s, nil
+}
+
+// SaveNotificationSettings creates or replaces user channels
+func (r *NotificationRepository) SaveNotificationSettings(ctx context.Context, s *domain.NotificationSettings) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO notification_settings (telegram_id, channels, phone, email)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(telegram_id) DO UPDATE SET
+			channels = excluded.channels,
+			phone = excluded.phone,
+			email = excluded.email,
+			updated_at = CURRENT_TIMESTAMP`,
+		s.TelegramID, strings.Join(s.Channels, ","), s.Phone, s.Email)
+	if err != nil {
+		r.logger.Error("Failed to save notification settings", zap.Error(err), zap.Int64("telegram_id", s.TelegramID))
+		return fmt.Errorf("failed to save notification settings: %w", err)
+	}
+	return nil
+}
+
+// CreateNotification records a delivery attempt and sets n.ID
+func (r *NotificationRepository) CreateNotification(ctx context.Context, n *domain.Notification) error {
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO notifications (telegram_id, template, channel, recipient, status, error, sent_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		RETURNING id, created_at`,
+		n.TelegramID, n.Template, n.Channel, n.Recipient, n.Status, n.Error, sqliteTime(n.SentAt)).
+		Scan(&n.ID, &n.CreatedAt)
+	if err != nil {
+		r.logger.Error("Failed to create notification", zap.Error(err), zap.String("template", n.Template))
+		return fmt.Errorf("failed to create notification: %w", err)
+	}
+	return nil
+}
+
+// MarkNotification stores the delivery outcome
+func (r *NotificationRepository) MarkNotification(ctx context.Context, id int64, status, errText string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE notifications
+		SET status = ?, error = ?, sent_at = CASE WHEN ? = 'sent' THEN CURRENT_TIMESTAMP ELSE sent_at END
+		WHERE id = ?`, status, errText, status, id)
+	if err != nil {
+		r.logger.Error("Failed to mark notification", zap.Error(err), zap.Int64("id", id))
+		return fmt.Errorf("failed to mark notification: %w", err)
+	}
+	return nil
+}
+
+// ListNotifications returns the latest delivery attempts; zero telegramID or empty status mean any
+func (r *NotificationRepository) ListNotifications(ctx context.Context, telegramID int64, status string, limit int) ([]domain.Notification, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, telegram_id, template, channel, recipient, status, error, created_at, sent_at
+		FROM notifications
+		WHERE (? = 0 OR telegram_id = ?) AND (? = '' OR status = ?)
+		ORDER BY id DESC
+		LIMIT ?`, telegramID, telegramID, status, status, limit)
+	if err != nil {
+		r.logger.Error("Failed to list notifications", zap.Error(err))
+		return nil, fmt.Errorf("failed to list notifications: %w", err)
+	}
+	defer rows.Close()
+
+	out := []domain.Notification{}
+	for rows.Next() {
+		var n domain.Notification
+		var sentAt sql.NullTime
+		if err := rows.Scan(&n.ID, &n.TelegramID, &n.Template, &n.Channel, &n.Recipient, &n.Status, &n.Error,
+			&n.CreatedAt, &sentAt); err != nil {
+			r.logger.Error("Failed to scan notification", zap.Error(err))
+			continue
+		}
+		if sentAt.Valid {
+			n.SentAt = &sentAt.Time
+		}
+		out = append(out, n)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,887 @@
+package repository
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"tezjet/internal/domain"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// OrderRepository хранит данные вокруг заявки: правки, рассылки водителям
+type OrderRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewOrderRepository(db *sql.DB, logger *zap.Logger) *OrderRepository {
+	return &OrderRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// UpdateDeliveryRequest saves edited order fields together with the revision record.
+// Returns false if the order is no longer editable (status changed meanwhile).
+func (r *OrderRepository) UpdateDeliveryRequest(ctx context.Context, req *domain.DeliveryRequest, rev *domain.DeliveryRequestRevision) (bool, error) {
+	changes, err := json.Marshal(rev.Changes)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal revision: %w", err)
+	}
+
+	var pickupAt interface{}
+	if req.PickupAt != nil {
+		pickupAt = req.PickupAt.UTC().Format("2006-01-02 15:04:05")
+	}
+
+	c := req.Cargo
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE delivery_requests
+		SET from_address = ?, from_lat = ?, from_lon = ?,
+			to_address = ?, to_lat = ?, to_lon = ?,
+			distance_km = ?, eta_min = ?, price = ?, truck_type = ?,
+			contact = ?, time_start = ?, comment = ?,
+			cargo_weight_kg = ?, cargo_volume_m3 = ?, cargo_length_cm = ?, cargo_width_cm = ?, cargo_height_cm = ?,
+			cargo_items = ?, needs_loaders = ?, fragile = ?, floor = ?, has_elevator = ?,
+			reminder_sent_at = CASE WHEN pickup_at IS ? THEN reminder_sent_at ELSE NULL END,
+			pickup_at = ?,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND telegram_id = ? AND status IN ('pending', 'matched')`
+
+	result, err := tx.ExecContext(ctx, query,
+		req.FromAddress, req.FromLat, req.FromLon,
+		req.ToAddress, req.ToLat, req.ToLon,
+		req.DistanceKm, req.EtaMin, req.Price, req.TruckType,
+		req.Contact, req.TimeStart, req.Comment,
+		c.WeightKg, c.VolumeM3, c.LengthCm, c.WidthCm, c.HeightCm,
+		c.Items, c.NeedsLoaders, c.Fragile, c.Floor, c.HasElevator,
+		pickupAt, pickupAt,
+		req.ID, req.TelegramID,
+	)
+	if err != nil {
+		r.logger.Error("Failed to update delivery request", zap.Error(err), zap.String("request_id", req.ID))
+		return false, fmt.Errorf("failed to update delivery request: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return false, nil
+	}
+
+	res, err := tx.ExecContext(ctx,
+		`INSERT INTO delivery_request_revisions (request_id, telegram_id, changes) VALUES (?, ?, ?)`,
+		req.ID, req.TelegramID, string(changes),
+	)
+	if err != nil {
+		r.logger.Error("Failed to save revision", zap.Error(err), zap.String("request_id", req.ID))
+		return false, fmt.Errorf("failed to save revision: %w", err)
+	}
+	rev.ID, _ = res.LastInsertId()
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return true, nil
+}
+
+// GetRevisions returns the edit history of an order, newest first
+func (r *OrderRepository) GetRevisions(ctx context.Context, requestID string) ([]domain.DeliveryRequestRevision, error) {
+	query := `
+		SELECT id, request_id, telegram_id, changes, created_at
+		FROM delivery_request_revisions
+		WHERE request_id = ?
+		ORDER BY id DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, requestID)
+	if err != nil {
+		r.logger.Error("Failed to get revisions", zap.Error(err), zap.String("request_id", requestID))
+		return nil, fmt.Errorf("failed to get revisions: %w", err)
+	}
+	defer rows.Close()
+
+	var revisions []domain.DeliveryRequestRevision
+	for rows.Next() {
+		var rev domain.DeliveryRequestRevision
+		var changes string
+		if err := rows.Scan(&rev.ID, &rev.RequestID, &rev.TelegramID, &changes, &rev.CreatedAt); err != nil {
+			r.logger.Error("Failed to scan revision", zap.Error(err))
+			continue
+		}
+		if err := json.Unmarshal([]byte(changes), &rev.Changes); err != nil {
+			r.logger.Warn("Bad revision payload", zap.Int64("revision_id", rev.ID), zap.Error(err))
+		}
+		revisions = append(revisions, rev)
+	}
+
+	return revisions, rows.Err()
+}
+
+// SaveBroadcast remembers the bot message an order was sent to a driver with
+func (r *OrderRepository) SaveBroadcast(ctx context.Context, b domain.OrderBroadcast) error {
+	query := `
+		INSERT INTO order_broadcasts (request_id, driver_telegram_id, message_id, has_photo)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(request_id, driver_telegram_id) DO UPDATE SET
+			message_id = excluded.message_id,
+			has_photo = excluded.has_photo,
+			created_at = CURRENT_TIMESTAMP`
+
+	if _, err := r.db.ExecContext(ctx, query, b.RequestID, b.DriverTelegramID, b.MessageID, b.HasPhoto); err != nil {
+		return fmt.Errorf("failed to save broadcast: %w", err)
+	}
+	return nil
+}
+
+// GetBroadcasts returns all driver messages sent for an order
+func (r *OrderRepository) GetBroadcasts(ctx context.Context, requestID string) ([]domain.OrderBroadcast, error) {
+	query := `
+		SELECT request_id, driver_telegram_id, message_id, has_photo, created_at
+		FROM order_broadcasts
+		WHERE request_id = ?`
+
+	rows, err := r.db.QueryContext(ctx, query, requestID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get broadcasts: %w", err)
+	}
+	defer rows.Close()
+
+	var broadcasts []domain.OrderBroadcast
+	for rows.Next() {
+		var b domain.OrderBroadcast
+		if err := rows.Scan(&b.RequestID, &b.DriverTelegramID, &b.MessageID, &b.HasPhoto, &b.CreatedAt); err != nil {
+			r.logger.Error("Failed to scan broadcast", zap.Error(err))
+			continue
+		}
+		broadcasts = append(broadcasts, b)
+	}
+
+	return broadcasts, rows.Err()
+}
+
+// GetOrdersDueForDispatch returns held scheduled orders whose pickup is within lead time
+func (r *OrderRepository) GetOrdersDueForDispatch(ctx context.Context, lead time.Duration) ([]string, error) {
+	query := `
+		SELECT id
+		FROM delivery_requests
+		WHERE status = 'pending'
+		  AND dispatched_at IS NULL
+		  AND pickup_at IS NOT NULL
+		  AND pickup_at <= datetime('now', ?)
+		ORDER BY pickup_at ASC
+		LIMIT 200`
+
+	rows, err := r.db.QueryContext(ctx, query, fmt.Sprintf("+%d seconds", int64(lead.Seconds())))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get orders due for dispatch: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan order id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// MarkDispatched marks a held order as sent to drivers. False if another worker already did it.
+func (r *OrderRepository) MarkDispatched(ctx context.Context, requestID string) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE delivery_requests
+		SET dispatched_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND dispatched_at IS NULL AND status = 'pending'`, requestID)
+	if err != nil {
+		r.logger.Error("Failed to mark order dispatched", zap.Error(err), zap.String("request_id", requestID))
+		return false, fmt.Errorf("failed to mark order dispatched: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rowsAffected > 0, nil
+}
+
+// StartPreferredWindow закрепляет заявку за избранным водителем до until.
+// False if the window already started or the order has no favourite driver.
+func (r *OrderRepository) StartPreferredWindow(ctx context.Context, requestID string, until time.Time) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE delivery_requests
+		SET preferred_until = ?
+		WHERE id = ? AND preferred_driver_id IS NOT NULL AND preferred_until IS NULL AND status = 'pending'`,
+		until.UTC().Format("2006-01-02 15:04:05"), requestID)
+	if err != nil {
+		return false, fmt.Errorf("failed to start preferred window: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rowsAffected > 0, nil
+}
+
+// GetPreferredExpired returns orders whose favourite-driver window has run out
+func (r *OrderRepository) GetPreferredExpired(ctx context.Context) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id
+		FROM delivery_requests
+		WHERE preferred_driver_id IS NOT NULL
+		  AND preferred_until IS NOT NULL
+		  AND preferred_until <= datetime('now')
+		ORDER BY preferred_until ASC
+		LIMIT 200`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get expired preferred orders: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan order id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// ReleasePreferred снимает закрепление за избранным водителем — заявка становится видна всем.
+// Returns the favourite's driver id; ok is false if another worker already released it.
+func (r *OrderRepository) ReleasePreferred(ctx context.Context, requestID string) (driverID string, ok bool, err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	err = tx.QueryRowContext(ctx, `
+		SELECT preferred_driver_id FROM delivery_requests
+		WHERE id = ? AND preferred_driver_id IS NOT NULL`, requestID).Scan(&driverID)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get preferred driver: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE delivery_requests
+		SET preferred_driver_id = NULL, preferred_until = NULL
+		WHERE id = ?`, requestID); err != nil {
+		return "", false, fmt.Errorf("failed to release preferred driver: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", false, fmt.Errorf("failed to commit release: %w", err)
+	}
+	return driverID, true, nil
+}
+
+// GetDuePickupReminders returns assigned orders whose pickup starts within `before`
+func (r *OrderRepository) GetDuePickupReminders(ctx context.Context, before time.Duration) ([]domain.PickupReminder, error) {
+	query := `
+		SELECT r.id, r.telegram_id, d.telegram_id, r.from_address, r.to_address,
+			   COALESCE(r.contact, ''), d.first_name || ' ' || d.last_name, d.contact_number,
+			   r.pickup_at
+		FROM delivery_requests r
+		JOIN drivers d ON d.id = COALESCE(r.driver_id, r.matched_driver_id)
+		WHERE r.status IN ('pending', 'matched', 'in_progress')
+		  AND r.reminder_sent_at IS NULL
+		  AND r.pickup_at IS NOT NULL
+		  AND r.pickup_at > datetime('now')
+		  AND r.pickup_at <= datetime('now', ?)
+		LIMIT 200`
+
+	rows, err := r.db.QueryContext(ctx, query, fmt.Sprintf("+%d seconds", int64(before.Seconds())))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pickup reminders: %w", err)
+	}
+	defer rows.Close()
+
+	var reminders []domain.PickupReminder
+	for rows.Next() {
+		var rem domain.PickupReminder
+		if err := rows.Scan(
+			&rem.RequestID, &rem.ClientTelegramID, &rem.DriverTelegramID, &rem.FromAddress, &rem.ToAddress,
+			&rem.Contact, &rem.DriverName, &rem.DriverPhone, &rem.PickupAt,
+		); err != nil {
+			r.logger.Error("Failed to scan pickup reminder", zap.Error(err))
+			continue
+		}
+		reminders = append(reminders, rem)
+	}
+	return reminders, rows.Err()
+}
+
+// MarkReminderSent records that the pickup reminder went out
+func (r *OrderRepository) MarkReminderSent(ctx context.Context, requestID string) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE delivery_requests
+		SET reminder_sent_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND reminder_sent_at IS NULL`, requestID)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark reminder sent: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rowsAffected > 0, nil
+}
+
+// GetStops returns drop-off points of an order ordered by seq
+func (r *OrderRepository) GetStops(ctx context.Context, requestID string) ([]domain.OrderStop, error) {
+	stops, err := r.GetStopsByRequestIDs(ctx, []string{requestID})
+	if err != nil {
+		return nil, err
+	}
+	return stops[requestID], nil
+}
+
+// GetStopsByRequestIDs загружает точки сразу для списка заявок (админка, история)
+func (r *OrderRepository) GetStopsByRequestIDs(ctx context.Context, requestIDs []string) (map[string][]domain.OrderStop, error) {
+	result := make(map[string][]domain.OrderStop)
+	if len(requestIDs) == 0 {
+		return result, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(requestIDs)), ",")
+	args := make([]interface{}, len(requestIDs))
+	for i, id := range requestIDs {
+		args[i] = id
+	}
+
+	query := `
+		SELECT id, request_id, seq, address, lat, lon, COALESCE(contact, ''), status, done_at
+		FROM order_stops
+		WHERE request_id IN (` + placeholders + `)
+		ORDER BY request_id, seq`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order stops: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var s domain.OrderStop
+		var doneAt sql.NullTime
+		if err := rows.Scan(&s.ID, &s.RequestID, &s.Seq, &s.Address, &s.Lat, &s.Lon, &s.Contact, &s.Status, &doneAt); err != nil {
+			r.logger.Error("Failed to scan order stop", zap.Error(err))
+			continue
+		}
+		if doneAt.Valid {
+			s.DoneAt = &doneAt.Time
+		}
+		result[s.RequestID] = append(result[s.RequestID], s)
+	}
+	return result, rows.Err()
+}
+
+// MarkStopDone отмечает точку выгрузки выполненной. Только водитель, принявший заявку,
+// и только пока заявка активна. False — точка уже отмечена или нет доступа.
+func (r *OrderRepository) MarkStopDone(ctx context.Context, requestID string, seq int, driverID string) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE order_stops
+		SET status = 'done', done_at = CURRENT_TIMESTAMP
+		WHERE request_id = ? AND seq = ? AND status = 'pending'
+		  AND EXISTS (
+			SELECT 1 FROM delivery_requests dr
+			WHERE dr.id = order_stops.request_id
+			  AND COALESCE(dr.driver_id, dr.matched_driver_id) = ?
+			  AND dr.status IN ('pending', 'matched', 'in_progress')
+		  )`, requestID, seq, driverID)
+	if err != nil {
+		r.logger.Error("Failed to mark stop done", zap.Error(err), zap.String("request_id", requestID), zap.Int("seq", seq))
+		return false, fmt.Errorf("failed to mark stop done: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rowsAffected > 0, nil
+}
+
+// StartPickup переводит заявку в in_progress, когда назначенный водитель забрал груз,
+// и сохраняет код подтверждения для получателя.
+func (r *OrderRepository) StartPickup(ctx context.Context, requestID, driverID, code string) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE delivery_requests
+		SET status = 'in_progress', picked_up_at = CURRENT_TIMESTAMP,
+		    confirm_code = ?, confirm_attempts = 0
+		WHERE id = ?
+		  AND COALESCE(driver_id, matched_driver_id) = ?
+		  AND status IN ('pending', 'matched')`, code, requestID, driverID)
+	if err != nil {
+		r.logger.Error("Failed to start pickup", zap.Error(err), zap.String("request_id", requestID))
+		return false, fmt.Errorf("failed to start pickup: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rowsAffected > 0, nil
+}
+
+// CancelByDriver returns an assigned order to the pending pool before pickup, records the
+// reason and bumps the driver's cancel counter. If the driver reached policy.Threshold
+// cancellations within policy.Window, dispatch is paused until the returned time.
+// ok is false if the order is not assigned to this driver or is already picked up.
+func (r *OrderRepository) CancelByDriver(ctx context.Context, requestID, driverID, reason, comment string, policy domain.CancelPolicy) (ok bool, cooldownUntil *time.Time, err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if ok, err := reopenOrder(ctx, tx, requestID, driverID); err != nil || !ok {
+		return false, nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO driver_cancellations (request_id, driver_id, reason, comment) VALUES (?, ?, ?, ?)`,
+		requestID, driverID, reason, comment); err != nil {
+		return false, nil, fmt.Errorf("failed to record cancellation: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE drivers SET cancel_count = COALESCE(cancel_count, 0) + 1 WHERE id = ?`, driverID); err != nil {
+		return false, nil, fmt.Errorf("failed to count cancellation: %w", err)
+	}
+
+	var recent int
+	since := time.Now().Add(-policy.Window).UTC().Format("2006-01-02 15:04:05")
+	if err := tx.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM driver_cancellations WHERE driver_id = ? AND created_at >= ?`,
+		driverID, since).Scan(&recent); err != nil {
+		return false, nil, fmt.Errorf("failed to count recent cancellations: %w", err)
+	}
+	if recent >= policy.Threshold {
+		until := time.Now().Add(policy.Cooldown).UTC()
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE drivers SET dispatch_cooldown_until = ? WHERE id = ?`,
+			until.Format("2006-01-02 15:04:05"), driverID); err != nil {
+			return false, nil, fmt.Errorf("failed to set dispatch cooldown: %w", err)
+		}
+		cooldownUntil = &until
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, nil, fmt.Errorf("failed to commit cancellation: %w", err)
+	}
+	return true, cooldownUntil, nil
+}
+
+// ReleaseByDriver returns an assigned order to the pending pool before pickup without
+// counting it as the driver's cancellation — the driver closed their own trip.
+// ok is false if the order is not assigned to this driver or is already picked up.
+func (r *OrderRepository) ReleaseByDriver(ctx context.Context, requestID, driverID string) (ok bool, err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if ok, err := reopenOrder(ctx, tx, requestID, driverID); err != nil || !ok {
+		return false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit order release: %w", err)
+	}
+	return true, nil
+}
+
+// reopenOrder снимает водителя с заявки до погрузки и возвращает её в поиск
+func reopenOrder(ctx context.Context, tx *sql.Tx, requestID, driverID string) (bool, error) {
+	result, err := tx.ExecContext(ctx, `
+		UPDATE delivery_requests
+		SET status = 'pending', driver_id = NULL, matched_driver_id = NULL, accepted_at = NULL,
+		    confirm_code = '', confirm_attempts = 0,
+		    preferred_driver_id = NULL, preferred_until = NULL
+		WHERE id = ?
+		  AND COALESCE(driver_id, matched_driver_id) = ?
+		  AND status IN ('pending', 'matched')
+		  AND picked_up_at IS NULL`, requestID, driverID)
+	if err != nil {
+		return false, fmt.Errorf("failed to reopen order: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return false, nil
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE driver_matches SET status = 'cancelled'
+		WHERE delivery_request_id = ? AND driver_id = ? AND status = 'accepted'`, requestID, driverID); err != nil {
+		return false, fmt.Errorf("failed to cancel match: %w", err)
+	}
+	return true, nil
+}
+
+// ConfirmDelivery сверяет код получателя и завершает заявку. Неверный код увеличивает
+// счётчик попыток; после maxAttempts помогает только администратор: ResetConfirmCode
+// или CompleteDeliveryByAdmin.
+func (r *OrderRepository) ConfirmDelivery(ctx context.Context, requestID, driverID, code string, maxAttempts int) (domain.ConfirmResult, int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return domain.ConfirmNotAllowed, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var storedCode string
+	var attempts int
+	err = tx.QueryRowContext(ctx, `
+		SELECT COALESCE(confirm_code, ''), COALESCE(confirm_attempts, 0)
+		FROM delivery_requests
+		WHERE id = ? AND COALESCE(driver_id, matched_driver_id) = ? AND status = 'in_progress'`,
+		requestID, driverID).Scan(&storedCode, &attempts)
+	if err == sql.ErrNoRows || (err == nil && storedCode == "") {
+		return domain.ConfirmNotAllowed, 0, nil
+	}
+	if err != nil {
+		return domain.ConfirmNotAllowed, 0, fmt.Errorf("failed to load confirm code: %w", err)
+	}
+	if attempts >= maxAttempts {
+		return domain.ConfirmLocked, 0, nil
+	}
+
+	if subtle.ConstantTimeCompare([]byte(storedCode), []byte(code)) != 1 {
+		if _, err := tx.ExecContext(ctx, `UPDATE delivery_requests SET confirm_attempts = confirm_attempts + 1 WHERE id = ?`, requestID); err != nil {
+			return domain.ConfirmNotAllowed, 0, fmt.Errorf("failed to count confirm attempt: %w", err)
+		}
+		if err := tx.Commit(); err != nil {
+			return domain.ConfirmNotAllowed, 0, fmt.Errorf("failed to commit: %w", err)
+		}
+		left := maxAttempts - attempts - 1
+		if left <= 0 {
+			return domain.ConfirmLocked, 0, nil
+		}
+		return domain.ConfirmWrongCode, left, nil
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE delivery_requests
+		SET status = 'completed', completed_at = CURRENT_TIMESTAMP
+		WHERE id = ?`, requestID); err != nil {
+		return domain.ConfirmNotAllowed, 0, fmt.Errorf("failed to complete order: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return domain.ConfirmNotAllowed, 0, fmt.Errorf("failed to commit: %w", err)
+	}
+	return domain.ConfirmOK, maxAttempts - attempts, nil
+}
+
+// ResetConfirmCode выдаёт заявке в пути новый код и обнуляет счётчик попыток.
+// Вызывается администратором, когда водитель исчерпал попытки ввода кода.
+func (r *OrderRepository) ResetConfirmCode(ctx context.Context, requestID, code string) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE delivery_requests
+		SET confirm_code = ?, confirm_attempts = 0
+		WHERE id = ? AND status = 'in_progress'`, code, requestID)
+	if err != nil {
+		r.logger.Error("Failed to reset confirm code", zap.Error(err), zap.String("request_id", requestID))
+		return false, fmt.Errorf("failed to reset confirm code: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rowsAffected > 0, nil
+}
+
+// CompleteDeliveryByAdmin завершает заявку в пути без кода получателя
+func (r *OrderRepository) CompleteDeliveryByAdmin(ctx context.Context, requestID string) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE delivery_requests
+		SET status = 'completed', completed_at = CURRENT_TIMESTAMP,
+		    confirm_code = '', confirm_attempts = 0
+		WHERE id = ? AND status = 'in_progress'`, requestID)
+	if err != nil {
+		r.logger.Error("Failed to complete delivery", zap.Error(err), zap.String("request_id", requestID))
+		return false, fmt.Errorf("failed to complete delivery: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rowsAffected > 0, nil
+}
+
+// AddProofs прикрепляет фото погрузки/выгрузки к заявке
+func (r *OrderRepository) AddProofs(ctx context.Context, requestID, driverID, kind string, paths []string) error {
+	for _, p := range paths {
+		if _, err := r.db.ExecContext(ctx, `
+			INSERT INTO order_proofs (request_id, kind, photo_path, driver_id)
+			VALUES (?, ?, ?, ?)`, requestID, kind, p, driverID); err != nil {
+			r.logger.Error("Failed to save order proof", zap.Error(err), zap.String("request_id", requestID))
+			return fmt.Errorf("failed to save order proof: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetProofsByRequestIDs возвращает фото подтверждения, сгруппированные по заявке
+func (r *OrderRepository) GetProofsByRequestIDs(ctx context.Context, requestIDs []string) (map[string][]domain.OrderProof, error) {
+	result := make(map[string][]domain.OrderProof)
+	if len(requestIDs) == 0 {
+		return result, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(requestIDs)), ",")
+	args := make([]interface{}, len(requestIDs))
+	for i, id := range requestIDs {
+		args[i] = id
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, request_id, kind, photo_path, COALESCE(driver_id, ''), created_at
+		FROM order_proofs
+		WHERE request_id IN (`+placeholders+`)
+		ORDER BY request_id, id`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order proofs: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p domain.OrderProof
+		if err := rows.Scan(&p.ID, &p.RequestID, &p.Kind, &p.PhotoPath, &p.DriverID, &p.CreatedAt); err != nil {
+			r.logger.Error("Failed to scan order proof", zap.Error(err))
+			continue
+		}
+		result[p.RequestID] = append(result[p.RequestID], p)
+	}
+	return result, rows.Err()
+}
+
+// GetProofs — фото подтверждения одной заявки
+func (r *OrderRepository) GetProofs(ctx context.Context, requestID string) ([]domain.OrderProof, error) {
+	proofs, err := r.GetProofsByRequestIDs(ctx, []string{requestID})
+	if err != nil {
+		return nil, err
+	}
+	return proofs[requestID], nil
+}
+
+// EnsureTrackingToken returns the order's tracking token, storing newToken if there is none yet.
+// Returns "" if the order does not belong to the client or is already closed.
+func (r *OrderRepository) EnsureTrackingToken(ctx context.Context, requestID string, telegramID int64, newToken string) (string, error) {
+	if _, err := r.db.ExecContext(ctx, `
+		UPDATE delivery_requests SET tracking_token = ?
+		WHERE id = ? AND telegram_id = ? AND tracking_token IS NULL
+		  AND status IN ('pending', 'matched', 'in_progress')`,
+		newToken, requestID, telegramID); err != nil {
+		return "", fmt.Errorf("failed to set tracking token: %w", err)
+	}
+
+	var token sql.NullString
+	err := r.db.QueryRowContext(ctx, `
+		SELECT tracking_token FROM delivery_requests
+		WHERE id = ? AND telegram_id = ? AND status IN ('pending', 'matched', 'in_progress')`,
+		requestID, telegramID).Scan(&token)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get tracking token: %w", err)
+	}
+	return token.String, nil
+}
+
+// RevokeTrackingToken отключает ссылку; следующий запрос выдаст новую
+func (r *OrderRepository) RevokeTrackingToken(ctx context.Context, requestID string, telegramID int64) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE delivery_requests SET tracking_token = NULL
+		WHERE id = ? AND telegram_id = ? AND tracking_token IS NOT NULL`, requestID, telegramID)
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke tracking token: %w", err)
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+// GetTrackingByToken returns the public view of the order, or nil if the token is unknown
+func (r *OrderRepository) GetTrackingByToken(ctx context.Context, token string) (*domain.OrderTracking, error) {
+	var t domain.OrderTracking
+	var acceptedAt, pickedUpAt, completedAt sql.NullTime
+	err := r.db.QueryRowContext(ctx, `
+		SELECT r.id, r.status, r.from_address, r.to_address, r.created_at, r.accepted_at, r.picked_up_at, r.completed_at,
+		       COALESCE(d.id, ''), COALESCE(d.first_name, ''), COALESCE(d.truck_type, ''), COALESCE(d.truck_number, '')
+		FROM delivery_requests r
+		LEFT JOIN drivers d ON d.id = COALESCE(r.driver_id, r.matched_driver_id)
+		WHERE r.tracking_token = ?`, token).Scan(
+		&t.RequestID, &t.Status, &t.FromAddress, &t.ToAddress, &t.CreatedAt, &acceptedAt, &pickedUpAt, &completedAt,
+		&t.DriverID, &t.DriverFirstName, &t.TruckType, &t.TruckNumber)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tracking: %w", err)
+	}
+	if acceptedAt.Valid {
+		t.AcceptedAt = &acceptedAt.Time
+	}
+	if pickedUpAt.Valid {
+		t.PickedUpAt = &pickedUpAt.Time
+	}
+	if completedAt.Valid {
+		t.CompletedAt = &completedAt.Time
+	}
+
+	stops, err := r.GetStops(ctx, t.RequestID)
+	if err != nil {
+		return nil, err
+	}
+	// контакты получателей на точках публично не показываем
+	for i := range stops {
+		stops[i].Contact = ""
+	}
+	t.Stops = stops
+	return &t, nil
+}
+
+// GetPriceSamples returns outcomes of past orders for the price suggestion:
+// same truck type, distance in [distLo, distHi) (distHi = 0 — без верхней границы), created after since.
+// Принятой считается заявка, которую взял водитель; истёкшая — непринятой.
+// Отменённые клиентом до назначения водителя и ещё ожидающие не учитываются.
+func (r *OrderRepository) GetPriceSamples(ctx context.Context, truckType string, distLo, distHi float64, since time.Time) ([]domain.PriceSample, error) {
+	query := `
+		SELECT price,
+		       CASE WHEN status IN ('matched', 'in_progress', 'completed')
+		              OR COALESCE(driver_id, matched_driver_id, '') != '' THEN 1 ELSE 0 END,
+		       pickup_at, created_at
+		FROM delivery_requests
+		WHERE truck_type = ?
+		  AND distance_km >= ?
+		  AND created_at >= ?
+		  AND (status IN ('matched', 'in_progress', 'completed', 'expired')
+		       OR COALESCE(driver_id, matched_driver_id, '') != '')`
+	args := []interface{}{truckType, distLo, since.UTC().Format("2006-01-02 15:04:05")}
+	if distHi > 0 {
+		query += ` AND distance_km < ?`
+		args = append(args, distHi)
+	}
+	query += ` ORDER BY created_at DESC LIMIT 2000`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query price samples: %w", err)
+	}
+	defer rows.Close()
+
+	var out []domain.PriceSample
+	for rows.Next() {
+		var s domain.PriceSample
+		var pickupAt sql.NullTime
+		if err := rows.Scan(&s.Price, &s.Accepted, &pickupAt, &s.PickupAt); err != nil {
+			r.logger.Error("Failed to scan price sample", zap.Error(err))
+			continue
+		}
+		if pickupAt.Valid {
+			s.PickupAt = pickupAt.Time
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+// GetPendingOrigins returns pickup points of pending orders that drivers currently see
+func (r *OrderRepository) GetPendingOrigins(ctx context.Context) ([]domain.GeoPoint, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT from_lat, from_lon
+		FROM delivery_requests
+		WHERE status = 'pending'
+		  AND dispatched_at IS NOT NULL
+		  AND (preferred_driver_id IS NULL OR preferred_until <= datetime('now'))
+		  AND (from_lat != 0 OR from_lon != 0)`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pending origins: %w", err)
+	}
+	defer rows.Close()
+
+	var out []domain.GeoPoint
+	for rows.Next() {
+		var p domain.GeoPoint
+		if err := rows.Scan(&p.Lat, &p.Lon); err != nil {
+			return nil, fmt.Errorf("failed to scan pending origin: %w", err)
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+// GetOrderPoints returns orders created since f.Since for the demand heatmap.
+// Отменённые клиентом не учитываются — это не спрос.
+func (r *OrderRepository) GetOrderPoints(ctx context.Context, f domain.MapFilter) ([]domain.OrderPoint, error) {
+	query := `
+		SELECT id, from_lat, from_lon, to_lat, to_lon, price, COALESCE(truck_type, ''), status, created_at
+		FROM delivery_requests
+		WHERE created_at >= ?
+		  AND status != 'cancelled'`
+	args := []interface{}{f.Since.UTC().Format("2006-01-02 15:04:05")}
+	query, args = orderMapFilter(query, args, f)
+	query += ` ORDER BY created_at DESC LIMIT 5000`
+
+	return r.queryOrderPoints(ctx, query, args)
+}
+
+// GetPendingOrderPoints returns pending orders that drivers currently see
+func (r *OrderRepository) GetPendingOrderPoints(ctx context.Context, f domain.MapFilter) ([]domain.OrderPoint, error) {
+	query := `
+		SELECT id, from_lat, from_lon, to_lat, to_lon, price, COALESCE(truck_type, ''), status, created_at
+		FROM delivery_requests
+		WHERE status = 'pending'
+		  AND dispatched_at IS NOT NULL
+		  AND (preferred_driver_id IS NULL OR preferred_until <= datetime('now'))`
+	query, args := orderMapFilter(query, nil, f)
+	query += ` ORDER BY created_at DESC LIMIT 500`
+
+	return r.queryOrderPoints(ctx, query, args)
+}
+
+func orderMapFilter(query string, args []interface{}, f domain.MapFilter) (string, []interface{}) {
+	if f.City != "" {
+		query += ` AND from_address LIKE '%' || ? || '%'`
+		args = append(args, f.City)
+	}
+	if f.TruckType != "" {
+		query += ` AND truck_type = ?`
+		args = append(args, f.TruckType)
+	}
+	return query, args
+}
+
+func (r *OrderRepository) queryOrderPoints(ctx context.Context, query string, args []interface{}) ([]domain.OrderPoint, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query order points: %w", err)
+	}
+	defer rows.Close()
+
+	var out []domain.OrderPoint
+	for rows.Next() {
+		var p domain.OrderPoint
+		if err := rows.Scan(&p.ID, &p.From.Lat, &p.From.Lon, &p.To.Lat, &p.To.Lon, &p.Price, &p.TruckType, &p.Status, &p.CreatedAt); err != nil {
+			r.logger.Error("Failed to scan order point", zap.Error(err))
+			continue
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
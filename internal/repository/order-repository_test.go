@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"tezjet/internal/domain"
+
+	"go.uber.org/zap"
+)
+
+func TestConfirmDeliveryAttempts(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	repo := NewOrderRepository(db, zap.NewNop())
+
+	insertTestOrder(t, db, "o1", domain.DeliveryStatusMatched, "d1")
+	if ok, err := repo.StartPickup(ctx, "o1", "d1", "1234"); err != nil || !ok {
+		t.Fatalf("StartPickup() = %v, %v", ok, err)
+	}
+
+	const maxAttempts = 3
+	steps := []struct {
+		name     string
+		driverID string
+		code     string
+		want     domain.ConfirmResult
+		wantLeft int
+	}{
+		{"other driver", "d2", "1234", domain.ConfirmNotAllowed, 0},
+		{"first wrong code", "d1", "0000", domain.ConfirmWrongCode, 2},
+		{"second wrong code", "d1", "1111", domain.ConfirmWrongCode, 1},
+		{"last wrong code locks", "d1", "2222", domain.ConfirmLocked, 0},
+		{"right code after lock", "d1", "1234", domain.ConfirmLocked, 0},
+	}
+	for _, s := range steps {
+		got, left, err := repo.ConfirmDelivery(ctx, "o1", s.driverID, s.code, maxAttempts)
+		if err != nil {
+			t.Fatalf("%s: %v", s.name, err)
+		}
+		if got != s.want || left != s.wantLeft {
+			t.Errorf("%s: ConfirmDelivery() = %v, %d, want %v, %d", s.name, got, left, s.want, s.wantLeft)
+		}
+	}
+
+	if ok, err := repo.ResetConfirmCode(ctx, "o1", "5678"); err != nil || !ok {
+		t.Fatalf("ResetConfirmCode() = %v, %v", ok, err)
+	}
+	if got, _, _ := repo.ConfirmDelivery(ctx, "o1", "d1", "1234", maxAttempts); got != domain.ConfirmWrongCode {
+		t.Errorf("old code after reset = %v, want ConfirmWrongCode", got)
+	}
+	got, left, err := repo.ConfirmDelivery(ctx, "o1", "d1", "5678", maxAttempts)
+	if err != nil || got != domain.ConfirmOK || left != 2 {
+		t.Errorf("new code after reset = %v, %d, %v, want ConfirmOK, 2", got, left, err)
+	}
+
+	var status string
+	if err := db.QueryRow(`SELECT status FROM delivery_requests WHERE id = 'o1'`).Scan(&status); err != nil {
+		t.Fatal(err)
+	}
+	if status != domain.DeliveryStatusCompleted {
+		t.Errorf("status = %q, want %q", status, domain.DeliveryStatusCompleted)
+	}
+	if got, _, _ := repo.ConfirmDelivery(ctx, "o1", "d1", "5678", maxAttempts); got != domain.ConfirmNotAllowed {
+		t.Errorf("confirm completed order = %v, want ConfirmNotAllowed", got)
+	}
+}
+
+func TestCompleteDeliveryByAdmin(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	repo := NewOrderRepository(db, zap.NewNop())
+
+	insertTestOrder(t, db, "matched", domain.DeliveryStatusMatched, "d1")
+	insertTestOrder(t, db, "locked", domain.DeliveryStatusMatched, "d1")
+	if _, err := repo.StartPickup(ctx, "locked", "d1", "1234"); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, _, err := repo.ConfirmDelivery(ctx, "locked", "d1", "0000", 2); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		id   string
+		want bool
+	}{
+		{"matched", false},
+		{"locked", true},
+		{"locked", false},
+		{"missing", false},
+	}
+	for _, tt := range tests {
+		ok, err := repo.CompleteDeliveryByAdmin(ctx, tt.id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok != tt.want {
+			t.Errorf("CompleteDeliveryByAdmin(%q) = %v, want %v", tt.id, ok, tt.want)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"tezjet/internal/domain"
+
+	"go.uber.org/zap"
+)
+
+// PreferencesRepository хранит настройки рассылки заявок водителям
+type PreferencesRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewPreferencesRepository(db *sql.DB, logger *zap.Logger) *PreferencesRepository {
+	return &PreferencesRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Колонки настроек для LEFT JOIN driver_preferences p: у водителя без строки — значения по умолчанию
+const preferencesColumns = `
+	COALESCE(p.radius_km, 30), COALESCE(p.cities, ''), COALESCE(p.min_price, 0), COALESCE(p.truck_types, ''),
+	COALESCE(p.quiet_from, ''), COALESCE(p.quiet_to, ''), COALESCE(p.paused, 0), p.paused_until,
+	COALESCE(p.intercity_only, 0)`
+
+type preferencesScan struct {
+	cities, truckTypes string
+	pausedUntil        sql.NullTime
+}
+
+func preferencesScanDest(p *domain.DriverPreferences, s *preferencesScan) []interface{} {
+	return []interface{}{
+		&p.RadiusKm, &s.cities, &p.MinPrice, &s.truckTypes,
+		&p.QuietFrom, &p.QuietTo, &p.Paused, &s.pausedUntil,
+		&p.IntercityOnly,
+	}
+}
+
+func (s *preferencesScan) apply(p *domain.DriverPreferences) {
+	p.Cities = splitList(s.cities)
+	p.TruckTypes = splitList(s.truckTypes)
+	if s.pausedUntil.Valid {
+		t := s.pausedUntil.Time
+		p.PausedUntil = &t
+	}
+}
+
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+// GetPreferences returns driver preferences, defaults if the driver never changed them
+func (r *PreferencesRepository) GetPreferences(ctx context.Context, telegramID int64) (domain.DriverPreferences, error) {
+	p := domain.DefaultDriverPreferences(telegramID)
+	var s preferencesScan
+	err := r.db.QueryRowContext(ctx, `SELECT `+preferencesColumns+`
+		FROM (SELECT ? AS telegram_id) d
+		LEFT JOIN driver_preferences p ON p.telegram_id = d.telegram_id`, telegramID).
+		Scan(preferencesScanDest(&p, &s)...)
+	if err != nil {
+		r.logger.Error("Failed to get driver preferences", zap.Error(err), zap.Int64("telegram_id", telegramID))
+		return p, fmt.Errorf("failed to get driver preferences: %w", err)
+	}
+	s.apply(&p)
+	return p, nil
+}
+
+// SavePreferences creates or replaces driver preferences
+func (r *PreferencesRepository) SavePreferences(ctx context.Context, p *domain.DriverPreferences) error {
+	var pausedUntil interface{}
+	if p.PausedUntil != nil {
+		pausedUntil = p.PausedUntil.UTC().Format("2006-01-02 15:04:05")
+	}
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO driver_preferences (telegram_id, radius_km, cities, min_price, truck_types,
+			quiet_from, quiet_to, paused, paused_until, intercity_only)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(telegram_id) DO UPDATE SET
+			radius_km = excluded.radius_km,
+			cities = excluded.cities,
+			min_price = excluded.min_price,
+			truck_types = excluded.truck_types,
+			quiet_from = excluded.quiet_from,
+			quiet_to = excluded.quiet_to,
+			paused = excluded.paused,
+			paused_until = excluded.paused_until,
+			intercity_only = excluded.intercity_only,
+			updated_at = CURRENT_TIMESTAMP`,
+		p.TelegramID, p.EffectiveRadius(), strings.Join(p.Cities, ","), p.MinPrice, strings.Join(p.TruckTypes, ","),
+		p.QuietFrom, p.QuietTo, p.Paused, pausedUntil, p.IntercityOnly)
+	if err != nil {
+		r.logger.Error("Failed to save driver preferences", zap.Error(err), zap.Int64("telegram_id", p.TelegramID))
+		return fmt.Errorf("failed to save driver preferences: %w", err)
+	}
+	return nil
+}
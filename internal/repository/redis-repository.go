@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"tezjet/internal/domain"
@@ -196,6 +197,101 @@ func (r *RedisRepository) ClearAllUserStates(ctx context.Context, userID int64)
 	return nil
 }
 
+// Driver live position: ключ живёт ttl, после остановки трансляции позиция пропадает сама
+func (r *RedisRepository) SaveDriverPosition(ctx context.Context, driverID string, pos domain.DriverPosition, ttl time.Duration) error {
+	key := fmt.Sprintf("driver_pos:%s", driverID)
+
+	data, err := json.Marshal(pos)
+	if err != nil {
+		return fmt.Errorf("failed to marshal driver position: %w", err)
+	}
+
+	if err := r.client.Set(ctx, key, data, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to save driver position to redis: %w", err)
+	}
+	return nil
+}
+
+func (r *RedisRepository) GetDriverPosition(ctx context.Context, driverID string) (*domain.DriverPosition, error) {
+	key := fmt.Sprintf("driver_pos:%s", driverID)
+
+	data, err := r.client.Get(ctx, key).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get driver position from redis: %w", err)
+	}
+
+	var pos domain.DriverPosition
+	if err := json.Unmarshal([]byte(data), &pos); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal driver position: %w", err)
+	}
+	return &pos, nil
+}
+
+// ListDriverPositions returns live positions of all drivers who are broadcasting location, keyed by driver ID
+func (r *RedisRepository) ListDriverPositions(ctx context.Context) (map[string]domain.DriverPosition, error) {
+	var keys []string
+	iter := r.client.Scan(ctx, 0, "driver_pos:*", 500).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan driver positions: %w", err)
+	}
+
+	out := make(map[string]domain.DriverPosition, len(keys))
+	if len(keys) == 0 {
+		return out, nil
+	}
+	values, err := r.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get driver positions from redis: %w", err)
+	}
+	for i, v := range values {
+		data, ok := v.(string)
+		if !ok { // ключ истёк между SCAN и MGET
+			continue
+		}
+		var pos domain.DriverPosition
+		if err := json.Unmarshal([]byte(data), &pos); err != nil {
+			continue
+		}
+		out[strings.TrimPrefix(keys[i], "driver_pos:")] = pos
+	}
+	return out, nil
+}
+
+// Surge zones: пересчитываются фоновой задачей, ключ живёт ttl на случай её остановки
+func (r *RedisRepository) SaveSurgeZones(ctx context.Context, zones []domain.SurgeZone, ttl time.Duration) error {
+	data, err := json.Marshal(zones)
+	if err != nil {
+		return fmt.Errorf("failed to marshal surge zones: %w", err)
+	}
+
+	if err := r.client.Set(ctx, "surge_zones", data, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to save surge zones to redis: %w", err)
+	}
+	return nil
+}
+
+func (r *RedisRepository) GetSurgeZones(ctx context.Context) ([]domain.SurgeZone, error) {
+	data, err := r.client.Get(ctx, "surge_zones").Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get surge zones from redis: %w", err)
+	}
+
+	var zones []domain.SurgeZone
+	if err := json.Unmarshal([]byte(data), &zones); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal surge zones: %w", err)
+	}
+	return zones, nil
+}
+
 // Health check method
 func (r *RedisRepository) Ping(ctx context.Context) error {
 	return r.client.Ping(ctx).Err()
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"database/sql"
+	"testing"
+
+	"tezjet/traits/database"
+
+	_ "github.com/mattn/go-sqlite3"
+	"go.uber.org/zap"
+)
+
+// newTestDB — схема приложения в памяти; одно соединение, иначе у каждого своя база
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = db.Close() })
+	if err := database.CreateTables(db, zap.NewNop()); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// insertTestOrder добавляет заявку с минимально необходимыми полями
+func insertTestOrder(t *testing.T, db *sql.DB, id, status, driverID string) {
+	t.Helper()
+	if _, err := db.Exec(`
+		INSERT INTO delivery_requests (id, telegram_id, from_address, from_lat, from_lon,
+			to_address, to_lat, to_lon, price, contact, status, driver_id)
+		VALUES (?, 1, 'Абая 1', 43.24, 76.91, 'Толе би 2', 43.25, 76.92, 5000, '+77010000000', ?, NULLIF(?, ''))`,
+		id, status, driverID); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// insertTestDriver добавляет водителя с заглушками в обязательных полях
+func insertTestDriver(t *testing.T, db *sql.DB, id string, telegramID int64) {
+	t.Helper()
+	if _, err := db.Exec(`
+		INSERT INTO drivers (id, telegram_id, first_name, last_name, birthday, contact_number,
+			start_city, latitude, longitude, profile_photo, license_front, license_back, status)
+		VALUES (?, ?, 'Test', 'Driver', '1990-01-01', '+77010000000', 'Алматы', 43.24, 76.91, '', '', '', 'approved')`,
+		id, telegramID); err != nil {
+		t.Fatal(err)
+	}
+}
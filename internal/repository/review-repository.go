@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"tezjet/internal/domain"
+
+	"go.uber.org/zap"
+)
+
+// ReviewRepository хранит взаимные оценки клиента и водителя после завершения заявки
+type ReviewRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewReviewRepository(db *sql.DB, logger *zap.Logger) *ReviewRepository {
+	return &ReviewRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// SaveReview stores a rating and recomputes the smoothed aggregate of the rated side.
+// Returns false if this side has already rated the order.
+func (r *ReviewRepository) SaveReview(ctx context.Context, rv *domain.OrderReview) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO order_reviews (request_id, rater_role, rater_telegram_id, driver_id, client_telegram_id, rating, comment)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(request_id, rater_role) DO NOTHING`,
+		rv.RequestID, rv.RaterRole, rv.RaterTelegramID, rv.DriverID, rv.ClientTelegramID, rv.Rating, rv.Comment)
+	if err != nil {
+		return false, fmt.Errorf("failed to insert review: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return false, nil
+	}
+
+	var sum float64
+	var count int
+	if rv.RaterRole == domain.ReviewerClient {
+		// клиент оценил водителя
+		if err := tx.QueryRowContext(ctx, `
+			SELECT COALESCE(SUM(rating), 0), COUNT(*) FROM order_reviews
+			WHERE driver_id = ? AND rater_role = 'client'`, rv.DriverID).Scan(&sum, &count); err != nil {
+			return false, fmt.Errorf("failed to aggregate driver rating: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE drivers SET rating = ?, rating_count = ? WHERE id = ?`,
+			domain.SmoothedRating(sum, count), count, rv.DriverID); err != nil {
+			return false, fmt.Errorf("failed to update driver rating: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE driver_matches SET driver_rating = ?, client_comment = ?
+			WHERE delivery_request_id = ? AND driver_id = ? AND status = 'accepted'`,
+			rv.Rating, rv.Comment, rv.RequestID, rv.DriverID); err != nil {
+			return false, fmt.Errorf("failed to update match rating: %w", err)
+		}
+	} else {
+		// водитель оценил клиента
+		if err := tx.QueryRowContext(ctx, `
+			SELECT COALESCE(SUM(rating), 0), COUNT(*) FROM order_reviews
+			WHERE client_telegram_id = ? AND rater_role = 'driver'`, rv.ClientTelegramID).Scan(&sum, &count); err != nil {
+			return false, fmt.Errorf("failed to aggregate client rating: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO client_ratings (telegram_id, rating, rating_count, updated_at)
+			VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+			ON CONFLICT(telegram_id) DO UPDATE SET
+				rating = excluded.rating,
+				rating_count = excluded.rating_count,
+				updated_at = CURRENT_TIMESTAMP`,
+			rv.ClientTelegramID, domain.SmoothedRating(sum, count), count); err != nil {
+			return false, fmt.Errorf("failed to update client rating: %w", err)
+		}
+		// driver_comment — комментарий водителя к ставке, текст отзыва остаётся в order_reviews
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE driver_matches SET client_rating = ?
+			WHERE delivery_request_id = ? AND driver_id = ? AND status = 'accepted'`,
+			rv.Rating, rv.RequestID, rv.DriverID); err != nil {
+			return false, fmt.Errorf("failed to update match rating: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit review: %w", err)
+	}
+	return true, nil
+}
+
+// SetReviewPrompt запоминает сообщение бота, ответом на которое пользователь пришлёт комментарий
+func (r *ReviewRepository) SetReviewPrompt(ctx context.Context, requestID, role string, messageID int) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE order_reviews SET prompt_message_id = ?
+		WHERE request_id = ? AND rater_role = ?`, messageID, requestID, role)
+	if err != nil {
+		return fmt.Errorf("failed to set review prompt: %w", err)
+	}
+	return nil
+}
+
+// SetCommentByPrompt attaches a comment to the review whose prompt the user replied to.
+// Returns false if there is no such review or it already has a comment.
+func (r *ReviewRepository) SetCommentByPrompt(ctx context.Context, raterTelegramID int64, messageID int, comment string) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE order_reviews SET comment = ?, prompt_message_id = 0
+		WHERE rater_telegram_id = ? AND prompt_message_id = ? AND COALESCE(comment, '') = ''`,
+		comment, raterTelegramID, messageID)
+	if err != nil {
+		return false, fmt.Errorf("failed to set review comment: %w", err)
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+// SetComment — комментарий к уже поставленной оценке (из веб-приложения)
+func (r *ReviewRepository) SetComment(ctx context.Context, requestID, role string, raterTelegramID int64, comment string) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE order_reviews SET comment = ?, prompt_message_id = 0
+		WHERE request_id = ? AND rater_role = ? AND rater_telegram_id = ? AND COALESCE(comment, '') = ''`,
+		comment, requestID, role, raterTelegramID)
+	if err != nil {
+		return false, fmt.Errorf("failed to set review comment: %w", err)
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+// GetDriverReviews returns up to perDriver latest client reviews with a comment for each driver
+func (r *ReviewRepository) GetDriverReviews(ctx context.Context, driverIDs []string, perDriver int) (map[string][]domain.OrderReview, error) {
+	result := make(map[string][]domain.OrderReview)
+	if len(driverIDs) == 0 {
+		return result, nil
+	}
+
+	args := make([]interface{}, 0, len(driverIDs)+1)
+	for _, id := range driverIDs {
+		args = append(args, id)
+	}
+	args = append(args, perDriver)
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, request_id, rater_role, driver_id, rating, comment, created_at FROM (
+			SELECT id, request_id, rater_role, driver_id, rating, COALESCE(comment, '') AS comment, created_at,
+			       ROW_NUMBER() OVER (PARTITION BY driver_id ORDER BY created_at DESC, id DESC) AS rn
+			FROM order_reviews
+			WHERE rater_role = 'client' AND COALESCE(comment, '') <> ''
+			  AND driver_id IN (?`+strings.Repeat(",?", len(driverIDs)-1)+`)
+		) WHERE rn <= ?`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query driver reviews: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var rv domain.OrderReview
+		if err := rows.Scan(&rv.ID, &rv.RequestID, &rv.RaterRole, &rv.DriverID, &rv.Rating, &rv.Comment, &rv.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan review: %w", err)
+		}
+		result[rv.DriverID] = append(result[rv.DriverID], rv)
+	}
+	return result, rows.Err()
+}
+
+// GetClientRating — сглаженный рейтинг клиента; без оценок возвращает 0, 0
+func (r *ReviewRepository) GetClientRating(ctx context.Context, telegramID int64) (float64, int, error) {
+	var rating float64
+	var count int
+	err := r.db.QueryRowContext(ctx, `
+		SELECT rating, rating_count FROM client_ratings WHERE telegram_id = ?`, telegramID).Scan(&rating, &count)
+	if err == sql.ErrNoRows {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get client rating: %w", err)
+	}
+	return rating, count, nil
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"tezjet/internal/domain"
+
+	"go.uber.org/zap"
+)
+
+func TestSaveReview(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	repo := NewReviewRepository(db, zap.NewNop())
+
+	insertTestDriver(t, db, "d1", 100)
+	insertTestOrder(t, db, "o1", domain.DeliveryStatusCompleted, "d1")
+	if _, err := db.Exec(`
+		INSERT INTO driver_matches (id, driver_id, delivery_request_id, client_telegram_id, status, proposed_price, driver_comment)
+		VALUES ('m1', 'd1', 'o1', 1, 'accepted', 5000, 'Буду через 20 минут')`); err != nil {
+		t.Fatal(err)
+	}
+
+	reviews := []struct {
+		rv   domain.OrderReview
+		want bool
+	}{
+		{domain.OrderReview{RequestID: "o1", RaterRole: domain.ReviewerClient, RaterTelegramID: 1, DriverID: "d1", ClientTelegramID: 1, Rating: 5, Comment: "Отлично"}, true},
+		{domain.OrderReview{RequestID: "o1", RaterRole: domain.ReviewerClient, RaterTelegramID: 1, DriverID: "d1", ClientTelegramID: 1, Rating: 1}, false},
+		{domain.OrderReview{RequestID: "o1", RaterRole: domain.ReviewerDriver, RaterTelegramID: 100, DriverID: "d1", ClientTelegramID: 1, Rating: 4, Comment: "Долго ждал"}, true},
+	}
+	for _, tt := range reviews {
+		rv := tt.rv
+		saved, err := repo.SaveReview(ctx, &rv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if saved != tt.want {
+			t.Errorf("SaveReview(%s, %d) = %v, want %v", rv.RaterRole, rv.Rating, saved, tt.want)
+		}
+	}
+
+	var driverRating float64
+	var driverCount int
+	if err := db.QueryRow(`SELECT rating, rating_count FROM drivers WHERE id = 'd1'`).Scan(&driverRating, &driverCount); err != nil {
+		t.Fatal(err)
+	}
+	if driverCount != 1 || math.Abs(driverRating-domain.SmoothedRating(5, 1)) > 1e-9 {
+		t.Errorf("driver rating = %v (%d), want %v (1)", driverRating, driverCount, domain.SmoothedRating(5, 1))
+	}
+
+	var clientRating float64
+	if err := db.QueryRow(`SELECT rating FROM client_ratings WHERE telegram_id = 1`).Scan(&clientRating); err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(clientRating-domain.SmoothedRating(4, 1)) > 1e-9 {
+		t.Errorf("client rating = %v, want %v", clientRating, domain.SmoothedRating(4, 1))
+	}
+
+	var bidComment, clientComment string
+	if err := db.QueryRow(`SELECT driver_comment, client_comment FROM driver_matches WHERE id = 'm1'`).Scan(&bidComment, &clientComment); err != nil {
+		t.Fatal(err)
+	}
+	if bidComment != "Буду через 20 минут" {
+		t.Errorf("bid comment overwritten: %q", bidComment)
+	}
+	if clientComment != "Отлично" {
+		t.Errorf("client comment = %q, want %q", clientComment, "Отлично")
+	}
+}
@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"tezjet/internal/domain"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// SearchRepository хранит сохранённые поиски водителей клиентами
+type SearchRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewSearchRepository(db *sql.DB, logger *zap.Logger) *SearchRepository {
+	return &SearchRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+const savedSearchColumns = `
+	id, telegram_id, from_address, from_lat, from_lon, to_address, to_lat, to_lon,
+	truck_type, contact, radius_km, date_from, date_to, status, notified_at, created_at`
+
+func scanSavedSearch(scan func(dest ...interface{}) error) (*domain.SavedSearch, error) {
+	var s domain.SavedSearch
+	var notified sql.NullTime
+	if err := scan(&s.ID, &s.TelegramID, &s.FromAddress, &s.FromLat, &s.FromLon, &s.ToAddress, &s.ToLat, &s.ToLon,
+		&s.TruckType, &s.Contact, &s.RadiusKm, &s.DateFrom, &s.DateTo, &s.Status, &notified, &s.CreatedAt); err != nil {
+		return nil, err
+	}
+	if notified.Valid {
+		s.NotifiedAt = &notified.Time
+	}
+	return &s, nil
+}
+
+func (r *SearchRepository) querySearches(ctx context.Context, query string, args ...interface{}) ([]domain.SavedSearch, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []domain.SavedSearch{}
+	for rows.Next() {
+		s, err := scanSavedSearch(rows.Scan)
+		if err != nil {
+			r.logger.Error("Failed to scan saved search", zap.Error(err))
+			continue
+		}
+		out = append(out, *s)
+	}
+	return out, rows.Err()
+}
+
+// CreateSearch saves a search. Returns 0 when the client already has MaxSavedSearches active ones.
+func (r *SearchRepository) CreateSearch(ctx context.Context, s *domain.SavedSearch) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `
+		INSERT INTO saved_searches (telegram_id, from_address, from_lat, from_lon, to_address, to_lat, to_lon,
+			truck_type, contact, radius_km, date_from, date_to, status)
+		SELECT ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 'active'
+		WHERE (SELECT COUNT(1) FROM saved_searches WHERE telegram_id = ? AND status = 'active') < ?`,
+		s.TelegramID, s.FromAddress, s.FromLat, s.FromLon, s.ToAddress, s.ToLat, s.ToLon,
+		s.TruckType, s.Contact, s.EffectiveRadius(), sqliteTime(&s.DateFrom), sqliteTime(&s.DateTo),
+		s.TelegramID, domain.MaxSavedSearches,
+	)
+	if err != nil {
+		r.logger.Error("Failed to create saved search", zap.Error(err), zap.Int64("telegram_id", s.TelegramID))
+		return 0, fmt.Errorf("failed to create saved search: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return 0, nil
+	}
+	return result.LastInsertId()
+}
+
+// ListSearches returns the client's active searches, nearest window first
+func (r *SearchRepository) ListSearches(ctx context.Context, telegramID int64) ([]domain.SavedSearch, error) {
+	out, err := r.querySearches(ctx, `SELECT `+savedSearchColumns+`
+		FROM saved_searches
+		WHERE telegram_id = ? AND status = 'active'
+		ORDER BY date_from, id`, telegramID)
+	if err != nil {
+		r.logger.Error("Failed to list saved searches", zap.Error(err), zap.Int64("telegram_id", telegramID))
+		return nil, fmt.Errorf("failed to list saved searches: %w", err)
+	}
+	return out, nil
+}
+
+// GetSearch returns a search owned by telegramID, nil if not found
+func (r *SearchRepository) GetSearch(ctx context.Context, id, telegramID int64) (*domain.SavedSearch, error) {
+	s, err := scanSavedSearch(r.db.QueryRowContext(ctx, `SELECT `+savedSearchColumns+`
+		FROM saved_searches
+		WHERE id = ? AND telegram_id = ?`, id, telegramID).Scan)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get saved search: %w", err)
+	}
+	return s, nil
+}
+
+// DeleteSearch marks an active search as deleted
+func (r *SearchRepository) DeleteSearch(ctx context.Context, id, telegramID int64) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE saved_searches SET status = 'deleted'
+		WHERE id = ? AND telegram_id = ? AND status = 'active'`, id, telegramID)
+	if err != nil {
+		r.logger.Error("Failed to delete saved search", zap.Error(err), zap.Int64("search_id", id))
+		return false, fmt.Errorf("failed to delete saved search: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rowsAffected > 0, nil
+}
+
+// GetSearchesAt returns active searches whose date window covers departure.
+// Поиски самого водителя (excludeTelegramID) не возвращаются; маршрут сверяет вызывающий.
+func (r *SearchRepository) GetSearchesAt(ctx context.Context, departure time.Time, excludeTelegramID int64) ([]domain.SavedSearch, error) {
+	out, err := r.querySearches(ctx, `SELECT `+savedSearchColumns+`
+		FROM saved_searches
+		WHERE status = 'active' AND date_from <= ? AND date_to > ? AND telegram_id != ?`,
+		sqliteTime(&departure), sqliteTime(&departure), excludeTelegramID)
+	if err != nil {
+		r.logger.Error("Failed to get saved searches for trip", zap.Error(err))
+		return nil, fmt.Errorf("failed to get saved searches: %w", err)
+	}
+	return out, nil
+}
+
+// MarkNotified records when the client was last told about a matching trip
+func (r *SearchRepository) MarkNotified(ctx context.Context, id int64, at time.Time) error {
+	if _, err := r.db.ExecContext(ctx, `UPDATE saved_searches SET notified_at = ? WHERE id = ?`, sqliteTime(&at), id); err != nil {
+		return fmt.Errorf("failed to mark saved search notified: %w", err)
+	}
+	return nil
+}
+
+// ExpireSearches closes active searches whose date window has passed
+func (r *SearchRepository) ExpireSearches(ctx context.Context) (int64, error) {
+	now := time.Now()
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE saved_searches SET status = 'expired'
+		WHERE status = 'active' AND date_to <= ?`, sqliteTime(&now))
+	if err != nil {
+		r.logger.Error("Failed to expire saved searches", zap.Error(err))
+		return 0, fmt.Errorf("failed to expire saved searches: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"tezjet/internal/domain"
+
+	"go.uber.org/zap"
+)
+
+// TariffRepository хранит тарифы, которые задаёт администратор
+type TariffRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewTariffRepository(db *sql.DB, logger *zap.Logger) *TariffRepository {
+	return &TariffRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// ListTariffs returns all tariffs, defaults (empty city) first
+func (r *TariffRepository) ListTariffs(ctx context.Context) ([]domain.Tariff, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, city, truck_type, base_fare, per_km, per_min, min_price, created_at, updated_at
+		FROM tariffs
+		ORDER BY city, truck_type`)
+	if err != nil {
+		r.logger.Error("Failed to list tariffs", zap.Error(err))
+		return nil, fmt.Errorf("failed to list tariffs: %w", err)
+	}
+	defer rows.Close()
+
+	out := []domain.Tariff{}
+	for rows.Next() {
+		var t domain.Tariff
+		if err := rows.Scan(&t.ID, &t.City, &t.TruckType, &t.BaseFare, &t.PerKm, &t.PerMin, &t.MinPrice,
+			&t.CreatedAt, &t.UpdatedAt); err != nil {
+			r.logger.Error("Failed to scan tariff", zap.Error(err))
+			continue
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// SaveTariff создаёт тариф или обновляет существующий для той же пары город/тип машины
+func (r *TariffRepository) SaveTariff(ctx context.Context, t *domain.Tariff) error {
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO tariffs (city, truck_type, base_fare, per_km, per_min, min_price)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(city, truck_type) DO UPDATE SET
+			base_fare = excluded.base_fare,
+			per_km = excluded.per_km,
+			per_min = excluded.per_min,
+			min_price = excluded.min_price,
+			updated_at = CURRENT_TIMESTAMP
+		RETURNING id`,
+		t.City, t.TruckType, t.BaseFare, t.PerKm, t.PerMin, t.MinPrice).Scan(&t.ID)
+	if err != nil {
+		r.logger.Error("Failed to save tariff", zap.Error(err), zap.String("city", t.City), zap.String("truck_type", t.TruckType))
+		return fmt.Errorf("failed to save tariff: %w", err)
+	}
+	return nil
+}
+
+// DeleteTariff removes a tariff; false if it did not exist
+func (r *TariffRepository) DeleteTariff(ctx context.Context, id int64) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM tariffs WHERE id = ?`, id)
+	if err != nil {
+		r.logger.Error("Failed to delete tariff", zap.Error(err), zap.Int64("tariff_id", id))
+		return false, fmt.Errorf("failed to delete tariff: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rowsAffected > 0, nil
+}
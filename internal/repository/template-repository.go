@@ -0,0 +1,275 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"tezjet/internal/domain"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// TemplateRepository хранит шаблоны заявок клиентов и расписания повторяющихся заявок
+type TemplateRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewTemplateRepository(db *sql.DB, logger *zap.Logger) *TemplateRepository {
+	return &TemplateRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// SaveTemplate создаёт шаблон или перезаписывает существующий с тем же названием.
+// Returns false when the client already has MaxOrderTemplates and the name is new.
+func (r *TemplateRepository) SaveTemplate(ctx context.Context, t *domain.OrderTemplate) (bool, error) {
+	const q = `
+		INSERT INTO order_templates (telegram_id, name, payload)
+		SELECT ?, ?, ?
+		WHERE (SELECT COUNT(1) FROM order_templates WHERE telegram_id = ?) < ?
+		   OR EXISTS (SELECT 1 FROM order_templates WHERE telegram_id = ? AND name = ?)
+		ON CONFLICT(telegram_id, name) DO UPDATE SET
+			payload = excluded.payload, updated_at = CURRENT_TIMESTAMP`
+
+	result, err := r.db.ExecContext(ctx, q,
+		t.TelegramID, t.Name, string(t.Payload),
+		t.TelegramID, domain.MaxOrderTemplates, t.TelegramID, t.Name,
+	)
+	if err != nil {
+		r.logger.Error("Failed to save order template", zap.Error(err), zap.Int64("telegram_id", t.TelegramID))
+		return false, fmt.Errorf("failed to save order template: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rowsAffected > 0, nil
+}
+
+// ListTemplates returns the client's templates, recently updated first
+func (r *TemplateRepository) ListTemplates(ctx context.Context, telegramID int64) ([]domain.OrderTemplate, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, telegram_id, name, payload, created_at, updated_at
+		FROM order_templates
+		WHERE telegram_id = ?
+		ORDER BY updated_at DESC, id DESC`, telegramID)
+	if err != nil {
+		r.logger.Error("Failed to list order templates", zap.Error(err), zap.Int64("telegram_id", telegramID))
+		return nil, fmt.Errorf("failed to list order templates: %w", err)
+	}
+	defer rows.Close()
+
+	out := []domain.OrderTemplate{}
+	for rows.Next() {
+		var t domain.OrderTemplate
+		var payload string
+		if err := rows.Scan(&t.ID, &t.TelegramID, &t.Name, &payload, &t.CreatedAt, &t.UpdatedAt); err != nil {
+			r.logger.Error("Failed to scan order template", zap.Error(err))
+			continue
+		}
+		t.Payload = []byte(payload)
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// GetTemplate returns a template owned by telegramID, nil if not found
+func (r *TemplateRepository) GetTemplate(ctx context.Context, id, telegramID int64) (*domain.OrderTemplate, error) {
+	var t domain.OrderTemplate
+	var payload string
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, telegram_id, name, payload, created_at, updated_at
+		FROM order_templates
+		WHERE id = ? AND telegram_id = ?`, id, telegramID).
+		Scan(&t.ID, &t.TelegramID, &t.Name, &payload, &t.CreatedAt, &t.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order template: %w", err)
+	}
+	t.Payload = []byte(payload)
+	return &t, nil
+}
+
+// DeleteTemplate removes a template together with its schedules
+func (r *TemplateRepository) DeleteTemplate(ctx context.Context, id, telegramID int64) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM order_templates WHERE id = ? AND telegram_id = ?`, id, telegramID)
+	if err != nil {
+		r.logger.Error("Failed to delete order template", zap.Error(err), zap.Int64("template_id", id))
+		return false, fmt.Errorf("failed to delete order template: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rowsAffected > 0, nil
+}
+
+const recurringColumns = `
+	ro.id, ro.telegram_id, ro.template_id, t.name, ro.weekdays, ro.time_of_day, ro.status,
+	ro.next_pickup_at, COALESCE(ro.last_request_id, ''), ro.created_at, t.payload`
+
+func scanRecurring(scan func(dest ...interface{}) error) (*domain.RecurringOrder, error) {
+	var ro domain.RecurringOrder
+	var next sql.NullTime
+	var payload string
+	if err := scan(&ro.ID, &ro.TelegramID, &ro.TemplateID, &ro.TemplateName, &ro.Weekdays, &ro.TimeOfDay, &ro.Status,
+		&next, &ro.LastRequestID, &ro.CreatedAt, &payload); err != nil {
+		return nil, err
+	}
+	if next.Valid {
+		ro.NextPickupAt = &next.Time
+	}
+	ro.Days = domain.WeekdayDays(ro.Weekdays)
+	ro.Payload = []byte(payload)
+	return &ro, nil
+}
+
+// CreateRecurring adds a schedule. Returns 0 when the client already has MaxRecurringOrders.
+func (r *TemplateRepository) CreateRecurring(ctx context.Context, ro *domain.RecurringOrder) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `
+		INSERT INTO recurring_orders (telegram_id, template_id, weekdays, time_of_day, status, next_pickup_at)
+		SELECT ?, ?, ?, ?, 'active', ?
+		WHERE (SELECT COUNT(1) FROM recurring_orders WHERE telegram_id = ? AND status != 'cancelled') < ?`,
+		ro.TelegramID, ro.TemplateID, ro.Weekdays, ro.TimeOfDay, sqliteTime(ro.NextPickupAt),
+		ro.TelegramID, domain.MaxRecurringOrders)
+	if err != nil {
+		r.logger.Error("Failed to create recurring order", zap.Error(err), zap.Int64("telegram_id", ro.TelegramID))
+		return 0, fmt.Errorf("failed to create recurring order: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return 0, nil
+	}
+	return result.LastInsertId()
+}
+
+// ListRecurring returns the client's schedules except cancelled ones
+func (r *TemplateRepository) ListRecurring(ctx context.Context, telegramID int64) ([]domain.RecurringOrder, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+recurringColumns+`
+		FROM recurring_orders ro
+		JOIN order_templates t ON t.id = ro.template_id
+		WHERE ro.telegram_id = ? AND ro.status != 'cancelled'
+		ORDER BY ro.created_at DESC`, telegramID)
+	if err != nil {
+		r.logger.Error("Failed to list recurring orders", zap.Error(err), zap.Int64("telegram_id", telegramID))
+		return nil, fmt.Errorf("failed to list recurring orders: %w", err)
+	}
+	defer rows.Close()
+
+	out := []domain.RecurringOrder{}
+	for rows.Next() {
+		ro, err := scanRecurring(rows.Scan)
+		if err != nil {
+			r.logger.Error("Failed to scan recurring order", zap.Error(err))
+			continue
+		}
+		out = append(out, *ro)
+	}
+	return out, rows.Err()
+}
+
+// GetRecurring returns a schedule owned by telegramID, nil if not found
+func (r *TemplateRepository) GetRecurring(ctx context.Context, id, telegramID int64) (*domain.RecurringOrder, error) {
+	row := r.db.QueryRowContext(ctx, `
+		SELECT `+recurringColumns+`
+		FROM recurring_orders ro
+		JOIN order_templates t ON t.id = ro.template_id
+		WHERE ro.id = ? AND ro.telegram_id = ?`, id, telegramID)
+	ro, err := scanRecurring(row.Scan)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recurring order: %w", err)
+	}
+	return ro, nil
+}
+
+// SetRecurringStatus pauses, resumes or cancels a schedule.
+// При возобновлении next_pickup_at пересчитывается от текущего момента.
+func (r *TemplateRepository) SetRecurringStatus(ctx context.Context, id, telegramID int64, status string, nextPickupAt *time.Time) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE recurring_orders
+		SET status = ?, next_pickup_at = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND telegram_id = ? AND status != 'cancelled' AND status != ?`,
+		status, sqliteTime(nextPickupAt), id, telegramID, status)
+	if err != nil {
+		r.logger.Error("Failed to update recurring order", zap.Error(err), zap.Int64("recurring_id", id))
+		return false, fmt.Errorf("failed to update recurring order: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rowsAffected > 0, nil
+}
+
+// GetDueRecurring returns active schedules whose next pickup is within `ahead`
+func (r *TemplateRepository) GetDueRecurring(ctx context.Context, ahead time.Duration) ([]domain.RecurringOrder, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+recurringColumns+`
+		FROM recurring_orders ro
+		JOIN order_templates t ON t.id = ro.template_id
+		WHERE ro.status = 'active'
+		  AND ro.next_pickup_at IS NOT NULL
+		  AND ro.next_pickup_at <= datetime('now', ?)
+		ORDER BY ro.next_pickup_at ASC
+		LIMIT 100`, fmt.Sprintf("+%d seconds", int64(ahead.Seconds())))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get due recurring orders: %w", err)
+	}
+	defer rows.Close()
+
+	var out []domain.RecurringOrder
+	for rows.Next() {
+		ro, err := scanRecurring(rows.Scan)
+		if err != nil {
+			r.logger.Error("Failed to scan recurring order", zap.Error(err))
+			continue
+		}
+		out = append(out, *ro)
+	}
+	return out, rows.Err()
+}
+
+// AdvanceRecurring переносит расписание на следующую дату.
+// Условие по текущему next_pickup_at не даёт двум воркерам создать одну заявку дважды.
+func (r *TemplateRepository) AdvanceRecurring(ctx context.Context, id int64, current, next time.Time) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE recurring_orders
+		SET next_pickup_at = ?, last_run_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND status = 'active' AND next_pickup_at = ?`,
+		sqliteTime(&next), id, sqliteTime(&current))
+	if err != nil {
+		return false, fmt.Errorf("failed to advance recurring order: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rowsAffected > 0, nil
+}
+
+// SetLastRequest remembers the order created by the latest run
+func (r *TemplateRepository) SetLastRequest(ctx context.Context, id int64, requestID string) error {
+	if _, err := r.db.ExecContext(ctx, `
+		UPDATE recurring_orders SET last_request_id = ? WHERE id = ?`, requestID, id); err != nil {
+		return fmt.Errorf("failed to set last request: %w", err)
+	}
+	return nil
+}
+
+func sqliteTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.UTC().Format("2006-01-02 15:04:05")
+}
@@ -4,6 +4,7 @@ package database
 import (
 	"database/sql"
 	"os"
+	"strings"
 	"tezjet/config"
 	"time"
 
@@ -71,6 +72,7 @@ func CreateTables(db *sql.DB, logger *zap.Logger) error {
 		id_user BIGINT NOT NULL UNIQUE,
 		userName VARCHAR(255) NOT NULL,
 		dataRegistred VARCHAR(50) NOT NULL,
+		language_code TEXT DEFAULT '',
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	);
@@ -107,14 +109,37 @@ func CreateTables(db *sql.DB, logger *zap.Logger) error {
 		to_lon REAL NOT NULL,
 		distance_km REAL DEFAULT 0.0,
 		eta_min INTEGER DEFAULT 0,
-		price INTEGER NOT NULL CHECK (price >= 2000),
+		price INTEGER NOT NULL CHECK (price > 0),
 		truck_type TEXT DEFAULT '',
 		contact TEXT NOT NULL,
 		time_start TEXT DEFAULT '',
+		pickup_at DATETIME NULL,
+		dispatched_at DATETIME NULL,
+		reminder_sent_at DATETIME NULL,
 		comment TEXT DEFAULT '',
 		item_photo_path TEXT DEFAULT '',
-		status TEXT DEFAULT 'pending' CHECK (status IN ('pending', 'matched', 'in_progress', 'completed', 'cancelled')),
+		cargo_weight_kg REAL DEFAULT 0,
+		cargo_volume_m3 REAL DEFAULT 0,
+		cargo_length_cm INTEGER DEFAULT 0,
+		cargo_width_cm INTEGER DEFAULT 0,
+		cargo_height_cm INTEGER DEFAULT 0,
+		cargo_items INTEGER DEFAULT 0,
+		needs_loaders BOOLEAN DEFAULT FALSE,
+		fragile BOOLEAN DEFAULT FALSE,
+		floor INTEGER DEFAULT 0,
+		has_elevator BOOLEAN DEFAULT FALSE,
+		confirm_code TEXT DEFAULT '',
+		confirm_attempts INTEGER DEFAULT 0,
+		accepted_at DATETIME NULL,
+		picked_up_at DATETIME NULL,
+		tracking_token TEXT NULL,
+		preferred_driver_id TEXT NULL,
+		preferred_until DATETIME NULL,
+		recurring_id INTEGER NULL,
+		trip_id TEXT NULL,
+		status TEXT DEFAULT 'pending' CHECK (status IN ('pending', 'matched', 'in_progress', 'completed', 'cancelled', 'expired')),
 		completed_at DATETIME NULL,
+		expired_at DATETIME NULL,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		FOREIGN KEY (driver_id) REFERENCES drivers(id) ON DELETE SET NULL,
@@ -138,6 +163,15 @@ func CreateTables(db *sql.DB, logger *zap.Logger) error {
 		license_back TEXT NOT NULL,
 		truck_type TEXT DEFAULT '',
 		truck_number TEXT DEFAULT '',
+		max_weight_kg REAL DEFAULT 0,
+		max_volume_m3 REAL DEFAULT 0,
+		body_length_cm INTEGER DEFAULT 0,
+		body_width_cm INTEGER DEFAULT 0,
+		body_height_cm INTEGER DEFAULT 0,
+		rating REAL DEFAULT 0,
+		rating_count INTEGER DEFAULT 0,
+		cancel_count INTEGER DEFAULT 0,
+		dispatch_cooldown_until DATETIME NULL,
 		is_verified BOOLEAN DEFAULT FALSE,
 		status TEXT DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected', 'suspended')),
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
@@ -159,8 +193,11 @@ func CreateTables(db *sql.DB, logger *zap.Logger) error {
 		to_lon REAL NOT NULL DEFAULT 0.0,
 		distance_km REAL DEFAULT 0.0,
 		eta_min INTEGER DEFAULT 0,
-		price INTEGER NOT NULL DEFAULT 2000 CHECK (price >= 2000),
+		price INTEGER NOT NULL CHECK (price > 0),
 		truck_type TEXT DEFAULT 'any',
+		max_weight INTEGER DEFAULT 0,
+		booked_weight REAL DEFAULT 0,
+		booked_volume REAL DEFAULT 0,
 		start_time TEXT NOT NULL DEFAULT '',
 		departure_time DATETIME DEFAULT CURRENT_TIMESTAMP,
 		comment TEXT DEFAULT '',
@@ -168,13 +205,322 @@ func CreateTables(db *sql.DB, logger *zap.Logger) error {
 		has_whatsapp BOOLEAN DEFAULT FALSE,
 		has_telegram BOOLEAN DEFAULT FALSE,
 		telegram_username TEXT DEFAULT '',
-		status TEXT DEFAULT 'active' CHECK (status IN ('active', 'completed', 'cancelled')),
+		status TEXT DEFAULT 'active' CHECK (status IN ('active', 'full', 'completed', 'cancelled')),
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		FOREIGN KEY (driver_id) REFERENCES drivers(id) ON DELETE CASCADE
 	);`
 
-	for _, sql := range []string{offertaTable, justTable, usersTable, driversTable, driverTripsTable, deliveryRequestsTable} {
+	// История правок заявки клиентом
+	revisionsTable := `
+	CREATE TABLE IF NOT EXISTS delivery_request_revisions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		request_id TEXT NOT NULL,
+		telegram_id INTEGER NOT NULL,
+		changes TEXT NOT NULL DEFAULT '{}',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (request_id) REFERENCES delivery_requests(id) ON DELETE CASCADE
+	);`
+
+	// Сообщения с заявкой, разосланные водителям (для редактирования после правок)
+	broadcastsTable := `
+	CREATE TABLE IF NOT EXISTS order_broadcasts (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		request_id TEXT NOT NULL,
+		driver_telegram_id INTEGER NOT NULL,
+		message_id INTEGER NOT NULL,
+		has_photo BOOLEAN DEFAULT FALSE,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(request_id, driver_telegram_id),
+		FOREIGN KEY (request_id) REFERENCES delivery_requests(id) ON DELETE CASCADE
+	);`
+
+	// Точки выгрузки многоточечной заявки, по порядку seq
+	orderStopsTable := `
+	CREATE TABLE IF NOT EXISTS order_stops (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		request_id TEXT NOT NULL,
+		seq INTEGER NOT NULL,
+		address TEXT NOT NULL,
+		lat REAL NOT NULL DEFAULT 0.0,
+		lon REAL NOT NULL DEFAULT 0.0,
+		contact TEXT DEFAULT '',
+		status TEXT DEFAULT 'pending' CHECK (status IN ('pending', 'done')),
+		done_at DATETIME NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(request_id, seq),
+		FOREIGN KEY (request_id) REFERENCES delivery_requests(id) ON DELETE CASCADE
+	);`
+
+	// Фото погрузки и выгрузки (proof of delivery)
+	orderProofsTable := `
+	CREATE TABLE IF NOT EXISTS order_proofs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		request_id TEXT NOT NULL,
+		kind TEXT NOT NULL CHECK (kind IN ('pickup', 'delivery')),
+		photo_path TEXT NOT NULL,
+		driver_id TEXT DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (request_id) REFERENCES delivery_requests(id) ON DELETE CASCADE
+	);`
+
+	// Предложения водителей по заявке (торг): цена, ETA, комментарий
+	driverMatchesTable := `
+	CREATE TABLE IF NOT EXISTS driver_matches (
+		id TEXT PRIMARY KEY,
+		driver_id TEXT NOT NULL,
+		driver_route_id TEXT DEFAULT '',
+		delivery_request_id TEXT NOT NULL,
+		client_telegram_id INTEGER NOT NULL,
+		status TEXT DEFAULT 'pending' CHECK (status IN ('pending', 'accepted', 'rejected', 'completed', 'expired', 'cancelled')),
+		proposed_price INTEGER NOT NULL,
+		final_price INTEGER NULL,
+		eta_min INTEGER DEFAULT 0,
+		pickup_time DATETIME NULL,
+		delivery_time DATETIME NULL,
+		driver_comment TEXT DEFAULT '',
+		client_comment TEXT DEFAULT '',
+		driver_rating INTEGER NULL,
+		client_rating INTEGER NULL,
+		expires_at DATETIME NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		completed_at DATETIME NULL,
+		FOREIGN KEY (driver_id) REFERENCES drivers(id) ON DELETE CASCADE,
+		FOREIGN KEY (delivery_request_id) REFERENCES delivery_requests(id) ON DELETE CASCADE
+	);`
+
+	// Оценки после завершения заявки: клиент → водитель и водитель → клиент
+	orderReviewsTable := `
+	CREATE TABLE IF NOT EXISTS order_reviews (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		request_id TEXT NOT NULL,
+		rater_role TEXT NOT NULL CHECK (rater_role IN ('client', 'driver')),
+		rater_telegram_id INTEGER NOT NULL,
+		driver_id TEXT NOT NULL,
+		client_telegram_id INTEGER NOT NULL,
+		rating INTEGER NOT NULL CHECK (rating BETWEEN 1 AND 5),
+		comment TEXT DEFAULT '',
+		prompt_message_id INTEGER DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(request_id, rater_role),
+		FOREIGN KEY (request_id) REFERENCES delivery_requests(id) ON DELETE CASCADE
+	);`
+
+	// Сглаженный рейтинг клиента (у клиентов нет обязательной строки в users)
+	clientRatingsTable := `
+	CREATE TABLE IF NOT EXISTS client_ratings (
+		telegram_id INTEGER PRIMARY KEY,
+		rating REAL DEFAULT 0,
+		rating_count INTEGER DEFAULT 0,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);`
+
+	// Жалобы и споры по заявке: подаёт клиент или водитель, разбирает админ
+	complaintsTable := `
+	CREATE TABLE IF NOT EXISTS complaints (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		request_id TEXT NOT NULL,
+		filed_by TEXT NOT NULL CHECK (filed_by IN ('client', 'driver')),
+		filed_by_telegram_id INTEGER NOT NULL,
+		driver_id TEXT DEFAULT '',
+		client_telegram_id INTEGER NOT NULL,
+		category TEXT NOT NULL,
+		description TEXT NOT NULL,
+		status TEXT DEFAULT 'open' CHECK (status IN ('open', 'investigating', 'resolved', 'rejected')),
+		assigned_to INTEGER DEFAULT 0,
+		resolution TEXT DEFAULT '',
+		outcome TEXT DEFAULT '' CHECK (outcome IN ('', 'none', 'warning', 'driver_suspended')),
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		resolved_at DATETIME NULL,
+		FOREIGN KEY (request_id) REFERENCES delivery_requests(id) ON DELETE CASCADE
+	);`
+
+	complaintAttachmentsTable := `
+	CREATE TABLE IF NOT EXISTS complaint_attachments (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		complaint_id INTEGER NOT NULL,
+		photo_path TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (complaint_id) REFERENCES complaints(id) ON DELETE CASCADE
+	);`
+
+	complaintCommentsTable := `
+	CREATE TABLE IF NOT EXISTS complaint_comments (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		complaint_id INTEGER NOT NULL,
+		author_telegram_id INTEGER NOT NULL,
+		body TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (complaint_id) REFERENCES complaints(id) ON DELETE CASCADE
+	);`
+
+	// Отказы водителей от назначенных заявок: причина и история для штрафов
+	driverCancellationsTable := `
+	CREATE TABLE IF NOT EXISTS driver_cancellations (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		request_id TEXT NOT NULL,
+		driver_id TEXT NOT NULL,
+		reason TEXT NOT NULL,
+		comment TEXT DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (request_id) REFERENCES delivery_requests(id) ON DELETE CASCADE,
+		FOREIGN KEY (driver_id) REFERENCES drivers(id) ON DELETE CASCADE
+	);`
+
+	// Профиль клиента: сохранённые адреса и избранные водители
+	clientAddressesTable := `
+	CREATE TABLE IF NOT EXISTS client_addresses (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id TEXT NOT NULL,
+		name TEXT NOT NULL,
+		address TEXT NOT NULL,
+		lat REAL NOT NULL,
+		lon REAL NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (user_id, name),
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+	);`
+
+	favoriteDriversTable := `
+	CREATE TABLE IF NOT EXISTS favorite_drivers (
+		user_id TEXT NOT NULL,
+		driver_id TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (user_id, driver_id),
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+		FOREIGN KEY (driver_id) REFERENCES drivers(id) ON DELETE CASCADE
+	);`
+
+	// Шаблоны заявок клиента и повторяющиеся заявки по расписанию
+	orderTemplatesTable := `
+	CREATE TABLE IF NOT EXISTS order_templates (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		telegram_id INTEGER NOT NULL,
+		name TEXT NOT NULL,
+		payload TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (telegram_id, name)
+	);`
+
+	recurringOrdersTable := `
+	CREATE TABLE IF NOT EXISTS recurring_orders (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		telegram_id INTEGER NOT NULL,
+		template_id INTEGER NOT NULL,
+		weekdays INTEGER NOT NULL CHECK (weekdays BETWEEN 1 AND 127),
+		time_of_day TEXT NOT NULL,
+		status TEXT DEFAULT 'active' CHECK (status IN ('active', 'paused', 'cancelled')),
+		next_pickup_at DATETIME NULL,
+		last_request_id TEXT NULL,
+		last_run_at DATETIME NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (template_id) REFERENCES order_templates(id) ON DELETE CASCADE
+	);`
+
+	// Тарифы: пустой city/truck_type — тариф по умолчанию для любого города/машины
+	tariffsTable := `
+	CREATE TABLE IF NOT EXISTS tariffs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		city TEXT NOT NULL DEFAULT '',
+		truck_type TEXT NOT NULL DEFAULT '',
+		base_fare INTEGER NOT NULL DEFAULT 0 CHECK (base_fare >= 0),
+		per_km REAL NOT NULL DEFAULT 0 CHECK (per_km >= 0),
+		per_min REAL NOT NULL DEFAULT 0 CHECK (per_min >= 0),
+		min_price INTEGER NOT NULL CHECK (min_price > 0),
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(city, truck_type)
+	);`
+
+	// Настройки рассылки заявок водителю; нет строки — настройки по умолчанию
+	driverPreferencesTable := `
+	CREATE TABLE IF NOT EXISTS driver_preferences (
+		telegram_id INTEGER PRIMARY KEY,
+		radius_km REAL NOT NULL DEFAULT 30 CHECK (radius_km > 0),
+		cities TEXT NOT NULL DEFAULT '',
+		min_price INTEGER NOT NULL DEFAULT 0 CHECK (min_price >= 0),
+		truck_types TEXT NOT NULL DEFAULT '',
+		quiet_from TEXT NOT NULL DEFAULT '',
+		quiet_to TEXT NOT NULL DEFAULT '',
+		paused BOOLEAN NOT NULL DEFAULT FALSE,
+		paused_until DATETIME,
+		intercity_only BOOLEAN NOT NULL DEFAULT FALSE,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);`
+
+	// Сохранённые поиски водителей: окно [date_from, date_to) в UTC
+	savedSearchesTable := `
+	CREATE TABLE IF NOT EXISTS saved_searches (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		telegram_id INTEGER NOT NULL,
+		from_address TEXT NOT NULL,
+		from_lat REAL NOT NULL,
+		from_lon REAL NOT NULL,
+		to_address TEXT NOT NULL,
+		to_lat REAL NOT NULL,
+		to_lon REAL NOT NULL,
+		truck_type TEXT NOT NULL DEFAULT 'any',
+		contact TEXT NOT NULL,
+		radius_km REAL NOT NULL DEFAULT 50 CHECK (radius_km > 0),
+		date_from DATETIME NOT NULL,
+		date_to DATETIME NOT NULL,
+		status TEXT DEFAULT 'active' CHECK (status IN ('active', 'expired', 'deleted')),
+		notified_at DATETIME NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);`
+
+	// Брони мест в рейсах водителей; payload — тело заявки, заявка создаётся при принятии
+	tripBookingsTable := `
+	CREATE TABLE IF NOT EXISTS trip_bookings (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		trip_id TEXT NOT NULL,
+		driver_id TEXT NOT NULL,
+		driver_telegram_id INTEGER NOT NULL,
+		telegram_id INTEGER NOT NULL,
+		from_address TEXT NOT NULL,
+		to_address TEXT NOT NULL,
+		price INTEGER NOT NULL CHECK (price > 0),
+		weight_kg REAL NOT NULL DEFAULT 0 CHECK (weight_kg >= 0),
+		volume_m3 REAL NOT NULL DEFAULT 0 CHECK (volume_m3 >= 0),
+		payload TEXT NOT NULL,
+		status TEXT DEFAULT 'requested' CHECK (status IN ('requested', 'accepted', 'declined', 'cancelled', 'expired')),
+		request_id TEXT NULL,
+		expires_at DATETIME NOT NULL,
+		decided_at DATETIME NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (trip_id) REFERENCES driver_trips(id) ON DELETE CASCADE
+	);`
+
+	// Выбор каналов уведомлений; нет строки — только Telegram
+	notificationSettingsTable := `
+	CREATE TABLE IF NOT EXISTS notification_settings (
+		telegram_id INTEGER PRIMARY KEY,
+		channels TEXT NOT NULL DEFAULT 'telegram',
+		phone TEXT NOT NULL DEFAULT '',
+		email TEXT NOT NULL DEFAULT '',
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);`
+
+	// Журнал доставки: строка на каждую попытку по каждому каналу
+	notificationsTable := `
+	CREATE TABLE IF NOT EXISTS notifications (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		telegram_id INTEGER NOT NULL DEFAULT 0,
+		template TEXT NOT NULL,
+		channel TEXT NOT NULL CHECK (channel IN ('telegram', 'sms', 'email')),
+		recipient TEXT NOT NULL DEFAULT '',
+		status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'sent', 'failed', 'skipped')),
+		error TEXT NOT NULL DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		sent_at DATETIME NULL
+	);`
+
+	for _, sql := range []string{offertaTable, justTable, usersTable, driversTable, driverTripsTable, deliveryRequestsTable, revisionsTable, broadcastsTable, orderStopsTable, orderProofsTable, driverMatchesTable, orderReviewsTable, clientRatingsTable, complaintsTable, complaintAttachmentsTable, complaintCommentsTable, driverCancellationsTable, clientAddressesTable, favoriteDriversTable, orderTemplatesTable, recurringOrdersTable, tariffsTable, driverPreferencesTable, savedSearchesTable, tripBookingsTable, notificationSettingsTable, notificationsTable} {
 		if _, err := db.Exec(sql); err != nil {
 			logger.Error("Failed to create table", zap.Error(err))
 			return err
@@ -186,7 +532,44 @@ func CreateTables(db *sql.DB, logger *zap.Logger) error {
 		"ALTER TABLE delivery_requests ADD COLUMN matched_driver_id TEXT NULL;",
 		"ALTER TABLE delivery_requests ADD COLUMN item_photo_path TEXT DEFAULT '';",
 		"ALTER TABLE delivery_requests ADD COLUMN completed_at DATETIME NULL;",
+		"ALTER TABLE delivery_requests ADD COLUMN expired_at DATETIME NULL;",
+		"ALTER TABLE delivery_requests ADD COLUMN pickup_at DATETIME NULL;",
+		"ALTER TABLE delivery_requests ADD COLUMN dispatched_at DATETIME NULL;",
+		"ALTER TABLE delivery_requests ADD COLUMN reminder_sent_at DATETIME NULL;",
 		"ALTER TABLE drivers ADD COLUMN truck_number TEXT DEFAULT '';",
+		"ALTER TABLE delivery_requests ADD COLUMN cargo_weight_kg REAL DEFAULT 0;",
+		"ALTER TABLE delivery_requests ADD COLUMN cargo_volume_m3 REAL DEFAULT 0;",
+		"ALTER TABLE delivery_requests ADD COLUMN cargo_length_cm INTEGER DEFAULT 0;",
+		"ALTER TABLE delivery_requests ADD COLUMN cargo_width_cm INTEGER DEFAULT 0;",
+		"ALTER TABLE delivery_requests ADD COLUMN cargo_height_cm INTEGER DEFAULT 0;",
+		"ALTER TABLE delivery_requests ADD COLUMN cargo_items INTEGER DEFAULT 0;",
+		"ALTER TABLE delivery_requests ADD COLUMN needs_loaders BOOLEAN DEFAULT FALSE;",
+		"ALTER TABLE delivery_requests ADD COLUMN fragile BOOLEAN DEFAULT FALSE;",
+		"ALTER TABLE delivery_requests ADD COLUMN floor INTEGER DEFAULT 0;",
+		"ALTER TABLE delivery_requests ADD COLUMN has_elevator BOOLEAN DEFAULT FALSE;",
+		"ALTER TABLE drivers ADD COLUMN max_weight_kg REAL DEFAULT 0;",
+		"ALTER TABLE drivers ADD COLUMN max_volume_m3 REAL DEFAULT 0;",
+		"ALTER TABLE drivers ADD COLUMN body_length_cm INTEGER DEFAULT 0;",
+		"ALTER TABLE drivers ADD COLUMN body_width_cm INTEGER DEFAULT 0;",
+		"ALTER TABLE drivers ADD COLUMN body_height_cm INTEGER DEFAULT 0;",
+		"ALTER TABLE driver_trips ADD COLUMN max_weight INTEGER DEFAULT 0;",
+		"ALTER TABLE delivery_requests ADD COLUMN confirm_code TEXT DEFAULT '';",
+		"ALTER TABLE delivery_requests ADD COLUMN confirm_attempts INTEGER DEFAULT 0;",
+		"ALTER TABLE delivery_requests ADD COLUMN picked_up_at DATETIME NULL;",
+		"ALTER TABLE drivers ADD COLUMN rating REAL DEFAULT 0;",
+		"ALTER TABLE drivers ADD COLUMN rating_count INTEGER DEFAULT 0;",
+		"ALTER TABLE drivers ADD COLUMN cancel_count INTEGER DEFAULT 0;",
+		"ALTER TABLE drivers ADD COLUMN dispatch_cooldown_until DATETIME NULL;",
+		"ALTER TABLE delivery_requests ADD COLUMN accepted_at DATETIME NULL;",
+		"ALTER TABLE delivery_requests ADD COLUMN tracking_token TEXT NULL;",
+		"ALTER TABLE delivery_requests ADD COLUMN preferred_driver_id TEXT NULL;",
+		"ALTER TABLE delivery_requests ADD COLUMN preferred_until DATETIME NULL;",
+		"ALTER TABLE delivery_requests ADD COLUMN recurring_id INTEGER NULL;",
+		"ALTER TABLE delivery_requests ADD COLUMN trip_id TEXT NULL;",
+		"ALTER TABLE driver_trips ADD COLUMN booked_weight REAL DEFAULT 0;",
+		"ALTER TABLE driver_trips ADD COLUMN booked_volume REAL DEFAULT 0;",
+		"ALTER TABLE trip_bookings ADD COLUMN volume_m3 REAL NOT NULL DEFAULT 0;",
+		"ALTER TABLE just ADD COLUMN language_code TEXT DEFAULT '';",
 	}
 	for _, q := range addCols {
 		if _, err := db.Exec(q); err != nil {
@@ -194,6 +577,36 @@ func CreateTables(db *sql.DB, logger *zap.Logger) error {
 		}
 	}
 
+	// Старые базы: CHECK по status без 'expired'
+	// и с жёстким минимумом цены — теперь минимум задаётся тарифами
+	if err := rebuildTable(db, logger, "delivery_requests", deliveryRequestsTable, func(current string) bool {
+		return strings.Contains(current, "'expired'") && !strings.Contains(current, "price >= 2000")
+	}); err != nil {
+		logger.Error("Failed to migrate delivery_requests", zap.Error(err))
+		return err
+	}
+
+	// Старые базы: CHECK по status рейса без 'full'
+	if err := rebuildTable(db, logger, "driver_trips", driverTripsTable, func(current string) bool {
+		return !strings.Contains(current, "price >= 2000") && strings.Contains(current, "'full'")
+	}); err != nil {
+		logger.Error("Failed to migrate driver_trips", zap.Error(err))
+		return err
+	}
+
+	// Старые базы: CHECK по status предложений без 'cancelled'
+	if err := rebuildTable(db, logger, "driver_matches", driverMatchesTable, func(current string) bool {
+		return strings.Contains(current, "'cancelled'")
+	}); err != nil {
+		logger.Error("Failed to migrate driver_matches", zap.Error(err))
+		return err
+	}
+
+	// Заявки до появления отложенной рассылки считаются разосланными в момент создания
+	if _, err := db.Exec(`UPDATE delivery_requests SET dispatched_at = created_at WHERE dispatched_at IS NULL AND pickup_at IS NULL`); err != nil {
+		logger.Warn("Failed to backfill dispatched_at", zap.Error(err))
+	}
+
 	indexes := []string{
 		"CREATE INDEX IF NOT EXISTS idx_users_telegram_id ON users(telegram_id);",
 		"CREATE INDEX IF NOT EXISTS idx_offerta_user_role ON offerta(id_user, role);",
@@ -203,6 +616,8 @@ func CreateTables(db *sql.DB, logger *zap.Logger) error {
 		"CREATE INDEX IF NOT EXISTS idx_dr_matched_driver_id ON delivery_requests(matched_driver_id);",
 		"CREATE INDEX IF NOT EXISTS idx_dr_status ON delivery_requests(status);",
 		"CREATE INDEX IF NOT EXISTS idx_dr_created_at ON delivery_requests(created_at);",
+		"CREATE INDEX IF NOT EXISTS idx_dr_pickup_at ON delivery_requests(pickup_at);",
+		"CREATE INDEX IF NOT EXISTS idx_dr_dispatched_at ON delivery_requests(dispatched_at);",
 		"CREATE INDEX IF NOT EXISTS idx_dr_location ON delivery_requests(from_lat, from_lon, to_lat, to_lon);",
 		"CREATE INDEX IF NOT EXISTS idx_drivers_telegram_id ON drivers(telegram_id);",
 		"CREATE INDEX IF NOT EXISTS idx_drivers_status ON drivers(status);",
@@ -216,6 +631,34 @@ func CreateTables(db *sql.DB, logger *zap.Logger) error {
 		"CREATE INDEX IF NOT EXISTS idx_dt_created_at ON driver_trips(created_at);",
 		"CREATE INDEX IF NOT EXISTS idx_dt_location ON driver_trips(from_lat, from_lon, to_lat, to_lon);",
 		"CREATE INDEX IF NOT EXISTS idx_dt_time ON driver_trips(start_time, departure_time);",
+		"CREATE INDEX IF NOT EXISTS idx_drr_request_id ON delivery_request_revisions(request_id);",
+		"CREATE INDEX IF NOT EXISTS idx_ob_request_id ON order_broadcasts(request_id);",
+		"CREATE INDEX IF NOT EXISTS idx_op_request_id ON order_proofs(request_id);",
+		"CREATE INDEX IF NOT EXISTS idx_dm_request_status ON driver_matches(delivery_request_id, status);",
+		"CREATE INDEX IF NOT EXISTS idx_dm_expires_at ON driver_matches(expires_at);",
+		"CREATE UNIQUE INDEX IF NOT EXISTS ux_dm_pending_bid ON driver_matches(delivery_request_id, driver_id) WHERE status = 'pending';",
+		"CREATE INDEX IF NOT EXISTS idx_or_driver_id ON order_reviews(driver_id, rater_role);",
+		"CREATE INDEX IF NOT EXISTS idx_or_client ON order_reviews(client_telegram_id, rater_role);",
+		"CREATE INDEX IF NOT EXISTS idx_or_prompt ON order_reviews(rater_telegram_id, prompt_message_id);",
+		"CREATE INDEX IF NOT EXISTS idx_complaints_status ON complaints(status, created_at);",
+		"CREATE INDEX IF NOT EXISTS idx_complaints_request_id ON complaints(request_id);",
+		"CREATE INDEX IF NOT EXISTS idx_complaints_filed_by ON complaints(filed_by_telegram_id);",
+		"CREATE INDEX IF NOT EXISTS idx_ca_complaint_id ON complaint_attachments(complaint_id);",
+		"CREATE INDEX IF NOT EXISTS idx_cc_complaint_id ON complaint_comments(complaint_id);",
+		"CREATE INDEX IF NOT EXISTS idx_dcan_driver_created ON driver_cancellations(driver_id, created_at);",
+		"CREATE UNIQUE INDEX IF NOT EXISTS ux_dr_tracking_token ON delivery_requests(tracking_token) WHERE tracking_token IS NOT NULL;",
+		"CREATE INDEX IF NOT EXISTS idx_dr_preferred_until ON delivery_requests(preferred_until) WHERE preferred_driver_id IS NOT NULL;",
+		"CREATE INDEX IF NOT EXISTS idx_fd_driver_id ON favorite_drivers(driver_id);",
+		"CREATE INDEX IF NOT EXISTS idx_ro_due ON recurring_orders(status, next_pickup_at);",
+		"CREATE INDEX IF NOT EXISTS idx_ro_telegram_id ON recurring_orders(telegram_id);",
+		"CREATE INDEX IF NOT EXISTS idx_ss_active ON saved_searches(status, date_to);",
+		"CREATE INDEX IF NOT EXISTS idx_ss_telegram_id ON saved_searches(telegram_id);",
+		"CREATE INDEX IF NOT EXISTS idx_dr_trip_id ON delivery_requests(trip_id) WHERE trip_id IS NOT NULL;",
+		"CREATE INDEX IF NOT EXISTS idx_tb_trip_id ON trip_bookings(trip_id);",
+		"CREATE INDEX IF NOT EXISTS idx_tb_telegram_id ON trip_bookings(telegram_id);",
+		"CREATE INDEX IF NOT EXISTS idx_tb_open ON trip_bookings(status, expires_at);",
+		"CREATE INDEX IF NOT EXISTS idx_notif_telegram_id ON notifications(telegram_id, id);",
+		"CREATE INDEX IF NOT EXISTS idx_notif_status ON notifications(status, id);",
 	}
 	for _, idx := range indexes {
 		if _, err := db.Exec(idx); err != nil {
@@ -251,6 +694,18 @@ func CreateTables(db *sql.DB, logger *zap.Logger) error {
 				AFTER UPDATE ON drivers
 				BEGIN UPDATE drivers SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id; END;`,
 		},
+		{
+			name: "trigger_driver_matches_updated_at",
+			sql: `CREATE TRIGGER IF NOT EXISTS trigger_driver_matches_updated_at 
+				AFTER UPDATE ON driver_matches
+				BEGIN UPDATE driver_matches SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id; END;`,
+		},
+		{
+			name: "trigger_complaints_updated_at",
+			sql: `CREATE TRIGGER IF NOT EXISTS trigger_complaints_updated_at 
+				AFTER UPDATE ON complaints
+				BEGIN UPDATE complaints SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id; END;`,
+		},
 		{
 			name: "trigger_driver_trips_updated_at",
 			sql: `CREATE TRIGGER IF NOT EXISTS trigger_driver_trips_updated_at 
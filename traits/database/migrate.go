@@ -0,0 +1,116 @@
+// traits/database/migrate.go
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// rebuildTable пересоздаёт таблицу по актуальной схеме, если upToDate вернул false.
+// SQLite не умеет менять CHECK-ограничения через ALTER TABLE, поэтому схема
+// переносится стандартным способом: новая таблица → копия данных → DROP → RENAME.
+// Индексы и триггеры пересоздаются дальше в CreateTables.
+func rebuildTable(db *sql.DB, logger *zap.Logger, table, createSQL string, upToDate func(currentSQL string) bool) error {
+	var currentSQL string
+	err := db.QueryRow(`SELECT sql FROM sqlite_master WHERE type = 'table' AND name = ?`, table).Scan(&currentSQL)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return fmt.Errorf("read %s schema: %w", table, err)
+	}
+	if upToDate(currentSQL) {
+		return nil
+	}
+
+	tmpTable := table + "_new"
+	prefix := "CREATE TABLE IF NOT EXISTS " + table + " ("
+	if !strings.Contains(createSQL, prefix) {
+		return fmt.Errorf("unexpected create statement for %s", table)
+	}
+	newSQL := strings.Replace(createSQL, prefix, "CREATE TABLE "+tmpTable+" (", 1)
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// foreign_keys нельзя переключить внутри транзакции, а DROP TABLE при включённых
+	// ключах каскадно удалит дочерние строки.
+	if _, err := conn.ExecContext(ctx, `PRAGMA foreign_keys = OFF`); err != nil {
+		return err
+	}
+	defer conn.ExecContext(ctx, `PRAGMA foreign_keys = ON`)
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DROP TABLE IF EXISTS `+tmpTable); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, newSQL); err != nil {
+		return fmt.Errorf("create %s: %w", tmpTable, err)
+	}
+
+	oldCols, err := tableColumns(ctx, tx, table)
+	if err != nil {
+		return err
+	}
+	newCols, err := tableColumns(ctx, tx, tmpTable)
+	if err != nil {
+		return err
+	}
+	var common []string
+	for _, c := range newCols {
+		for _, o := range oldCols {
+			if c == o {
+				common = append(common, c)
+				break
+			}
+		}
+	}
+	cols := strings.Join(common, ", ")
+
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf(`INSERT INTO %s (%s) SELECT %s FROM %s`, tmpTable, cols, cols, table)); err != nil {
+		return fmt.Errorf("copy %s: %w", table, err)
+	}
+	if _, err := tx.ExecContext(ctx, `DROP TABLE `+table); err != nil {
+		return fmt.Errorf("drop %s: %w", table, err)
+	}
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf(`ALTER TABLE %s RENAME TO %s`, tmpTable, table)); err != nil {
+		return fmt.Errorf("rename %s: %w", tmpTable, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	logger.Info("Table rebuilt with new schema", zap.String("table", table), zap.Int("columns", len(common)))
+	return nil
+}
+
+func tableColumns(ctx context.Context, tx *sql.Tx, table string) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT name FROM pragma_table_info(?)`, table)
+	if err != nil {
+		return nil, fmt.Errorf("table info %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	var cols []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		cols = append(cols, name)
+	}
+	return cols, rows.Err()
+}
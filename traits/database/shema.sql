@@ -63,12 +63,12 @@ CREATE TABLE IF NOT EXISTS delivery_requests (
     to_address TEXT NOT NULL,
     to_lat REAL NOT NULL,
     to_lon REAL NOT NULL,
-    price INTEGER NOT NULL CHECK (price >= 2000),
+    price INTEGER NOT NULL CHECK (price > 0),
     contact TEXT NOT NULL,
     comment TEXT,
     truck_type TEXT NOT NULL CHECK (truck_type IN ('small', 'medium', 'large', 'refrigerator', 'tow')),
     distance_km REAL DEFAULT 0.0,
-    status TEXT DEFAULT 'pending' CHECK (status IN ('pending', 'matched', 'in_progress', 'completed', 'cancelled')),
+    status TEXT DEFAULT 'pending' CHECK (status IN ('pending', 'matched', 'in_progress', 'completed', 'cancelled', 'expired')),
     item_photo_path TEXT,
     matched_driver_id INTEGER,
     completed_at DATETIME,
@@ -89,7 +89,7 @@ CREATE TABLE IF NOT EXISTS driver_routes (
     to_address TEXT NOT NULL,
     to_lat REAL NOT NULL,
     to_lon REAL NOT NULL,
-    price INTEGER NOT NULL CHECK (price >= 2000),
+    price INTEGER NOT NULL CHECK (price > 0),
     truck_type TEXT NOT NULL CHECK (truck_type IN ('small', 'medium', 'large', 'refrigerator', 'tow')),
     max_weight INTEGER DEFAULT 0,
     comment TEXT,
@@ -383,7 +383,7 @@ WHERE departure_time <= datetime('now', '-1 hour')
 -- Data integrity constraints and business rules:
 
 -- 1. Drivers must be 18+ years old (enforced in application)
--- 2. Minimum price is 2000 (enforced by CHECK constraint)
+-- 2. Price must be positive (CHECK constraint); the minimum comes from the city tariffs (enforced in application)
 -- 3. Coordinates must be valid (enforced in application)
 -- 4. File paths must exist (enforced in application)
 -- 5. Ratings must be between 1-5 (enforced by CHECK constraint)